@@ -15,6 +15,10 @@ import (
 // @host localhost:8080
 // @BasePath /api
 // @schemes http
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description Keycloak access token: "Bearer <token>"
 func main() {
 	logger.Init()
 	logger.Info("starting FeedbackLab API application")
@@ -7,24 +7,71 @@ import (
 	"net/url"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 // Config holds application configuration settings.
 type Config struct {
-	AppPort         int
-	HealthPort      int
-	DatabaseURL     string
-	ParsedDBURL     *url.URL
-	MigrationsDir   string
-	SwaggerUsername string
-	SwaggerPassword string
-	MinioEndpoint   string
-	MinioAccessKey  string
-	MinioSecretKey  string
-	MinioBucket     string
-	MinioUseSSL     bool
+	AppPort             int
+	HealthPort          int
+	DatabaseURL         string
+	ParsedDBURL         *url.URL
+	MigrationsDir       string
+	SwaggerUsername     string
+	SwaggerPassword     string
+	StorageDriver       string
+	StorageDir          string
+	StoragePublicURL    string
+	StorageURLSecret    string
+	MinioEndpoint       string
+	MinioAccessKey      string
+	MinioSecretKey      string
+	MinioBucket         string
+	MinioUseSSL         bool
+	UploadMaxSize       int64
+	UploadAllowedTypes  []string
+	FileURLTTL          time.Duration
+	FileGCInterval      time.Duration
+	FileGCGrace         time.Duration
+	FileGCRemove        bool
+	ClamAVAddress       string
+	ScanTimeout         time.Duration
+	KeycloakURL         string
+	KeycloakRealm       string
+	KeycloakIssuer      string
+	KeycloakClient      string
+	JWKSURL             string
+	JWKSCacheTTL        time.Duration
+	WorkflowFile        string
+	SLACheckInterval    time.Duration
+	ContractWindows     []int
+	ContractCheckPeriod time.Duration
+	NotifyChannel       string
+	GitlabURL           string
+	GitlabToken         string
+	GitlabSecret        string
+	GitlabSenderID      string
+	MattermostURL       string
+	MattermostToken     string
+	MattermostChannel   string
+	MattermostHookToken string
+	MattermostSenderID  string
+	SMTPHost            string
+	SMTPPort            int
+	SMTPUsername        string
+	SMTPPassword        string
+	SMTPFrom            string
+	SMTPReplyTo         string
+	SMTPStartTLS        bool
+	PublicURL           string
+	MailWebhookToken    string
+	MailTokenSecret     string
+	MailSenderID        string
+	MailAuthservID      string
+	Maildir             string
 }
 
 // Load reads configuration from environment variables and returns a Config instance.
@@ -60,6 +107,19 @@ func Load() (*Config, error) {
 	cfg.SwaggerUsername = getEnv("SWAGGER_USERNAME", "swagger")
 	cfg.SwaggerPassword = getEnv("SWAGGER_PASSWORD", "swagger")
 
+	// Files are kept in MinIO, or with STORAGE_DRIVER=fs under STORAGE_DIR,
+	// or with STORAGE_DRIVER=memory only until the process exits. The fs
+	// driver serves its own signed URLs from STORAGE_PUBLIC_URL.
+	cfg.StorageDriver = getEnv("STORAGE_DRIVER", "minio")
+	switch cfg.StorageDriver {
+	case "minio", "fs", "memory":
+	default:
+		return nil, fmt.Errorf("invalid STORAGE_DRIVER: %q", cfg.StorageDriver)
+	}
+	cfg.StorageDir = getEnv("STORAGE_DIR", "./data/storage")
+	cfg.StoragePublicURL = getEnv("STORAGE_PUBLIC_URL", fmt.Sprintf("http://localhost:%d", cfg.AppPort))
+	cfg.StorageURLSecret = getEnv("STORAGE_URL_SECRET", "")
+
 	cfg.MinioEndpoint = getEnv("MINIO_ENDPOINT", "localhost:9000")
 	cfg.MinioAccessKey = getEnv("MINIO_ACCESS_KEY", "minioadmin")
 	cfg.MinioSecretKey = getEnv("MINIO_SECRET_KEY", "minioadmin123")
@@ -70,6 +130,137 @@ func Load() (*Config, error) {
 	}
 	cfg.MinioUseSSL = minioUseSSL
 
+	// Uploads are limited to UPLOAD_MAX_SIZE bytes and to the comma-separated
+	// content types in UPLOAD_ALLOWED_TYPES, detected from the file contents.
+	uploadMaxSize, err := getEnvInt("UPLOAD_MAX_SIZE", 20<<20)
+	if err != nil {
+		return nil, fmt.Errorf("invalid UPLOAD_MAX_SIZE: %w", err)
+	}
+	cfg.UploadMaxSize = int64(uploadMaxSize)
+	cfg.UploadAllowedTypes = getEnvList("UPLOAD_ALLOWED_TYPES", nil)
+	// Presigned upload and download URLs expire after FILE_URL_TTL.
+	fileURLTTL, err := getEnvDuration("FILE_URL_TTL", 15*time.Minute)
+	if err != nil {
+		return nil, fmt.Errorf("invalid FILE_URL_TTL: %w", err)
+	}
+	cfg.FileURLTTL = fileURLTTL
+
+	// Objects of deleted attachments are removed right away. Every
+	// FILE_GC_INTERVAL the bucket is also swept for ticket files older than
+	// FILE_GC_GRACE that no attachment references; they are only reported
+	// unless FILE_GC_REMOVE is set.
+	fileGCInterval, err := getEnvDuration("FILE_GC_INTERVAL", 6*time.Hour)
+	if err != nil {
+		return nil, fmt.Errorf("invalid FILE_GC_INTERVAL: %w", err)
+	}
+	cfg.FileGCInterval = fileGCInterval
+	fileGCGrace, err := getEnvDuration("FILE_GC_GRACE", 24*time.Hour)
+	if err != nil {
+		return nil, fmt.Errorf("invalid FILE_GC_GRACE: %w", err)
+	}
+	cfg.FileGCGrace = fileGCGrace
+	fileGCRemove, err := getEnvBool("FILE_GC_REMOVE", false)
+	if err != nil {
+		return nil, fmt.Errorf("invalid FILE_GC_REMOVE: %w", err)
+	}
+	cfg.FileGCRemove = fileGCRemove
+
+	// New files are scanned by the clamd at CLAMAV_ADDRESS (host:port or a
+	// unix socket path) before they can be downloaded. Without it, only the
+	// EICAR test file is caught.
+	cfg.ClamAVAddress = getEnv("CLAMAV_ADDRESS", "")
+	scanTimeout, err := getEnvDuration("SCAN_TIMEOUT", 2*time.Minute)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SCAN_TIMEOUT: %w", err)
+	}
+	cfg.ScanTimeout = scanTimeout
+
+	cfg.KeycloakURL = strings.TrimRight(getEnv("KEYCLOAK_URL", "http://keycloak:8082"), "/")
+	cfg.KeycloakRealm = getEnv("KEYCLOAK_REALM", "feedbacklab")
+	cfg.KeycloakClient = getEnv("KEYCLOAK_CLIENT_ID", "feedbacklab-api")
+	// The issuer must match the "iss" claim, which uses the public Keycloak host,
+	// while JWKS may be fetched through the internal docker network address.
+	cfg.KeycloakIssuer = getEnv("KEYCLOAK_ISSUER", cfg.KeycloakURL+"/realms/"+cfg.KeycloakRealm)
+	cfg.JWKSURL = getEnv("KEYCLOAK_JWKS_URL",
+		cfg.KeycloakURL+"/realms/"+cfg.KeycloakRealm+"/protocol/openid-connect/certs",
+	)
+	jwksTTL, err := getEnvDuration("KEYCLOAK_JWKS_TTL", 15*time.Minute)
+	if err != nil {
+		return nil, fmt.Errorf("invalid KEYCLOAK_JWKS_TTL: %w", err)
+	}
+	cfg.JWKSCacheTTL = jwksTTL
+
+	cfg.WorkflowFile = getEnv("TICKET_WORKFLOW_FILE", "")
+	// Ticket deadlines are checked for warnings and breaches every
+	// SLA_CHECK_INTERVAL.
+	slaCheckInterval, err := getEnvDuration("SLA_CHECK_INTERVAL", time.Minute)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SLA_CHECK_INTERVAL: %w", err)
+	}
+	cfg.SLACheckInterval = slaCheckInterval
+	// Project owners are reminded when a contract ends within each of the
+	// CONTRACT_EXPIRY_WINDOWS (days, comma-separated), checked every
+	// CONTRACT_CHECK_INTERVAL.
+	for _, item := range getEnvList("CONTRACT_EXPIRY_WINDOWS", []string{"30", "14", "7"}) {
+		days, err := strconv.Atoi(item)
+		if err != nil || days < 0 {
+			return nil, fmt.Errorf("invalid CONTRACT_EXPIRY_WINDOWS: %q", item)
+		}
+		cfg.ContractWindows = append(cfg.ContractWindows, days)
+	}
+	contractCheckPeriod, err := getEnvDuration("CONTRACT_CHECK_INTERVAL", time.Hour)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CONTRACT_CHECK_INTERVAL: %w", err)
+	}
+	cfg.ContractCheckPeriod = contractCheckPeriod
+	cfg.NotifyChannel = getEnv("NOTIFY_CHANNEL", "feedbacklab_events")
+
+	// GitLab sync is disabled while GITLAB_URL is empty. Comments imported
+	// from GitLab are posted as GITLAB_SENDER_ID.
+	cfg.GitlabURL = getEnv("GITLAB_URL", "")
+	cfg.GitlabToken = getEnv("GITLAB_TOKEN", "")
+	cfg.GitlabSecret = getEnv("GITLAB_WEBHOOK_SECRET", "")
+	cfg.GitlabSenderID = getEnv("GITLAB_SENDER_ID", "00000000-0000-0000-0000-000000000000")
+
+	// Mattermost mirroring is disabled while MATTERMOST_URL is empty. Ticket
+	// threads are opened in MATTERMOST_CHANNEL of each project's team.
+	cfg.MattermostURL = getEnv("MATTERMOST_URL", "")
+	cfg.MattermostToken = getEnv("MATTERMOST_TOKEN", "")
+	cfg.MattermostChannel = getEnv("MATTERMOST_CHANNEL", "support")
+	cfg.MattermostHookToken = getEnv("MATTERMOST_WEBHOOK_TOKEN", "")
+	cfg.MattermostSenderID = getEnv("MATTERMOST_SENDER_ID", "00000000-0000-0000-0000-000000000000")
+
+	// Email notifications are disabled while SMTP_HOST is empty. Links in
+	// the emails point to APP_PUBLIC_URL.
+	cfg.SMTPHost = getEnv("SMTP_HOST", "")
+	smtpPort, err := getEnvInt("SMTP_PORT", 587)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SMTP_PORT: %w", err)
+	}
+	cfg.SMTPPort = smtpPort
+	cfg.SMTPUsername = getEnv("SMTP_USERNAME", "")
+	cfg.SMTPPassword = getEnv("SMTP_PASSWORD", "")
+	cfg.SMTPFrom = getEnv("SMTP_FROM", "FeedbackLab <noreply@feedbacklab.local>")
+	cfg.SMTPReplyTo = getEnv("SMTP_REPLY_TO", "")
+	smtpStartTLS, err := getEnvBool("SMTP_STARTTLS", true)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SMTP_STARTTLS: %w", err)
+	}
+	cfg.SMTPStartTLS = smtpStartTLS
+	cfg.PublicURL = getEnv("APP_PUBLIC_URL", "http://localhost:3000")
+
+	// Inbound email is accepted from an MTA pipe on /webhooks/mail with
+	// MAIL_WEBHOOK_TOKEN, and from MAILDIR when set. Replies are matched to
+	// tickets by tokens signed with MAIL_TOKEN_SECRET, which also enables
+	// the tokens in notification emails. Senders are matched to users only
+	// when the MTA whose Authentication-Results carry MAIL_AUTHSERV_ID
+	// verified them; other mail is posted as MAIL_SENDER_ID.
+	cfg.MailWebhookToken = getEnv("MAIL_WEBHOOK_TOKEN", "")
+	cfg.MailTokenSecret = getEnv("MAIL_TOKEN_SECRET", "")
+	cfg.MailSenderID = getEnv("MAIL_SENDER_ID", "00000000-0000-0000-0000-000000000000")
+	cfg.MailAuthservID = getEnv("MAIL_AUTHSERV_ID", "")
+	cfg.Maildir = getEnv("MAILDIR", "")
+
 	log.Println("config loaded and parsed successfully")
 	return cfg, nil
 }
@@ -81,6 +272,20 @@ func getEnv(key, fallback string) string {
 	return fallback
 }
 
+func getEnvList(key string, fallback []string) []string {
+	val, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback
+	}
+	var list []string
+	for _, item := range strings.Split(val, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
 func getEnvInt(key string, fallback int) (int, error) {
 	if val, ok := os.LookupEnv(key); ok {
 		parsed, err := strconv.Atoi(val)
@@ -102,3 +307,14 @@ func getEnvBool(key string, fallback bool) (bool, error) {
 	}
 	return fallback, nil
 }
+
+func getEnvDuration(key string, fallback time.Duration) (time.Duration, error) {
+	if val, ok := os.LookupEnv(key); ok {
+		parsed, err := time.ParseDuration(val)
+		if err != nil {
+			return 0, err
+		}
+		return parsed, nil
+	}
+	return fallback, nil
+}
@@ -0,0 +1,134 @@
+package access
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"innotech/pkg/middleware"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ProjectLocator resolves the project a request operates on.
+type ProjectLocator func(c *fiber.Ctx) (int, error)
+
+// ErrBadReference is returned by locators for malformed resource references.
+var ErrBadReference = errors.New("invalid resource reference")
+
+// Guard exposes the policy as Fiber middleware.
+type Guard struct {
+	policy Policy
+	repo   Repository
+}
+
+// NewGuard creates a new Guard instance.
+func NewGuard(policy Policy, repo Repository) *Guard {
+	return &Guard{policy: policy, repo: repo}
+}
+
+// Param locates the project through the resource id in a path parameter.
+func (g *Guard) Param(name string, res Resource) ProjectLocator {
+	return func(c *fiber.Ctx) (int, error) {
+		id, err := strconv.Atoi(c.Params(name))
+		if err != nil {
+			return 0, ErrBadReference
+		}
+		return g.repo.ProjectOf(c.UserContext(), res, id)
+	}
+}
+
+// Query locates the project through the resource id in a query parameter.
+func (g *Guard) Query(name string, res Resource) ProjectLocator {
+	return func(c *fiber.Ctx) (int, error) {
+		id, err := strconv.Atoi(c.Query(name))
+		if err != nil {
+			return 0, ErrBadReference
+		}
+		return g.repo.ProjectOf(c.UserContext(), res, id)
+	}
+}
+
+// Body locates the project through a resource id field of the JSON body.
+func (g *Guard) Body(field string, res Resource) ProjectLocator {
+	return func(c *fiber.Ctx) (int, error) {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(c.Body(), &fields); err != nil {
+			return 0, ErrBadReference
+		}
+		var id int
+		if err := json.Unmarshal(fields[field], &id); err != nil || id <= 0 {
+			return 0, ErrBadReference
+		}
+		return g.repo.ProjectOf(c.UserContext(), res, id)
+	}
+}
+
+// Require allows the request through only if the caller holds perm in the located project.
+func (g *Guard) Require(perm Permission, locate ProjectLocator) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		projectID, err := locate(c)
+		if err != nil {
+			return g.fail(c, err)
+		}
+		if err := g.policy.Authorize(c.UserContext(), projectID, perm); err != nil {
+			return g.fail(c, err)
+		}
+		c.Locals("project_id", projectID)
+		return c.Next()
+	}
+}
+
+// Scoped stores the caller's project scope for perm so list handlers can filter.
+func (g *Guard) Scoped(perm Permission) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		scope, err := g.policy.Scope(c.UserContext(), perm)
+		if err != nil {
+			return g.fail(c, err)
+		}
+		c.Locals("access_scope", scope)
+		return c.Next()
+	}
+}
+
+// Superuser allows the request through only for realm administrators.
+func (g *Guard) Superuser() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !g.policy.IsSuperuser(c.UserContext()) {
+			return g.fail(c, ErrForbidden)
+		}
+		return c.Next()
+	}
+}
+
+// ScopeFrom returns the scope stored by Scoped. Without one nothing is visible.
+func ScopeFrom(c *fiber.Ctx) Scope {
+	scope, ok := c.Locals("access_scope").(Scope)
+	if !ok {
+		return Scope{}
+	}
+	return scope
+}
+
+func (g *Guard) fail(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, ErrBadReference):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": middleware.Localize(c, "common.error.bad_request"),
+		})
+	case errors.Is(err, sql.ErrNoRows):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": middleware.Localize(c, "common.error.not_found"),
+		})
+	case errors.Is(err, ErrUnauthenticated):
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": middleware.Localize(c, "common.error.unauthorized"),
+		})
+	case errors.Is(err, ErrForbidden):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": middleware.Localize(c, "common.error.forbidden"),
+		})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+}
@@ -0,0 +1,58 @@
+package access
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Handler exposes the permission vocabulary and the caller's effective grants.
+type Handler struct {
+	policy Policy
+}
+
+// NewHandler creates a new Handler instance.
+func NewHandler(policy Policy) *Handler {
+	return &Handler{policy: policy}
+}
+
+// CatalogueResponse describes all permissions and what each role grants.
+type CatalogueResponse struct {
+	Permissions []PermissionInfo      `json:"permissions"`
+	Roles       map[Role][]Permission `json:"roles"`
+}
+
+// GetCatalogue godoc
+// @Summary получить словарь прав доступа
+// @Tags Access
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} access.CatalogueResponse
+// @Router /access/permissions [get]
+func (h *Handler) GetCatalogue(c *fiber.Ctx) error {
+	return c.JSON(CatalogueResponse{
+		Permissions: Catalogue,
+		Roles:       RolePermissions,
+	})
+}
+
+// GetEffective godoc
+// @Summary получить права текущего пользователя в проекте
+// @Tags Access
+// @Produce json
+// @Security BearerAuth
+// @Param project_id path int true "Project ID"
+// @Success 200 {array} string
+// @Failure 400 {object} map[string]string
+// @Router /access/projects/{project_id}/permissions [get]
+func (h *Handler) GetEffective(c *fiber.Ctx) error {
+	projectID, err := strconv.Atoi(c.Params("project_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid project id"})
+	}
+	perms, err := h.policy.Effective(c.UserContext(), projectID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(perms)
+}
@@ -0,0 +1,120 @@
+// Package access implements project-scoped role based access control.
+package access
+
+import (
+	"slices"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Permission is a single capability a project member may hold.
+type Permission string
+
+// Permission vocabulary. This is the only place permissions are declared; the
+// catalogue endpoint and the user_projects validation are derived from it.
+const (
+	ProjectRead   Permission = "project.read"
+	ProjectManage Permission = "project.manage"
+	MembersManage Permission = "members.manage"
+
+	TicketRead   Permission = "ticket.read"
+	TicketCreate Permission = "ticket.create"
+	TicketUpdate Permission = "ticket.update"
+	TicketDelete Permission = "ticket.delete"
+
+	ChatRead   Permission = "chat.read"
+	ChatWrite  Permission = "chat.write"
+	ChatDelete Permission = "chat.delete"
+
+	AttachmentRead   Permission = "attachment.read"
+	AttachmentWrite  Permission = "attachment.write"
+	AttachmentDelete Permission = "attachment.delete"
+
+	ModuleRead   Permission = "module.read"
+	ModuleManage Permission = "module.manage"
+
+	DocumentationRead   Permission = "documentation.read"
+	DocumentationManage Permission = "documentation.manage"
+
+	ContractRead   Permission = "contract.read"
+	ContractManage Permission = "contract.manage"
+
+	WebhookManage Permission = "webhook.manage"
+)
+
+// Role is a project membership role stored in user_projects.role.
+type Role string
+
+// Project roles, ordered from least to most privileged.
+const (
+	RoleViewer Role = "viewer"
+	RoleEditor Role = "editor"
+	RoleAdmin  Role = "admin"
+	RoleOwner  Role = "owner"
+)
+
+// SuperuserRole is the Keycloak realm role that bypasses project checks.
+const SuperuserRole = "admin"
+
+// PermissionInfo describes a permission for the catalogue endpoint.
+type PermissionInfo struct {
+	Name        Permission `json:"name"`
+	Description string     `json:"description"`
+}
+
+// Catalogue lists every permission known to the API.
+var Catalogue = []PermissionInfo{
+	{ProjectRead, "View the project card"},
+	{ProjectManage, "Edit or delete the project"},
+	{MembersManage, "Add, change or remove project members"},
+	{TicketRead, "View tickets"},
+	{TicketCreate, "Open new tickets"},
+	{TicketUpdate, "Edit tickets and change their status"},
+	{TicketDelete, "Delete tickets"},
+	{ChatRead, "Read ticket conversations"},
+	{ChatWrite, "Post and edit chat messages"},
+	{ChatDelete, "Delete chat messages"},
+	{AttachmentRead, "View ticket and message attachments"},
+	{AttachmentWrite, "Upload and edit attachments"},
+	{AttachmentDelete, "Delete attachments"},
+	{ModuleRead, "View project modules"},
+	{ModuleManage, "Create, edit and delete modules"},
+	{DocumentationRead, "View project documentation"},
+	{DocumentationManage, "Upload and edit documentation"},
+	{ContractRead, "View contracts"},
+	{ContractManage, "Create, edit and delete contracts"},
+	{WebhookManage, "Manage outbound webhooks and redeliver events"},
+}
+
+var viewerPermissions = []Permission{
+	ProjectRead, TicketRead, ChatRead, AttachmentRead, ModuleRead, DocumentationRead, ContractRead,
+}
+
+var editorPermissions = append(slices.Clone(viewerPermissions),
+	TicketCreate, TicketUpdate, ChatWrite, AttachmentWrite,
+)
+
+var adminPermissions = append(slices.Clone(editorPermissions),
+	TicketDelete, ChatDelete, AttachmentDelete, ModuleManage, DocumentationManage, ContractManage, MembersManage,
+	WebhookManage,
+)
+
+var ownerPermissions = append(slices.Clone(adminPermissions), ProjectManage)
+
+// RolePermissions holds the permissions each role grants implicitly.
+var RolePermissions = map[Role][]Permission{
+	RoleViewer: viewerPermissions,
+	RoleEditor: editorPermissions,
+	RoleAdmin:  adminPermissions,
+	RoleOwner:  ownerPermissions,
+}
+
+// IsKnown reports whether p is part of the vocabulary.
+func IsKnown(p Permission) bool {
+	return slices.ContainsFunc(Catalogue, func(info PermissionInfo) bool { return info.Name == p })
+}
+
+// ValidatePermission is a validator.Func for the "permission" tag.
+func ValidatePermission(fl validator.FieldLevel) bool {
+	return IsKnown(Permission(fl.Field().String()))
+}
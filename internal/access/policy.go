@@ -0,0 +1,167 @@
+package access
+
+import (
+	"context"
+	"errors"
+	"innotech/pkg/auth"
+	"slices"
+)
+
+var (
+	// ErrUnauthenticated is returned when the context carries no identity.
+	ErrUnauthenticated = errors.New("unauthenticated")
+	// ErrForbidden is returned when the caller lacks the required permission.
+	ErrForbidden = errors.New("forbidden")
+)
+
+// Scope is the set of projects a caller may see for a given permission.
+type Scope struct {
+	All        bool  `json:"all"`
+	ProjectIDs []int `json:"project_ids"`
+}
+
+// Allows reports whether the scope includes projectID.
+func (s Scope) Allows(projectID int) bool {
+	return s.All || slices.Contains(s.ProjectIDs, projectID)
+}
+
+// Filter keeps the items whose project is inside the scope.
+func Filter[T any](s Scope, items []T, project func(T) int) []T {
+	if s.All {
+		return items
+	}
+	out := make([]T, 0, len(items))
+	for _, it := range items {
+		if s.Allows(project(it)) {
+			out = append(out, it)
+		}
+	}
+	return out
+}
+
+// Policy decides what the caller in ctx may do within a project.
+type Policy interface {
+	Authorize(ctx context.Context, projectID int, perm Permission) error
+	Scope(ctx context.Context, perm Permission) (Scope, error)
+	Effective(ctx context.Context, projectID int) ([]Permission, error)
+	IsSuperuser(ctx context.Context) bool
+	SenderRole(ctx context.Context, projectID int) (string, error)
+}
+
+// Sender roles from sender_role_enum: project admins and owners act on the
+// support side, everyone else acts as the client.
+const (
+	SenderClient = "client"
+	SenderAdmin  = "admin"
+)
+
+type policy struct {
+	repo Repository
+}
+
+// NewPolicy creates a Policy backed by user_projects memberships.
+func NewPolicy(repo Repository) Policy {
+	return &policy{repo: repo}
+}
+
+// Grants returns the effective permissions of a membership: the role defaults
+// plus any explicitly granted permissions.
+func (m *Membership) Grants() []Permission {
+	perms := slices.Clone(RolePermissions[m.Role])
+	for _, p := range m.Permissions {
+		perm := Permission(p)
+		if IsKnown(perm) && !slices.Contains(perms, perm) {
+			perms = append(perms, perm)
+		}
+	}
+	return perms
+}
+
+func (p *policy) IsSuperuser(ctx context.Context) bool {
+	id, ok := auth.FromContext(ctx)
+	return ok && id.HasRole(SuperuserRole)
+}
+
+func (p *policy) Authorize(ctx context.Context, projectID int, perm Permission) error {
+	id, ok := auth.FromContext(ctx)
+	if !ok {
+		return ErrUnauthenticated
+	}
+	if id.HasRole(SuperuserRole) {
+		return nil
+	}
+
+	m, err := p.repo.GetMembership(ctx, id.Subject, projectID)
+	if err != nil {
+		return err
+	}
+	if m == nil || !slices.Contains(m.Grants(), perm) {
+		return ErrForbidden
+	}
+	return nil
+}
+
+func (p *policy) SenderRole(ctx context.Context, projectID int) (string, error) {
+	id, ok := auth.FromContext(ctx)
+	if !ok {
+		return "", ErrUnauthenticated
+	}
+	if id.HasRole(SuperuserRole) {
+		return SenderAdmin, nil
+	}
+
+	m, err := p.repo.GetMembership(ctx, id.Subject, projectID)
+	if err != nil {
+		return "", err
+	}
+	if m != nil && (m.Role == RoleAdmin || m.Role == RoleOwner) {
+		return SenderAdmin, nil
+	}
+	return SenderClient, nil
+}
+
+func (p *policy) Scope(ctx context.Context, perm Permission) (Scope, error) {
+	id, ok := auth.FromContext(ctx)
+	if !ok {
+		return Scope{}, ErrUnauthenticated
+	}
+	if id.HasRole(SuperuserRole) {
+		return Scope{All: true}, nil
+	}
+
+	list, err := p.repo.ListMemberships(ctx, id.Subject)
+	if err != nil {
+		return Scope{}, err
+	}
+
+	scope := Scope{ProjectIDs: []int{}}
+	for i := range list {
+		if slices.Contains(list[i].Grants(), perm) {
+			scope.ProjectIDs = append(scope.ProjectIDs, list[i].ProjectID)
+		}
+	}
+	return scope, nil
+}
+
+func (p *policy) Effective(ctx context.Context, projectID int) ([]Permission, error) {
+	id, ok := auth.FromContext(ctx)
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+	if id.HasRole(SuperuserRole) {
+		all := make([]Permission, 0, len(Catalogue))
+		for _, info := range Catalogue {
+			all = append(all, info.Name)
+		}
+		return all, nil
+	}
+
+	m, err := p.repo.GetMembership(ctx, id.Subject, projectID)
+	if err != nil {
+		return nil, err
+	}
+	if m == nil {
+		return []Permission{}, nil
+	}
+	return m.Grants(), nil
+}
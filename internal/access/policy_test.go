@@ -0,0 +1,107 @@
+package access
+
+import (
+	"context"
+	"innotech/pkg/auth"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type mockRepository struct{ mock.Mock }
+
+func (m *mockRepository) GetMembership(ctx context.Context, userID string, projectID int) (*Membership, error) {
+	args := m.Called(ctx, userID, projectID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Membership), args.Error(1)
+}
+
+func (m *mockRepository) ListMemberships(ctx context.Context, userID string) ([]Membership, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]Membership), args.Error(1)
+}
+
+func (m *mockRepository) ProjectOf(ctx context.Context, res Resource, id int) (int, error) {
+	args := m.Called(ctx, res, id)
+	return args.Int(0), args.Error(1)
+}
+
+func userCtx(sub string, roles ...string) context.Context {
+	return auth.WithIdentity(context.Background(), &auth.Identity{Subject: sub, Roles: roles})
+}
+
+func TestPolicy_Authorize_RoleGrantsPermission(t *testing.T) {
+	repo := new(mockRepository)
+	p := NewPolicy(repo)
+
+	repo.On("GetMembership", mock.Anything, "u1", 7).
+		Return(&Membership{UserID: "u1", ProjectID: 7, Role: RoleEditor}, nil)
+
+	assert.NoError(t, p.Authorize(userCtx("u1"), 7, TicketCreate))
+	assert.ErrorIs(t, p.Authorize(userCtx("u1"), 7, TicketDelete), ErrForbidden)
+}
+
+func TestPolicy_Authorize_ExplicitPermissionExtendsRole(t *testing.T) {
+	repo := new(mockRepository)
+	p := NewPolicy(repo)
+
+	repo.On("GetMembership", mock.Anything, "u1", 7).
+		Return(&Membership{UserID: "u1", ProjectID: 7, Role: RoleViewer, Permissions: []string{"chat.write", "bogus"}}, nil)
+
+	assert.NoError(t, p.Authorize(userCtx("u1"), 7, ChatWrite))
+	assert.ErrorIs(t, p.Authorize(userCtx("u1"), 7, TicketCreate), ErrForbidden)
+}
+
+func TestPolicy_Authorize_NonMemberForbidden(t *testing.T) {
+	repo := new(mockRepository)
+	p := NewPolicy(repo)
+
+	repo.On("GetMembership", mock.Anything, "u1", 9).Return(nil, nil)
+
+	assert.ErrorIs(t, p.Authorize(userCtx("u1"), 9, TicketRead), ErrForbidden)
+}
+
+func TestPolicy_Authorize_SuperuserBypassesMembership(t *testing.T) {
+	repo := new(mockRepository)
+	p := NewPolicy(repo)
+
+	assert.NoError(t, p.Authorize(userCtx("root", SuperuserRole), 42, ProjectManage))
+	repo.AssertNotCalled(t, "GetMembership", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestPolicy_Authorize_Unauthenticated(t *testing.T) {
+	p := NewPolicy(new(mockRepository))
+	assert.ErrorIs(t, p.Authorize(context.Background(), 1, TicketRead), ErrUnauthenticated)
+}
+
+func TestPolicy_Scope_ListsProjectsWithPermission(t *testing.T) {
+	repo := new(mockRepository)
+	p := NewPolicy(repo)
+
+	repo.On("ListMemberships", mock.Anything, "u1").Return([]Membership{
+		{ProjectID: 1, Role: RoleViewer},
+		{ProjectID: 2, Role: RoleAdmin},
+		{ProjectID: 3, Role: RoleViewer, Permissions: []string{"contract.manage"}},
+	}, nil)
+
+	scope, err := p.Scope(userCtx("u1"), ContractManage)
+	require.NoError(t, err)
+	assert.Equal(t, []int{2, 3}, scope.ProjectIDs)
+	assert.False(t, scope.Allows(1))
+
+	type item struct{ project int }
+	filtered := Filter(scope, []item{{1}, {2}, {3}}, func(i item) int { return i.project })
+	assert.Equal(t, []item{{2}, {3}}, filtered)
+}
+
+func TestRolePermissions_OnlyUseKnownPermissions(t *testing.T) {
+	for role, perms := range RolePermissions {
+		for _, perm := range perms {
+			assert.True(t, IsKnown(perm), "role %s grants unknown permission %s", role, perm)
+		}
+	}
+}
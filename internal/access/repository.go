@@ -0,0 +1,120 @@
+package access
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"innotech/internal/storage/postgres"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Resource identifies a kind of entity whose owning project can be looked up.
+type Resource string
+
+// Resources guarded by the policy.
+const (
+	ResourceProject           Resource = "project"
+	ResourceTicket            Resource = "ticket"
+	ResourceTicketChat        Resource = "ticket_chat"
+	ResourceTicketAttachment  Resource = "ticket_attachment"
+	ResourceMessageAttachment Resource = "message_attachment"
+	ResourceModule            Resource = "module"
+	ResourceDocumentation     Resource = "documentation"
+	ResourceContract          Resource = "contract"
+	ResourceWebhook           Resource = "webhook"
+	ResourceWebhookDelivery   Resource = "webhook_delivery"
+)
+
+var projectQueries = map[Resource]string{
+	ResourceProject:       `SELECT id FROM projects WHERE id = $1`,
+	ResourceTicket:        `SELECT project_id FROM tickets WHERE id = $1`,
+	ResourceModule:        `SELECT project_id FROM modules WHERE id = $1`,
+	ResourceContract:      `SELECT project_id FROM contracts WHERE id = $1`,
+	ResourceDocumentation: `SELECT project_id FROM documentations WHERE id = $1`,
+	ResourceWebhook:       `SELECT project_id FROM webhook_subscriptions WHERE id = $1`,
+	ResourceWebhookDelivery: `
+		SELECT s.project_id FROM webhook_deliveries d
+		JOIN webhook_subscriptions s ON s.id = d.subscription_id
+		WHERE d.id = $1`,
+	ResourceTicketChat: `
+		SELECT t.project_id FROM ticket_chats c
+		JOIN tickets t ON t.id = c.ticket_id
+		WHERE c.id = $1`,
+	ResourceTicketAttachment: `
+		SELECT t.project_id FROM ticket_attachments a
+		JOIN tickets t ON t.id = a.ticket_id
+		WHERE a.id = $1`,
+	ResourceMessageAttachment: `
+		SELECT t.project_id FROM message_attachments a
+		JOIN ticket_chats c ON c.id = a.chat_id
+		JOIN tickets t ON t.id = c.ticket_id
+		WHERE a.id = $1`,
+}
+
+// Membership is the access-relevant part of a user_projects row.
+type Membership struct {
+	UserID      string               `db:"user_id" json:"user_id"`
+	ProjectID   int                  `db:"project_id" json:"project_id"`
+	Role        Role                 `db:"role" json:"role"`
+	Permissions postgres.StringArray `db:"permissions" json:"permissions"`
+}
+
+// Repository defines the data access needed by the policy engine.
+type Repository interface {
+	GetMembership(ctx context.Context, userID string, projectID int) (*Membership, error)
+	ListMemberships(ctx context.Context, userID string) ([]Membership, error)
+	ProjectOf(ctx context.Context, res Resource, id int) (int, error)
+}
+
+type repository struct {
+	db *sqlx.DB
+}
+
+// NewRepository creates a new Repository instance.
+func NewRepository(db *sqlx.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) GetMembership(ctx context.Context, userID string, projectID int) (*Membership, error) {
+	var m Membership
+	err := r.db.GetContext(ctx, &m, `
+		SELECT user_id, project_id, role, permissions
+		FROM user_projects
+		WHERE user_id = $1 AND project_id = $2
+	`, userID, projectID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (r *repository) ListMemberships(ctx context.Context, userID string) ([]Membership, error) {
+	var list []Membership
+	err := r.db.SelectContext(ctx, &list, `
+		SELECT user_id, project_id, role, permissions
+		FROM user_projects
+		WHERE user_id = $1
+		ORDER BY project_id
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *repository) ProjectOf(ctx context.Context, res Resource, id int) (int, error) {
+	query, ok := projectQueries[res]
+	if !ok {
+		return 0, fmt.Errorf("unknown resource %q", res)
+	}
+	var projectID int
+	if err := r.db.GetContext(ctx, &projectID, query, id); err != nil {
+		return 0, err
+	}
+	return projectID, nil
+}
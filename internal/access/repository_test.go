@@ -0,0 +1,67 @@
+package access
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepository_GetMembership_ScansTextArray(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = mockDB.Close() }()
+
+	repo := NewRepository(sqlx.NewDb(mockDB, "sqlmock"))
+
+	rows := sqlmock.NewRows([]string{"user_id", "project_id", "role", "permissions"}).
+		AddRow("u1", 3, "viewer", "{chat.write,attachment.write}")
+	mock.ExpectQuery(`SELECT user_id, project_id, role, permissions\s+FROM user_projects\s+WHERE user_id = \$1 AND project_id = \$2`).
+		WithArgs("u1", 3).
+		WillReturnRows(rows)
+
+	m, err := repo.GetMembership(context.Background(), "u1", 3)
+
+	require.NoError(t, err)
+	assert.Equal(t, RoleViewer, m.Role)
+	assert.Equal(t, []string{"chat.write", "attachment.write"}, []string(m.Permissions))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_GetMembership_NotMemberReturnsNil(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = mockDB.Close() }()
+
+	repo := NewRepository(sqlx.NewDb(mockDB, "sqlmock"))
+
+	mock.ExpectQuery(`FROM user_projects`).
+		WithArgs("u1", 3).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "project_id", "role", "permissions"}))
+
+	m, err := repo.GetMembership(context.Background(), "u1", 3)
+
+	assert.NoError(t, err)
+	assert.Nil(t, m)
+}
+
+func TestRepository_ProjectOf_MessageAttachmentJoinsTicket(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = mockDB.Close() }()
+
+	repo := NewRepository(sqlx.NewDb(mockDB, "sqlmock"))
+
+	mock.ExpectQuery(`SELECT t.project_id FROM message_attachments a\s+JOIN ticket_chats c ON c.id = a.chat_id\s+JOIN tickets t ON t.id = c.ticket_id`).
+		WithArgs(11).
+		WillReturnRows(sqlmock.NewRows([]string{"project_id"}).AddRow(5))
+
+	projectID, err := repo.ProjectOf(context.Background(), ResourceMessageAttachment, 11)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 5, projectID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,11 @@
+package access
+
+import "github.com/gofiber/fiber/v2"
+
+// RegisterRoutes registers HTTP routes for access control queries.
+func RegisterRoutes(app *fiber.App, h *Handler) {
+	api := app.Group("/api/access")
+
+	api.Get("/permissions", h.GetCatalogue)
+	api.Get("/projects/:project_id/permissions", h.GetEffective)
+}
@@ -2,20 +2,33 @@
 package app
 
 import (
+	"context"
 	// Import swagger docs for API documentation.
 	_ "innotech/docs"
+	"innotech/internal/access"
 	"innotech/internal/container"
 	"innotech/internal/contract"
 	"innotech/internal/documentations"
+	"innotech/internal/events"
 	"innotech/internal/files"
+	"innotech/internal/gitlab"
+	"innotech/internal/modules"
+	"innotech/internal/notifications"
+	"innotech/internal/outbox"
 	"innotech/internal/projects"
+	"innotech/internal/search"
+	"innotech/internal/sla"
 	user_projects "innotech/internal/userprojects"
+	"innotech/internal/webhooks"
 	"strconv"
 
 	"innotech/internal/health"
+	"innotech/internal/mailintake"
+	"innotech/internal/mattermost"
 	"innotech/internal/messageattachments"
 	"innotech/internal/ticketattachments"
 	"innotech/internal/ticketchats"
+	"innotech/internal/ticketevents"
 	"innotech/internal/tickets"
 	"innotech/pkg/middleware"
 
@@ -28,7 +41,13 @@ import (
 
 // Start initializes and starts the HTTP server with all registered routes.
 func Start(container *container.Container) {
-	app := fiber.New()
+	// Inbound emails with attachments are posted whole to /webhooks/mail;
+	// uploads get some room for the multipart envelope.
+	bodyLimit := mailintake.MaxMessageSize
+	if limit := int(container.Config.UploadMaxSize) + 1<<20; limit > bodyLimit {
+		bodyLimit = limit
+	}
+	app := fiber.New(fiber.Config{BodyLimit: bodyLimit})
 
 	app.Use(middleware.I18nMiddleware(container.I18nBundle))
 
@@ -50,16 +69,54 @@ func Start(container *container.Container) {
 
 	health.RegisterRoutes(app, container.HealthHandler)
 
-	tickets.RegisterRoutes(app, container.TicketHandler)
-	ticketchats.RegisterRoutes(app, container.TicketChatsHandler)
-	ticketattachments.RegisterRoutes(app, container.TicketAttachmentsHandler)
-	messageattachments.RegisterRoutes(app, container.MessageAttachmentsHandler)
-	contract.RegisterRoutes(app, container.ContractHandler)
-	projects.RegisterRoutes(app, container.ProjectHandler)
-	documentations.RegisterRoutes(app, container.DocumentationHandler)
-	user_projects.RegisterRoutes(app, container.UserProjectHandler)
+	// The filesystem storage driver serves its presigned URLs itself; they
+	// carry their own signature instead of an access token.
+	if container.StorageHandler != nil {
+		app.Get("/storage/*", container.StorageHandler)
+		app.Put("/storage/*", container.StorageHandler)
+	}
+
+	// Every /api route requires a Keycloak access token.
+	app.Use("/api", middleware.Auth(container.TokenVerifier))
+	app.Use("/api", container.NotificationHandler.TrackContact)
+
+	access.RegisterRoutes(app, container.AccessHandler)
+	tickets.RegisterRoutes(app, container.TicketHandler, container.AccessGuard)
+	ticketevents.RegisterRoutes(app, container.TicketEventsHandler, container.AccessGuard)
+	ticketchats.RegisterRoutes(app, container.TicketChatsHandler, container.TicketChatsStream, container.AccessGuard)
+	ticketattachments.RegisterRoutes(app, container.TicketAttachmentsHandler, container.AccessGuard)
+	messageattachments.RegisterRoutes(app, container.MessageAttachmentsHandler, container.AccessGuard)
+	contract.RegisterRoutes(app, container.ContractHandler, container.AccessGuard)
+	sla.RegisterRoutes(app, container.SLAHandler, container.AccessGuard)
+	projects.RegisterRoutes(app, container.ProjectHandler, container.AccessGuard)
+	events.RegisterRoutes(app, container.ProjectEventsStream, container.AccessGuard)
+	documentations.RegisterRoutes(app, container.DocumentationHandler, container.AccessGuard)
+	modules.RegisterRoutes(app, container.ModuleHandler, container.AccessGuard)
+	user_projects.RegisterRoutes(app, container.UserProjectHandler, container.AccessGuard)
+	search.RegisterRoutes(app, container.SearchHandler)
+	gitlab.RegisterRoutes(app, container.GitlabHandler, container.AccessGuard)
+	mattermost.RegisterRoutes(app, container.MattermostHandler)
+	webhooks.RegisterRoutes(app, container.WebhookHandler, container.AccessGuard)
+	outbox.RegisterRoutes(app, container.OutboxHandler, container.AccessGuard)
+	notifications.RegisterRoutes(app, container.NotificationHandler, container.AccessGuard)
+	mailintake.RegisterRoutes(app, container.MailHandler, container.AccessGuard)
 
-	files.RegisterRoutes(app, container.FileHandler)
+	files.RegisterRoutes(app, container.FileHandler, container.AccessGuard)
+
+	go container.Hub.Run(context.Background())
+	go container.WebhookDispatcher.Run(context.Background())
+	go container.OutboxDispatcher.Run(context.Background())
+	go container.FileCollector.Run(context.Background())
+	go container.FilePreviewer.Run(context.Background())
+	go container.FileQuarantine.Run(context.Background())
+	go container.SLAMonitor.Run(context.Background())
+	go container.ContractMonitor.Run(context.Background())
+	if container.NotificationMailer != nil {
+		go container.NotificationMailer.Run(context.Background())
+	}
+	if container.Maildir != nil {
+		go container.Maildir.Run(context.Background())
+	}
 
 	log.Printf(" Server running on port %d\n", container.Config.AppPort)
 	if err := app.Listen(":" + strconv.Itoa(container.Config.AppPort)); err != nil {
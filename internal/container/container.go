@@ -3,22 +3,44 @@ package container
 
 import (
 	"innotech/config"
+	"innotech/internal/access"
 	"innotech/internal/contract"
 	"innotech/internal/documentations"
+	"innotech/internal/events"
 	"innotech/internal/files"
+	"innotech/internal/gitlab"
 	"innotech/internal/health"
+	"innotech/internal/mailintake"
+	"innotech/internal/mattermost"
 	"innotech/internal/messageattachments"
+	"innotech/internal/modules"
+	"innotech/internal/notifications"
+	"innotech/internal/outbox"
 	"innotech/internal/projects"
+	"innotech/internal/search"
+	"innotech/internal/sla"
+	"innotech/internal/storage/transport"
 	"innotech/internal/ticketattachments"
 	"innotech/internal/ticketchats"
+	"innotech/internal/ticketevents"
 	"innotech/internal/tickets"
 	user_projects "innotech/internal/userprojects"
+	"innotech/internal/webhooks"
+	"innotech/pkg/auth"
+	"innotech/pkg/clamav"
 	"innotech/pkg/db"
 	"innotech/pkg/i18n"
 	"innotech/pkg/logger"
+	"innotech/pkg/middleware"
 	minio_client "innotech/pkg/minio"
+	"innotech/pkg/pgnotify"
+	"innotech/pkg/storage"
+	storage_fs "innotech/pkg/storage/fs"
+	"innotech/pkg/storage/memory"
 	"log"
+	"time"
 
+	"github.com/gofiber/fiber/v2"
 	"github.com/jmoiron/sqlx"
 	goi18n "github.com/nicksnyder/go-i18n/v2/i18n"
 )
@@ -28,16 +50,42 @@ type Container struct {
 	Config                    *config.Config
 	DB                        *sqlx.DB
 	I18nBundle                *goi18n.Bundle
+	TokenVerifier             *auth.Verifier
+	AccessGuard               *access.Guard
+	AccessHandler             *access.Handler
 	HealthHandler             *health.Handler
 	TicketHandler             *tickets.Handler
+	TicketEventsHandler       *ticketevents.Handler
 	TicketChatsHandler        *ticketchats.Handler
+	TicketChatsStream         *ticketchats.Stream
+	Hub                       *pgnotify.Hub
+	ProjectEventsStream       *events.Stream
 	TicketAttachmentsHandler  *ticketattachments.Handler
 	MessageAttachmentsHandler *messageattachments.Handler
 	ContractHandler           *contract.Handler
+	ContractMonitor           *contract.Monitor
+	SLAHandler                *sla.Handler
+	SLAMonitor                *sla.Monitor
 	ProjectHandler            *projects.Handler
 	DocumentationHandler      *documentations.Handler
+	ModuleHandler             *modules.Handler
 	UserProjectHandler        *user_projects.Handler
 	FileHandler               *files.Handler
+	FileCollector             *files.Collector
+	FilePreviewer             *files.Previewer
+	FileQuarantine            *files.Quarantine
+	StorageHandler            fiber.Handler
+	SearchHandler             *search.Handler
+	GitlabHandler             *gitlab.Handler
+	MattermostHandler         *mattermost.Handler
+	WebhookHandler            *webhooks.Handler
+	WebhookDispatcher         *webhooks.Dispatcher
+	OutboxHandler             *outbox.Handler
+	OutboxDispatcher          *outbox.Dispatcher
+	NotificationHandler       *notifications.Handler
+	NotificationMailer        *notifications.Mailer
+	MailHandler               *mailintake.Handler
+	Maildir                   *mailintake.Maildir
 }
 
 // New creates and initializes a new Container with all dependencies.
@@ -52,72 +100,272 @@ func New() *Container {
 		log.Fatalf("DB connection failed: %v", err)
 	}
 
+	tokenVerifier := auth.NewVerifier(
+		auth.NewKeySet(cfg.JWKSURL, cfg.JWKSCacheTTL),
+		auth.Config{
+			Issuer:   cfg.KeycloakIssuer,
+			ClientID: cfg.KeycloakClient,
+			Leeway:   30 * time.Second,
+		},
+	)
+
+	if err := middleware.RegisterValidation("permission", access.ValidatePermission); err != nil {
+		log.Fatalf("failed to register permission validator: %v", err)
+	}
+	if err := middleware.RegisterValidation("webhook_event", webhooks.ValidateEvent); err != nil {
+		log.Fatalf("failed to register webhook event validator: %v", err)
+	}
+	if err := middleware.RegisterValidation("webhook_url", webhooks.ValidateURL); err != nil {
+		log.Fatalf("failed to register webhook url validator: %v", err)
+	}
+	middleware.RegisterStructValidation(contract.ValidatePeriod, transport.CreateContractDTO{}, transport.UpdateContractDTO{})
+	accessRepo := access.NewRepository(database)
+	accessPolicy := access.NewPolicy(accessRepo)
+	accessGuard := access.NewGuard(accessPolicy, accessRepo)
+	accessHandler := access.NewHandler(accessPolicy)
+
 	healthService := health.NewSelfHealthService()
 	healthHandler := health.NewHandler(healthService)
 
+	bundle := i18n.InitBundle()
+	if err := i18n.LoadTranslations(bundle, "./locales"); err != nil {
+		log.Printf("warning: failed to load translations: %v", err)
+	}
+
+	transactor := db.NewTransactor(database)
+	hub := pgnotify.NewHub(database, cfg.DatabaseURL, cfg.NotifyChannel)
+
+	outboxRepo := outbox.NewRepository(database)
+	outboxWriter := outbox.NewWriter(outboxRepo, hub)
+	outboxDispatcher := outbox.NewDispatcher(outboxRepo, hub)
+	outboxHandler := outbox.NewHandler(outboxDispatcher)
+
+	webhookRepo := webhooks.NewRepository(database)
+	webhookService := webhooks.NewService(webhookRepo)
+	webhookHandler := webhooks.NewHandler(webhookService)
+	webhookDispatcher := webhooks.NewDispatcher(webhookRepo)
+
+	eventLogRepo := events.NewRepository(database)
+	activity := events.NewPublisher(eventLogRepo, outboxWriter)
+	projectEventsStream := events.NewStream(eventLogRepo, hub)
+
+	var gitlabClient *gitlab.Client
+	if cfg.GitlabURL != "" {
+		gitlabClient = gitlab.NewClient(cfg.GitlabURL, cfg.GitlabToken)
+	}
+	gitlabRepo := gitlab.NewRepository(database)
+	gitlabSyncer := gitlab.NewSyncer(gitlabRepo, gitlabClient)
+
+	var mattermostClient mattermost.Client
+	if cfg.MattermostURL != "" {
+		mattermostClient = mattermost.NewClient(cfg.MattermostURL, cfg.MattermostToken)
+	}
+	mattermostRepo := mattermost.NewRepository(database)
+	mattermostMirror := mattermost.NewMirror(mattermostRepo, mattermostClient, cfg.MattermostChannel)
+
+	outboxDispatcher.Register(events.TopicAppended, "webhooks", events.ListenerConsumer(webhookService))
+	outboxDispatcher.Register(events.TopicAppended, "sse", events.AnnounceConsumer(eventLogRepo, hub, transactor))
+	outboxDispatcher.Register(tickets.TopicTicketChanged, "gitlab", tickets.MirrorConsumer(gitlabSyncer))
+	outboxDispatcher.Register(tickets.TopicTicketChanged, "mattermost", tickets.MirrorConsumer(mattermostMirror))
+	outboxDispatcher.Register(ticketchats.TopicMessageCreated, "gitlab", ticketchats.MirrorConsumer(gitlabSyncer))
+	outboxDispatcher.Register(ticketchats.TopicMessageCreated, "mattermost", ticketchats.MirrorConsumer(mattermostMirror))
+
+	var replyTokens *mailintake.Tokens
+	if cfg.MailTokenSecret != "" {
+		replyTokens = mailintake.NewTokens(cfg.MailTokenSecret)
+	}
+
+	notificationRepo := notifications.NewRepository(database)
+	notificationService := notifications.NewService(notificationRepo)
+	notificationHandler := notifications.NewHandler(notificationService)
+	var notificationMailer *notifications.Mailer
+	var contractOwners contract.OwnerNotifier
+	if cfg.SMTPHost != "" {
+		renderer, err := notifications.NewRenderer(bundle, cfg.PublicURL, replyTokens.Token)
+		if err != nil {
+			log.Fatalf("failed to load notification templates: %v", err)
+		}
+		sender := notifications.NewSMTPSender(notifications.SMTPConfig{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.SMTPFrom,
+			ReplyTo:  cfg.SMTPReplyTo,
+			StartTLS: cfg.SMTPStartTLS,
+		})
+		notificationMailer = notifications.NewMailer(notificationRepo, renderer, sender)
+
+		notifier := notifications.NewNotifier(notificationRepo)
+		outboxDispatcher.Register(tickets.TopicTicketChanged, "email", outbox.Decode(notifier.TicketChanged))
+		outboxDispatcher.Register(ticketchats.TopicMessageCreated, "email", outbox.Decode(notifier.MessageCreated))
+		contractOwners = notifier
+	}
+
+	eventRepo := ticketevents.NewRepository(database)
+	eventService := ticketevents.NewService(eventRepo)
+	eventHandler := ticketevents.NewHandler(eventService)
+
 	ticketRepo := tickets.NewRepository(database)
-	ticketService := tickets.NewService(ticketRepo)
+	workflow := tickets.DefaultWorkflow()
+	if cfg.WorkflowFile != "" {
+		workflow, err = tickets.LoadWorkflow(cfg.WorkflowFile)
+		if err != nil {
+			log.Fatalf("failed to load ticket workflow: %v", err)
+		}
+	}
+	contractRepo := contract.NewRepository(database)
+	contractService := contract.NewService(contractRepo)
+	contractHandler := contract.NewHandler(contractService)
+	contractMonitor := contract.NewMonitor(contractRepo, activity, contractOwners, transactor, cfg.ContractWindows, cfg.ContractCheckPeriod, logger.Global)
+
+	slaRepo := sla.NewRepository(database)
+	slaService := sla.NewService(slaRepo, transactor)
+	slaHandler := sla.NewHandler(slaService)
+	slaMonitor := sla.NewMonitor(slaRepo, activity, transactor, cfg.SLACheckInterval, logger.Global)
+
+	ticketService := tickets.NewService(ticketRepo, workflow, accessPolicy, contractService, sla.NewClock(slaRepo), eventService, activity, outboxWriter, transactor)
 	ticketHandler := tickets.NewHandler(ticketService, logger.Global)
 
 	chatRepo := ticketchats.NewRepository(database)
-	chatService := ticketchats.NewService(chatRepo)
-	chatHandler := ticketchats.NewHandler(chatService)
+	chatRealtime := ticketchats.NewRealtime(hub)
+	chatService := ticketchats.NewService(chatRepo, chatRealtime, activity, outboxWriter, transactor)
+	chatHandler := ticketchats.NewHandler(chatService, accessPolicy)
+	chatStream := ticketchats.NewStream(chatRealtime)
+
+	gitlabReceiver := gitlab.NewReceiver(gitlabRepo, ticketService, chatService, cfg.GitlabSenderID)
+	gitlabHandler := gitlab.NewHandler(gitlabSyncer, gitlabReceiver, cfg.GitlabSecret)
+
+	mattermostReceiver := mattermost.NewReceiver(mattermostRepo, mattermostClient, chatService, cfg.MattermostSenderID)
+	mattermostHandler := mattermost.NewHandler(mattermostReceiver, cfg.MattermostHookToken)
+
+	fileStore, storageHandler := newStorage(cfg)
+	uploadTypes := cfg.UploadAllowedTypes
+	if len(uploadTypes) == 0 {
+		uploadTypes = files.DefaultAllowedTypes
+	}
+	fileVerifier := files.NewVerifier(fileStore, cfg.UploadMaxSize, uploadTypes)
+	fileLinks := files.NewLinks(fileStore, cfg.FileURLTTL, logger.Global)
 
 	attachRepo := ticketattachments.NewRepository(database)
-	attachService := ticketattachments.NewService(attachRepo)
+	attachService := ticketattachments.NewService(attachRepo, activity, fileVerifier, fileLinks, transactor)
 	attachHandler := ticketattachments.NewHandler(attachService)
 
 	msgAttachRepo := messageattachments.NewRepository(database)
-	msgAttachService := messageattachments.NewService(msgAttachRepo)
+	msgAttachService := messageattachments.NewService(msgAttachRepo, fileVerifier, fileLinks)
 	msgAttachHandler := messageattachments.NewHandler(msgAttachService)
 
-	contractRepo := contract.NewRepository(database)
-	contractService := contract.NewService(contractRepo)
-	contractHandler := contract.NewHandler(contractService)
-
 	projectRepo := projects.NewRepository(database)
 	projectService := projects.NewService(projectRepo)
 	projectHandler := projects.NewHandler(projectService)
 
 	docRepo := documentations.NewRepository(database)
-	docService := documentations.NewService(docRepo)
+	docService := documentations.NewService(docRepo, activity, transactor)
 	docHandler := documentations.NewHandler(docService)
 
+	moduleRepo := modules.NewRepository(database)
+	moduleService := modules.NewService(moduleRepo)
+	moduleHandler := modules.NewHandler(moduleService)
+
 	userProjectRepo := user_projects.NewRepository(database)
 	userProjectService := user_projects.NewService(userProjectRepo)
 	userProjectHandler := user_projects.NewHandler(userProjectService)
 
-	bundle := i18n.InitBundle()
-	if err := i18n.LoadTranslations(bundle, "./locales"); err != nil {
-		log.Printf("warning: failed to load translations: %v", err)
-	}
+	searchRepo := search.NewRepository(database)
+	searchService := search.NewService(searchRepo, accessPolicy)
+	searchHandler := search.NewHandler(searchService)
 
-	minioClient, err := minio_client.New(
-		cfg.MinioEndpoint,
-		cfg.MinioAccessKey,
-		cfg.MinioSecretKey,
-		cfg.MinioBucket,
-		cfg.MinioUseSSL,
-	)
-	if err != nil {
-		log.Fatalf("failed to initialize MinIO client: %v", err)
+	fileRepo := files.NewRepository(database)
+	fileService := files.NewService(fileStore, fileVerifier, fileRepo, attachService, msgAttachService, chatService, cfg.FileURLTTL, logger.Global)
+	fileCollector := files.NewCollector(fileRepo, fileStore, transactor, cfg.FileGCInterval, cfg.FileGCGrace, cfg.FileGCRemove, logger.Global)
+	filePreviewer := files.NewPreviewer(fileRepo, fileStore, transactor, logger.Global)
+	fileQuarantine := files.NewQuarantine(fileRepo, fileStore, newScanner(cfg), activity, transactor, logger.Global)
+	fileHandler := files.NewHandler(fileService, fileCollector, logger.Global)
+
+	mailRepo := mailintake.NewRepository(database)
+	mailService := mailintake.NewService(mailRepo)
+	mailProcessor := mailintake.NewProcessor(mailRepo, ticketService, chatService, attachService, fileStore, replyTokens, cfg.MailAuthservID, cfg.MailSenderID, transactor)
+	mailHandler := mailintake.NewHandler(mailService, mailProcessor, cfg.MailWebhookToken)
+	var maildir *mailintake.Maildir
+	if cfg.Maildir != "" {
+		maildir = mailintake.NewMaildir(cfg.Maildir, mailProcessor)
 	}
-	fileService := files.NewService(minioClient, logger.Global)
-	fileHandler := files.NewHandler(fileService, logger.Global)
 
 	return &Container{
 		Config:                    cfg,
 		DB:                        database,
 		I18nBundle:                bundle,
+		TokenVerifier:             tokenVerifier,
+		AccessGuard:               accessGuard,
+		AccessHandler:             accessHandler,
 		HealthHandler:             healthHandler,
 		TicketHandler:             ticketHandler,
+		TicketEventsHandler:       eventHandler,
 		TicketChatsHandler:        chatHandler,
+		TicketChatsStream:         chatStream,
+		Hub:                       hub,
+		ProjectEventsStream:       projectEventsStream,
 		TicketAttachmentsHandler:  attachHandler,
 		MessageAttachmentsHandler: msgAttachHandler,
 		ContractHandler:           contractHandler,
+		ContractMonitor:           contractMonitor,
+		SLAHandler:                slaHandler,
+		SLAMonitor:                slaMonitor,
 		ProjectHandler:            projectHandler,
 		DocumentationHandler:      docHandler,
+		ModuleHandler:             moduleHandler,
 		UserProjectHandler:        userProjectHandler,
 		FileHandler:               fileHandler,
+		FileCollector:             fileCollector,
+		FilePreviewer:             filePreviewer,
+		FileQuarantine:            fileQuarantine,
+		StorageHandler:            storageHandler,
+		SearchHandler:             searchHandler,
+		GitlabHandler:             gitlabHandler,
+		MattermostHandler:         mattermostHandler,
+		WebhookHandler:            webhookHandler,
+		WebhookDispatcher:         webhookDispatcher,
+		OutboxHandler:             outboxHandler,
+		OutboxDispatcher:          outboxDispatcher,
+		NotificationHandler:       notificationHandler,
+		NotificationMailer:        notificationMailer,
+		MailHandler:               mailHandler,
+		Maildir:                   maildir,
+	}
+}
+
+// newStorage creates the configured storage backend. Only the filesystem
+// driver comes with a handler, which serves its signed URLs.
+func newStorage(cfg *config.Config) (storage.Backend, fiber.Handler) {
+	switch cfg.StorageDriver {
+	case "fs":
+		driver, err := storage_fs.New(cfg.StorageDir, cfg.StoragePublicURL, cfg.StorageURLSecret)
+		if err != nil {
+			log.Fatalf("failed to initialize file storage: %v", err)
+		}
+		return driver, driver.Serve
+	case "memory":
+		return memory.New(), nil
+	}
+	minioClient, err := minio_client.New(
+		cfg.MinioEndpoint,
+		cfg.MinioAccessKey,
+		cfg.MinioSecretKey,
+		cfg.MinioBucket,
+		cfg.MinioUseSSL,
+	)
+	if err != nil {
+		log.Fatalf("failed to initialize MinIO client: %v", err)
+	}
+	return minioClient, nil
+}
+
+// newScanner creates the malware scanner for uploaded files, falling back to
+// the stub when no clamd is configured.
+func newScanner(cfg *config.Config) files.Scanner {
+	if cfg.ClamAVAddress == "" {
+		logger.Warn("CLAMAV_ADDRESS is not set, uploaded files are not scanned for malware")
+		return files.StubScanner{}
 	}
+	return clamav.New(cfg.ClamAVAddress, cfg.ScanTimeout)
 }
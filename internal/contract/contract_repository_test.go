@@ -2,49 +2,109 @@
 package contract
 
 import (
+	"context"
+	"database/sql"
+	"innotech/internal/storage/postgres"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/jmoiron/sqlx"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+var contractColumns = []string{"id", "project_id", "name", "valid_from", "valid_to", "client_company", "grace_period_days", "sla_policy_id", "date_created", "date_updated"}
+
 func TestContractRepository_CRUD(t *testing.T) {
 	db, mock, _ := sqlmock.New()
 	defer func() { _ = db.Close() }()
-	sqlxDB := sqlx.NewDb(db, "sqlmock")
-	repo := NewRepository(sqlxDB)
-
-	rows := sqlmock.NewRows([]string{"id", "project_id", "client_name", "start_date", "end_date", "description"}).
-		AddRow(1, 1, "Client", time.Now(), time.Now().AddDate(1, 0, 0), "Desc")
-	mock.ExpectQuery(`SELECT \* FROM contracts ORDER BY id`).WillReturnRows(rows)
-	all, err := repo.GetAll()
-	assert.NoError(t, err)
-	assert.Len(t, all, 1)
-
-	row := sqlmock.NewRows([]string{"id", "project_id", "client_name", "start_date", "end_date", "description"}).
-		AddRow(1, 1, "Client", time.Now(), time.Now().AddDate(1, 0, 0), "Desc")
-	mock.ExpectQuery(`SELECT \* FROM contracts WHERE id=\$1`).WithArgs(1).WillReturnRows(row)
-	got, err := repo.GetByID(1)
-	assert.NoError(t, err)
-	assert.Equal(t, "Client", got.ClientName)
-
-	startDate := time.Now()
-	endDate := time.Now().AddDate(1, 0, 0)
-	c := &Contract{ProjectID: 1, ClientName: "New Client", StartDate: startDate, EndDate: endDate, Description: "D"}
-	mock.ExpectQuery(`INSERT INTO contracts \(project_id, client_name, start_date, end_date, description\) VALUES \(\$1, \$2, \$3, \$4, \$5\) RETURNING id`).
-		WithArgs(1, "New Client", startDate, endDate, "D").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
-	err = repo.Create(c)
-	assert.NoError(t, err)
-
-	mock.ExpectExec(`UPDATE contracts SET client_name=\$1, start_date=\$2, end_date=\$3, description=\$4 WHERE id=\$5`).
-		WithArgs("New Client", startDate, endDate, "D", 1).WillReturnResult(sqlmock.NewResult(1, 1))
-	c.ID = 1
-	err = repo.Update(c)
-	assert.NoError(t, err)
-
-	mock.ExpectExec(`DELETE FROM contracts WHERE id=\$1`).WithArgs(1).WillReturnResult(sqlmock.NewResult(1, 1))
-	err = repo.Delete(1)
-	assert.NoError(t, err)
+	repo := NewRepository(sqlx.NewDb(db, "sqlmock"))
+
+	ctx := context.Background()
+	now := time.Now()
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(1, 0, 0)
+	company := "ООО Ромашка"
+
+	mock.ExpectQuery(`SELECT \* FROM contracts ORDER BY id`).
+		WillReturnRows(sqlmock.NewRows(contractColumns).AddRow(1, 3, "Поддержка", from, to, company, 0, 5, now, now))
+	all, err := repo.GetAll(ctx)
+	require.NoError(t, err)
+	require.Len(t, all, 1)
+	assert.Equal(t, "Поддержка", all[0].Name)
+	assert.Equal(t, to, *all[0].ValidTo)
+
+	mock.ExpectQuery(`SELECT \* FROM contracts WHERE project_id = \$1 ORDER BY id`).WithArgs(3).
+		WillReturnRows(sqlmock.NewRows(contractColumns).AddRow(1, 3, "Поддержка", nil, nil, nil, 0, nil, now, now))
+	list, err := repo.ListByProject(ctx, 3)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Nil(t, list[0].ValidFrom)
+
+	mock.ExpectQuery(`SELECT \* FROM contracts WHERE project_id = \$1 ORDER BY id`).WithArgs(4).
+		WillReturnRows(sqlmock.NewRows(contractColumns))
+	list, err = repo.ListByProject(ctx, 4)
+	require.NoError(t, err)
+	assert.NotNil(t, list, "empty project lists as []")
+
+	mock.ExpectQuery(`SELECT \* FROM contracts WHERE id = \$1`).WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(contractColumns).AddRow(1, 3, "Поддержка", from, to, company, 0, 5, now, now))
+	got, err := repo.GetByID(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, company, *got.ClientCompany)
+
+	policyID := 5
+	c := &postgres.Contract{ProjectID: 3, Name: "Внедрение", ValidFrom: &from, ValidTo: &to, ClientCompany: &company, GraceDays: 14, SLAPolicyID: &policyID}
+	mock.ExpectQuery(`INSERT INTO contracts \(project_id, name, valid_from, valid_to, client_company, grace_period_days, sla_policy_id\)`).
+		WithArgs(3, "Внедрение", &from, &to, &company, 14, &policyID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "date_created", "date_updated"}).AddRow(2, now, now))
+	require.NoError(t, repo.Create(ctx, c))
+	assert.Equal(t, 2, c.ID)
+
+	update := &postgres.Contract{ID: 2, Name: "Внедрение и поддержка", ValidFrom: &from}
+	mock.ExpectQuery(`UPDATE contracts\s+SET name = \$2, valid_from = \$3, valid_to = \$4, client_company = \$5, grace_period_days = \$6, sla_policy_id = \$7\s+WHERE id = \$1\s+RETURNING project_id, date_created, date_updated`).
+		WithArgs(2, "Внедрение и поддержка", &from, nil, nil, 0, nil).
+		WillReturnRows(sqlmock.NewRows([]string{"project_id", "date_created", "date_updated"}).AddRow(3, now, now))
+	require.NoError(t, repo.Update(ctx, update))
+	assert.Equal(t, 3, update.ProjectID)
+
+	mock.ExpectQuery(`UPDATE contracts`).WithArgs(9, "Нет", nil, nil, nil, 0, nil).
+		WillReturnRows(sqlmock.NewRows([]string{"project_id", "date_created", "date_updated"}))
+	assert.ErrorIs(t, repo.Update(ctx, &postgres.Contract{ID: 9, Name: "Нет"}), sql.ErrNoRows)
+
+	mock.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM user_projects WHERE contract_id = \$1 AND user_id = \$2\)`).
+		WithArgs(2, "user-1").WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	assigned, err := repo.IsAssigned(ctx, 2, "user-1")
+	require.NoError(t, err)
+	assert.True(t, assigned)
+
+	mock.ExpectExec(`DELETE FROM contracts WHERE id = \$1`).WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, repo.Delete(ctx, 2))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestContractRepository_ClaimReminder(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer func() { _ = db.Close() }()
+	repo := NewRepository(sqlx.NewDb(db, "sqlmock"))
+
+	ctx := context.Background()
+	validTo := time.Date(2026, 3, 5, 0, 0, 0, 0, time.UTC)
+	c := &postgres.Contract{ID: 2, ValidTo: &validTo}
+
+	mock.ExpectQuery(`INSERT INTO contract_reminders .* window_days <= \$3`).WithArgs(2, &validTo, 7).
+		WillReturnRows(sqlmock.NewRows([]string{"bool"}).AddRow(true))
+	claimed, err := repo.ClaimReminder(ctx, c, 7)
+	require.NoError(t, err)
+	assert.True(t, claimed)
+
+	mock.ExpectQuery(`INSERT INTO contract_reminders`).WithArgs(2, &validTo, 14).
+		WillReturnRows(sqlmock.NewRows([]string{"bool"}))
+	claimed, err = repo.ClaimReminder(ctx, c, 14)
+	require.NoError(t, err)
+	assert.False(t, claimed, "a narrower window was reminded about already")
+
+	assert.NoError(t, mock.ExpectationsWereMet())
 }
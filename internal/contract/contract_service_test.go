@@ -1,68 +1,114 @@
 package contract
 
 import (
+	"context"
+	"innotech/internal/storage/postgres"
+	"innotech/internal/storage/transport"
+	"innotech/pkg/logger"
+	"innotech/pkg/middleware"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
 	"testing"
 	"time"
 
-	"github.com/DATA-DOG/go-sqlmock"
-	"github.com/jmoiron/sqlx"
+	"github.com/gofiber/fiber/v2"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
-func TestContractService_CRUD(t *testing.T) {
-	db, mock, _ := sqlmock.New()
-	defer func() { _ = db.Close() }()
-	sqlxDB := sqlx.NewDb(db, "sqlmock")
-
-	repo := NewRepository(sqlxDB)
-	svc := NewService(repo)
+func TestMain(m *testing.M) {
+	logger.Init()
+	middleware.RegisterStructValidation(ValidatePeriod, transport.CreateContractDTO{}, transport.UpdateContractDTO{})
+	os.Exit(m.Run())
+}
 
-	now := time.Now()
-	startDate := now
-	endDate := now.AddDate(1, 0, 0)
+type MockContractRepository struct{ mock.Mock }
 
-	rows := sqlmock.NewRows([]string{"id", "project_id", "client_name", "start_date", "end_date", "description"}).
-		AddRow(1, 1, "Client", startDate, endDate, "Agreement")
-	mock.ExpectQuery(`SELECT \* FROM contracts ORDER BY id`).WillReturnRows(rows)
+func (m *MockContractRepository) Create(ctx context.Context, c *postgres.Contract) error {
+	return m.Called(ctx, c).Error(0)
+}
+func (m *MockContractRepository) GetByID(ctx context.Context, id int) (*postgres.Contract, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(*postgres.Contract), args.Error(1)
+}
+func (m *MockContractRepository) GetAll(ctx context.Context) ([]postgres.Contract, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]postgres.Contract), args.Error(1)
+}
+func (m *MockContractRepository) ListByProject(ctx context.Context, projectID int) ([]postgres.Contract, error) {
+	args := m.Called(ctx, projectID)
+	return args.Get(0).([]postgres.Contract), args.Error(1)
+}
+func (m *MockContractRepository) Update(ctx context.Context, c *postgres.Contract) error {
+	return m.Called(ctx, c).Error(0)
+}
+func (m *MockContractRepository) Delete(ctx context.Context, id int) error {
+	return m.Called(ctx, id).Error(0)
+}
+func (m *MockContractRepository) IsAssigned(ctx context.Context, contractID int, userID string) (bool, error) {
+	args := m.Called(ctx, contractID, userID)
+	return args.Bool(0), args.Error(1)
+}
+func (m *MockContractRepository) Expiring(ctx context.Context, from, to time.Time) ([]postgres.Contract, error) {
+	args := m.Called(ctx, from, to)
+	return args.Get(0).([]postgres.Contract), args.Error(1)
+}
+func (m *MockContractRepository) ClaimReminder(ctx context.Context, c *postgres.Contract, window int) (bool, error) {
+	args := m.Called(ctx, c, window)
+	return args.Bool(0), args.Error(1)
+}
 
-	items, err := svc.GetAll()
-	assert.NoError(t, err)
-	assert.Len(t, items, 1)
-	assert.Equal(t, "Client", items[0].ClientName)
+func TestContractService_CRUD(t *testing.T) {
+	repo := new(MockContractRepository)
+	svc := NewService(repo)
+	ctx := context.Background()
 
-	row := sqlmock.NewRows([]string{"id", "project_id", "client_name", "start_date", "end_date", "description"}).
-		AddRow(1, 1, "Client", startDate, endDate, "Agreement")
-	mock.ExpectQuery(`SELECT \* FROM contracts WHERE id=\$1`).WithArgs(1).WillReturnRows(row)
+	c := &postgres.Contract{ID: 1, ProjectID: 3, Name: "Поддержка"}
+	repo.On("Create", ctx, c).Return(nil)
+	repo.On("GetAll", ctx).Return([]postgres.Contract{*c}, nil)
+	repo.On("ListByProject", ctx, 3).Return([]postgres.Contract{*c}, nil)
+	repo.On("GetByID", ctx, 1).Return(c, nil)
+	repo.On("Update", ctx, c).Return(nil)
+	repo.On("Delete", ctx, 1).Return(nil)
 
-	got, err := svc.GetByID(1)
+	assert.NoError(t, svc.Create(ctx, c))
+	all, err := svc.GetAll(ctx)
 	assert.NoError(t, err)
-	assert.Equal(t, "Client", got.ClientName)
-
-	contract := &Contract{
-		ProjectID:   1,
-		ClientName:  "New Client",
-		StartDate:   startDate,
-		EndDate:     endDate,
-		Description: "New Agreement",
-	}
-	mock.ExpectQuery(`INSERT INTO contracts \(project_id, client_name, start_date, end_date, description\) VALUES \(\$1, \$2, \$3, \$4, \$5\) RETURNING id`).
-		WithArgs(1, "New Client", startDate, endDate, "New Agreement").
-		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
-
-	err = svc.Create(contract)
+	assert.Len(t, all, 1)
+	list, err := svc.ListByProject(ctx, 3)
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+	got, err := svc.GetByID(ctx, 1)
 	assert.NoError(t, err)
-	assert.Equal(t, 2, contract.ID)
+	assert.Equal(t, "Поддержка", got.Name)
+	assert.NoError(t, svc.Update(ctx, c))
+	assert.NoError(t, svc.Delete(ctx, 1))
+	repo.AssertExpectations(t)
+}
 
-	contract.ID = 2
-	contract.ClientName = "Updated Client"
-	mock.ExpectExec(`UPDATE contracts SET client_name=\$1, start_date=\$2, end_date=\$3, description=\$4 WHERE id=\$5`).
-		WithArgs("Updated Client", startDate, endDate, "New Agreement", 2).
-		WillReturnResult(sqlmock.NewResult(1, 1))
+func TestHandler_Create_ValidatesPeriod(t *testing.T) {
+	repo := new(MockContractRepository)
+	repo.On("Create", mock.Anything, mock.Anything).Return(nil)
+	h := NewHandler(NewService(repo))
+	app := fiber.New()
+	app.Post("/contracts", middleware.ValidateBody[transport.CreateContractDTO](h.Create))
 
-	err = svc.Update(contract)
-	assert.NoError(t, err)
+	post := func(body string) int {
+		req := httptest.NewRequest(http.MethodPost, "/contracts", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
 
-	mock.ExpectExec(`DELETE FROM contracts WHERE id=\$1`).WithArgs(2).WillReturnResult(sqlmock.NewResult(1, 1))
-	err = svc.Delete(2)
-	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusCreated, post(`{"project_id":3,"name":"Поддержка","valid_from":"2025-01-01T00:00:00Z","valid_to":"2026-01-01T00:00:00Z"}`))
+	assert.Equal(t, fiber.StatusCreated, post(`{"project_id":3,"name":"Поддержка","valid_from":"2025-01-01T00:00:00Z"}`))
+	assert.Equal(t, fiber.StatusCreated, post(`{"project_id":3,"name":"Поддержка","valid_to":"2026-01-01T00:00:00Z"}`))
+	assert.Equal(t, fiber.StatusBadRequest, post(`{"project_id":3,"name":"Поддержка","valid_from":"2026-01-01T00:00:00Z","valid_to":"2025-01-01T00:00:00Z"}`))
+	assert.Equal(t, fiber.StatusBadRequest, post(`{"project_id":3,"name":"Поддержка","valid_from":"2025-01-01T00:00:00Z","valid_to":"2025-01-01T00:00:00Z"}`))
+	assert.Equal(t, fiber.StatusBadRequest, post(`{"project_id":3,"valid_from":"2025-01-01T00:00:00Z"}`))
+	repo.AssertNumberOfCalls(t, "Create", 3)
 }
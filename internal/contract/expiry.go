@@ -0,0 +1,142 @@
+package contract
+
+import (
+	"context"
+	"innotech/internal/events"
+	"innotech/internal/storage/postgres"
+	"innotech/pkg/db"
+	"log/slog"
+	"slices"
+	"time"
+)
+
+// ExpiringContract is a contract in the expiry report.
+type ExpiringContract struct {
+	postgres.Contract
+	// DaysLeft counts the days from today to valid_to; it is negative once
+	// the contract ended.
+	DaysLeft int `json:"days_left"`
+	// ReadOnly is set once the grace period is over too and no new tickets
+	// are accepted on the contract.
+	ReadOnly bool `json:"read_only"`
+}
+
+// Activity records events in the activity log of a project.
+type Activity interface {
+	Publish(ctx context.Context, projectID int, eventType string, payload any) error
+}
+
+// OwnerNotifier notifies the owners of a project about its contracts.
+type OwnerNotifier interface {
+	ContractExpiring(ctx context.Context, c *postgres.Contract, daysLeft, window int) error
+}
+
+// Monitor reminds about contracts that are about to expire. A contract is
+// announced on the project feed, and its project owners are notified, once
+// for each window (in days before valid_to) it enters; a contract first seen
+// inside several windows is only reminded about for the narrowest one.
+type Monitor struct {
+	repo     Repository
+	activity Activity
+	owners   OwnerNotifier
+	tx       db.Transactor
+	windows  []int
+	interval time.Duration
+	logger   *slog.Logger
+	now      func() time.Time
+}
+
+// NewMonitor creates a Monitor that checks contracts every interval. Owners
+// are not notified when owners is nil.
+func NewMonitor(repo Repository, activity Activity, owners OwnerNotifier, tx db.Transactor, windows []int, interval time.Duration, logger *slog.Logger) *Monitor {
+	windows = slices.Clone(windows)
+	slices.Sort(windows)
+	return &Monitor{
+		repo:     repo,
+		activity: activity,
+		owners:   owners,
+		tx:       tx,
+		windows:  slices.Compact(windows),
+		interval: interval,
+		logger:   logger,
+		now:      time.Now,
+	}
+}
+
+// Run checks contracts until ctx is done.
+func (m *Monitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := m.Check(ctx); err != nil {
+			m.logger.Error("contract expiry check failed", "err", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check sends the reminders that came due since the last check and returns
+// how many it sent.
+func (m *Monitor) Check(ctx context.Context) (int, error) {
+	if len(m.windows) == 0 {
+		return 0, nil
+	}
+	today := dateOf(m.now())
+	var n int
+	err := m.tx.WithinTx(ctx, func(ctx context.Context) error {
+		list, err := m.repo.Expiring(ctx, today, today.AddDate(0, 0, m.windows[len(m.windows)-1]))
+		if err != nil {
+			return err
+		}
+		for i := range list {
+			c := &list[i]
+			left := daysBetween(today, *c.ValidTo)
+			window := m.windows[slices.IndexFunc(m.windows, func(w int) bool { return w >= left })]
+			claimed, err := m.repo.ClaimReminder(ctx, c, window)
+			if err != nil {
+				return err
+			}
+			if !claimed {
+				continue
+			}
+			err = m.activity.Publish(ctx, c.ProjectID, events.ContractExpiring, map[string]any{
+				"contract_id": c.ID,
+				"name":        c.Name,
+				"valid_to":    c.ValidTo.Format(time.DateOnly),
+				"days_left":   left,
+				"window":      window,
+			})
+			if err != nil {
+				return err
+			}
+			if m.owners != nil {
+				if err := m.owners.ContractExpiring(ctx, c, left, window); err != nil {
+					return err
+				}
+			}
+			n++
+		}
+		return nil
+	})
+	return n, err
+}
+
+// expiringOn describes c in the expiry report as of day.
+func expiringOn(c postgres.Contract, day time.Time) ExpiringContract {
+	return ExpiringContract{
+		Contract: c,
+		DaysLeft: daysBetween(dateOf(day), *c.ValidTo),
+		ReadOnly: PeriodOn(&c, day) == PeriodExpired,
+	}
+}
+
+// daysBetween returns the number of calendar days from the date day to the
+// date of t.
+func daysBetween(day, t time.Time) int {
+	return int(dateOf(t).Sub(day).Hours() / 24)
+}
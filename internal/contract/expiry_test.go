@@ -0,0 +1,105 @@
+package contract
+
+import (
+	"context"
+	"innotech/internal/events"
+	"innotech/internal/storage/postgres"
+	"innotech/pkg/db"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type published struct {
+	projectID int
+	eventType string
+	payload   any
+}
+
+type fakeActivity struct {
+	events []published
+}
+
+func (a *fakeActivity) Publish(_ context.Context, projectID int, eventType string, payload any) error {
+	a.events = append(a.events, published{projectID, eventType, payload})
+	return nil
+}
+
+type reminder struct {
+	contractID, daysLeft, window int
+}
+
+type fakeOwners struct {
+	reminders []reminder
+}
+
+func (o *fakeOwners) ContractExpiring(_ context.Context, c *postgres.Contract, daysLeft, window int) error {
+	o.reminders = append(o.reminders, reminder{c.ID, daysLeft, window})
+	return nil
+}
+
+func date(y int, m time.Month, d int) *time.Time {
+	t := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	return &t
+}
+
+func TestMonitor_Check_RemindsOncePerWindow(t *testing.T) {
+	repo := new(MockContractRepository)
+	activity, owners := &fakeActivity{}, &fakeOwners{}
+	monitor := NewMonitor(repo, activity, owners, db.NopTransactor{}, []int{7, 30, 14}, time.Hour, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	monitor.now = func() time.Time { return time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC) }
+
+	list := []postgres.Contract{
+		{ID: 1, ProjectID: 3, Name: "Поддержка", ValidTo: date(2026, 3, 25)},
+		{ID: 2, ProjectID: 3, Name: "Сопровождение", ValidTo: date(2026, 3, 5)},
+		{ID: 3, ProjectID: 4, Name: "Доработки", ValidTo: date(2026, 3, 10)},
+	}
+	repo.On("Expiring", mock.Anything, *date(2026, 3, 1), *date(2026, 3, 31)).Return(list, nil).Once()
+	repo.On("ClaimReminder", mock.Anything, &list[0], 30).Return(true, nil).Once()
+	repo.On("ClaimReminder", mock.Anything, &list[1], 7).Return(true, nil).Once()
+	repo.On("ClaimReminder", mock.Anything, &list[2], 14).Return(false, nil).Once()
+
+	n, err := monitor.Check(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []reminder{{1, 24, 30}, {2, 4, 7}}, owners.reminders)
+	require.Len(t, activity.events, 2)
+	assert.Equal(t, published{3, events.ContractExpiring, map[string]any{
+		"contract_id": 2,
+		"name":        "Сопровождение",
+		"valid_to":    "2026-03-05",
+		"days_left":   4,
+		"window":      7,
+	}}, activity.events[1])
+	repo.AssertExpectations(t)
+}
+
+func TestContractService_Expiring_ReportsDaysLeftAndReadOnly(t *testing.T) {
+	repo := new(MockContractRepository)
+	svc := NewService(repo)
+	now := time.Date(2026, 3, 10, 15, 0, 0, 0, time.UTC)
+
+	list := []postgres.Contract{
+		{ID: 1, ProjectID: 3, ValidTo: date(2026, 3, 1)},
+		{ID: 2, ProjectID: 3, ValidTo: date(2026, 3, 5), GraceDays: 7},
+		{ID: 3, ProjectID: 3, ValidTo: date(2026, 3, 20)},
+	}
+	repo.On("Expiring", mock.Anything, *date(2026, 2, 24), *date(2026, 3, 24)).Return(list, nil).Once()
+
+	report, err := svc.Expiring(context.Background(), now, 14)
+
+	require.NoError(t, err)
+	require.Len(t, report, 3)
+	assert.Equal(t, -9, report[0].DaysLeft)
+	assert.True(t, report[0].ReadOnly)
+	assert.Equal(t, -5, report[1].DaysLeft)
+	assert.False(t, report[1].ReadOnly, "still within the grace period")
+	assert.Equal(t, 10, report[2].DaysLeft)
+	assert.False(t, report[2].ReadOnly)
+}
@@ -1,7 +1,14 @@
 package contract
 
 import (
+	"database/sql"
+	"errors"
+	"innotech/internal/access"
+	"innotech/internal/storage/postgres"
+	"innotech/internal/storage/transport"
+	"slices"
 	"strconv"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -12,102 +19,189 @@ type Handler struct {
 }
 
 // NewHandler creates a new Handler instance.
-func NewHandler(service *Service) *Handler {
-	return &Handler{service: *service}
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
 }
 
-// GetAll godoc
-// @Summary Get all contracts
-// @Description Returns all contracts
+// Create godoc
+// @Summary Создать договор
 // @Tags Contracts
+// @Accept json
 // @Produce json
-// @Success 200 {array} contract.Contract
-// @Router /api/contracts [get]
-func (h *Handler) GetAll(c *fiber.Ctx) error {
-	items, err := h.service.GetAll()
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+// @Param contract body transport.CreateContractDTO true "Contract Data"
+// @Success 201 {object} postgres.Contract
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/contracts [post]
+// Create handles the creation of a new contract.
+func (h *Handler) Create(c *fiber.Ctx) error {
+	dto := c.Locals("body").(*transport.CreateContractDTO)
+	contract := postgres.Contract{
+		ProjectID:     dto.ProjectID,
+		Name:          dto.Name,
+		ValidFrom:     dto.ValidFrom,
+		ValidTo:       dto.ValidTo,
+		ClientCompany: dto.ClientCompany,
+		GraceDays:     dto.GraceDays,
+		SLAPolicyID:   dto.SLAPolicyID,
+	}
+	if err := h.service.Create(c.UserContext(), &contract); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
-	return c.JSON(items)
+	return c.Status(fiber.StatusCreated).JSON(contract)
 }
 
 // GetByID godoc
-// @Summary Get contract by ID
-// @Description Returns a single contract by its ID
+// @Summary Получить договор по ID
 // @Tags Contracts
 // @Produce json
 // @Param id path int true "Contract ID"
-// @Success 200 {object} contract.Contract
+// @Success 200 {object} postgres.Contract
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
 // @Router /api/contracts/{id} [get]
+// GetByID retrieves a contract by its ID.
 func (h *Handler) GetByID(c *fiber.Ctx) error {
 	id, err := strconv.Atoi(c.Params("id"))
 	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "invalid id"})
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+	contract, err := h.service.GetByID(c.UserContext(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "not found"})
 	}
-	item, err := h.service.GetByID(id)
 	if err != nil {
-		return c.Status(404).JSON(fiber.Map{"error": "not found"})
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
-	return c.JSON(item)
+	return c.JSON(contract)
 }
 
-// Create godoc
-// @Summary Create a new contract
+// GetAll godoc
+// @Summary Получить все договоры
 // @Tags Contracts
-// @Accept json
 // @Produce json
-// @Param contract body contract.Contract true "Contract Data"
-// @Success 201 {object} contract.Contract
-// @Router /api/contracts [post]
-func (h *Handler) Create(c *fiber.Ctx) error {
-	var input Contract
-	if err := c.BodyParser(&input); err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+// @Success 200 {array} postgres.Contract
+// @Failure 500 {object} map[string]string
+// @Router /api/contracts [get]
+// GetAll retrieves the contracts of the projects the caller can see.
+func (h *Handler) GetAll(c *fiber.Ctx) error {
+	list, err := h.service.GetAll(c.UserContext())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
-	if err := h.service.Create(&input); err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	list = access.Filter(access.ScopeFrom(c), list, func(ct postgres.Contract) int { return ct.ProjectID })
+	return c.JSON(list)
+}
+
+// DefaultReportDays is the range of the expiry report when none is given.
+const DefaultReportDays = 30
+
+// Expiring godoc
+// @Summary Получить договоры, срок действия которых истекает
+// @Description Договоры, срок действия которых заканчивается в ближайшие days дней или закончился не раньше чем days дней назад. Договоры с read_only=true уже не принимают новые заявки.
+// @Tags Contracts
+// @Produce json
+// @Param days query int false "Report range in days" default(30)
+// @Param project_id query int false "Project ID"
+// @Success 200 {array} contract.ExpiringContract
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/contracts/expiring [get]
+// Expiring reports the contracts about to expire, or recently expired, in
+// the projects the caller can see.
+func (h *Handler) Expiring(c *fiber.Ctx) error {
+	q := c.Locals("query").(*transport.ExpiringContractsQueryDTO)
+	days := q.Days
+	if days == 0 {
+		days = DefaultReportDays
+	}
+	list, err := h.service.Expiring(c.UserContext(), time.Now(), days)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	list = access.Filter(access.ScopeFrom(c), list, func(ec ExpiringContract) int { return ec.ProjectID })
+	if q.ProjectID != nil {
+		list = slices.DeleteFunc(list, func(ec ExpiringContract) bool { return ec.ProjectID != *q.ProjectID })
+	}
+	return c.JSON(list)
+}
+
+// ListByProject godoc
+// @Summary Получить договоры проекта
+// @Tags Contracts
+// @Produce json
+// @Param id path int true "Project ID"
+// @Success 200 {array} postgres.Contract
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/projects/{id}/contracts [get]
+// ListByProject retrieves the contracts of a project.
+func (h *Handler) ListByProject(c *fiber.Ctx) error {
+	projectID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
 	}
-	return c.Status(201).JSON(input)
+	list, err := h.service.ListByProject(c.UserContext(), projectID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(list)
 }
 
 // Update godoc
-// @Summary Update existing contract
+// @Summary Обновить договор
 // @Tags Contracts
 // @Accept json
 // @Produce json
 // @Param id path int true "Contract ID"
-// @Param contract body contract.Contract true "Updated Contract Data"
-// @Success 200 {object} contract.Contract
+// @Param contract body transport.UpdateContractDTO true "Contract Data"
+// @Success 200 {object} postgres.Contract
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
 // @Router /api/contracts/{id} [put]
+// Update handles the update of an existing contract.
 func (h *Handler) Update(c *fiber.Ctx) error {
 	id, err := strconv.Atoi(c.Params("id"))
 	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "invalid id"})
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
 	}
-	var input Contract
-	if err := c.BodyParser(&input); err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	dto := c.Locals("body").(*transport.UpdateContractDTO)
+	contract := postgres.Contract{
+		ID:            id,
+		Name:          dto.Name,
+		ValidFrom:     dto.ValidFrom,
+		ValidTo:       dto.ValidTo,
+		ClientCompany: dto.ClientCompany,
+		GraceDays:     dto.GraceDays,
+		SLAPolicyID:   dto.SLAPolicyID,
 	}
-	input.ID = id
-	if err := h.service.Update(&input); err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	err = h.service.Update(c.UserContext(), &contract)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "not found"})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
-	return c.JSON(input)
+	return c.JSON(contract)
 }
 
 // Delete godoc
-// @Summary Delete contract
+// @Summary Удалить договор
 // @Tags Contracts
 // @Param id path int true "Contract ID"
-// @Success 204 "No Content"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
 // @Router /api/contracts/{id} [delete]
+// Delete handles the deletion of a contract.
 func (h *Handler) Delete(c *fiber.Ctx) error {
 	id, err := strconv.Atoi(c.Params("id"))
 	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "invalid id"})
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
 	}
-	if err := h.service.Delete(id); err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	if err := h.service.Delete(c.UserContext(), id); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
-	return c.SendStatus(204)
+	return c.SendStatus(fiber.StatusNoContent)
 }
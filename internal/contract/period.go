@@ -0,0 +1,53 @@
+package contract
+
+import (
+	"innotech/internal/storage/postgres"
+	"innotech/internal/storage/transport"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Where a day falls in the validity period of a contract.
+const (
+	PeriodActive     = "active"
+	PeriodNotStarted = "not_started"
+	PeriodExpired    = "expired"
+)
+
+// PeriodOn reports where day falls in the validity period of c. Both ends
+// are inclusive, and the contract stays active for its grace period after
+// valid_to. Open ends never exclude a day.
+func PeriodOn(c *postgres.Contract, day time.Time) string {
+	day = dateOf(day)
+	if c.ValidFrom != nil && day.Before(dateOf(*c.ValidFrom)) {
+		return PeriodNotStarted
+	}
+	if c.ValidTo != nil && day.After(dateOf(*c.ValidTo).AddDate(0, 0, c.GraceDays)) {
+		return PeriodExpired
+	}
+	return PeriodActive
+}
+
+// dateOf returns the calendar date of t, in t's location, as midnight UTC,
+// the way DATE columns are scanned.
+func dateOf(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// ValidatePeriod is a validator.StructLevelFunc for the contract DTOs. When
+// both ends of the validity period are given, valid_to must come after
+// valid_from; either end may be left open.
+func ValidatePeriod(sl validator.StructLevel) {
+	var from, to *time.Time
+	switch dto := sl.Current().Interface().(type) {
+	case transport.CreateContractDTO:
+		from, to = dto.ValidFrom, dto.ValidTo
+	case transport.UpdateContractDTO:
+		from, to = dto.ValidFrom, dto.ValidTo
+	}
+	if from != nil && to != nil && !to.After(*from) {
+		sl.ReportError(to, "ValidTo", "valid_to", "gtfield", "ValidFrom")
+	}
+}
@@ -0,0 +1,83 @@
+package contract
+
+import (
+	"innotech/internal/storage/postgres"
+	"innotech/internal/storage/transport"
+	"testing"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPeriodOn(t *testing.T) {
+	date := func(s string) *time.Time {
+		d, _ := time.Parse(time.DateOnly, s)
+		return &d
+	}
+	c := &postgres.Contract{ValidFrom: date("2025-01-01"), ValidTo: date("2025-12-31")}
+	graced := &postgres.Contract{ValidTo: date("2025-12-31"), GraceDays: 10}
+	msk := time.FixedZone("MSK", 3*60*60)
+
+	tests := []struct {
+		name string
+		c    *postgres.Contract
+		day  time.Time
+		want string
+	}{
+		{"before start", c, time.Date(2024, 12, 31, 23, 59, 0, 0, time.UTC), PeriodNotStarted},
+		{"first day", c, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), PeriodActive},
+		{"last day", c, time.Date(2025, 12, 31, 23, 59, 0, 0, time.UTC), PeriodActive},
+		{"after end", c, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), PeriodExpired},
+		{"local date counts", c, time.Date(2026, 1, 1, 1, 0, 0, 0, msk), PeriodExpired},
+		{"within grace", graced, time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC), PeriodActive},
+		{"after grace", graced, time.Date(2026, 1, 11, 0, 0, 0, 0, time.UTC), PeriodExpired},
+		{"open-ended", &postgres.Contract{}, time.Now(), PeriodActive},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, PeriodOn(tt.c, tt.day))
+		})
+	}
+}
+
+func TestValidatePeriod_AllowsOpenEnds(t *testing.T) {
+	v := validator.New()
+	v.RegisterStructValidation(ValidatePeriod, transport.CreateContractDTO{}, transport.UpdateContractDTO{})
+	day := func(s string) *time.Time {
+		d, _ := time.Parse(time.DateOnly, s)
+		return &d
+	}
+
+	tests := []struct {
+		name     string
+		from, to *time.Time
+		ok       bool
+	}{
+		{"closed period", day("2025-01-01"), day("2025-12-31"), true},
+		{"open start", nil, day("2025-12-31"), true},
+		{"open end", day("2025-01-01"), nil, true},
+		{"open both ends", nil, nil, true},
+		{"ends before it starts", day("2025-12-31"), day("2025-01-01"), false},
+		{"ends the day it starts", day("2025-01-01"), day("2025-01-01"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			create := &transport.CreateContractDTO{ProjectID: 3, Name: "Поддержка", ValidFrom: tt.from, ValidTo: tt.to}
+			update := &transport.UpdateContractDTO{Name: "Поддержка", ValidFrom: tt.from, ValidTo: tt.to}
+			for _, dto := range []any{create, update} {
+				err := v.Struct(dto)
+				if tt.ok {
+					assert.NoError(t, err)
+					continue
+				}
+				var errs validator.ValidationErrors
+				require.ErrorAs(t, err, &errs)
+				require.Len(t, errs, 1)
+				assert.Equal(t, "ValidTo", errs[0].Field())
+				assert.Equal(t, "gtfield", errs[0].Tag())
+			}
+		})
+	}
+}
@@ -1,53 +1,139 @@
 package contract
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"innotech/internal/storage/postgres"
+	"innotech/pkg/db"
+	"time"
+
 	"github.com/jmoiron/sqlx"
 )
 
-// Repository handles database operations for contracts.
-type Repository struct {
+// Repository defines the interface for contract data access operations.
+type Repository interface {
+	Create(ctx context.Context, c *postgres.Contract) error
+	GetByID(ctx context.Context, id int) (*postgres.Contract, error)
+	GetAll(ctx context.Context) ([]postgres.Contract, error)
+	ListByProject(ctx context.Context, projectID int) ([]postgres.Contract, error)
+	Update(ctx context.Context, c *postgres.Contract) error
+	Delete(ctx context.Context, id int) error
+	IsAssigned(ctx context.Context, contractID int, userID string) (bool, error)
+	Expiring(ctx context.Context, from, to time.Time) ([]postgres.Contract, error)
+	ClaimReminder(ctx context.Context, c *postgres.Contract, window int) (bool, error)
+}
+
+type contractRepository struct {
 	db *sqlx.DB
 }
 
 // NewRepository creates a new Repository instance.
-func NewRepository(db *sqlx.DB) *Repository {
-	return &Repository{db: db}
+func NewRepository(db *sqlx.DB) Repository {
+	return &contractRepository{db: db}
 }
 
-// GetAll retrieves all contracts from the database.
-func (r *Repository) GetAll() ([]Contract, error) {
-	var items []Contract
-	err := r.db.Select(&items, "SELECT * FROM contracts ORDER BY id")
-	return items, err
+func (r *contractRepository) Create(ctx context.Context, c *postgres.Contract) error {
+	query := `
+		INSERT INTO contracts (project_id, name, valid_from, valid_to, client_company, grace_period_days, sla_policy_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, date_created, date_updated
+	`
+	return db.Conn(ctx, r.db).QueryRowxContext(ctx, query, c.ProjectID, c.Name, c.ValidFrom, c.ValidTo, c.ClientCompany, c.GraceDays, c.SLAPolicyID).
+		Scan(&c.ID, &c.DateCreated, &c.DateUpdated)
 }
 
-// GetByID retrieves a contract by its ID.
-func (r *Repository) GetByID(id int) (*Contract, error) {
-	var item Contract
-	err := r.db.Get(&item, "SELECT * FROM contracts WHERE id=$1", id)
-	return &item, err
+func (r *contractRepository) GetByID(ctx context.Context, id int) (*postgres.Contract, error) {
+	var c postgres.Contract
+	err := db.Conn(ctx, r.db).GetContext(ctx, &c, `SELECT * FROM contracts WHERE id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
 }
 
-// Create inserts a new contract into the database.
-func (r *Repository) Create(c *Contract) error {
-	return r.db.QueryRow(
-		`INSERT INTO contracts (project_id, client_name, start_date, end_date, description)
-         VALUES ($1, $2, $3, $4, $5) RETURNING id`,
-		c.ProjectID, c.ClientName, c.StartDate, c.EndDate, c.Description,
-	).Scan(&c.ID)
+func (r *contractRepository) GetAll(ctx context.Context) ([]postgres.Contract, error) {
+	list := []postgres.Contract{}
+	err := db.Conn(ctx, r.db).SelectContext(ctx, &list, `SELECT * FROM contracts ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	return list, nil
 }
 
-// Update modifies an existing contract in the database.
-func (r *Repository) Update(c *Contract) error {
-	_, err := r.db.Exec(
-		`UPDATE contracts SET client_name=$1, start_date=$2, end_date=$3, description=$4 WHERE id=$5`,
-		c.ClientName, c.StartDate, c.EndDate, c.Description, c.ID,
+func (r *contractRepository) ListByProject(ctx context.Context, projectID int) ([]postgres.Contract, error) {
+	list := []postgres.Contract{}
+	err := db.Conn(ctx, r.db).SelectContext(ctx, &list,
+		`SELECT * FROM contracts WHERE project_id = $1 ORDER BY id`,
+		projectID,
 	)
-	return err
+	if err != nil {
+		return nil, err
+	}
+	return list, nil
 }
 
-// Delete removes a contract from the database by its ID.
-func (r *Repository) Delete(id int) error {
-	_, err := r.db.Exec(`DELETE FROM contracts WHERE id=$1`, id)
+// Update stores the contract and fills in the fields it does not change.
+// It returns sql.ErrNoRows when the contract does not exist.
+func (r *contractRepository) Update(ctx context.Context, c *postgres.Contract) error {
+	query := `
+		UPDATE contracts
+		SET name = $2, valid_from = $3, valid_to = $4, client_company = $5, grace_period_days = $6, sla_policy_id = $7
+		WHERE id = $1
+		RETURNING project_id, date_created, date_updated
+	`
+	return db.Conn(ctx, r.db).QueryRowxContext(ctx, query, c.ID, c.Name, c.ValidFrom, c.ValidTo, c.ClientCompany, c.GraceDays, c.SLAPolicyID).
+		Scan(&c.ProjectID, &c.DateCreated, &c.DateUpdated)
+}
+
+func (r *contractRepository) Delete(ctx context.Context, id int) error {
+	_, err := db.Conn(ctx, r.db).ExecContext(ctx, `DELETE FROM contracts WHERE id = $1`, id)
 	return err
 }
+
+// IsAssigned reports whether the user is attached to the contract in the
+// contract's project.
+func (r *contractRepository) IsAssigned(ctx context.Context, contractID int, userID string) (bool, error) {
+	var ok bool
+	err := db.Conn(ctx, r.db).GetContext(ctx, &ok,
+		`SELECT EXISTS (SELECT 1 FROM user_projects WHERE contract_id = $1 AND user_id = $2)`,
+		contractID, userID,
+	)
+	return ok, err
+}
+
+// Expiring returns the contracts whose valid_to falls between the dates from
+// and to, both inclusive, soonest first.
+func (r *contractRepository) Expiring(ctx context.Context, from, to time.Time) ([]postgres.Contract, error) {
+	list := []postgres.Contract{}
+	err := db.Conn(ctx, r.db).SelectContext(ctx, &list,
+		`SELECT * FROM contracts WHERE valid_to BETWEEN $1::date AND $2::date ORDER BY valid_to, id`,
+		from, to,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// ClaimReminder records the expiry reminder for window days before the
+// current valid_to of c. It reports false when that reminder, or one for a
+// narrower window, was already sent.
+func (r *contractRepository) ClaimReminder(ctx context.Context, c *postgres.Contract, window int) (bool, error) {
+	query := `
+		INSERT INTO contract_reminders (contract_id, valid_to, window_days)
+		SELECT $1, $2::date, $3
+		WHERE NOT EXISTS (
+			SELECT 1 FROM contract_reminders
+			WHERE contract_id = $1 AND valid_to = $2::date AND window_days <= $3
+		)
+		ON CONFLICT DO NOTHING
+		RETURNING TRUE
+	`
+	var claimed bool
+	err := db.Conn(ctx, r.db).GetContext(ctx, &claimed, query, c.ID, c.ValidTo, window)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return claimed, err
+}
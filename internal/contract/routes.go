@@ -1,14 +1,24 @@
 package contract
 
-import "github.com/gofiber/fiber/v2"
+import (
+	"innotech/internal/access"
+	"innotech/internal/storage/transport"
+	"innotech/pkg/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
 
 // RegisterRoutes registers HTTP routes for contract operations.
-func RegisterRoutes(app *fiber.App, h *Handler) {
+func RegisterRoutes(app *fiber.App, h *Handler, guard *access.Guard) {
+	projects := app.Group("/api/projects")
+	projects.Get("/:id/contracts", guard.Require(access.ContractRead, guard.Param("id", access.ResourceProject)), h.ListByProject)
+
 	api := app.Group("/api/contracts")
 
-	api.Get("/", h.GetAll)
-	api.Get("/:id", h.GetByID)
-	api.Post("/", h.Create)
-	api.Put("/:id", h.Update)
-	api.Delete("/:id", h.Delete)
+	api.Get("/", guard.Scoped(access.ContractRead), h.GetAll)
+	api.Get("/expiring", guard.Scoped(access.ContractRead), middleware.ValidateQuery[transport.ExpiringContractsQueryDTO](h.Expiring))
+	api.Get("/:id", guard.Require(access.ContractRead, guard.Param("id", access.ResourceContract)), h.GetByID)
+	api.Post("/", guard.Require(access.ContractManage, guard.Body("project_id", access.ResourceProject)), middleware.ValidateBody[transport.CreateContractDTO](h.Create))
+	api.Put("/:id", guard.Require(access.ContractManage, guard.Param("id", access.ResourceContract)), middleware.ValidateBody[transport.UpdateContractDTO](h.Update))
+	api.Delete("/:id", guard.Require(access.ContractManage, guard.Param("id", access.ResourceContract)), h.Delete)
 }
@@ -1,36 +1,71 @@
 package contract
 
-// Service handles business logic for contract operations.
-type Service struct {
-	repo *Repository
+import (
+	"context"
+	"innotech/internal/storage/postgres"
+	"time"
+)
+
+// Service defines the interface for contract business logic operations.
+type Service interface {
+	Create(ctx context.Context, c *postgres.Contract) error
+	GetByID(ctx context.Context, id int) (*postgres.Contract, error)
+	GetAll(ctx context.Context) ([]postgres.Contract, error)
+	ListByProject(ctx context.Context, projectID int) ([]postgres.Contract, error)
+	Update(ctx context.Context, c *postgres.Contract) error
+	Delete(ctx context.Context, id int) error
+	IsAssigned(ctx context.Context, contractID int, userID string) (bool, error)
+	Expiring(ctx context.Context, day time.Time, days int) ([]ExpiringContract, error)
+}
+
+type contractService struct {
+	repo Repository
 }
 
 // NewService creates a new Service instance.
-func NewService(repo *Repository) *Service {
-	return &Service{repo: repo}
+func NewService(repo Repository) Service {
+	return &contractService{repo: repo}
+}
+
+func (s *contractService) Create(ctx context.Context, c *postgres.Contract) error {
+	return s.repo.Create(ctx, c)
+}
+
+func (s *contractService) GetByID(ctx context.Context, id int) (*postgres.Contract, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *contractService) GetAll(ctx context.Context) ([]postgres.Contract, error) {
+	return s.repo.GetAll(ctx)
 }
 
-// GetAll retrieves all contracts.
-func (s *Service) GetAll() ([]Contract, error) {
-	return s.repo.GetAll()
+func (s *contractService) ListByProject(ctx context.Context, projectID int) ([]postgres.Contract, error) {
+	return s.repo.ListByProject(ctx, projectID)
 }
 
-// GetByID retrieves a contract by its ID.
-func (s *Service) GetByID(id int) (*Contract, error) {
-	return s.repo.GetByID(id)
+func (s *contractService) Update(ctx context.Context, c *postgres.Contract) error {
+	return s.repo.Update(ctx, c)
 }
 
-// Create creates a new contract.
-func (s *Service) Create(c *Contract) error {
-	return s.repo.Create(c)
+func (s *contractService) Delete(ctx context.Context, id int) error {
+	return s.repo.Delete(ctx, id)
 }
 
-// Update updates an existing contract.
-func (s *Service) Update(c *Contract) error {
-	return s.repo.Update(c)
+func (s *contractService) IsAssigned(ctx context.Context, contractID int, userID string) (bool, error) {
+	return s.repo.IsAssigned(ctx, contractID, userID)
 }
 
-// Delete deletes a contract by its ID.
-func (s *Service) Delete(id int) error {
-	return s.repo.Delete(id)
+// Expiring returns the contracts that end within days after day, and those
+// that ended within days before it, soonest first.
+func (s *contractService) Expiring(ctx context.Context, day time.Time, days int) ([]ExpiringContract, error) {
+	today := dateOf(day)
+	list, err := s.repo.Expiring(ctx, today.AddDate(0, 0, -days), today.AddDate(0, 0, days))
+	if err != nil {
+		return nil, err
+	}
+	report := make([]ExpiringContract, len(list))
+	for i, c := range list {
+		report[i] = expiringOn(c, day)
+	}
+	return report, nil
 }
@@ -2,7 +2,9 @@ package documentations
 
 import (
 	"context"
+	"innotech/internal/events"
 	"innotech/internal/storage/postgres"
+	"innotech/pkg/db"
 	"testing"
 	"time"
 
@@ -30,9 +32,16 @@ func (m *MockDocumentationRepository) Delete(ctx context.Context, id int) error
 	return m.Called(ctx, id).Error(0)
 }
 
+type MockActivityPublisher struct{ mock.Mock }
+
+func (m *MockActivityPublisher) Publish(ctx context.Context, projectID int, eventType string, payload any) error {
+	return m.Called(ctx, projectID, eventType, payload).Error(0)
+}
+
 func TestDocumentationService_CRUD(t *testing.T) {
 	mockRepo := new(MockDocumentationRepository)
-	svc := NewService(mockRepo)
+	activity := new(MockActivityPublisher)
+	svc := NewService(mockRepo, activity, db.NopTransactor{})
 
 	ctx := context.Background()
 	now := time.Now()
@@ -46,6 +55,7 @@ func TestDocumentationService_CRUD(t *testing.T) {
 	mockRepo.On("GetByID", ctx, 1).Return(doc, nil)
 	mockRepo.On("Update", ctx, doc).Return(nil)
 	mockRepo.On("Delete", ctx, 1).Return(nil)
+	activity.On("Publish", ctx, 1, events.DocumentationPublished, mock.Anything).Return(nil).Once()
 
 	assert.NoError(t, svc.Create(ctx, doc))
 	list, err := svc.GetAll(ctx)
@@ -58,4 +68,25 @@ func TestDocumentationService_CRUD(t *testing.T) {
 
 	assert.NoError(t, svc.Update(ctx, doc))
 	assert.NoError(t, svc.Delete(ctx, 1))
+	activity.AssertExpectations(t)
+}
+
+func TestDocumentationService_Update_NewVersionPublishesEvent(t *testing.T) {
+	mockRepo := new(MockDocumentationRepository)
+	activity := new(MockActivityPublisher)
+	svc := NewService(mockRepo, activity, db.NopTransactor{})
+
+	ctx := context.Background()
+	v1, v2 := "1.0", "2.0"
+	mockRepo.On("GetByID", ctx, 4).Return(&postgres.Documentation{ID: 4, ProjectID: 9, Version: &v1}, nil)
+
+	doc := &postgres.Documentation{ID: 4, FilePath: "/spec.pdf", Version: &v2}
+	mockRepo.On("Update", ctx, doc).Return(nil)
+	activity.On("Publish", ctx, 9, events.DocumentationUpdated, mock.MatchedBy(func(p map[string]any) bool {
+		return p["version"] == &v2 && p["previous_version"] == &v1
+	})).Return(nil).Once()
+
+	assert.NoError(t, svc.Update(ctx, doc))
+	assert.Equal(t, 9, doc.ProjectID)
+	activity.AssertExpectations(t)
 }
@@ -1,8 +1,10 @@
 package documentations
 
 import (
+	"innotech/internal/access"
 	"innotech/internal/storage/postgres"
 	"innotech/internal/storage/transport"
+	"innotech/pkg/middleware"
 	"strconv"
 
 	"github.com/gofiber/fiber/v2"
@@ -31,13 +33,14 @@ func NewHandler(service Service) *Handler {
 // Create handles the creation of a new documentation entry.
 func (h *Handler) Create(c *fiber.Ctx) error {
 	dto := c.Locals("body").(*transport.CreateDocumentationDTO)
+	uploadedBy := middleware.UserID(c)
 	d := postgres.Documentation{
 		ProjectID:  dto.ProjectID,
 		FilePath:   dto.FilePath,
 		Version:    dto.Version,
-		UploadedBy: dto.UploadedBy,
+		UploadedBy: &uploadedBy,
 	}
-	if err := h.service.Create(c.Context(), &d); err != nil {
+	if err := h.service.Create(c.UserContext(), &d); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 	return c.Status(fiber.StatusCreated).JSON(d)
@@ -58,7 +61,7 @@ func (h *Handler) GetByID(c *fiber.Ctx) error {
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
 	}
-	d, err := h.service.GetByID(c.Context(), id)
+	d, err := h.service.GetByID(c.UserContext(), id)
 	if err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
 	}
@@ -74,10 +77,11 @@ func (h *Handler) GetByID(c *fiber.Ctx) error {
 // @Router /api/documentations [get]
 // GetAll retrieves all documentation entries.
 func (h *Handler) GetAll(c *fiber.Ctx) error {
-	docs, err := h.service.GetAll(c.Context())
+	docs, err := h.service.GetAll(c.UserContext())
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
+	docs = access.Filter(access.ScopeFrom(c), docs, func(d postgres.Documentation) int { return d.ProjectID })
 	return c.JSON(docs)
 }
 
@@ -99,13 +103,14 @@ func (h *Handler) Update(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
 	}
 	dto := c.Locals("body").(*transport.UpdateDocumentationDTO)
+	uploadedBy := middleware.UserID(c)
 	d := postgres.Documentation{
 		ID:         id,
 		FilePath:   dto.FilePath,
 		Version:    dto.Version,
-		UploadedBy: dto.UploadedBy,
+		UploadedBy: &uploadedBy,
 	}
-	if err := h.service.Update(c.Context(), &d); err != nil {
+	if err := h.service.Update(c.UserContext(), &d); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 	return c.JSON(d)
@@ -125,7 +130,7 @@ func (h *Handler) Delete(c *fiber.Ctx) error {
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
 	}
-	if err := h.service.Delete(c.Context(), id); err != nil {
+	if err := h.service.Delete(c.UserContext(), id); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 	return c.SendStatus(fiber.StatusNoContent)
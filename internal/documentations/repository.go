@@ -3,6 +3,7 @@ package documentations
 import (
 	"context"
 	"innotech/internal/storage/postgres"
+	"innotech/pkg/db"
 
 	"github.com/jmoiron/sqlx"
 )
@@ -31,7 +32,7 @@ func (r *documentationRepository) Create(ctx context.Context, d *postgres.Docume
 		VALUES (:project_id, :file_path, :version, :uploaded_by)
 		RETURNING id, date_created, date_updated
 	`
-	stmt, err := r.db.PrepareNamedContext(ctx, query)
+	stmt, err := db.Conn(ctx, r.db).PrepareNamedContext(ctx, query)
 	if err != nil {
 		return err
 	}
@@ -40,7 +41,7 @@ func (r *documentationRepository) Create(ctx context.Context, d *postgres.Docume
 
 func (r *documentationRepository) GetByID(ctx context.Context, id int) (*postgres.Documentation, error) {
 	var d postgres.Documentation
-	err := r.db.GetContext(ctx, &d, "SELECT * FROM documentations WHERE id=$1", id)
+	err := db.Conn(ctx, r.db).GetContext(ctx, &d, "SELECT * FROM documentations WHERE id=$1", id)
 	if err != nil {
 		return nil, err
 	}
@@ -49,7 +50,7 @@ func (r *documentationRepository) GetByID(ctx context.Context, id int) (*postgre
 
 func (r *documentationRepository) GetAll(ctx context.Context) ([]postgres.Documentation, error) {
 	var docs []postgres.Documentation
-	err := r.db.SelectContext(ctx, &docs, "SELECT * FROM documentations ORDER BY date_created DESC")
+	err := db.Conn(ctx, r.db).SelectContext(ctx, &docs, "SELECT * FROM documentations ORDER BY date_created DESC")
 	return docs, err
 }
 
@@ -60,7 +61,7 @@ func (r *documentationRepository) Update(ctx context.Context, d *postgres.Docume
 		WHERE id=:id
 		RETURNING date_updated
 	`
-	stmt, err := r.db.PrepareNamedContext(ctx, query)
+	stmt, err := db.Conn(ctx, r.db).PrepareNamedContext(ctx, query)
 	if err != nil {
 		return err
 	}
@@ -68,6 +69,6 @@ func (r *documentationRepository) Update(ctx context.Context, d *postgres.Docume
 }
 
 func (r *documentationRepository) Delete(ctx context.Context, id int) error {
-	_, err := r.db.ExecContext(ctx, "DELETE FROM documentations WHERE id=$1", id)
+	_, err := db.Conn(ctx, r.db).ExecContext(ctx, "DELETE FROM documentations WHERE id=$1", id)
 	return err
 }
@@ -1,6 +1,7 @@
 package documentations
 
 import (
+	"innotech/internal/access"
 	"innotech/internal/storage/transport"
 	"innotech/pkg/middleware"
 
@@ -8,12 +9,12 @@ import (
 )
 
 // RegisterRoutes registers HTTP routes for documentation operations.
-func RegisterRoutes(app *fiber.App, h *Handler) {
+func RegisterRoutes(app *fiber.App, h *Handler, guard *access.Guard) {
 	api := app.Group("/api/documentations")
 
-	api.Get("/", h.GetAll)
-	api.Get("/:id", h.GetByID)
-	api.Post("/", middleware.ValidateBody[transport.CreateDocumentationDTO](h.Create))
-	api.Put("/:id", middleware.ValidateBody[transport.UpdateDocumentationDTO](h.Update))
-	api.Delete("/:id", h.Delete)
+	api.Get("/", guard.Scoped(access.DocumentationRead), h.GetAll)
+	api.Get("/:id", guard.Require(access.DocumentationRead, guard.Param("id", access.ResourceDocumentation)), h.GetByID)
+	api.Post("/", guard.Require(access.DocumentationManage, guard.Body("project_id", access.ResourceProject)), middleware.ValidateBody[transport.CreateDocumentationDTO](h.Create))
+	api.Put("/:id", guard.Require(access.DocumentationManage, guard.Param("id", access.ResourceDocumentation)), middleware.ValidateBody[transport.UpdateDocumentationDTO](h.Update))
+	api.Delete("/:id", guard.Require(access.DocumentationManage, guard.Param("id", access.ResourceDocumentation)), h.Delete)
 }
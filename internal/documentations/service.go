@@ -2,9 +2,16 @@ package documentations
 
 import (
 	"context"
+	"innotech/internal/events"
 	"innotech/internal/storage/postgres"
+	"innotech/pkg/db"
 )
 
+// ActivityPublisher appends events to the project activity feed.
+type ActivityPublisher interface {
+	Publish(ctx context.Context, projectID int, eventType string, payload any) error
+}
+
 // Service defines the interface for documentation business logic operations.
 type Service interface {
 	Create(ctx context.Context, d *postgres.Documentation) error
@@ -15,16 +22,27 @@ type Service interface {
 }
 
 type documentationService struct {
-	repo Repository
+	repo     Repository
+	activity ActivityPublisher
+	tx       db.Transactor
 }
 
 // NewService creates a new Service instance.
-func NewService(repo Repository) Service {
-	return &documentationService{repo: repo}
+func NewService(repo Repository, activity ActivityPublisher, tx db.Transactor) Service {
+	return &documentationService{repo: repo, activity: activity, tx: tx}
 }
 
 func (s *documentationService) Create(ctx context.Context, d *postgres.Documentation) error {
-	return s.repo.Create(ctx, d)
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Create(ctx, d); err != nil {
+			return err
+		}
+		return s.activity.Publish(ctx, d.ProjectID, events.DocumentationPublished, map[string]any{
+			"documentation_id": d.ID,
+			"file_path":        d.FilePath,
+			"version":          d.Version,
+		})
+	})
 }
 
 func (s *documentationService) GetByID(ctx context.Context, id int) (*postgres.Documentation, error) {
@@ -35,8 +53,35 @@ func (s *documentationService) GetAll(ctx context.Context) ([]postgres.Documenta
 	return s.repo.GetAll(ctx)
 }
 
+// Update announces a new version on the project feed when the version changes.
 func (s *documentationService) Update(ctx context.Context, d *postgres.Documentation) error {
-	return s.repo.Update(ctx, d)
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		current, err := s.repo.GetByID(ctx, d.ID)
+		if err != nil {
+			return err
+		}
+		if err := s.repo.Update(ctx, d); err != nil {
+			return err
+		}
+		d.ProjectID = current.ProjectID
+		d.DateCreated = current.DateCreated
+		if deref(current.Version) == deref(d.Version) {
+			return nil
+		}
+		return s.activity.Publish(ctx, d.ProjectID, events.DocumentationUpdated, map[string]any{
+			"documentation_id": d.ID,
+			"file_path":        d.FilePath,
+			"version":          d.Version,
+			"previous_version": current.Version,
+		})
+	})
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
 }
 
 func (s *documentationService) Delete(ctx context.Context, id int) error {
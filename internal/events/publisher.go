@@ -0,0 +1,128 @@
+// Package events provides the project activity log and its live SSE feed.
+package events
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"innotech/internal/outbox"
+	"innotech/internal/storage/postgres"
+	"innotech/pkg/auth"
+	"innotech/pkg/db"
+	"innotech/pkg/pgnotify"
+	"strconv"
+)
+
+// Project activity event types.
+const (
+	TicketCreated          = "ticket.created"
+	TicketStatusChanged    = "ticket.status_changed"
+	TicketAssigned         = "ticket.assigned"
+	TicketSLAAtRisk        = "ticket.sla_at_risk"
+	TicketSLABreached      = "ticket.sla_breached"
+	AttachmentAdded        = "attachment.added"
+	DocumentationPublished = "documentation.published"
+	DocumentationUpdated   = "documentation.updated"
+	ChatMessageCreated     = "chat.message_created"
+	ContractExpiring       = "contract.expiring"
+	FileInfected           = "file.infected"
+)
+
+// Hub fans out notifications to every application replica.
+type Hub interface {
+	Subscribe(topic string) *pgnotify.Subscription
+	Publish(ctx context.Context, topic string, data any) error
+}
+
+// Topic returns the hub topic announcing new events of a project.
+func Topic(projectID int) string {
+	return "project_events:" + strconv.Itoa(projectID)
+}
+
+// signal tells stream subscribers that the log has grown; they read the
+// events themselves, so payload size never matters for the notification.
+type signal struct {
+	Seq int64 `json:"seq"`
+}
+
+// TopicAppended is the outbox topic of committed events; the payload is the
+// event.
+const TopicAppended = "project_event.appended"
+
+// Outbox records messages to be delivered once the transaction commits.
+type Outbox interface {
+	Write(ctx context.Context, topic string, payload any) error
+}
+
+// Listener reacts to committed events. Events are delivered through the
+// outbox, possibly more than once; an error makes the outbox retry.
+type Listener interface {
+	EventAppended(ctx context.Context, e *postgres.ProjectEvent) error
+}
+
+// ListenerConsumer adapts l to an outbox consumer of TopicAppended.
+func ListenerConsumer(l Listener) outbox.Consumer {
+	return outbox.Decode(l.EventAppended)
+}
+
+// AnnounceConsumer returns an outbox consumer of TopicAppended that gives the
+// committed event its sequence number and then signals stream subscribers of
+// the event's project. Streams resume by sequence number, so a repeated
+// signal is harmless; an event deleted in the meantime is not announced.
+func AnnounceConsumer(repo Repository, hub Hub, tx db.Transactor) outbox.Consumer {
+	return outbox.Decode(func(ctx context.Context, e *postgres.ProjectEvent) error {
+		err := tx.WithinTx(ctx, func(ctx context.Context) error {
+			return repo.Sequence(ctx, e)
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return hub.Publish(ctx, Topic(e.ProjectID), signal{Seq: *e.Seq})
+	})
+}
+
+// Publisher appends events to the project activity log.
+type Publisher interface {
+	Publish(ctx context.Context, projectID int, eventType string, payload any) error
+	PublishForTicket(ctx context.Context, ticketID int, eventType string, payload any) error
+}
+
+type publisher struct {
+	repo   Repository
+	outbox Outbox
+}
+
+// NewPublisher creates a new Publisher instance. Events written inside a
+// transaction bound with db.Transactor become visible, and are handed to the
+// outbox consumers, only when it commits.
+func NewPublisher(repo Repository, outbox Outbox) Publisher {
+	return &publisher{repo: repo, outbox: outbox}
+}
+
+func (p *publisher) Publish(ctx context.Context, projectID int, eventType string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	e := &postgres.ProjectEvent{ProjectID: projectID, Type: eventType, Payload: data}
+	if sub := auth.SubjectFromContext(ctx); sub != "" {
+		e.ActorID = &sub
+	}
+	if err := p.repo.Append(ctx, e); err != nil {
+		return err
+	}
+	return p.outbox.Write(ctx, TopicAppended, e)
+}
+
+func (p *publisher) PublishForTicket(ctx context.Context, ticketID int, eventType string, payload any) error {
+	projectID, err := p.repo.ProjectOfTicket(ctx, ticketID)
+	if err != nil {
+		return err
+	}
+	return p.Publish(ctx, projectID, eventType, payload)
+}
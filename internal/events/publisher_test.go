@@ -0,0 +1,145 @@
+package events
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"innotech/internal/storage/postgres"
+	"innotech/pkg/auth"
+	"innotech/pkg/db"
+	"innotech/pkg/pgnotify"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type mockRepo struct{ mock.Mock }
+
+func (m *mockRepo) Append(ctx context.Context, e *postgres.ProjectEvent) error {
+	return m.Called(ctx, e).Error(0)
+}
+
+func (m *mockRepo) Sequence(ctx context.Context, e *postgres.ProjectEvent) error {
+	return m.Called(ctx, e).Error(0)
+}
+
+func (m *mockRepo) ListAfter(ctx context.Context, projectID int, afterSeq int64, limit int) ([]postgres.ProjectEvent, error) {
+	args := m.Called(ctx, projectID, afterSeq, limit)
+	return args.Get(0).([]postgres.ProjectEvent), args.Error(1)
+}
+
+func (m *mockRepo) LastSeq(ctx context.Context, projectID int) (int64, error) {
+	args := m.Called(ctx, projectID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *mockRepo) ProjectOfTicket(ctx context.Context, ticketID int) (int, error) {
+	args := m.Called(ctx, ticketID)
+	return args.Int(0), args.Error(1)
+}
+
+type recordingOutbox struct {
+	events []*postgres.ProjectEvent
+}
+
+func (o *recordingOutbox) Write(_ context.Context, topic string, payload any) error {
+	if topic == TopicAppended {
+		o.events = append(o.events, payload.(*postgres.ProjectEvent))
+	}
+	return nil
+}
+
+type localHub struct{ *pgnotify.Broker }
+
+func (h localHub) Publish(_ context.Context, topic string, data any) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	h.Dispatch(pgnotify.Message{Topic: topic, Data: raw})
+	return nil
+}
+
+func TestPublisher_PublishForTicket_AppendsAndQueues(t *testing.T) {
+	repo := new(mockRepo)
+	ob := &recordingOutbox{}
+	p := NewPublisher(repo, ob)
+
+	ctx := auth.WithIdentity(context.Background(), &auth.Identity{Subject: "u1"})
+	repo.On("ProjectOfTicket", ctx, 12).Return(5, nil).Once()
+	repo.On("Append", ctx, mock.MatchedBy(func(e *postgres.ProjectEvent) bool {
+		return e.ProjectID == 5 && e.Type == AttachmentAdded && *e.ActorID == "u1" &&
+			string(e.Payload) == `{"ticket_id":12}`
+	})).Run(func(args mock.Arguments) {
+		args.Get(1).(*postgres.ProjectEvent).ID = 77
+	}).Return(nil).Once()
+
+	require.NoError(t, p.PublishForTicket(ctx, 12, AttachmentAdded, map[string]any{"ticket_id": 12}))
+
+	require.Len(t, ob.events, 1)
+	assert.Equal(t, int64(77), ob.events[0].ID)
+	repo.AssertExpectations(t)
+}
+
+func TestAnnounceConsumer_SequencesAndSignalsProjectTopic(t *testing.T) {
+	repo := new(mockRepo)
+	hub := localHub{pgnotify.NewBroker()}
+	sub := hub.Subscribe(Topic(5))
+	defer sub.Close()
+
+	repo.On("Sequence", mock.Anything, mock.MatchedBy(func(e *postgres.ProjectEvent) bool {
+		return e.ID == 77 && e.ProjectID == 5
+	})).Run(func(args mock.Arguments) {
+		seq := int64(103)
+		args.Get(1).(*postgres.ProjectEvent).Seq = &seq
+	}).Return(nil).Once()
+
+	err := AnnounceConsumer(repo, hub, db.NopTransactor{})(context.Background(), []byte(`{"id":77,"project_id":5,"type":"ticket.created"}`))
+
+	require.NoError(t, err)
+	m := <-sub.C
+	assert.JSONEq(t, `{"seq":103}`, string(m.Data))
+	repo.AssertExpectations(t)
+}
+
+func TestAnnounceConsumer_SkipsDeletedEvent(t *testing.T) {
+	repo := new(mockRepo)
+	hub := localHub{pgnotify.NewBroker()}
+	sub := hub.Subscribe(Topic(5))
+	defer sub.Close()
+
+	repo.On("Sequence", mock.Anything, mock.Anything).Return(sql.ErrNoRows).Once()
+
+	err := AnnounceConsumer(repo, hub, db.NopTransactor{})(context.Background(), []byte(`{"id":77,"project_id":5,"type":"ticket.created"}`))
+
+	require.NoError(t, err)
+	select {
+	case m := <-sub.C:
+		t.Fatalf("unexpected signal %s", m.Data)
+	default:
+	}
+}
+
+func TestStream_Replay_WritesEventsInSSEFormat(t *testing.T) {
+	repo := new(mockRepo)
+	s := NewStream(repo, localHub{pgnotify.NewBroker()})
+
+	seq := int64(14)
+	repo.On("ListAfter", mock.Anything, 5, int64(10), replayBatch).Return([]postgres.ProjectEvent{
+		{ID: 11, Seq: &seq, ProjectID: 5, Type: TicketCreated, Payload: postgres.JSON(`{"ticket_id":1}`)},
+	}, nil).Once()
+
+	var buf bytes.Buffer
+	w := bufio.NewWriter(&buf)
+	lastSeq, err := s.replay(w, 5, 10)
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(14), lastSeq)
+	assert.Contains(t, buf.String(), "id: 14\nevent: ticket.created\ndata: {")
+	assert.Contains(t, buf.String(), `"payload":{"ticket_id":1}`)
+	assert.True(t, bytes.HasSuffix(buf.Bytes(), []byte("\n\n")))
+}
@@ -0,0 +1,83 @@
+package events
+
+import (
+	"context"
+	"innotech/internal/storage/postgres"
+	"innotech/pkg/db"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Repository defines the interface for project activity log operations.
+type Repository interface {
+	Append(ctx context.Context, e *postgres.ProjectEvent) error
+	Sequence(ctx context.Context, e *postgres.ProjectEvent) error
+	ListAfter(ctx context.Context, projectID int, afterSeq int64, limit int) ([]postgres.ProjectEvent, error)
+	LastSeq(ctx context.Context, projectID int) (int64, error)
+	ProjectOfTicket(ctx context.Context, ticketID int) (int, error)
+}
+
+type repository struct {
+	db *sqlx.DB
+}
+
+// NewRepository creates a new Repository instance.
+func NewRepository(db *sqlx.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) Append(ctx context.Context, e *postgres.ProjectEvent) error {
+	query := `
+		INSERT INTO project_events (project_id, type, actor_id, payload)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, date_created
+	`
+	return db.Conn(ctx, r.db).QueryRowxContext(ctx, query, e.ProjectID, e.Type, e.ActorID, e.Payload).
+		Scan(&e.ID, &e.DateCreated)
+}
+
+// Sequence assigns e its place in the commit order of its project, unless it
+// already has one, and fails with sql.ErrNoRows when e no longer exists. It
+// must run in a transaction: the project stays locked until the commit, so a
+// project's sequence numbers become visible in increasing order.
+func (r *repository) Sequence(ctx context.Context, e *postgres.ProjectEvent) error {
+	conn := db.Conn(ctx, r.db)
+	if _, err := conn.ExecContext(ctx,
+		`SELECT pg_advisory_xact_lock(hashtext('project_events'), $1)`, e.ProjectID,
+	); err != nil {
+		return err
+	}
+	query := `
+		UPDATE project_events SET seq = COALESCE(seq, nextval('project_events_seq'))
+		WHERE id = $1
+		RETURNING seq
+	`
+	return conn.GetContext(ctx, &e.Seq, query, e.ID)
+}
+
+func (r *repository) ListAfter(ctx context.Context, projectID int, afterSeq int64, limit int) ([]postgres.ProjectEvent, error) {
+	list := []postgres.ProjectEvent{}
+	err := db.Conn(ctx, r.db).SelectContext(ctx, &list,
+		`SELECT * FROM project_events WHERE project_id = $1 AND seq > $2 ORDER BY seq LIMIT $3`,
+		projectID, afterSeq, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *repository) LastSeq(ctx context.Context, projectID int) (int64, error) {
+	var seq int64
+	err := db.Conn(ctx, r.db).GetContext(ctx, &seq,
+		`SELECT COALESCE(MAX(seq), 0) FROM project_events WHERE project_id = $1`,
+		projectID,
+	)
+	return seq, err
+}
+
+func (r *repository) ProjectOfTicket(ctx context.Context, ticketID int) (int, error) {
+	var projectID int
+	err := db.Conn(ctx, r.db).GetContext(ctx, &projectID, `SELECT project_id FROM tickets WHERE id = $1`, ticketID)
+	return projectID, err
+}
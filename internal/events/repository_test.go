@@ -0,0 +1,52 @@
+package events
+
+import (
+	"context"
+	"innotech/internal/storage/postgres"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepository_Sequence_LocksProjectBeforeNumbering(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = mockDB.Close() }()
+
+	repo := NewRepository(sqlx.NewDb(mockDB, "sqlmock"))
+
+	mock.ExpectExec(`SELECT pg_advisory_xact_lock\(hashtext\('project_events'\), \$1\)`).
+		WithArgs(5).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`UPDATE project_events SET seq = COALESCE\(seq, nextval\('project_events_seq'\)\)\s+WHERE id = \$1\s+RETURNING seq`).
+		WithArgs(int64(77)).
+		WillReturnRows(sqlmock.NewRows([]string{"seq"}).AddRow(int64(103)))
+
+	e := &postgres.ProjectEvent{ID: 77, ProjectID: 5}
+	require.NoError(t, repo.Sequence(context.Background(), e))
+
+	require.NotNil(t, e.Seq)
+	assert.Equal(t, int64(103), *e.Seq)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_ListAfter_FollowsSequence(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = mockDB.Close() }()
+
+	repo := NewRepository(sqlx.NewDb(mockDB, "sqlmock"))
+
+	mock.ExpectQuery(`SELECT \* FROM project_events WHERE project_id = \$1 AND seq > \$2 ORDER BY seq LIMIT \$3`).
+		WithArgs(5, int64(10), 200).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "seq", "project_id", "type", "actor_id", "payload", "date_created"}))
+
+	list, err := repo.ListAfter(context.Background(), 5, 10, 200)
+
+	require.NoError(t, err)
+	assert.Empty(t, list)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
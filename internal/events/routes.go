@@ -0,0 +1,14 @@
+package events
+
+import (
+	"innotech/internal/access"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RegisterRoutes registers HTTP routes for the project activity feed.
+func RegisterRoutes(app *fiber.App, stream *Stream, guard *access.Guard) {
+	api := app.Group("/api/projects")
+
+	api.Get("/:id/events", guard.Require(access.ProjectRead, guard.Param("id", access.ResourceProject)), stream.Serve)
+}
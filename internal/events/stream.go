@@ -0,0 +1,153 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"innotech/internal/storage/postgres"
+	"innotech/pkg/logger"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	keepAliveInterval = 15 * time.Second
+	queryTimeout      = 5 * time.Second
+	replayBatch       = 200
+	retryMillis       = 3000
+)
+
+// Stream serves the project activity feed as Server-Sent Events.
+type Stream struct {
+	repo Repository
+	hub  Hub
+}
+
+// NewStream creates a new Stream instance.
+func NewStream(repo Repository, hub Hub) *Stream {
+	return &Stream{repo: repo, hub: hub}
+}
+
+// Serve godoc
+// @Summary поток событий проекта (Server-Sent Events)
+// @Description События ticket.created, ticket.status_changed, ticket.assigned, attachment.added,
+// @Description documentation.published и documentation.updated. Поддерживается докачка по Last-Event-ID.
+// @Tags Projects
+// @Produce text/event-stream
+// @Param id path int true "Project ID"
+// @Param Last-Event-ID header int false "Sequence number of the last received event"
+// @Param last_event_id query int false "Same as Last-Event-ID for the first connection"
+// @Param access_token query string false "Bearer token for browsers"
+// @Success 200 {object} postgres.ProjectEvent
+// @Failure 400 {object} map[string]string
+// @Router /projects/{id}/events [get]
+func (s *Stream) Serve(c *fiber.Ctx) error {
+	projectID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+
+	lastSeq, err := s.resumeFrom(c, projectID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	// Subscribe before reading the backlog so nothing committed in between is missed.
+	sub := s.hub.Subscribe(Topic(projectID))
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer sub.Close()
+
+		fmt.Fprintf(w, "retry: %d\n\n", retryMillis)
+		if lastSeq, err = s.replay(w, projectID, lastSeq); err != nil {
+			return
+		}
+
+		keepAlive := time.NewTicker(keepAliveInterval)
+		defer keepAlive.Stop()
+
+		for {
+			select {
+			case _, ok := <-sub.C:
+				if !ok {
+					return
+				}
+				if lastSeq, err = s.replay(w, projectID, lastSeq); err != nil {
+					return
+				}
+			case <-keepAlive.C:
+				fmt.Fprint(w, ": keep-alive\n\n")
+				if err := w.Flush(); err != nil {
+					return
+				}
+			}
+		}
+	})
+	return nil
+}
+
+// resumeFrom returns the sequence number after which events should be sent:
+// the one the client reports, or the current end of the log for a fresh
+// subscription.
+func (s *Stream) resumeFrom(c *fiber.Ctx, projectID int) (int64, error) {
+	raw := c.Get("Last-Event-ID")
+	if raw == "" {
+		raw = c.Query("last_event_id")
+	}
+	if raw != "" {
+		seq, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || seq < 0 {
+			return 0, fmt.Errorf("invalid Last-Event-ID %q", raw)
+		}
+		return seq, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), queryTimeout)
+	defer cancel()
+	return s.repo.LastSeq(ctx, projectID)
+}
+
+// replay writes every sequenced event after lastSeq and returns the new
+// position. Events are sequenced in commit order, so none can show up behind
+// the position later.
+func (s *Stream) replay(w *bufio.Writer, projectID int, lastSeq int64) (int64, error) {
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), queryTimeout)
+		list, err := s.repo.ListAfter(ctx, projectID, lastSeq, replayBatch)
+		cancel()
+		if err != nil {
+			logger.Error("failed to read project events", "project_id", projectID, "error", err)
+			return lastSeq, err
+		}
+
+		for i := range list {
+			if err := writeEvent(w, &list[i]); err != nil {
+				return lastSeq, err
+			}
+			lastSeq = *list[i].Seq
+		}
+		if err := w.Flush(); err != nil {
+			return lastSeq, err
+		}
+		if len(list) < replayBatch {
+			return lastSeq, nil
+		}
+	}
+}
+
+func writeEvent(w *bufio.Writer, e *postgres.ProjectEvent) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", *e.Seq, e.Type, data)
+	return err
+}
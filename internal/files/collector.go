@@ -0,0 +1,187 @@
+package files
+
+import (
+	"context"
+	"innotech/pkg/db"
+	"innotech/pkg/storage"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+const (
+	// DeletionBatchSize is the number of queued deletions handled at once.
+	DeletionBatchSize = 100
+	sweepBatchSize    = 500
+	deletionPoll      = 30 * time.Second
+)
+
+// Report summarizes a sweep of the bucket.
+type Report struct {
+	Scanned int      `json:"scanned"`
+	Orphans []string `json:"orphans"`
+	Removed int      `json:"removed"`
+}
+
+// Collector removes stored files once their attachment records are gone,
+// and periodically sweeps the bucket for ticket files that no record
+// references.
+type Collector struct {
+	repo     Repository
+	store    storage.Backend
+	tx       db.Transactor
+	interval time.Duration
+	grace    time.Duration
+	remove   bool
+	logger   *slog.Logger
+}
+
+// NewCollector creates a Collector. Sweeps run every interval and only
+// consider objects older than grace, so that uploads still waiting for
+// completion are left alone. Orphans are removed when remove is set and
+// only reported otherwise.
+func NewCollector(repo Repository, store storage.Backend, tx db.Transactor, interval, grace time.Duration, remove bool, logger *slog.Logger) *Collector {
+	return &Collector{repo: repo, store: store, tx: tx, interval: interval, grace: grace, remove: remove, logger: logger}
+}
+
+// Run processes queued deletions and sweeps the bucket until ctx is done.
+func (c *Collector) Run(ctx context.Context) {
+	ticker := time.NewTicker(deletionPoll)
+	defer ticker.Stop()
+	var lastSweep time.Time
+
+	for {
+		for {
+			n, err := c.PurgeDeleted(ctx)
+			if err != nil {
+				c.logger.Error("file deletion failed", "err", err)
+			}
+			if err != nil || n < DeletionBatchSize {
+				break
+			}
+		}
+		if c.interval > 0 && time.Since(lastSweep) >= c.interval {
+			lastSweep = time.Now()
+			if _, err := c.Sweep(ctx, c.remove); err != nil {
+				c.logger.Error("file sweep failed", "err", err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PurgeDeleted removes one batch of queued objects from storage, along
+// with their quarantined copies, and returns the batch size. Keys that were
+// attached again are only dropped from the queue; keys whose removal fails
+// stay queued.
+func (c *Collector) PurgeDeleted(ctx context.Context) (int, error) {
+	var n int
+	err := c.tx.WithinTx(ctx, func(ctx context.Context) error {
+		keys, err := c.repo.PendingDeletions(ctx, DeletionBatchSize)
+		if err != nil || len(keys) == 0 {
+			return err
+		}
+		n = len(keys)
+		used, err := c.repo.Referenced(ctx, keys)
+		if err != nil {
+			return err
+		}
+		done := make([]string, 0, len(keys))
+		var removed []string
+		for _, key := range keys {
+			if !used[key] {
+				if err := c.deleteObjects(ctx, key, QuarantineKey(key)); err != nil {
+					c.logger.Error("cannot remove deleted file", "key", key, "err", err)
+					continue
+				}
+				c.logger.Info("deleted file removed", "key", key)
+				removed = append(removed, key)
+			}
+			done = append(done, key)
+		}
+		if err := c.repo.ForgetScans(ctx, removed); err != nil {
+			return err
+		}
+		return c.repo.ForgetDeletions(ctx, done)
+	})
+	return n, err
+}
+
+// Sweep looks for ticket files older than the grace period that no
+// attachment references, in place or in quarantine, and removes them if
+// remove is set.
+func (c *Collector) Sweep(ctx context.Context, remove bool) (*Report, error) {
+	report := &Report{Orphans: []string{}}
+	cutoff := time.Now().Add(-c.grace)
+	var batch, keys []string
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		used, err := c.repo.Referenced(ctx, keys)
+		if err != nil {
+			return err
+		}
+		var removed []string
+		for i, name := range batch {
+			if used[keys[i]] {
+				continue
+			}
+			report.Orphans = append(report.Orphans, name)
+			if !remove {
+				continue
+			}
+			if err := c.deleteObjects(ctx, name); err != nil {
+				c.logger.Error("cannot remove orphaned file", "key", name, "err", err)
+				continue
+			}
+			removed = append(removed, keys[i])
+			report.Removed++
+		}
+		batch, keys = batch[:0], keys[:0]
+		return c.repo.ForgetScans(ctx, removed)
+	}
+
+	var err error
+	for _, prefix := range []string{"projects/", QuarantineKey("projects/")} {
+		err = c.store.List(ctx, prefix, func(name string, info storage.Info) error {
+			report.Scanned++
+			// Only ticket files are tied to attachment records.
+			if !strings.Contains(name, "/tickets/") || info.Modified.After(cutoff) {
+				return nil
+			}
+			batch = append(batch, name)
+			keys = append(keys, strings.TrimPrefix(name, quarantinePrefix))
+			if len(batch) < sweepBatchSize {
+				return nil
+			}
+			return flush()
+		})
+		if err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = flush()
+	}
+	if err != nil {
+		return nil, err
+	}
+	c.logger.Info("file sweep finished", "scanned", report.Scanned, "orphans", len(report.Orphans), "removed", report.Removed)
+	return report, nil
+}
+
+// deleteObjects deletes the named objects from storage.
+func (c *Collector) deleteObjects(ctx context.Context, names ...string) error {
+	for _, name := range names {
+		if err := c.store.Delete(ctx, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
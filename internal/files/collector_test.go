@@ -0,0 +1,195 @@
+package files
+
+import (
+	"context"
+	"database/sql"
+	"innotech/pkg/db"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeRepo struct {
+	queue    []string
+	used     map[string]bool
+	previews []string
+	set      map[string][2]string
+	scans    map[string]string
+	found    map[string]string
+}
+
+func (r *fakeRepo) PendingDeletions(_ context.Context, limit int) ([]string, error) {
+	if len(r.queue) > limit {
+		return r.queue[:limit], nil
+	}
+	return r.queue, nil
+}
+
+func (r *fakeRepo) ForgetDeletions(_ context.Context, keys []string) error {
+	forget := map[string]bool{}
+	for _, k := range keys {
+		forget[k] = true
+	}
+	var rest []string
+	for _, k := range r.queue {
+		if !forget[k] {
+			rest = append(rest, k)
+		}
+	}
+	r.queue = rest
+	return nil
+}
+
+func (r *fakeRepo) Referenced(_ context.Context, keys []string) (map[string]bool, error) {
+	set := map[string]bool{}
+	for _, k := range keys {
+		if r.used[k] {
+			set[k] = true
+		}
+	}
+	return set, nil
+}
+
+func (r *fakeRepo) PendingPreviews(_ context.Context, limit int) ([]string, error) {
+	if len(r.previews) > limit {
+		return r.previews[:limit], nil
+	}
+	return r.previews, nil
+}
+
+func (r *fakeRepo) ForgetPreviews(_ context.Context, keys []string) error {
+	forget := map[string]bool{}
+	for _, k := range keys {
+		forget[k] = true
+	}
+	var rest []string
+	for _, k := range r.previews {
+		if !forget[k] {
+			rest = append(rest, k)
+		}
+	}
+	r.previews = rest
+	return nil
+}
+
+func (r *fakeRepo) SetPreviews(_ context.Context, key, thumbnail, preview string) error {
+	if r.set == nil {
+		r.set = map[string][2]string{}
+	}
+	r.set[key] = [2]string{thumbnail, preview}
+	return nil
+}
+
+func (r *fakeRepo) QueueScan(_ context.Context, key string) error {
+	if r.scans == nil {
+		r.scans = map[string]string{}
+	}
+	if _, ok := r.scans[key]; !ok {
+		r.scans[key] = ScanPending
+	}
+	return nil
+}
+
+func (r *fakeRepo) PendingScans(_ context.Context, limit int) ([]string, error) {
+	var keys []string
+	for key, status := range r.scans {
+		if status == ScanPending && len(keys) < limit {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (r *fakeRepo) SetScanResult(_ context.Context, key, status, signature string) error {
+	r.scans[key] = status
+	if signature != "" {
+		if r.found == nil {
+			r.found = map[string]string{}
+		}
+		r.found[key] = signature
+	}
+	return nil
+}
+
+func (r *fakeRepo) ScanStatus(_ context.Context, key string) (string, error) {
+	status, ok := r.scans[key]
+	if !ok {
+		return "", sql.ErrNoRows
+	}
+	return status, nil
+}
+
+func (r *fakeRepo) ForgetScans(_ context.Context, keys []string) error {
+	for _, k := range keys {
+		delete(r.scans, k)
+	}
+	return nil
+}
+
+func TestCollector_PurgeDeleted(t *testing.T) {
+	store := &fakeStore{objects: map[string][]byte{
+		"projects/1/tickets/2/a":            []byte("a"),
+		"projects/1/tickets/2/b":            []byte("b"),
+		"quarantine/projects/1/tickets/2/c": []byte("c"),
+	}}
+	repo := &fakeRepo{
+		queue: []string{"projects/1/tickets/2/a", "projects/1/tickets/2/b", "projects/1/tickets/2/c"},
+		used:  map[string]bool{"projects/1/tickets/2/b": true},
+		scans: map[string]string{"projects/1/tickets/2/a": ScanClean, "projects/1/tickets/2/b": ScanClean},
+	}
+	c := NewCollector(repo, store, db.NopTransactor{}, time.Hour, time.Hour, false, discardLogger())
+
+	n, err := c.PurgeDeleted(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+	assert.Empty(t, repo.queue)
+	assert.NotContains(t, store.objects, "projects/1/tickets/2/a")
+	assert.NotContains(t, store.objects, "quarantine/projects/1/tickets/2/c", "quarantined copy removed")
+	assert.Contains(t, store.objects, "projects/1/tickets/2/b", "attached again, kept")
+	assert.Equal(t, map[string]string{"projects/1/tickets/2/b": ScanClean}, repo.scans)
+}
+
+func TestCollector_Sweep(t *testing.T) {
+	old := time.Now().Add(-48 * time.Hour)
+	store := &fakeStore{
+		objects: map[string][]byte{
+			"projects/1/tickets/2/orphan":              []byte("x"),
+			"projects/1/tickets/2/attached":            []byte("x"),
+			"projects/1/tickets/2/fresh":               []byte("x"),
+			"projects/1/files/upload":                  []byte("x"),
+			"quarantine/projects/1/tickets/2/pending":  []byte("x"),
+			"quarantine/projects/1/tickets/2/unlinked": []byte("x"),
+		},
+		modified: map[string]time.Time{
+			"projects/1/tickets/2/orphan":              old,
+			"projects/1/tickets/2/attached":            old,
+			"projects/1/tickets/2/fresh":               time.Now(),
+			"projects/1/files/upload":                  old,
+			"quarantine/projects/1/tickets/2/pending":  old,
+			"quarantine/projects/1/tickets/2/unlinked": old,
+		},
+	}
+	repo := &fakeRepo{used: map[string]bool{
+		"projects/1/tickets/2/attached": true,
+		"projects/1/tickets/2/pending":  true,
+	}}
+	c := NewCollector(repo, store, db.NopTransactor{}, time.Hour, 24*time.Hour, false, discardLogger())
+
+	report, err := c.Sweep(context.Background(), false)
+	require.NoError(t, err)
+	assert.Equal(t, 6, report.Scanned)
+	assert.ElementsMatch(t, []string{"projects/1/tickets/2/orphan", "quarantine/projects/1/tickets/2/unlinked"}, report.Orphans)
+	assert.Zero(t, report.Removed)
+	assert.Len(t, store.objects, 6)
+
+	report, err = c.Sweep(context.Background(), true)
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Removed)
+	assert.NotContains(t, store.objects, "projects/1/tickets/2/orphan")
+	assert.NotContains(t, store.objects, "quarantine/projects/1/tickets/2/unlinked")
+	assert.Len(t, store.objects, 4)
+}
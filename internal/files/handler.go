@@ -2,6 +2,10 @@
 package files
 
 import (
+	"database/sql"
+	"errors"
+	"innotech/internal/storage/transport"
+	"innotech/pkg/middleware"
 	"log/slog"
 
 	"github.com/gofiber/fiber/v2"
@@ -9,23 +13,29 @@ import (
 
 // Handler handles HTTP requests for files operations.
 type Handler struct {
-	service *Service
-	logger  *slog.Logger
+	service   *Service
+	collector *Collector
+	logger    *slog.Logger
 }
 
 // NewHandler creates a new Handler instance.
-func NewHandler(service *Service, logger *slog.Logger) *Handler {
-	return &Handler{service: service, logger: logger}
+func NewHandler(service *Service, collector *Collector, logger *slog.Logger) *Handler {
+	return &Handler{service: service, collector: collector, logger: logger}
 }
 
 // Upload godoc
 // @Summary Загрузить файл
+// @Description файл сохраняется в хранилище проекта или заявки под сгенерированным ключом; тип определяется по содержимому
 // @Tags Files
 // @Accept multipart/form-data
 // @Produce json
 // @Param file formData file true "Файл для загрузки"
-// @Success 200 {object} map[string]string
+// @Param project_id query int false "Project ID"
+// @Param ticket_id query int false "Ticket ID"
+// @Success 201 {object} files.Upload
 // @Failure 400 {object} map[string]string
+// @Failure 413 {object} map[string]string
+// @Failure 415 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /files/upload [post]
 // Upload POST /files/upload
@@ -33,22 +43,130 @@ func (h *Handler) Upload(c *fiber.Ctx) error {
 	file, err := c.FormFile("file")
 	if err != nil {
 		h.logger.Error("no file provided", "err", err)
-		return fiber.NewError(fiber.StatusBadRequest, "file is required")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "file is required"})
 	}
 
-	savePath := "/tmp/" + file.Filename
+	f, err := file.Open()
+	if err != nil {
+		h.logger.Error("cannot open uploaded file", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "file read error"})
+	}
+	defer f.Close()
 
-	if err := c.SaveFile(file, savePath); err != nil {
-		h.logger.Error("cannot save uploaded file", "err", err)
-		return fiber.NewError(fiber.StatusInternalServerError, "file save error")
+	projectID, _ := c.Locals("project_id").(int)
+	upload, err := h.service.Upload(c.UserContext(), projectID, c.QueryInt("ticket_id"), file.Filename, f, file.Size)
+	if err != nil {
+		return h.fail(c, err)
 	}
+	return c.Status(fiber.StatusCreated).JSON(upload)
+}
 
-	url, err := h.service.UploadFile(c.Context(), file.Filename, savePath)
+// StartUpload godoc
+// @Summary получить ссылку для прямой загрузки файла в хранилище
+// @Description выдаёт запрос (POST-форму или PUT, см. method) с ограничениями на размер и тип; после загрузки вызовите /files/uploads/complete
+// @Tags Files
+// @Accept json
+// @Produce json
+// @Param upload body transport.StartUploadDTO true "Upload"
+// @Success 201 {object} files.PendingUpload
+// @Failure 413 {object} map[string]string
+// @Failure 415 {object} map[string]string
+// @Router /files/uploads [post]
+func (h *Handler) StartUpload(c *fiber.Ctx) error {
+	dto := c.Locals("body").(*transport.StartUploadDTO)
+	projectID, _ := c.Locals("project_id").(int)
+
+	pending, err := h.service.StartUpload(c.UserContext(), projectID, dto.TicketID, dto.ContentType, dto.Size)
 	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, "upload to storage failed")
+		return h.fail(c, err)
 	}
+	return c.Status(fiber.StatusCreated).JSON(pending)
+}
 
-	return c.JSON(fiber.Map{
-		"url": url,
-	})
+// CompleteUpload godoc
+// @Summary прикрепить загруженный напрямую файл к заявке или сообщению
+// @Tags Files
+// @Accept json
+// @Produce json
+// @Param upload body transport.CompleteUploadDTO true "Upload"
+// @Success 201 {object} files.Registered
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 415 {object} map[string]string
+// @Router /files/uploads/complete [post]
+func (h *Handler) CompleteUpload(c *fiber.Ctx) error {
+	dto := c.Locals("body").(*transport.CompleteUploadDTO)
+	projectID, _ := c.Locals("project_id").(int)
+
+	registered, err := h.service.CompleteUpload(c.UserContext(), projectID, dto.TicketID, dto.ChatID, dto.Key, middleware.UserID(c), dto.Description)
+	if err != nil {
+		return h.fail(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(registered)
+}
+
+// Download godoc
+// @Summary получить временную ссылку на скачивание файла
+// @Tags Files
+// @Produce json
+// @Param key query string true "Object key"
+// @Param filename query string false "Имя файла для сохранения"
+// @Success 200 {object} files.Download
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /files/download [get]
+func (h *Handler) Download(c *fiber.Ctx) error {
+	projectID, _ := c.Locals("project_id").(int)
+
+	download, err := h.service.DownloadURL(c.UserContext(), projectID, c.Query("key"), c.Query("filename"))
+	if err != nil {
+		return h.fail(c, err)
+	}
+	return c.JSON(download)
+}
+
+// Sweep godoc
+// @Summary найти в хранилище файлы заявок без вложений
+// @Description с remove=true найденные файлы удаляются
+// @Tags Files
+// @Produce json
+// @Param remove query bool false "Удалить найденные файлы"
+// @Success 200 {object} files.Report
+// @Router /files/gc [post]
+func (h *Handler) Sweep(c *fiber.Ctx) error {
+	report, err := h.collector.Sweep(c.UserContext(), c.QueryBool("remove"))
+	if err != nil {
+		return h.fail(c, err)
+	}
+	return c.JSON(report)
+}
+
+func (h *Handler) fail(c *fiber.Ctx, err error) error {
+	status := Status(err)
+	if status == fiber.StatusInternalServerError {
+		h.logger.Error("file operation failed", "err", err)
+	}
+	return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+}
+
+// Status maps errors of file operations, including attachment verification,
+// to HTTP statuses.
+func Status(err error) int {
+	switch {
+	case errors.Is(err, ErrTooLarge):
+		return fiber.StatusRequestEntityTooLarge
+	case errors.Is(err, ErrTypeNotAllowed):
+		return fiber.StatusUnsupportedMediaType
+	case errors.Is(err, ErrForeignObject), errors.Is(err, ErrChatMismatch):
+		return fiber.StatusBadRequest
+	case errors.Is(err, ErrNotFound), errors.Is(err, sql.ErrNoRows):
+		return fiber.StatusNotFound
+	case errors.Is(err, ErrNotScanned):
+		return fiber.StatusConflict
+	case errors.Is(err, ErrInfected):
+		return fiber.StatusForbidden
+	default:
+		return fiber.StatusInternalServerError
+	}
 }
@@ -0,0 +1,254 @@
+package files
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif" // decoders for image.Decode
+	"image/jpeg"
+	_ "image/png"
+	"innotech/internal/storage/postgres"
+	"innotech/pkg/db"
+	"innotech/pkg/storage"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	// ThumbnailSize bounds the width and height of thumbnails.
+	ThumbnailSize = 256
+	// PreviewSize bounds the width and height of web-sized previews.
+	PreviewSize = 1280
+	// PreviewBatchSize is the number of queued files handled at once.
+	PreviewBatchSize = 20
+
+	thumbnailSuffix = ".thumb.jpg"
+	previewSuffix   = ".preview.jpg"
+	// maxPixels guards against small files that decode to huge images.
+	maxPixels = 50_000_000
+)
+
+// previewTypes are the content types previews are generated for.
+var previewTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// errNoPreview is returned for files that cannot be previewed.
+var errNoPreview = errors.New("file cannot be previewed")
+
+// Previewer generates thumbnails and previews of attached images. They are
+// stored next to the original, under its key with a suffix.
+type Previewer struct {
+	repo   Repository
+	store  storage.Backend
+	tx     db.Transactor
+	logger *slog.Logger
+}
+
+// NewPreviewer creates a Previewer.
+func NewPreviewer(repo Repository, store storage.Backend, tx db.Transactor, logger *slog.Logger) *Previewer {
+	return &Previewer{repo: repo, store: store, tx: tx, logger: logger}
+}
+
+// Run generates previews of queued files until ctx is done.
+func (p *Previewer) Run(ctx context.Context) {
+	ticker := time.NewTicker(deletionPoll)
+	defer ticker.Stop()
+
+	for {
+		for {
+			n, err := p.Generate(ctx)
+			if err != nil {
+				p.logger.Error("preview generation failed", "err", err)
+			}
+			if err != nil || n < PreviewBatchSize {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Generate handles one batch of queued files and returns the batch size.
+// Files that are not images, cannot be decoded or are no longer attached
+// are dropped from the queue; files whose previews cannot be stored stay
+// queued.
+func (p *Previewer) Generate(ctx context.Context) (int, error) {
+	var n int
+	err := p.tx.WithinTx(ctx, func(ctx context.Context) error {
+		keys, err := p.repo.PendingPreviews(ctx, PreviewBatchSize)
+		if err != nil || len(keys) == 0 {
+			return err
+		}
+		n = len(keys)
+		used, err := p.repo.Referenced(ctx, keys)
+		if err != nil {
+			return err
+		}
+		done := make([]string, 0, len(keys))
+		for _, key := range keys {
+			if used[key] {
+				err := p.preview(ctx, key)
+				if err != nil && !errors.Is(err, errNoPreview) {
+					p.logger.Error("cannot generate preview", "key", key, "err", err)
+					continue
+				}
+				if err != nil {
+					p.logger.Warn("file not previewed", "key", key, "err", err)
+				}
+			}
+			done = append(done, key)
+		}
+		return p.repo.ForgetPreviews(ctx, done)
+	})
+	return n, err
+}
+
+func (p *Previewer) preview(ctx context.Context, key string) error {
+	info, err := p.store.Stat(ctx, key)
+	if errors.Is(err, storage.ErrNotFound) {
+		return fmt.Errorf("%w: %v", errNoPreview, err)
+	}
+	if err != nil {
+		return err
+	}
+	if !previewTypes[info.ContentType] {
+		return nil
+	}
+
+	r, err := p.store.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	data, err := io.ReadAll(r)
+	r.Close()
+	if err != nil {
+		return err
+	}
+	img, err := decodeImage(data)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errNoPreview, err)
+	}
+
+	thumbnail, preview := key+thumbnailSuffix, key+previewSuffix
+	for name, size := range map[string]int{thumbnail: ThumbnailSize, preview: PreviewSize} {
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, fit(img, size), &jpeg.Options{Quality: 85}); err != nil {
+			return err
+		}
+		if err := p.store.Put(ctx, name, &buf, int64(buf.Len()), "image/jpeg"); err != nil {
+			return err
+		}
+	}
+	if err := p.repo.SetPreviews(ctx, key, thumbnail, preview); err != nil {
+		return err
+	}
+	p.logger.Info("preview generated", "key", key)
+	return nil
+}
+
+// decodeImage decodes data, refusing images with too many pixels.
+func decodeImage(data []byte) (image.Image, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxPixels {
+		return nil, fmt.Errorf("image of %dx%d pixels", cfg.Width, cfg.Height)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	return img, err
+}
+
+// fit scales img down to fit in a size by size square, on a white
+// background since JPEG has no transparency. Smaller images keep their size.
+func fit(img image.Image, size int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w > size || h > size {
+		if w >= h {
+			w, h = size, max(1, h*size/w)
+		} else {
+			w, h = max(1, w*size/h), size
+		}
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, b, draw.Over, nil)
+	return dst
+}
+
+// Links fills in the preview links and icon hints of attachments in
+// responses.
+type Links struct {
+	store  storage.Backend
+	ttl    time.Duration
+	logger *slog.Logger
+}
+
+// NewLinks creates a Links issuing URLs that are valid for ttl.
+func NewLinks(store storage.Backend, ttl time.Duration, logger *slog.Logger) *Links {
+	return &Links{store: store, ttl: ttl, logger: logger}
+}
+
+// TicketAttachment fills in the links of att.
+func (l *Links) TicketAttachment(ctx context.Context, att *postgres.TicketAttachment) {
+	att.ThumbnailURL = l.url(ctx, att.ThumbnailPath)
+	att.PreviewURL = l.url(ctx, att.PreviewPath)
+	att.Icon = Icon(att.FileType)
+}
+
+// MessageAttachment fills in the links of att.
+func (l *Links) MessageAttachment(ctx context.Context, att *postgres.MessageAttachment) {
+	att.ThumbnailURL = l.url(ctx, att.ThumbnailPath)
+	att.PreviewURL = l.url(ctx, att.PreviewPath)
+	att.Icon = Icon(att.FileType)
+}
+
+func (l *Links) url(ctx context.Context, key *string) *string {
+	if key == nil {
+		return nil
+	}
+	url, err := l.store.PresignGet(ctx, *key, "", l.ttl)
+	if err != nil {
+		l.logger.Error("cannot sign preview URL", "key", *key, "err", err)
+		return nil
+	}
+	return &url
+}
+
+// Icon returns a hint for the icon to show for a file of the content type:
+// image, pdf, archive, spreadsheet, text or file.
+func Icon(contentType *string) string {
+	if contentType == nil {
+		return "file"
+	}
+	switch t := *contentType; {
+	case strings.HasPrefix(t, "image/"):
+		return "image"
+	case t == "application/pdf":
+		return "pdf"
+	case t == "application/zip", t == "application/x-gzip", t == "application/x-rar-compressed", t == "application/x-7z-compressed":
+		return "archive"
+	case t == "text/csv":
+		return "spreadsheet"
+	case strings.HasPrefix(t, "text/"):
+		return "text"
+	}
+	return "file"
+}
@@ -0,0 +1,132 @@
+package files
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"innotech/internal/storage/postgres"
+	"innotech/pkg/db"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encodePNG(t *testing.T, w, h int) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for x := 0; x < w; x++ {
+		img.Set(x, h/2, color.NRGBA{R: 255, A: 255})
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func decodeJPEG(t *testing.T, data []byte) image.Rectangle {
+	img, err := jpeg.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	return img.Bounds()
+}
+
+func TestPreviewer_Generate(t *testing.T) {
+	const (
+		wide   = "projects/1/tickets/2/wide"
+		small  = "projects/1/tickets/2/small"
+		doc    = "projects/1/tickets/2/doc"
+		broken = "projects/1/tickets/2/broken"
+		gone   = "projects/1/tickets/2/gone"
+	)
+	store := &fakeStore{
+		objects: map[string][]byte{
+			wide:   encodePNG(t, 2000, 500),
+			small:  encodePNG(t, 100, 40),
+			doc:    []byte("%PDF-1.7"),
+			broken: []byte("\x89PNG\r\n\x1a\nnot really"),
+			gone:   encodePNG(t, 10, 10),
+		},
+		contentTypes: map[string]string{wide: "image/png", small: "image/png", doc: "application/pdf", broken: "image/png", gone: "image/png"},
+	}
+	repo := &fakeRepo{
+		previews: []string{wide, small, doc, broken, gone},
+		used:     map[string]bool{wide: true, small: true, doc: true, broken: true},
+	}
+	p := NewPreviewer(repo, store, db.NopTransactor{}, discardLogger())
+
+	n, err := p.Generate(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 5, n)
+	assert.Empty(t, repo.previews)
+
+	assert.Equal(t, map[string][2]string{
+		wide:  {wide + ".thumb.jpg", wide + ".preview.jpg"},
+		small: {small + ".thumb.jpg", small + ".preview.jpg"},
+	}, repo.set)
+	assert.Equal(t, image.Rect(0, 0, 256, 64), decodeJPEG(t, store.objects[wide+".thumb.jpg"]))
+	assert.Equal(t, image.Rect(0, 0, 1280, 320), decodeJPEG(t, store.objects[wide+".preview.jpg"]))
+	assert.Equal(t, image.Rect(0, 0, 100, 40), decodeJPEG(t, store.objects[small+".preview.jpg"]), "not upscaled")
+	assert.Equal(t, "image/jpeg", store.contentTypes[wide+".thumb.jpg"])
+	assert.NotContains(t, store.objects, gone+".thumb.jpg", "detached files are skipped")
+}
+
+func TestPreviewer_Generate_KeepsQueuedOnStoreError(t *testing.T) {
+	key := "projects/1/tickets/2/a"
+	store := &fakeStore{objects: map[string][]byte{key: encodePNG(t, 10, 10)}, contentTypes: map[string]string{key: "image/png"}}
+	repo := &fakeRepo{previews: []string{key}, used: map[string]bool{key: true}}
+	store.err = errors.New("unavailable")
+
+	_, err := NewPreviewer(repo, store, db.NopTransactor{}, discardLogger()).Generate(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{key}, repo.previews)
+	assert.Empty(t, repo.set)
+}
+
+func TestDecodeImage_RefusesHugeImages(t *testing.T) {
+	// A small PNG whose header claims 10000x6000 pixels.
+	data := encodePNG(t, 1, 1)
+	ihdr := data[12:29]
+	binary.BigEndian.PutUint32(ihdr[4:], 10000)
+	binary.BigEndian.PutUint32(ihdr[8:], 6000)
+	binary.BigEndian.PutUint32(data[29:], crc32.ChecksumIEEE(ihdr))
+
+	_, err := decodeImage(data)
+	assert.ErrorContains(t, err, "10000x6000")
+}
+
+func TestLinks(t *testing.T) {
+	links := NewLinks(&fakeStore{}, time.Minute, discardLogger())
+	imageType, pdfType := "image/png", "application/pdf"
+	thumb, preview := "projects/1/tickets/2/a.thumb.jpg", "projects/1/tickets/2/a.preview.jpg"
+
+	att := &postgres.TicketAttachment{FileType: &imageType, ThumbnailPath: &thumb, PreviewPath: &preview}
+	links.TicketAttachment(context.Background(), att)
+	assert.Equal(t, "http://storage/bucket/"+thumb+"?name=", *att.ThumbnailURL)
+	assert.Equal(t, "http://storage/bucket/"+preview+"?name=", *att.PreviewURL)
+	assert.Equal(t, "image", att.Icon)
+
+	msg := &postgres.MessageAttachment{FileType: &pdfType}
+	links.MessageAttachment(context.Background(), msg)
+	assert.Nil(t, msg.ThumbnailURL)
+	assert.Nil(t, msg.PreviewURL)
+	assert.Equal(t, "pdf", msg.Icon)
+}
+
+func TestIcon(t *testing.T) {
+	for contentType, icon := range map[string]string{
+		"image/webp":      "image",
+		"application/pdf": "pdf",
+		"application/zip": "archive",
+		"text/csv":        "spreadsheet",
+		"text/plain":      "text",
+		"application/x-y": "file",
+	} {
+		assert.Equal(t, icon, Icon(&contentType), contentType)
+	}
+	assert.Equal(t, "file", Icon(nil))
+}
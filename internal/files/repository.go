@@ -0,0 +1,164 @@
+package files
+
+import (
+	"context"
+	"innotech/internal/storage/postgres"
+	"innotech/pkg/db"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Repository defines the interface for tracking stored files in the database.
+type Repository interface {
+	PendingDeletions(ctx context.Context, limit int) ([]string, error)
+	ForgetDeletions(ctx context.Context, keys []string) error
+	Referenced(ctx context.Context, keys []string) (map[string]bool, error)
+	PendingPreviews(ctx context.Context, limit int) ([]string, error)
+	ForgetPreviews(ctx context.Context, keys []string) error
+	SetPreviews(ctx context.Context, key, thumbnail, preview string) error
+	QueueScan(ctx context.Context, key string) error
+	PendingScans(ctx context.Context, limit int) ([]string, error)
+	SetScanResult(ctx context.Context, key, status, signature string) error
+	ScanStatus(ctx context.Context, key string) (string, error)
+	ForgetScans(ctx context.Context, keys []string) error
+}
+
+type repository struct {
+	db *sqlx.DB
+}
+
+// NewRepository creates a new Repository instance.
+func NewRepository(db *sqlx.DB) Repository {
+	return &repository{db: db}
+}
+
+// PendingDeletions locks up to limit queued keys for the current transaction.
+func (r *repository) PendingDeletions(ctx context.Context, limit int) ([]string, error) {
+	var keys []string
+	err := db.Conn(ctx, r.db).SelectContext(ctx, &keys, `
+		SELECT key FROM file_deletions
+		ORDER BY date_created
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED`, limit)
+	return keys, err
+}
+
+func (r *repository) ForgetDeletions(ctx context.Context, keys []string) error {
+	_, err := db.Conn(ctx, r.db).ExecContext(ctx,
+		`DELETE FROM file_deletions WHERE key = ANY($1::text[])`, postgres.StringArray(keys))
+	return err
+}
+
+// Referenced reports which of keys are still used by an attachment, as its
+// file or as one of the file's previews.
+func (r *repository) Referenced(ctx context.Context, keys []string) (map[string]bool, error) {
+	var used []string
+	err := db.Conn(ctx, r.db).SelectContext(ctx, &used, `
+		SELECT key FROM unnest($1::text[]) AS key
+		WHERE EXISTS (SELECT 1 FROM ticket_attachments WHERE file_path = key)
+		   OR EXISTS (SELECT 1 FROM message_attachments WHERE file_path = key)
+		   OR EXISTS (SELECT 1 FROM ticket_attachments WHERE thumbnail_path = key OR preview_path = key)
+		   OR EXISTS (SELECT 1 FROM message_attachments WHERE thumbnail_path = key OR preview_path = key)`, postgres.StringArray(keys))
+	if err != nil {
+		return nil, err
+	}
+	set := make(map[string]bool, len(used))
+	for _, k := range used {
+		set[k] = true
+	}
+	return set, nil
+}
+
+// PendingPreviews locks up to limit keys waiting for previews for the
+// current transaction.
+func (r *repository) PendingPreviews(ctx context.Context, limit int) ([]string, error) {
+	var keys []string
+	err := db.Conn(ctx, r.db).SelectContext(ctx, &keys, `
+		SELECT key FROM file_previews
+		ORDER BY date_created
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED`, limit)
+	return keys, err
+}
+
+func (r *repository) ForgetPreviews(ctx context.Context, keys []string) error {
+	_, err := db.Conn(ctx, r.db).ExecContext(ctx,
+		`DELETE FROM file_previews WHERE key = ANY($1::text[])`, postgres.StringArray(keys))
+	return err
+}
+
+// SetPreviews records the previews of key on the attachments of the file.
+func (r *repository) SetPreviews(ctx context.Context, key, thumbnail, preview string) error {
+	conn := db.Conn(ctx, r.db)
+	for _, table := range []string{"ticket_attachments", "message_attachments"} {
+		_, err := conn.ExecContext(ctx,
+			`UPDATE `+table+` SET thumbnail_path = $2, preview_path = $3 WHERE file_path = $1`, key, thumbnail, preview)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// QueueScan queues key for a malware scan unless it was queued before.
+func (r *repository) QueueScan(ctx context.Context, key string) error {
+	_, err := db.Conn(ctx, r.db).ExecContext(ctx,
+		`INSERT INTO file_scans (key) VALUES ($1) ON CONFLICT DO NOTHING`, key)
+	return err
+}
+
+// PendingScans locks up to limit keys waiting for a scan for the current
+// transaction.
+func (r *repository) PendingScans(ctx context.Context, limit int) ([]string, error) {
+	var keys []string
+	err := db.Conn(ctx, r.db).SelectContext(ctx, &keys, `
+		SELECT key FROM file_scans
+		WHERE status = 'pending'
+		ORDER BY date_created
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED`, limit)
+	return keys, err
+}
+
+// SetScanResult records the scan of key on the scan and on the attachments
+// of the file. Clean files are queued for previews.
+func (r *repository) SetScanResult(ctx context.Context, key, status, signature string) error {
+	conn := db.Conn(ctx, r.db)
+	_, err := conn.ExecContext(ctx, `
+		UPDATE file_scans
+		SET status = $2, signature = NULLIF($3, ''), date_scanned = NOW()
+		WHERE key = $1`, key, status, signature)
+	if err != nil {
+		return err
+	}
+	for _, table := range []string{"ticket_attachments", "message_attachments"} {
+		_, err := conn.ExecContext(ctx, `UPDATE `+table+` SET scan_status = $2 WHERE file_path = $1`, key, status)
+		if err != nil {
+			return err
+		}
+	}
+	if status != ScanClean {
+		return nil
+	}
+	_, err = conn.ExecContext(ctx, `
+		INSERT INTO file_previews (key)
+		SELECT $1::text
+		WHERE EXISTS (SELECT 1 FROM ticket_attachments WHERE file_path = $1)
+		   OR EXISTS (SELECT 1 FROM message_attachments WHERE file_path = $1)
+		ON CONFLICT DO NOTHING`, key)
+	return err
+}
+
+// ScanStatus returns the scan status of key, or sql.ErrNoRows if it was
+// never queued.
+func (r *repository) ScanStatus(ctx context.Context, key string) (string, error) {
+	var status string
+	err := db.Conn(ctx, r.db).GetContext(ctx, &status, `SELECT status FROM file_scans WHERE key = $1`, key)
+	return status, err
+}
+
+func (r *repository) ForgetScans(ctx context.Context, keys []string) error {
+	_, err := db.Conn(ctx, r.db).ExecContext(ctx,
+		`DELETE FROM file_scans WHERE key = ANY($1::text[])`, postgres.StringArray(keys))
+	return err
+}
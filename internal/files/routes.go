@@ -1,9 +1,40 @@
 package files
 
-import "github.com/gofiber/fiber/v2"
+import (
+	"innotech/internal/access"
+	"innotech/internal/storage/transport"
+	"innotech/pkg/middleware"
 
-// RegisterRoutes registers HTTP routes for files operations.
-func RegisterRoutes(router fiber.Router, h *Handler) {
-	files := router.Group("/files")
-	files.Post("/upload", h.Upload)
+	"github.com/gofiber/fiber/v2"
+)
+
+// RegisterRoutes registers HTTP routes for files operations. Uploads go to a
+// ticket when ticket_id is given and to the project in project_id otherwise.
+// Downloads are authorized by the project in the object key.
+func RegisterRoutes(app *fiber.App, h *Handler, guard *access.Guard) {
+	files := app.Group("/api/files")
+	files.Post("/upload", guard.Require(access.AttachmentWrite, uploadTarget(guard)), h.Upload)
+	files.Post("/uploads", guard.Require(access.AttachmentWrite, guard.Body("ticket_id", access.ResourceTicket)), middleware.ValidateBody[transport.StartUploadDTO](h.StartUpload))
+	files.Post("/uploads/complete", guard.Require(access.AttachmentWrite, guard.Body("ticket_id", access.ResourceTicket)), middleware.ValidateBody[transport.CompleteUploadDTO](h.CompleteUpload))
+	files.Get("/download", guard.Require(access.AttachmentRead, keyProject), h.Download)
+	files.Post("/gc", guard.Superuser(), h.Sweep)
+}
+
+func keyProject(c *fiber.Ctx) (int, error) {
+	projectID := KeyProject(c.Query("key"))
+	if projectID == 0 {
+		return 0, access.ErrBadReference
+	}
+	return projectID, nil
+}
+
+func uploadTarget(guard *access.Guard) access.ProjectLocator {
+	byTicket := guard.Query("ticket_id", access.ResourceTicket)
+	byProject := guard.Query("project_id", access.ResourceProject)
+	return func(c *fiber.Ctx) (int, error) {
+		if c.Query("ticket_id") != "" {
+			return byTicket(c)
+		}
+		return byProject(c)
+	}
 }
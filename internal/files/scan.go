@@ -0,0 +1,197 @@
+package files
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"innotech/internal/events"
+	"innotech/pkg/db"
+	"innotech/pkg/storage"
+	"io"
+	"log/slog"
+	"time"
+)
+
+// Scan statuses of stored files.
+const (
+	ScanPending  = "pending"
+	ScanClean    = "clean"
+	ScanInfected = "infected"
+)
+
+const (
+	// ScanBatchSize is the number of queued files scanned at once.
+	ScanBatchSize = 10
+
+	quarantinePrefix = "quarantine/"
+)
+
+// eicar is the standard antivirus test string, split so that scanners on
+// developer machines leave this file alone.
+var eicar = []byte(`X5O!P%@AP[4\PZX54(P^)7CC)7}$` + `EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`)
+
+// QuarantineKey returns where the file stored as key is kept until it is
+// found clean.
+func QuarantineKey(key string) string {
+	return quarantinePrefix + key
+}
+
+// Scanner inspects file contents for malware.
+type Scanner interface {
+	// Scan returns the name of the malware found in r, or an empty string
+	// when it is clean.
+	Scan(ctx context.Context, r io.Reader) (string, error)
+}
+
+// StubScanner stands in for a real scanner in development and tests. It
+// only recognizes the EICAR test file.
+type StubScanner struct{}
+
+// Scan reports r as infected if it contains the EICAR test string.
+func (StubScanner) Scan(_ context.Context, r io.Reader) (string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+	if bytes.Contains(data, eicar) {
+		return "Eicar-Test-Signature", nil
+	}
+	return "", nil
+}
+
+// Activity records events in the activity log of a project.
+type Activity interface {
+	Publish(ctx context.Context, projectID int, eventType string, payload any) error
+}
+
+// Quarantine scans new files. Clean files are moved out of quarantine to
+// their key; infected files stay there and are reported to the project.
+type Quarantine struct {
+	repo     Repository
+	store    storage.Backend
+	scanner  Scanner
+	activity Activity
+	tx       db.Transactor
+	logger   *slog.Logger
+}
+
+// NewQuarantine creates a Quarantine.
+func NewQuarantine(repo Repository, store storage.Backend, scanner Scanner, activity Activity, tx db.Transactor, logger *slog.Logger) *Quarantine {
+	return &Quarantine{repo: repo, store: store, scanner: scanner, activity: activity, tx: tx, logger: logger}
+}
+
+// Run scans queued files until ctx is done.
+func (q *Quarantine) Run(ctx context.Context) {
+	ticker := time.NewTicker(deletionPoll)
+	defer ticker.Stop()
+
+	for {
+		for {
+			n, err := q.ScanPending(ctx)
+			if err != nil {
+				q.logger.Error("file scan failed", "err", err)
+			}
+			if err != nil || n < ScanBatchSize {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ScanPending scans one batch of queued files and returns the batch size.
+// Files that cannot be scanned stay queued; files that are gone are dropped.
+func (q *Quarantine) ScanPending(ctx context.Context) (int, error) {
+	var n int
+	err := q.tx.WithinTx(ctx, func(ctx context.Context) error {
+		keys, err := q.repo.PendingScans(ctx, ScanBatchSize)
+		if err != nil || len(keys) == 0 {
+			return err
+		}
+		n = len(keys)
+		var gone []string
+		for _, key := range keys {
+			err := q.scan(ctx, key)
+			if errors.Is(err, storage.ErrNotFound) {
+				q.logger.Warn("file to scan is missing", "key", key)
+				gone = append(gone, key)
+				continue
+			}
+			if err != nil {
+				q.logger.Error("cannot scan file", "key", key, "err", err)
+			}
+		}
+		if len(gone) == 0 {
+			return nil
+		}
+		return q.repo.ForgetScans(ctx, gone)
+	})
+	return n, err
+}
+
+// scan inspects the file stored as key. Files stored before scanning was
+// introduced are not in quarantine and are scanned where they are.
+func (q *Quarantine) scan(ctx context.Context, key string) error {
+	src := QuarantineKey(key)
+	r, err := q.store.Get(ctx, src)
+	if errors.Is(err, storage.ErrNotFound) {
+		src = key
+		r, err = q.store.Get(ctx, src)
+	}
+	if err != nil {
+		return err
+	}
+	signature, err := q.scanner.Scan(ctx, r)
+	r.Close()
+	if err != nil {
+		return err
+	}
+
+	if signature == "" {
+		if src != key {
+			if err := q.move(ctx, src, key); err != nil {
+				return err
+			}
+		}
+		if err := q.repo.SetScanResult(ctx, key, ScanClean, ""); err != nil {
+			return err
+		}
+		q.logger.Info("file is clean", "key", key)
+		return nil
+	}
+
+	// Take the file out of reach of download URLs issued before the scan.
+	if src == key {
+		if err := q.move(ctx, key, QuarantineKey(key)); err != nil {
+			return err
+		}
+	}
+	if err := q.repo.SetScanResult(ctx, key, ScanInfected, signature); err != nil {
+		return err
+	}
+	q.logger.Warn("infected file quarantined", "key", key, "signature", signature)
+	return q.activity.Publish(ctx, KeyProject(key), events.FileInfected, map[string]any{
+		"key":       key,
+		"signature": signature,
+	})
+}
+
+func (q *Quarantine) move(ctx context.Context, from, to string) error {
+	info, err := q.store.Stat(ctx, from)
+	if err != nil {
+		return err
+	}
+	r, err := q.store.Get(ctx, from)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	if err := q.store.Put(ctx, to, r, info.Size, info.ContentType); err != nil {
+		return err
+	}
+	return q.store.Delete(ctx, from)
+}
@@ -0,0 +1,90 @@
+package files
+
+import (
+	"bytes"
+	"context"
+	"innotech/internal/events"
+	"innotech/pkg/db"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type published struct {
+	projectID int
+	eventType string
+	payload   any
+}
+
+type fakeActivity struct {
+	events []published
+}
+
+func (a *fakeActivity) Publish(_ context.Context, projectID int, eventType string, payload any) error {
+	a.events = append(a.events, published{projectID, eventType, payload})
+	return nil
+}
+
+func TestQuarantine_ScanPending(t *testing.T) {
+	clean := "projects/3/tickets/7/clean"
+	infected := "projects/3/files/infected"
+	legacy := "projects/4/tickets/9/legacy"
+	missing := "projects/3/files/missing"
+	virus := append([]byte("header "), eicar...)
+	store := &fakeStore{
+		objects: map[string][]byte{
+			QuarantineKey(clean):    []byte("%PDF-1.7"),
+			QuarantineKey(infected): virus,
+			legacy:                  virus,
+		},
+		contentTypes: map[string]string{QuarantineKey(clean): "application/pdf"},
+	}
+	repo := &fakeRepo{scans: map[string]string{
+		clean:                   ScanPending,
+		infected:                ScanPending,
+		legacy:                  ScanPending,
+		missing:                 ScanPending,
+		"projects/3/files/done": ScanClean,
+	}}
+	activity := &fakeActivity{}
+	q := NewQuarantine(repo, store, StubScanner{}, activity, db.NopTransactor{}, discardLogger())
+
+	n, err := q.ScanPending(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 4, n)
+
+	assert.Equal(t, []byte("%PDF-1.7"), store.objects[clean], "clean file is released")
+	assert.Equal(t, "application/pdf", store.contentTypes[clean])
+	assert.NotContains(t, store.objects, QuarantineKey(clean))
+	assert.Contains(t, store.objects, QuarantineKey(infected), "infected file stays in quarantine")
+	assert.NotContains(t, store.objects, infected)
+	assert.Contains(t, store.objects, QuarantineKey(legacy), "infected legacy file is quarantined")
+	assert.NotContains(t, store.objects, legacy)
+
+	assert.Equal(t, map[string]string{
+		clean:                   ScanClean,
+		infected:                ScanInfected,
+		legacy:                  ScanInfected,
+		"projects/3/files/done": ScanClean,
+	}, repo.scans, "missing file is forgotten")
+	assert.Equal(t, "Eicar-Test-Signature", repo.found[infected])
+
+	require.Len(t, activity.events, 2)
+	assert.Equal(t, published{3, events.FileInfected, map[string]any{
+		"key":       infected,
+		"signature": "Eicar-Test-Signature",
+	}}, activity.events[0])
+	assert.Equal(t, 4, activity.events[1].projectID)
+}
+
+func TestStubScanner(t *testing.T) {
+	signature, err := StubScanner{}.Scan(context.Background(), strings.NewReader("hello"))
+	require.NoError(t, err)
+	assert.Empty(t, signature)
+
+	signature, err = StubScanner{}.Scan(context.Background(), bytes.NewReader(eicar))
+	require.NoError(t, err)
+	assert.Equal(t, "Eicar-Test-Signature", signature)
+}
@@ -1,38 +1,302 @@
 package files
 
 import (
+	"bytes"
 	"context"
-	minio_client "innotech/pkg/minio"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"innotech/internal/storage/postgres"
+	"innotech/pkg/storage"
+	"io"
 	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// sniffLength is the number of leading bytes used to detect the content type.
+const sniffLength = 512
+
+var (
+	// ErrTooLarge is returned for files over the configured size limit.
+	ErrTooLarge = errors.New("file is too large")
+	// ErrTypeNotAllowed is returned for files whose detected type is not allowed.
+	ErrTypeNotAllowed = errors.New("file type is not allowed")
+	// ErrNotFound is returned when the uploaded object is missing.
+	ErrNotFound = errors.New("file not found")
+	// ErrForeignObject is returned for keys outside the ticket or project.
+	ErrForeignObject = errors.New("file does not belong to the ticket")
+	// ErrChatMismatch is returned for chat messages of another ticket.
+	ErrChatMismatch = errors.New("message does not belong to the ticket")
+	// ErrNotScanned is returned for files that have not been found clean yet.
+	ErrNotScanned = errors.New("file has not been scanned yet")
+	// ErrInfected is returned for files found to contain malware.
+	ErrInfected = errors.New("file is infected")
 )
 
-// Service defines the interface for files business logic operations.
+// DefaultAllowedTypes are the content types accepted when none are configured.
+// Office documents are zip containers and are detected as application/zip.
+var DefaultAllowedTypes = []string{
+	"image/png",
+	"image/jpeg",
+	"image/gif",
+	"image/webp",
+	"application/pdf",
+	"application/zip",
+	"text/plain",
+	"text/csv",
+}
+
+// TicketAttachments links stored files to tickets.
+type TicketAttachments interface {
+	Create(ctx context.Context, att *postgres.TicketAttachment) error
+}
+
+// MessageAttachments links stored files to chat messages.
+type MessageAttachments interface {
+	Create(ctx context.Context, att *postgres.MessageAttachment) error
+}
+
+// Chats looks up ticket chat messages.
+type Chats interface {
+	GetByID(ctx context.Context, id int) (*postgres.TicketChat, error)
+}
+
+// Upload describes a stored file.
+type Upload struct {
+	Key         string `json:"key"`
+	Size        int64  `json:"size"`
+	Checksum    string `json:"checksum"`
+	ContentType string `json:"content_type"`
+	Filename    string `json:"filename"`
+}
+
+// PendingUpload is a presigned request for uploading a file directly to
+// storage. Depending on the backend it is a form POST that must carry
+// Fields followed by the file, or a PUT of the file with Headers.
+type PendingUpload struct {
+	Key string `json:"key"`
+	storage.Upload
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Registered is the attachment a completed upload was registered as.
+type Registered struct {
+	TicketAttachment  *postgres.TicketAttachment  `json:"ticket_attachment,omitempty"`
+	MessageAttachment *postgres.MessageAttachment `json:"message_attachment,omitempty"`
+}
+
+// Download is a short-lived URL for fetching a file from storage.
+type Download struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Service streams uploads to object storage, or lets clients upload and
+// download directly through presigned URLs. Uploads are quarantined until
+// they are scanned.
 type Service struct {
-	minio  *minio_client.MinioClient
-	logger *slog.Logger
+	store              storage.Backend
+	verifier           *Verifier
+	repo               Repository
+	ticketAttachments  TicketAttachments
+	messageAttachments MessageAttachments
+	chats              Chats
+	urlTTL             time.Duration
+	logger             *slog.Logger
 }
 
-// NewService creates a new Service instance.
-func NewService(minioClient *minio_client.MinioClient, logger *slog.Logger) *Service {
-	return &Service{minio: minioClient, logger: logger}
+// NewService creates a new Service instance. Uploads are held to the limits
+// of verifier. Presigned URLs are valid for urlTTL.
+func NewService(store storage.Backend, verifier *Verifier, repo Repository, ticketAttachments TicketAttachments, messageAttachments MessageAttachments, chats Chats, urlTTL time.Duration, logger *slog.Logger) *Service {
+	return &Service{
+		store:              store,
+		verifier:           verifier,
+		repo:               repo,
+		ticketAttachments:  ticketAttachments,
+		messageAttachments: messageAttachments,
+		chats:              chats,
+		urlTTL:             urlTTL,
+		logger:             logger,
+	}
 }
 
-// UploadFile creates a new file to MinIO
-func (s *Service) UploadFile(ctx context.Context, filename, path string) (string, error) {
-	s.logger.Info("uploading file", "filename", filename)
+// Upload streams r of the given size to storage under a new key in the
+// project, or in the ticket when ticketID is not zero. The content type is
+// detected from the data; the client-supplied filename is only reported back.
+// The file is kept in quarantine until it is scanned.
+func (s *Service) Upload(ctx context.Context, projectID, ticketID int, filename string, r io.Reader, size int64) (*Upload, error) {
+	if size > s.verifier.maxSize {
+		return nil, ErrTooLarge
+	}
 
-	err := s.minio.Upload(ctx, filename, path)
+	head := make([]byte, sniffLength)
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	head = head[:n]
+	contentType := DetectContentType(head)
+	if !s.verifier.allowed[contentType] {
+		return nil, fmt.Errorf("%w: %s", ErrTypeNotAllowed, contentType)
+	}
+
+	key := objectKey(projectID, ticketID)
+	hash := sha256.New()
+	body := io.TeeReader(io.MultiReader(bytes.NewReader(head), r), hash)
+	if err := s.store.Put(ctx, QuarantineKey(key), body, size, contentType); err != nil {
+		s.logger.Error("upload failed", "key", key, "err", err)
+		return nil, err
+	}
+	if err := s.repo.QueueScan(ctx, key); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("file uploaded", "key", key, "size", size, "content_type", contentType)
+	return &Upload{
+		Key:         key,
+		Size:        size,
+		Checksum:    hex.EncodeToString(hash.Sum(nil)),
+		ContentType: contentType,
+		Filename:    filename,
+	}, nil
+}
+
+// StartUpload issues a presigned request for uploading a file of the declared
+// content type and size into the ticket. The file goes to quarantine and is
+// not attached until CompleteUpload is called.
+func (s *Service) StartUpload(ctx context.Context, projectID, ticketID int, contentType string, size int64) (*PendingUpload, error) {
+	if size > s.verifier.maxSize {
+		return nil, ErrTooLarge
+	}
+	if !s.verifier.allowed[contentType] {
+		return nil, fmt.Errorf("%w: %s", ErrTypeNotAllowed, contentType)
+	}
+	key := objectKey(projectID, ticketID)
+	expiresAt := time.Now().Add(s.urlTTL)
+	upload, err := s.store.PresignPut(ctx, QuarantineKey(key), contentType, size, s.urlTTL)
+	if err != nil {
+		return nil, err
+	}
+	return &PendingUpload{Key: key, Upload: *upload, ExpiresAt: expiresAt}, nil
+}
+
+// CompleteUpload attaches an object uploaded through StartUpload to the
+// ticket, or to the chat message when chatID is not nil. The attachment
+// services verify the object; objects refused for their size or contents
+// are removed.
+func (s *Service) CompleteUpload(ctx context.Context, projectID, ticketID int, chatID *int, key, uploadedBy string, description *string) (*Registered, error) {
+	if !strings.HasPrefix(key, ticketPrefix(projectID, ticketID)) || strings.Contains(key, "..") {
+		return nil, ErrForeignObject
+	}
+	if chatID != nil {
+		chat, err := s.chats.GetByID(ctx, *chatID)
+		if err != nil {
+			return nil, err
+		}
+		if chat.TicketID != ticketID {
+			return nil, ErrChatMismatch
+		}
+	}
+
+	var registered Registered
+	var err error
+	if chatID != nil {
+		registered.MessageAttachment = &postgres.MessageAttachment{ChatID: *chatID, FilePath: key, UploadedBy: uploadedBy}
+		err = s.messageAttachments.Create(ctx, registered.MessageAttachment)
+	} else {
+		registered.TicketAttachment = &postgres.TicketAttachment{TicketID: ticketID, FilePath: key, UploadedBy: uploadedBy, Description: description}
+		err = s.ticketAttachments.Create(ctx, registered.TicketAttachment)
+	}
+	if errors.Is(err, ErrTooLarge) || errors.Is(err, ErrTypeNotAllowed) {
+		if err := s.store.Delete(ctx, QuarantineKey(key)); err != nil {
+			s.logger.Error("cannot remove refused upload", "key", key, "err", err)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &registered, nil
+}
+
+// DownloadURL issues a short-lived URL for a file of the project. Only files
+// found clean can be downloaded.
+func (s *Service) DownloadURL(ctx context.Context, projectID int, key, filename string) (*Download, error) {
+	if KeyProject(key) != projectID {
+		return nil, ErrForeignObject
+	}
+	status, err := s.repo.ScanStatus(ctx, key)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotScanned
+	}
+	if err != nil {
+		return nil, err
+	}
+	switch status {
+	case ScanClean:
+	case ScanInfected:
+		return nil, ErrInfected
+	default:
+		return nil, ErrNotScanned
+	}
+	if _, err := s.store.Stat(ctx, key); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	expiresAt := time.Now().Add(s.urlTTL)
+	url, err := s.store.PresignGet(ctx, key, filename, s.urlTTL)
 	if err != nil {
-		s.logger.Error("upload failed", "err", err)
-		return "", err
+		return nil, err
 	}
+	return &Download{URL: url, ExpiresAt: expiresAt}, nil
+}
 
-	url, err := s.minio.GetFileURL(filename)
+// KeyProject returns the project a storage key belongs to, or zero for keys
+// outside the project namespace.
+func KeyProject(key string) int {
+	rest, ok := strings.CutPrefix(key, "projects/")
+	if !ok || strings.Contains(key, "..") {
+		return 0
+	}
+	id, _, ok := strings.Cut(rest, "/")
+	if !ok {
+		return 0
+	}
+	projectID, err := strconv.Atoi(id)
+	if err != nil || projectID <= 0 {
+		return 0
+	}
+	return projectID
+}
+
+// DetectContentType sniffs the media type of data without its parameters.
+// Stored files carry the sniffed type, never the one their sender declared.
+func DetectContentType(data []byte) string {
+	mediaType, _, err := mime.ParseMediaType(http.DetectContentType(data))
 	if err != nil {
-		s.logger.Error("cannot get file url", "err", err)
-		return "", err
+		return "application/octet-stream"
 	}
+	return mediaType
+}
+
+func objectKey(projectID, ticketID int) string {
+	if ticketID != 0 {
+		return ticketPrefix(projectID, ticketID) + uuid.NewString()
+	}
+	return fmt.Sprintf("projects/%d/files/%s", projectID, uuid.NewString())
+}
 
-	s.logger.Info("file uploaded", "url", url)
-	return url, nil
+// ticketPrefix is the namespace of the files of a ticket. Email intake stores
+// attachments under it as well.
+func ticketPrefix(projectID, ticketID int) string {
+	return fmt.Sprintf("projects/%d/tickets/%d/", projectID, ticketID)
 }
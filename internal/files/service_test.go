@@ -0,0 +1,335 @@
+package files
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"innotech/internal/storage/postgres"
+	"innotech/pkg/storage"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeStore struct {
+	objects      map[string][]byte
+	contentTypes map[string]string
+	policies     map[string]int64
+	modified     map[string]time.Time
+	err          error
+}
+
+func (s *fakeStore) Put(_ context.Context, name string, r io.Reader, size int64, contentType string) error {
+	if s.err != nil {
+		return s.err
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if int64(len(data)) != size {
+		return errors.New("size mismatch")
+	}
+	if s.objects == nil {
+		s.objects, s.contentTypes = map[string][]byte{}, map[string]string{}
+	}
+	s.objects[name], s.contentTypes[name] = data, contentType
+	return nil
+}
+
+func (s *fakeStore) PresignPut(_ context.Context, name, contentType string, maxSize int64, _ time.Duration) (*storage.Upload, error) {
+	if s.policies == nil {
+		s.policies = map[string]int64{}
+	}
+	s.policies[name] = maxSize
+	return &storage.Upload{
+		Method: "POST",
+		URL:    "http://storage/bucket",
+		Fields: map[string]string{"key": name, "Content-Type": contentType},
+	}, nil
+}
+
+func (s *fakeStore) PresignGet(_ context.Context, name, filename string, _ time.Duration) (string, error) {
+	return "http://storage/bucket/" + name + "?name=" + filename, nil
+}
+
+func (s *fakeStore) Stat(_ context.Context, name string) (*storage.Info, error) {
+	data, ok := s.objects[name]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	return &storage.Info{Size: int64(len(data)), ContentType: s.contentTypes[name], Modified: s.modified[name]}, nil
+}
+
+func (s *fakeStore) Get(_ context.Context, name string) (io.ReadCloser, error) {
+	data, ok := s.objects[name]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s *fakeStore) List(_ context.Context, prefix string, fn func(string, storage.Info) error) error {
+	for name, data := range s.objects {
+		if strings.HasPrefix(name, prefix) {
+			if err := fn(name, storage.Info{Size: int64(len(data)), Modified: s.modified[name]}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *fakeStore) Delete(_ context.Context, name string) error {
+	delete(s.objects, name)
+	return nil
+}
+
+// fakeAttachments verifies files the way the attachment services do.
+type fakeAttachments struct {
+	verifier *Verifier
+	chats    fakeChats
+	tickets  []*postgres.TicketAttachment
+	messages []*postgres.MessageAttachment
+}
+
+func (f *fakeAttachments) Create(ctx context.Context, att *postgres.TicketAttachment) error {
+	obj, err := f.verifier.Verify(ctx, att.TicketID, att.FilePath)
+	if err != nil {
+		return err
+	}
+	att.FileType, att.Size, att.Checksum = &obj.ContentType, &obj.Size, &obj.Checksum
+	f.tickets = append(f.tickets, att)
+	return nil
+}
+
+type fakeMessageAttachments struct{ *fakeAttachments }
+
+func (f fakeMessageAttachments) Create(ctx context.Context, att *postgres.MessageAttachment) error {
+	obj, err := f.verifier.Verify(ctx, f.chats[att.ChatID], att.FilePath)
+	if err != nil {
+		return err
+	}
+	att.FileType, att.Size, att.Checksum = &obj.ContentType, &obj.Size, &obj.Checksum
+	f.messages = append(f.messages, att)
+	return nil
+}
+
+type fakeChats map[int]int
+
+func (f fakeChats) GetByID(_ context.Context, id int) (*postgres.TicketChat, error) {
+	return &postgres.TicketChat{ID: id, TicketID: f[id]}, nil
+}
+
+func newTestService(store storage.Backend) *Service {
+	s, _ := newTestServiceWithAttachments(store, &fakeRepo{})
+	return s
+}
+
+func newTestServiceWithAttachments(store storage.Backend, repo Repository) (*Service, *fakeAttachments) {
+	verifier := NewVerifier(store, 1<<20, DefaultAllowedTypes)
+	chats := fakeChats{11: 7, 12: 8}
+	atts := &fakeAttachments{verifier: verifier, chats: chats}
+	s := NewService(store, verifier, repo, atts, fakeMessageAttachments{atts}, chats, 15*time.Minute, discardLogger())
+	return s, atts
+}
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func TestService_Upload_StreamsToTicketKey(t *testing.T) {
+	store := &fakeStore{}
+	repo := &fakeRepo{}
+	s, _ := newTestServiceWithAttachments(store, repo)
+	data := append([]byte("%PDF-1.7\n"), bytes.Repeat([]byte("x"), 2000)...)
+
+	up, err := s.Upload(context.Background(), 3, 7, "../../etc/report.pdf", bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+
+	sum := sha256.Sum256(data)
+	assert.Regexp(t, `^projects/3/tickets/7/[0-9a-f-]{36}$`, up.Key)
+	assert.Equal(t, int64(len(data)), up.Size)
+	assert.Equal(t, hex.EncodeToString(sum[:]), up.Checksum)
+	assert.Equal(t, "application/pdf", up.ContentType)
+	assert.Equal(t, "../../etc/report.pdf", up.Filename)
+	assert.Equal(t, data, store.objects[QuarantineKey(up.Key)])
+	assert.Equal(t, "application/pdf", store.contentTypes[QuarantineKey(up.Key)])
+	assert.NotContains(t, store.objects, up.Key, "kept in quarantine")
+	assert.Equal(t, map[string]string{up.Key: ScanPending}, repo.scans)
+}
+
+func TestService_Upload_ProjectKeyAndShortFile(t *testing.T) {
+	store := &fakeStore{}
+
+	up, err := newTestService(store).Upload(context.Background(), 3, 0, "note.txt", bytes.NewReader([]byte("hello")), 5)
+	require.NoError(t, err)
+	assert.Regexp(t, `^projects/3/files/[0-9a-f-]{36}$`, up.Key)
+	assert.Equal(t, "text/plain", up.ContentType)
+	assert.Equal(t, []byte("hello"), store.objects[QuarantineKey(up.Key)])
+}
+
+func TestService_Upload_UniqueKeys(t *testing.T) {
+	store := &fakeStore{}
+	s := newTestService(store)
+
+	a, err := s.Upload(context.Background(), 1, 1, "same.txt", bytes.NewReader([]byte("a")), 1)
+	require.NoError(t, err)
+	b, err := s.Upload(context.Background(), 1, 1, "same.txt", bytes.NewReader([]byte("b")), 1)
+	require.NoError(t, err)
+	assert.NotEqual(t, a.Key, b.Key)
+}
+
+func TestService_Upload_TooLarge(t *testing.T) {
+	store := &fakeStore{}
+
+	_, err := newTestService(store).Upload(context.Background(), 1, 0, "big.txt", bytes.NewReader(nil), 2<<20)
+	assert.ErrorIs(t, err, ErrTooLarge)
+	assert.Empty(t, store.objects)
+}
+
+func TestService_Upload_TypeNotAllowed(t *testing.T) {
+	store := &fakeStore{}
+	exe := append([]byte("MZ\x90\x00\x03\x00\x00\x00"), make([]byte, 64)...)
+
+	_, err := newTestService(store).Upload(context.Background(), 1, 0, "invoice.pdf", bytes.NewReader(exe), int64(len(exe)))
+	assert.ErrorIs(t, err, ErrTypeNotAllowed)
+	assert.Empty(t, store.objects)
+}
+
+func TestService_Upload_StoreError(t *testing.T) {
+	store := &fakeStore{err: errors.New("unavailable")}
+
+	_, err := newTestService(store).Upload(context.Background(), 1, 0, "a.txt", bytes.NewReader([]byte("a")), 1)
+	assert.EqualError(t, err, "unavailable")
+}
+
+func TestService_StartUpload(t *testing.T) {
+	store := &fakeStore{}
+
+	pending, err := newTestService(store).StartUpload(context.Background(), 3, 7, "image/png", 4096)
+	require.NoError(t, err)
+	assert.Regexp(t, `^projects/3/tickets/7/[0-9a-f-]{36}$`, pending.Key)
+	assert.Equal(t, "POST", pending.Method)
+	assert.Equal(t, "http://storage/bucket", pending.URL)
+	assert.Equal(t, QuarantineKey(pending.Key), pending.Fields["key"])
+	assert.Equal(t, int64(4096), store.policies[QuarantineKey(pending.Key)])
+	assert.WithinDuration(t, time.Now().Add(15*time.Minute), pending.ExpiresAt, time.Minute)
+}
+
+func TestService_StartUpload_Refused(t *testing.T) {
+	s := newTestService(&fakeStore{})
+
+	_, err := s.StartUpload(context.Background(), 3, 7, "image/png", 2<<20)
+	assert.ErrorIs(t, err, ErrTooLarge)
+	_, err = s.StartUpload(context.Background(), 3, 7, "application/x-msdownload", 10)
+	assert.ErrorIs(t, err, ErrTypeNotAllowed)
+}
+
+func TestService_CompleteUpload_TicketAttachment(t *testing.T) {
+	key := "projects/3/tickets/7/6f1c7a52-2b36-4d8e-9a43-0c1f0f7b8f10"
+	store := &fakeStore{objects: map[string][]byte{key: []byte("\x89PNG\r\n\x1a\n....")}, contentTypes: map[string]string{key: "image/png"}}
+	s, atts := newTestServiceWithAttachments(store, &fakeRepo{})
+	desc := "screenshot"
+
+	reg, err := s.CompleteUpload(context.Background(), 3, 7, nil, key, "user-1", &desc)
+	require.NoError(t, err)
+	require.NotNil(t, reg.TicketAttachment)
+	assert.Nil(t, reg.MessageAttachment)
+	assert.Equal(t, 7, reg.TicketAttachment.TicketID)
+	assert.Equal(t, key, reg.TicketAttachment.FilePath)
+	assert.Equal(t, "user-1", reg.TicketAttachment.UploadedBy)
+	assert.Equal(t, "image/png", *reg.TicketAttachment.FileType)
+	assert.Equal(t, int64(12), *reg.TicketAttachment.Size)
+	sum := sha256.Sum256(store.objects[key])
+	assert.Equal(t, hex.EncodeToString(sum[:]), *reg.TicketAttachment.Checksum)
+	assert.Equal(t, &desc, reg.TicketAttachment.Description)
+	assert.Len(t, atts.tickets, 1)
+}
+
+func TestService_CompleteUpload_MessageAttachment(t *testing.T) {
+	key := "projects/3/tickets/7/6f1c7a52-2b36-4d8e-9a43-0c1f0f7b8f10"
+	store := &fakeStore{objects: map[string][]byte{QuarantineKey(key): []byte("log line")}}
+	s, atts := newTestServiceWithAttachments(store, &fakeRepo{})
+	chatID := 11
+
+	reg, err := s.CompleteUpload(context.Background(), 3, 7, &chatID, key, "user-1", nil)
+	require.NoError(t, err)
+	require.NotNil(t, reg.MessageAttachment)
+	assert.Equal(t, 11, reg.MessageAttachment.ChatID)
+	assert.Equal(t, "text/plain", *reg.MessageAttachment.FileType)
+	assert.Len(t, atts.messages, 1)
+	assert.Empty(t, atts.tickets)
+}
+
+func TestService_CompleteUpload_Refused(t *testing.T) {
+	key := "projects/3/tickets/7/6f1c7a52-2b36-4d8e-9a43-0c1f0f7b8f10"
+	exe := append([]byte("MZ\x90\x00\x03\x00\x00\x00"), make([]byte, 64)...)
+	store := &fakeStore{objects: map[string][]byte{QuarantineKey(key): exe}}
+	s, atts := newTestServiceWithAttachments(store, &fakeRepo{})
+	otherChat := 12
+
+	_, err := s.CompleteUpload(context.Background(), 3, 8, nil, key, "user-1", nil)
+	assert.ErrorIs(t, err, ErrForeignObject)
+	_, err = s.CompleteUpload(context.Background(), 3, 7, nil, "projects/3/tickets/7/../../9/x", "user-1", nil)
+	assert.ErrorIs(t, err, ErrForeignObject)
+	_, err = s.CompleteUpload(context.Background(), 3, 7, &otherChat, key, "user-1", nil)
+	assert.ErrorIs(t, err, ErrChatMismatch)
+	_, err = s.CompleteUpload(context.Background(), 3, 7, nil, key+"-missing", "user-1", nil)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	_, err = s.CompleteUpload(context.Background(), 3, 7, nil, key, "user-1", nil)
+	assert.ErrorIs(t, err, ErrTypeNotAllowed)
+	assert.NotContains(t, store.objects, QuarantineKey(key), "refused upload is removed")
+	assert.Empty(t, atts.tickets)
+}
+
+func TestService_DownloadURL(t *testing.T) {
+	key := "projects/3/tickets/7/6f1c7a52-2b36-4d8e-9a43-0c1f0f7b8f10"
+	store := &fakeStore{objects: map[string][]byte{key: []byte("data")}}
+	repo := &fakeRepo{scans: map[string]string{key: ScanClean, "projects/3/files/missing": ScanClean}}
+	s, _ := newTestServiceWithAttachments(store, repo)
+
+	d, err := s.DownloadURL(context.Background(), 3, key, "report.pdf")
+	require.NoError(t, err)
+	assert.Equal(t, "http://storage/bucket/"+key+"?name=report.pdf", d.URL)
+
+	_, err = s.DownloadURL(context.Background(), 4, key, "")
+	assert.ErrorIs(t, err, ErrForeignObject)
+	_, err = s.DownloadURL(context.Background(), 3, "projects/3/files/missing", "")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestService_DownloadURL_RefusesUncleanFiles(t *testing.T) {
+	pending, infected, unknown := "projects/3/files/pending", "projects/3/files/infected", "projects/3/files/unknown"
+	store := &fakeStore{objects: map[string][]byte{
+		QuarantineKey(pending):  []byte("data"),
+		QuarantineKey(infected): []byte("data"),
+		unknown:                 []byte("data"),
+	}}
+	repo := &fakeRepo{scans: map[string]string{pending: ScanPending, infected: ScanInfected}}
+	s, _ := newTestServiceWithAttachments(store, repo)
+
+	_, err := s.DownloadURL(context.Background(), 3, pending, "")
+	assert.ErrorIs(t, err, ErrNotScanned)
+	_, err = s.DownloadURL(context.Background(), 3, infected, "")
+	assert.ErrorIs(t, err, ErrInfected)
+	_, err = s.DownloadURL(context.Background(), 3, unknown, "")
+	assert.ErrorIs(t, err, ErrNotScanned)
+}
+
+func TestKeyProject(t *testing.T) {
+	assert.Equal(t, 3, KeyProject("projects/3/tickets/7/abc"))
+	assert.Equal(t, 12, KeyProject("projects/12/files/abc"))
+	assert.Zero(t, KeyProject("tickets/7/abc"))
+	assert.Zero(t, KeyProject("projects/x/files/abc"))
+	assert.Zero(t, KeyProject("projects/3"))
+	assert.Zero(t, KeyProject("projects/3/../4/files/abc"))
+}
@@ -0,0 +1,92 @@
+package files
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"innotech/pkg/storage"
+	"io"
+	"strings"
+)
+
+// Object describes a verified stored file.
+type Object struct {
+	Key         string
+	Size        int64
+	Checksum    string
+	ContentType string
+}
+
+// Verifier checks that attachment records point at real stored files.
+type Verifier struct {
+	store   storage.Backend
+	maxSize int64
+	allowed map[string]bool
+}
+
+// NewVerifier creates a Verifier. Files larger than maxSize bytes or of a
+// type outside allowed are refused.
+func NewVerifier(store storage.Backend, maxSize int64, allowed []string) *Verifier {
+	types := make(map[string]bool, len(allowed))
+	for _, t := range allowed {
+		types[t] = true
+	}
+	return &Verifier{store: store, maxSize: maxSize, allowed: types}
+}
+
+// Verify reads the object named key, which must belong to the ticket, and
+// returns its size, SHA-256 checksum and detected content type.
+func (v *Verifier) Verify(ctx context.Context, ticketID int, key string) (*Object, error) {
+	projectID := KeyProject(key)
+	if projectID == 0 || !strings.HasPrefix(key, ticketPrefix(projectID, ticketID)) {
+		return nil, ErrForeignObject
+	}
+	// New files are still in quarantine; older ones may have been released.
+	src := QuarantineKey(key)
+	info, err := v.store.Stat(ctx, src)
+	if errors.Is(err, storage.ErrNotFound) {
+		src = key
+		info, err = v.store.Stat(ctx, src)
+	}
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if info.Size > v.maxSize {
+		return nil, ErrTooLarge
+	}
+
+	r, err := v.store.Get(ctx, src)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	hash := sha256.New()
+	head := make([]byte, sniffLength)
+	n, err := io.ReadFull(io.TeeReader(r, hash), head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	contentType := DetectContentType(head[:n])
+	if !v.allowed[contentType] {
+		return nil, fmt.Errorf("%w: %s", ErrTypeNotAllowed, contentType)
+	}
+	rest, err := io.Copy(hash, r)
+	if err != nil {
+		return nil, err
+	}
+	return &Object{
+		Key:         key,
+		Size:        int64(n) + rest,
+		Checksum:    hex.EncodeToString(hash.Sum(nil)),
+		ContentType: contentType,
+	}, nil
+}
@@ -0,0 +1,125 @@
+// Package gitlab synchronizes escalated tickets with GitLab issues.
+package gitlab
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const requestTimeout = 10 * time.Second
+
+// Issue state events accepted by the GitLab issues API.
+const (
+	StateEventClose  = "close"
+	StateEventReopen = "reopen"
+)
+
+// Issue is the subset of a GitLab issue the integration uses.
+type Issue struct {
+	ID     int    `json:"id"`
+	IID    int    `json:"iid"`
+	State  string `json:"state"`
+	WebURL string `json:"web_url"`
+}
+
+// Note is the subset of a GitLab issue comment the integration uses.
+type Note struct {
+	ID   int64  `json:"id"`
+	Body string `json:"body"`
+}
+
+// APIError is returned when GitLab answers with a non-2xx status.
+type APIError struct {
+	Status  int
+	Message string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("gitlab: %d %s", e.Status, e.Message)
+}
+
+// Client talks to the GitLab REST API v4 with a private or project access token.
+type Client struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+// NewClient creates a Client for the GitLab instance at baseURL.
+func NewClient(baseURL, token string) *Client {
+	return &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
+		http:    &http.Client{Timeout: requestTimeout},
+	}
+}
+
+// CreateIssue opens an issue in the GitLab project.
+func (c *Client) CreateIssue(ctx context.Context, projectID int, title, description string) (*Issue, error) {
+	var issue Issue
+	body := map[string]string{"title": title, "description": description}
+	if err := c.do(ctx, http.MethodPost, issuesPath(projectID), body, &issue); err != nil {
+		return nil, err
+	}
+	return &issue, nil
+}
+
+// SetIssueState closes or reopens an issue. GitLab ignores a state event that
+// matches the current state.
+func (c *Client) SetIssueState(ctx context.Context, projectID, iid int, stateEvent string) error {
+	path := issuesPath(projectID) + "/" + strconv.Itoa(iid)
+	return c.do(ctx, http.MethodPut, path, map[string]string{"state_event": stateEvent}, nil)
+}
+
+// CreateNote adds a comment to an issue.
+func (c *Client) CreateNote(ctx context.Context, projectID, iid int, body string) (*Note, error) {
+	var note Note
+	path := issuesPath(projectID) + "/" + strconv.Itoa(iid) + "/notes"
+	if err := c.do(ctx, http.MethodPost, path, map[string]string{"body": body}, &note); err != nil {
+		return nil, err
+	}
+	return &note, nil
+}
+
+func issuesPath(projectID int) string {
+	return "/projects/" + url.PathEscape(strconv.Itoa(projectID)) + "/issues"
+}
+
+func (c *Client) do(ctx context.Context, method, path string, in, out any) error {
+	data, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+"/api/v4"+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("PRIVATE-TOKEN", c.token)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("gitlab: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+		return &APIError{Status: resp.StatusCode, Message: strings.TrimSpace(string(msg))}
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("gitlab: decode response: %w", err)
+	}
+	return nil
+}
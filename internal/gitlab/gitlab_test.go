@@ -0,0 +1,376 @@
+package gitlab
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"innotech/internal/storage/postgres"
+	"innotech/internal/tickets"
+	"innotech/pkg/logger"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	logger.Init()
+	os.Exit(m.Run())
+}
+
+// fakeGitLab is a minimal in-memory GitLab issues API.
+type fakeGitLab struct {
+	*httptest.Server
+
+	mu     sync.Mutex
+	token  string
+	issues map[int]*Issue
+	notes  map[int][]string
+	nextID int
+}
+
+func newFakeGitLab(t *testing.T) *fakeGitLab {
+	f := &fakeGitLab{issues: map[int]*Issue{}, notes: map[int][]string{}, nextID: 1}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeGitLab) serve(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.token = r.Header.Get("PRIVATE-TOKEN")
+
+	var body map[string]string
+	_ = json.NewDecoder(r.Body).Decode(&body)
+
+	// /api/v4/projects/{project}/issues[/{iid}[/notes]]
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/v4/"), "/")
+	switch {
+	case r.Method == http.MethodPost && len(parts) == 3:
+		iid := f.nextID
+		f.nextID++
+		f.issues[iid] = &Issue{
+			ID:     1000 + iid,
+			IID:    iid,
+			State:  IssueOpened,
+			WebURL: fmt.Sprintf("%s/group/repo/-/issues/%d", f.URL, iid),
+		}
+		f.notes[iid] = append(f.notes[iid], "title:"+body["title"])
+		writeJSON(w, http.StatusCreated, f.issues[iid])
+	case r.Method == http.MethodPut && len(parts) == 4:
+		iid, _ := strconv.Atoi(parts[3])
+		issue, ok := f.issues[iid]
+		if !ok {
+			writeJSON(w, http.StatusNotFound, map[string]string{"message": "404 Issue Not Found"})
+			return
+		}
+		if body["state_event"] == StateEventClose {
+			issue.State = IssueClosed
+		} else {
+			issue.State = IssueOpened
+		}
+		writeJSON(w, http.StatusOK, issue)
+	case r.Method == http.MethodPost && len(parts) == 5 && parts[4] == "notes":
+		iid, _ := strconv.Atoi(parts[3])
+		f.notes[iid] = append(f.notes[iid], body["body"])
+		writeJSON(w, http.StatusCreated, Note{ID: int64(500 + len(f.notes[iid])), Body: body["body"]})
+	default:
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "404 Not Found"})
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func (f *fakeGitLab) issue(iid int) Issue {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return *f.issues[iid]
+}
+
+type mockRepo struct{ mock.Mock }
+
+func (m *mockRepo) GetTicket(ctx context.Context, id int) (*postgres.Ticket, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*postgres.Ticket), args.Error(1)
+}
+
+func (m *mockRepo) GitlabProjectID(ctx context.Context, projectID int) (*int, error) {
+	args := m.Called(ctx, projectID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*int), args.Error(1)
+}
+
+func (m *mockRepo) LinkIssue(ctx context.Context, ticketID, iid int, url string) (bool, error) {
+	args := m.Called(ctx, ticketID, iid, url)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *mockRepo) TicketByIssue(ctx context.Context, gitlabProjectID, iid int) (*postgres.Ticket, error) {
+	args := m.Called(ctx, gitlabProjectID, iid)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*postgres.Ticket), args.Error(1)
+}
+
+func (m *mockRepo) SetNoteID(ctx context.Context, chatID int, noteID int64) error {
+	return m.Called(ctx, chatID, noteID).Error(0)
+}
+
+func (m *mockRepo) NoteOfChat(ctx context.Context, chatID int) (*int64, error) {
+	args := m.Called(ctx, chatID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*int64), args.Error(1)
+}
+
+func (m *mockRepo) NoteExists(ctx context.Context, noteID int64) (bool, error) {
+	args := m.Called(ctx, noteID)
+	return args.Bool(0), args.Error(1)
+}
+
+func ptr[T any](v T) *T { return &v }
+
+func TestSyncer_Escalate_CreatesIssueAndLinksTicket(t *testing.T) {
+	gl := newFakeGitLab(t)
+	repo := new(mockRepo)
+	s := NewSyncer(repo, NewClient(gl.URL+"/", "secret-token"))
+	ctx := context.Background()
+
+	repo.On("GetTicket", ctx, 7).Return(&postgres.Ticket{ID: 7, ProjectID: 2, Title: "Login fails", Status: tickets.StatusOpen}, nil).Once()
+	repo.On("GitlabProjectID", ctx, 2).Return(ptr(42), nil).Once()
+	repo.On("LinkIssue", ctx, 7, 1, gl.URL+"/group/repo/-/issues/1").Return(true, nil).Once()
+
+	link, err := s.Escalate(ctx, 7)
+
+	require.NoError(t, err)
+	assert.Equal(t, &Link{TicketID: 7, IssueIID: 1, IssueURL: gl.URL + "/group/repo/-/issues/1"}, link)
+	assert.Equal(t, "secret-token", gl.token)
+	assert.Equal(t, []string{"title:[#7] Login fails"}, gl.notes[1])
+	repo.AssertExpectations(t)
+}
+
+func TestSyncer_Escalate_RejectsLinkedOrUnmappedTickets(t *testing.T) {
+	gl := newFakeGitLab(t)
+	repo := new(mockRepo)
+	s := NewSyncer(repo, NewClient(gl.URL, "tok"))
+	ctx := context.Background()
+
+	repo.On("GetTicket", ctx, 1).Return(&postgres.Ticket{ID: 1, GitlabIssueIID: ptr(3)}, nil).Once()
+	_, err := s.Escalate(ctx, 1)
+	assert.ErrorIs(t, err, ErrAlreadyEscalated)
+
+	repo.On("GetTicket", ctx, 2).Return(&postgres.Ticket{ID: 2, ProjectID: 5}, nil).Once()
+	repo.On("GitlabProjectID", ctx, 5).Return(nil, nil).Once()
+	_, err = s.Escalate(ctx, 2)
+	assert.ErrorIs(t, err, ErrProjectNotLinked)
+
+	_, err = NewSyncer(repo, nil).Escalate(ctx, 3)
+	assert.ErrorIs(t, err, ErrDisabled)
+	assert.Empty(t, gl.issues)
+}
+
+func TestSyncer_TicketChanged_ClosesAndReopensIssue(t *testing.T) {
+	gl := newFakeGitLab(t)
+	repo := new(mockRepo)
+	s := NewSyncer(repo, NewClient(gl.URL, "tok"))
+	ctx := context.Background()
+
+	_, err := s.client.CreateIssue(ctx, 42, "t", "d")
+	require.NoError(t, err)
+	linked := &postgres.Ticket{ID: 7, ProjectID: 2, GitlabIssueIID: ptr(1)}
+	repo.On("GetTicket", ctx, 7).Return(linked, nil)
+	repo.On("GitlabProjectID", ctx, 2).Return(ptr(42), nil)
+
+	open := &postgres.Ticket{ID: 7, Status: tickets.StatusInProgress}
+	resolved := &postgres.Ticket{ID: 7, Status: tickets.StatusResolved}
+	closed := &postgres.Ticket{ID: 7, Status: tickets.StatusClosed}
+
+	// New tickets have no issue to sync.
+	require.NoError(t, s.TicketChanged(ctx, nil, open))
+	repo.AssertNotCalled(t, "GetTicket", ctx, 7)
+
+	require.NoError(t, s.TicketChanged(ctx, open, resolved))
+	assert.Equal(t, IssueClosed, gl.issue(1).State)
+
+	// Moving between two finished statuses leaves the issue alone.
+	require.NoError(t, s.TicketChanged(ctx, resolved, closed))
+	repo.AssertNumberOfCalls(t, "GetTicket", 1)
+
+	require.NoError(t, s.TicketChanged(ctx, closed, open))
+	assert.Equal(t, IssueOpened, gl.issue(1).State)
+}
+
+func TestSyncer_MessageCreated_PostsNoteWithMarker(t *testing.T) {
+	gl := newFakeGitLab(t)
+	repo := new(mockRepo)
+	s := NewSyncer(repo, NewClient(gl.URL, "tok"))
+	ctx := context.Background()
+
+	repo.On("NoteOfChat", ctx, 15).Return(nil, nil).Once()
+	repo.On("GetTicket", ctx, 7).Return(&postgres.Ticket{ID: 7, ProjectID: 2, GitlabIssueIID: ptr(4)}, nil).Once()
+	repo.On("GitlabProjectID", ctx, 2).Return(ptr(42), nil).Once()
+	repo.On("SetNoteID", ctx, 15, int64(501)).Return(nil).Once()
+
+	require.NoError(t, s.MessageCreated(ctx, &postgres.TicketChat{ID: 15, TicketID: 7, SenderRole: "client", Message: "still broken"}))
+	// Imported messages are not sent back.
+	require.NoError(t, s.MessageCreated(ctx, &postgres.TicketChat{ID: 16, TicketID: 7, Message: "echo", GitlabNoteID: ptr(int64(9))}))
+	// A redelivered message that was copied already is not posted twice.
+	repo.On("NoteOfChat", ctx, 15).Return(ptr(int64(501)), nil).Once()
+	require.NoError(t, s.MessageCreated(ctx, &postgres.TicketChat{ID: 15, TicketID: 7, SenderRole: "client", Message: "still broken"}))
+
+	require.Len(t, gl.notes[4], 1)
+	assert.Equal(t, "**client:** still broken\n\n<!-- feedbacklab:chat:15 -->", gl.notes[4][0])
+	repo.AssertExpectations(t)
+}
+
+type mockTickets struct{ mock.Mock }
+
+func (m *mockTickets) ApplyStatus(ctx context.Context, id int, status, resolution string) (*postgres.Ticket, error) {
+	args := m.Called(ctx, id, status, resolution)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*postgres.Ticket), args.Error(1)
+}
+
+type mockChats struct{ mock.Mock }
+
+func (m *mockChats) Create(ctx context.Context, chat *postgres.TicketChat) error {
+	return m.Called(ctx, chat).Error(0)
+}
+
+func issueEvent(action string) *IssueEvent {
+	e := &IssueEvent{Project: ProjectRef{ID: 42}}
+	e.ObjectAttributes.IID = 4
+	e.ObjectAttributes.Action = action
+	e.ObjectAttributes.URL = "https://gitlab.example/-/issues/4"
+	return e
+}
+
+func TestReceiver_HandleIssue_SyncsStatus(t *testing.T) {
+	repo := new(mockRepo)
+	tk := new(mockTickets)
+	r := NewReceiver(repo, tk, new(mockChats), "sender")
+	ctx := context.Background()
+
+	repo.On("TicketByIssue", ctx, 42, 4).Return(&postgres.Ticket{ID: 7, Status: tickets.StatusInProgress}, nil).Once()
+	tk.On("ApplyStatus", ctx, 7, tickets.StatusResolved, "Closed in GitLab: https://gitlab.example/-/issues/4").Return(&postgres.Ticket{}, nil).Once()
+	require.NoError(t, r.HandleIssue(ctx, issueEvent(ActionClose)))
+
+	repo.On("TicketByIssue", ctx, 42, 4).Return(&postgres.Ticket{ID: 7, Status: tickets.StatusClosed}, nil).Once()
+	tk.On("ApplyStatus", ctx, 7, tickets.StatusOpen, "").Return(&postgres.Ticket{}, nil).Once()
+	require.NoError(t, r.HandleIssue(ctx, issueEvent(ActionReopen)))
+
+	tk.AssertExpectations(t)
+}
+
+func TestReceiver_HandleIssue_IgnoresEchoesAndUnknownIssues(t *testing.T) {
+	repo := new(mockRepo)
+	tk := new(mockTickets)
+	r := NewReceiver(repo, tk, new(mockChats), "sender")
+	ctx := context.Background()
+
+	repo.On("TicketByIssue", ctx, 42, 4).Return(&postgres.Ticket{ID: 7, Status: tickets.StatusResolved}, nil).Once()
+	require.NoError(t, r.HandleIssue(ctx, issueEvent(ActionClose)))
+
+	repo.On("TicketByIssue", ctx, 42, 4).Return(nil, nil).Once()
+	require.NoError(t, r.HandleIssue(ctx, issueEvent(ActionReopen)))
+
+	require.NoError(t, r.HandleIssue(ctx, issueEvent("update")))
+	tk.AssertNotCalled(t, "ApplyStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func noteEvent(id int64, body string) *NoteEvent {
+	e := &NoteEvent{Project: ProjectRef{ID: 42}, Issue: &struct {
+		IID int `json:"iid"`
+	}{IID: 4}}
+	e.User.Name = "Jane Dev"
+	e.ObjectAttributes.ID = id
+	e.ObjectAttributes.Note = body
+	e.ObjectAttributes.NoteableType = "Issue"
+	return e
+}
+
+func TestReceiver_HandleNote_ImportsComment(t *testing.T) {
+	repo := new(mockRepo)
+	chats := new(mockChats)
+	r := NewReceiver(repo, new(mockTickets), chats, "00000000-0000-0000-0000-000000000000")
+	ctx := context.Background()
+
+	repo.On("NoteExists", ctx, int64(90)).Return(false, nil).Once()
+	repo.On("TicketByIssue", ctx, 42, 4).Return(&postgres.Ticket{ID: 7}, nil).Once()
+	chats.On("Create", ctx, mock.MatchedBy(func(c *postgres.TicketChat) bool {
+		return c.TicketID == 7 && c.SenderRole == "admin" && c.Message == "Jane Dev (GitLab): Deployed a fix" &&
+			c.SenderID == "00000000-0000-0000-0000-000000000000" && *c.GitlabNoteID == 90
+	})).Return(nil).Once()
+
+	require.NoError(t, r.HandleNote(ctx, noteEvent(90, "Deployed a fix")))
+	chats.AssertExpectations(t)
+}
+
+func TestReceiver_HandleNote_SkipsOwnAndKnownNotes(t *testing.T) {
+	repo := new(mockRepo)
+	chats := new(mockChats)
+	r := NewReceiver(repo, new(mockTickets), chats, "sender")
+	ctx := context.Background()
+
+	require.NoError(t, r.HandleNote(ctx, noteEvent(91, "**client:** hi\n\n<!-- feedbacklab:chat:15 -->")))
+
+	repo.On("NoteExists", ctx, int64(92)).Return(true, nil).Once()
+	require.NoError(t, r.HandleNote(ctx, noteEvent(92, "redelivered")))
+
+	system := noteEvent(93, "changed the description")
+	system.ObjectAttributes.System = true
+	require.NoError(t, r.HandleNote(ctx, system))
+
+	chats.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestHandler_Webhook_ChecksTokenAndDispatches(t *testing.T) {
+	repo := new(mockRepo)
+	tk := new(mockTickets)
+	h := NewHandler(NewSyncer(repo, nil), NewReceiver(repo, tk, new(mockChats), "sender"), "hook-secret")
+	app := fiber.New()
+	app.Post("/webhooks/gitlab", h.Webhook)
+
+	send := func(token, event, body string) int {
+		req := httptest.NewRequest(http.MethodPost, "/webhooks/gitlab", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Gitlab-Token", token)
+		req.Header.Set("X-Gitlab-Event", event)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, send("wrong", "Issue Hook", `{}`))
+
+	repo.On("TicketByIssue", mock.Anything, 42, 4).Return(&postgres.Ticket{ID: 7, Status: tickets.StatusOpen}, nil).Once()
+	tk.On("ApplyStatus", mock.Anything, 7, tickets.StatusResolved, mock.Anything).
+		Return(nil, &tickets.TransitionError{Code: tickets.CodeInvalidTransition}).Once()
+	payload := `{"project":{"id":42},"object_attributes":{"iid":4,"action":"close"}}`
+	assert.Equal(t, http.StatusOK, send("hook-secret", "Issue Hook", payload))
+
+	assert.Equal(t, http.StatusOK, send("hook-secret", "Push Hook", `{}`))
+	tk.AssertExpectations(t)
+}
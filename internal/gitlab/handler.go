@@ -0,0 +1,107 @@
+package gitlab
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"innotech/internal/tickets"
+	"innotech/pkg/logger"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Handler handles HTTP requests of the GitLab integration.
+type Handler struct {
+	syncer   *Syncer
+	receiver *Receiver
+	secret   string
+}
+
+// NewHandler creates a new Handler instance. Webhook deliveries must carry
+// secret in the X-Gitlab-Token header; an empty secret rejects them all.
+func NewHandler(syncer *Syncer, receiver *Receiver, secret string) *Handler {
+	return &Handler{syncer: syncer, receiver: receiver, secret: secret}
+}
+
+// Escalate godoc
+// @Summary эскалировать тикет в GitLab
+// @Tags GitLab
+// @Produce json
+// @Param id path int true "Ticket ID"
+// @Success 201 {object} gitlab.Link
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 502 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /tickets/{id}/escalate [post]
+func (h *Handler) Escalate(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+
+	link, err := h.syncer.Escalate(c.UserContext(), id)
+	if err != nil {
+		var apiErr *APIError
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "not found"})
+		case errors.Is(err, ErrAlreadyEscalated), errors.Is(err, ErrProjectNotLinked):
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		case errors.Is(err, ErrDisabled):
+			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"error": err.Error()})
+		case errors.As(err, &apiErr):
+			return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(fiber.StatusCreated).JSON(link)
+}
+
+// Webhook godoc
+// @Summary принять вебхук GitLab (Issue Hook, Note Hook)
+// @Tags GitLab
+// @Accept json
+// @Produce json
+// @Param X-Gitlab-Token header string true "Webhook secret"
+// @Param X-Gitlab-Event header string true "Event name"
+// @Success 200 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /webhooks/gitlab [post]
+func (h *Handler) Webhook(c *fiber.Ctx) error {
+	token := c.Get("X-Gitlab-Token")
+	if h.secret == "" || subtle.ConstantTimeCompare([]byte(token), []byte(h.secret)) != 1 {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "invalid token"})
+	}
+
+	var err error
+	switch event := c.Get("X-Gitlab-Event"); event {
+	case "Issue Hook":
+		var e IssueEvent
+		if err := c.BodyParser(&e); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid payload"})
+		}
+		err = h.receiver.HandleIssue(c.UserContext(), &e)
+	case "Note Hook":
+		var e NoteEvent
+		if err := c.BodyParser(&e); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid payload"})
+		}
+		err = h.receiver.HandleNote(c.UserContext(), &e)
+	default:
+		return c.JSON(fiber.Map{"status": "ignored"})
+	}
+
+	// A status change the workflow does not allow will not succeed on retry,
+	// so it is acknowledged instead of making GitLab redeliver it.
+	var te *tickets.TransitionError
+	if errors.As(err, &te) {
+		logger.Warn("gitlab event rejected by ticket workflow", "error", err)
+		return c.JSON(fiber.Map{"status": "ignored"})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"status": "ok"})
+}
@@ -0,0 +1,134 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"innotech/internal/access"
+	"innotech/internal/storage/postgres"
+	"innotech/internal/tickets"
+	"strings"
+)
+
+// Webhook actions of issue events that change the issue state.
+const (
+	ActionClose  = "close"
+	ActionReopen = "reopen"
+)
+
+// IssueEvent is the payload of a GitLab "Issue Hook" webhook.
+type IssueEvent struct {
+	Project          ProjectRef `json:"project"`
+	ObjectAttributes struct {
+		IID    int    `json:"iid"`
+		Action string `json:"action"`
+		URL    string `json:"url"`
+	} `json:"object_attributes"`
+}
+
+// NoteEvent is the payload of a GitLab "Note Hook" webhook.
+type NoteEvent struct {
+	Project ProjectRef `json:"project"`
+	User    struct {
+		Name     string `json:"name"`
+		Username string `json:"username"`
+	} `json:"user"`
+	ObjectAttributes struct {
+		ID           int64  `json:"id"`
+		Note         string `json:"note"`
+		NoteableType string `json:"noteable_type"`
+		System       bool   `json:"system"`
+	} `json:"object_attributes"`
+	Issue *struct {
+		IID int `json:"iid"`
+	} `json:"issue"`
+}
+
+// ProjectRef identifies the GitLab project of a webhook event.
+type ProjectRef struct {
+	ID int `json:"id"`
+}
+
+// StatusApplier changes ticket statuses on behalf of the integration.
+type StatusApplier interface {
+	ApplyStatus(ctx context.Context, id int, status, resolution string) (*postgres.Ticket, error)
+}
+
+// ChatWriter posts messages to ticket chats.
+type ChatWriter interface {
+	Create(ctx context.Context, chat *postgres.TicketChat) error
+}
+
+// Receiver applies GitLab webhook events to the linked tickets.
+type Receiver struct {
+	repo     Repository
+	tickets  StatusApplier
+	chats    ChatWriter
+	senderID string
+}
+
+// NewReceiver creates a Receiver. Comments imported from GitLab are posted
+// under senderID.
+func NewReceiver(repo Repository, tickets StatusApplier, chats ChatWriter, senderID string) *Receiver {
+	return &Receiver{repo: repo, tickets: tickets, chats: chats, senderID: senderID}
+}
+
+// HandleIssue resolves the ticket when its issue is closed and reopens it when
+// the issue is reopened. Events that match the ticket state are ignored, which
+// also absorbs the echoes of changes made by Syncer.
+func (r *Receiver) HandleIssue(ctx context.Context, e *IssueEvent) error {
+	var want string
+	switch e.ObjectAttributes.Action {
+	case ActionClose:
+		want = IssueClosed
+	case ActionReopen:
+		want = IssueOpened
+	default:
+		return nil
+	}
+
+	t, err := r.repo.TicketByIssue(ctx, e.Project.ID, e.ObjectAttributes.IID)
+	if err != nil || t == nil || issueState(t.Status) == want {
+		return err
+	}
+
+	if want == IssueClosed {
+		_, err = r.tickets.ApplyStatus(ctx, t.ID, tickets.StatusResolved, "Closed in GitLab: "+e.ObjectAttributes.URL)
+	} else {
+		_, err = r.tickets.ApplyStatus(ctx, t.ID, tickets.StatusOpen, "")
+	}
+	return err
+}
+
+// HandleNote imports an issue comment into the ticket chat. System notes and
+// comments posted by Syncer are skipped.
+func (r *Receiver) HandleNote(ctx context.Context, e *NoteEvent) error {
+	note := e.ObjectAttributes
+	if note.NoteableType != "Issue" || e.Issue == nil || note.System {
+		return nil
+	}
+	if strings.Contains(note.Note, chatMarkerPrefix) {
+		return nil
+	}
+	exists, err := r.repo.NoteExists(ctx, note.ID)
+	if err != nil || exists {
+		return err
+	}
+
+	t, err := r.repo.TicketByIssue(ctx, e.Project.ID, e.Issue.IID)
+	if err != nil || t == nil {
+		return err
+	}
+
+	author := e.User.Name
+	if author == "" {
+		author = e.User.Username
+	}
+	return r.chats.Create(ctx, &postgres.TicketChat{
+		TicketID:     t.ID,
+		SenderID:     r.senderID,
+		SenderRole:   access.SenderAdmin,
+		Message:      fmt.Sprintf("%s (GitLab): %s", author, note.Note),
+		MessageType:  "text",
+		GitlabNoteID: &note.ID,
+	})
+}
@@ -0,0 +1,119 @@
+package gitlab
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"innotech/internal/storage/postgres"
+	"innotech/pkg/db"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Repository defines the data access operations of the GitLab integration.
+type Repository interface {
+	GetTicket(ctx context.Context, id int) (*postgres.Ticket, error)
+	GitlabProjectID(ctx context.Context, projectID int) (*int, error)
+	LinkIssue(ctx context.Context, ticketID, iid int, url string) (bool, error)
+	TicketByIssue(ctx context.Context, gitlabProjectID, iid int) (*postgres.Ticket, error)
+	SetNoteID(ctx context.Context, chatID int, noteID int64) error
+	NoteOfChat(ctx context.Context, chatID int) (*int64, error)
+	NoteExists(ctx context.Context, noteID int64) (bool, error)
+}
+
+// issueTicketColumns lists the ticket columns the integration reads.
+const issueTicketColumns = `t.id, t.project_id, t.title, t.message, t.status, t.gitlab_issue_url, t.gitlab_issue_iid`
+
+type repository struct {
+	db *sqlx.DB
+}
+
+// NewRepository creates a new Repository instance.
+func NewRepository(db *sqlx.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) GetTicket(ctx context.Context, id int) (*postgres.Ticket, error) {
+	var t postgres.Ticket
+	err := db.Conn(ctx, r.db).GetContext(ctx, &t,
+		`SELECT `+issueTicketColumns+` FROM tickets t WHERE t.id = $1`,
+		id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *repository) GitlabProjectID(ctx context.Context, projectID int) (*int, error) {
+	var id *int
+	err := db.Conn(ctx, r.db).GetContext(ctx, &id,
+		`SELECT gitlab_project_id FROM projects WHERE id = $1`,
+		projectID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return id, nil
+}
+
+// LinkIssue stores the issue on the ticket unless another issue was linked
+// first; it reports whether the link was stored.
+func (r *repository) LinkIssue(ctx context.Context, ticketID, iid int, url string) (bool, error) {
+	res, err := db.Conn(ctx, r.db).ExecContext(ctx,
+		`UPDATE tickets SET gitlab_issue_iid = $2, gitlab_issue_url = $3 WHERE id = $1 AND gitlab_issue_iid IS NULL`,
+		ticketID, iid, url,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// TicketByIssue returns the ticket linked to the issue, or nil if there is none.
+func (r *repository) TicketByIssue(ctx context.Context, gitlabProjectID, iid int) (*postgres.Ticket, error) {
+	var t postgres.Ticket
+	err := db.Conn(ctx, r.db).GetContext(ctx, &t,
+		`SELECT `+issueTicketColumns+` FROM tickets t
+		JOIN projects p ON p.id = t.project_id
+		WHERE p.gitlab_project_id = $1 AND t.gitlab_issue_iid = $2`,
+		gitlabProjectID, iid,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *repository) SetNoteID(ctx context.Context, chatID int, noteID int64) error {
+	_, err := db.Conn(ctx, r.db).ExecContext(ctx,
+		`UPDATE ticket_chats SET gitlab_note_id = $2 WHERE id = $1`,
+		chatID, noteID,
+	)
+	return err
+}
+
+func (r *repository) NoteOfChat(ctx context.Context, chatID int) (*int64, error) {
+	var id *int64
+	err := db.Conn(ctx, r.db).GetContext(ctx, &id,
+		`SELECT gitlab_note_id FROM ticket_chats WHERE id = $1`,
+		chatID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return id, nil
+}
+
+func (r *repository) NoteExists(ctx context.Context, noteID int64) (bool, error) {
+	var exists bool
+	err := db.Conn(ctx, r.db).GetContext(ctx, &exists,
+		`SELECT EXISTS (SELECT 1 FROM ticket_chats WHERE gitlab_note_id = $1)`,
+		noteID,
+	)
+	return exists, err
+}
@@ -0,0 +1,17 @@
+package gitlab
+
+import (
+	"innotech/internal/access"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RegisterRoutes registers HTTP routes of the GitLab integration. The webhook
+// lives outside /api because GitLab authenticates with a shared secret rather
+// than a Keycloak token.
+func RegisterRoutes(app *fiber.App, h *Handler, guard *access.Guard) {
+	app.Post("/webhooks/gitlab", h.Webhook)
+
+	api := app.Group("/api/tickets")
+	api.Post("/:id/escalate", guard.Require(access.TicketUpdate, guard.Param("id", access.ResourceTicket)), h.Escalate)
+}
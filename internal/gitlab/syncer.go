@@ -0,0 +1,167 @@
+package gitlab
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"innotech/internal/storage/postgres"
+	"innotech/internal/tickets"
+	"innotech/pkg/logger"
+)
+
+var (
+	// ErrDisabled is returned when no GitLab instance is configured.
+	ErrDisabled = errors.New("gitlab integration is not configured")
+	// ErrProjectNotLinked is returned when the ticket's project has no GitLab project.
+	ErrProjectNotLinked = errors.New("project is not linked to a gitlab project")
+	// ErrAlreadyEscalated is returned when the ticket already has an issue.
+	ErrAlreadyEscalated = errors.New("ticket is already escalated")
+)
+
+// Issue states as reported by GitLab.
+const (
+	IssueOpened = "opened"
+	IssueClosed = "closed"
+)
+
+// chatMarker tags notes posted by the integration so that their webhook
+// deliveries are not imported back into the chat.
+const (
+	chatMarkerPrefix = "<!-- feedbacklab:chat:"
+	chatMarker       = chatMarkerPrefix + "%d -->"
+)
+
+// Link describes the GitLab issue a ticket was escalated to.
+type Link struct {
+	TicketID int    `json:"ticket_id"`
+	IssueIID int    `json:"gitlab_issue_iid"`
+	IssueURL string `json:"gitlab_issue_url"`
+}
+
+// Syncer pushes ticket changes to GitLab. It implements tickets.Mirror and
+// ticketchats.Mirror; a nil client disables it.
+type Syncer struct {
+	repo   Repository
+	client *Client
+}
+
+// NewSyncer creates a Syncer. Pass a nil client when GitLab is not configured.
+func NewSyncer(repo Repository, client *Client) *Syncer {
+	return &Syncer{repo: repo, client: client}
+}
+
+// Escalate opens an issue for the ticket in its project's GitLab project and
+// links it to the ticket.
+func (s *Syncer) Escalate(ctx context.Context, ticketID int) (*Link, error) {
+	if s.client == nil {
+		return nil, ErrDisabled
+	}
+	t, err := s.repo.GetTicket(ctx, ticketID)
+	if err != nil {
+		return nil, err
+	}
+	if t.GitlabIssueIID != nil {
+		return nil, ErrAlreadyEscalated
+	}
+	glProject, err := s.repo.GitlabProjectID(ctx, t.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	if glProject == nil {
+		return nil, ErrProjectNotLinked
+	}
+
+	title := fmt.Sprintf("[#%d] %s", t.ID, t.Title)
+	description := fmt.Sprintf("%s\n\n---\nFeedbackLab ticket #%d", t.Message, t.ID)
+	issue, err := s.client.CreateIssue(ctx, *glProject, title, description)
+	if err != nil {
+		return nil, err
+	}
+
+	linked, err := s.repo.LinkIssue(ctx, t.ID, issue.IID, issue.WebURL)
+	if err != nil {
+		return nil, err
+	}
+	if !linked {
+		logger.Warn("ticket escalated concurrently, issue left unlinked", "ticket_id", t.ID, "issue_url", issue.WebURL)
+		return nil, ErrAlreadyEscalated
+	}
+
+	if issueState(t.Status) == IssueClosed {
+		if err := s.client.SetIssueState(ctx, *glProject, issue.IID, StateEventClose); err != nil {
+			logger.Warn("failed to close escalated issue", "ticket_id", t.ID, "error", err)
+		}
+	}
+	return &Link{TicketID: t.ID, IssueIID: issue.IID, IssueURL: issue.WebURL}, nil
+}
+
+// TicketChanged closes or reopens the linked issue when the ticket moves
+// between open and finished statuses. New tickets have no issue yet. Setting
+// the state an issue already has changes nothing, so retries are safe.
+func (s *Syncer) TicketChanged(ctx context.Context, before, after *postgres.Ticket) error {
+	if s.client == nil || before == nil || issueState(before.Status) == issueState(after.Status) {
+		return nil
+	}
+	t, glProject, err := s.linked(ctx, after.ID)
+	if err != nil || t == nil {
+		return err
+	}
+
+	event := StateEventReopen
+	if issueState(after.Status) == IssueClosed {
+		event = StateEventClose
+	}
+	return s.client.SetIssueState(ctx, glProject, *t.GitlabIssueIID, event)
+}
+
+// MessageCreated copies a chat message to the linked issue as a comment.
+// Messages that came from GitLab or were already copied are skipped.
+func (s *Syncer) MessageCreated(ctx context.Context, chat *postgres.TicketChat) error {
+	if s.client == nil || chat.GitlabNoteID != nil {
+		return nil
+	}
+	noteID, err := s.repo.NoteOfChat(ctx, chat.ID)
+	if errors.Is(err, sql.ErrNoRows) || noteID != nil {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	t, glProject, err := s.linked(ctx, chat.TicketID)
+	if err != nil || t == nil {
+		return err
+	}
+
+	body := fmt.Sprintf("**%s:** %s\n\n"+chatMarker, chat.SenderRole, chat.Message, chat.ID)
+	note, err := s.client.CreateNote(ctx, glProject, *t.GitlabIssueIID, body)
+	if err != nil {
+		return err
+	}
+	return s.repo.SetNoteID(ctx, chat.ID, note.ID)
+}
+
+// linked loads the ticket and its GitLab project. The ticket is nil when it
+// is gone, has no issue or its project is not linked to GitLab.
+func (s *Syncer) linked(ctx context.Context, ticketID int) (*postgres.Ticket, int, error) {
+	t, err := s.repo.GetTicket(ctx, ticketID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, 0, nil
+	}
+	if err != nil || t.GitlabIssueIID == nil {
+		return nil, 0, err
+	}
+	glProject, err := s.repo.GitlabProjectID(ctx, t.ProjectID)
+	if err != nil || glProject == nil {
+		return nil, 0, err
+	}
+	return t, *glProject, nil
+}
+
+// issueState maps a ticket status to the state its issue should be in.
+func issueState(status string) string {
+	if status == tickets.StatusResolved || status == tickets.StatusClosed {
+		return IssueClosed
+	}
+	return IssueOpened
+}
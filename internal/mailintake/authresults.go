@@ -0,0 +1,91 @@
+package mailintake
+
+import (
+	"strings"
+)
+
+// SenderVerified reports whether the receiving MTA vouches for the From
+// address of msg: one of its Authentication-Results headers (RFC 8601)
+// stamped with authservID records a DMARC pass for the From domain, or a
+// DKIM or SPF pass for a domain aligned with it. The MTA must strip headers
+// claiming its authserv-id from incoming mail. Nothing is verified while
+// authservID is empty.
+func SenderVerified(msg *Message, authservID string) bool {
+	if authservID == "" || msg.From == nil {
+		return false
+	}
+	_, fromDomain, ok := strings.Cut(strings.ToLower(msg.From.Address), "@")
+	if !ok || fromDomain == "" {
+		return false
+	}
+	for _, header := range msg.AuthResults {
+		parts := strings.Split(stripComments(header), ";")
+		if id := strings.Fields(parts[0]); len(id) == 0 || !strings.EqualFold(id[0], authservID) {
+			continue
+		}
+		for _, res := range parts[1:] {
+			if passes(res, fromDomain) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// passes reports whether one result of an Authentication-Results header is
+// a pass that covers fromDomain.
+func passes(res, fromDomain string) bool {
+	fields := strings.Fields(strings.ToLower(res))
+	if len(fields) == 0 {
+		return false
+	}
+	method, result, _ := strings.Cut(fields[0], "=")
+	if result != "pass" {
+		return false
+	}
+	props := map[string]string{}
+	for _, f := range fields[1:] {
+		if k, v, ok := strings.Cut(f, "="); ok {
+			props[k] = strings.Trim(v, `"`)
+		}
+	}
+	switch method {
+	case "dmarc":
+		return props["header.from"] == fromDomain
+	case "dkim":
+		return aligned(fromDomain, props["header.d"])
+	case "spf":
+		mailFrom := props["smtp.mailfrom"]
+		if _, domain, ok := strings.Cut(mailFrom, "@"); ok {
+			mailFrom = domain
+		}
+		return aligned(fromDomain, mailFrom)
+	}
+	return false
+}
+
+// aligned reports whether fromDomain is domain or one of its subdomains.
+// Bare top-level domains never align.
+func aligned(fromDomain, domain string) bool {
+	if !strings.Contains(domain, ".") {
+		return false
+	}
+	return fromDomain == domain || strings.HasSuffix(fromDomain, "."+domain)
+}
+
+// stripComments removes the parenthesized comments of a header value.
+func stripComments(s string) string {
+	var b strings.Builder
+	depth := 0
+	for _, r := range s {
+		switch {
+		case r == '(':
+			depth++
+		case r == ')' && depth > 0:
+			depth--
+		case depth == 0:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
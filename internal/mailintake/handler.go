@@ -0,0 +1,111 @@
+package mailintake
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"innotech/internal/storage/postgres"
+	"innotech/internal/storage/transport"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Handler handles inbound email deliveries and mailbox management.
+type Handler struct {
+	service   Service
+	processor *Processor
+	token     string
+}
+
+// NewHandler creates a new Handler instance. Deliveries must carry token in
+// the X-Mail-Token header; an empty token rejects them all.
+func NewHandler(service Service, processor *Processor, token string) *Handler {
+	return &Handler{service: service, processor: processor, token: token}
+}
+
+// Receive godoc
+// @Summary принять входящее письмо (RFC 5322) от почтового сервера
+// @Description создаёт заявку или добавляет ответ в чат заявки по токену в теме или заголовках
+// @Tags Mail
+// @Accept message/rfc822
+// @Produce json
+// @Param X-Mail-Token header string true "Shared secret"
+// @Success 200 {object} mailintake.Result
+// @Failure 401 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Router /webhooks/mail [post]
+func (h *Handler) Receive(c *fiber.Ctx) error {
+	if h.token == "" || subtle.ConstantTimeCompare([]byte(c.Get("X-Mail-Token")), []byte(h.token)) != 1 {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "invalid token"})
+	}
+
+	res, err := h.processor.Process(c.UserContext(), bytes.NewReader(c.Body()))
+	if errors.Is(err, ErrRejected) {
+		// A 4xx tells the MTA to bounce rather than retry.
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(res)
+}
+
+// ListMailboxes godoc
+// @Summary получить почтовые ящики для приёма заявок
+// @Tags Mail
+// @Produce json
+// @Success 200 {array} postgres.Mailbox
+// @Router /mailboxes [get]
+func (h *Handler) ListMailboxes(c *fiber.Ctx) error {
+	list, err := h.service.ListMailboxes(c.UserContext())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(list)
+}
+
+// CreateMailbox godoc
+// @Summary привязать почтовый адрес к договору проекта
+// @Tags Mail
+// @Accept json
+// @Produce json
+// @Param mailbox body transport.CreateMailboxDTO true "Mailbox"
+// @Success 201 {object} postgres.Mailbox
+// @Failure 400 {object} map[string]string
+// @Router /mailboxes [post]
+func (h *Handler) CreateMailbox(c *fiber.Ctx) error {
+	dto := c.Locals("body").(*transport.CreateMailboxDTO)
+	m := postgres.Mailbox{Address: dto.Address, ProjectID: dto.ProjectID, ContractID: dto.ContractID}
+	err := h.service.CreateMailbox(c.UserContext(), &m)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "contract does not belong to the project"})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(fiber.StatusCreated).JSON(m)
+}
+
+// DeleteMailbox godoc
+// @Summary удалить почтовый ящик
+// @Tags Mail
+// @Param id path int true "ID"
+// @Success 204
+// @Failure 404 {object} map[string]string
+// @Router /mailboxes/{id} [delete]
+func (h *Handler) DeleteMailbox(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+	err = h.service.DeleteMailbox(c.UserContext(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "not found"})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,104 @@
+package mailintake
+
+import (
+	"context"
+	"errors"
+	"innotech/pkg/logger"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const maildirPollInterval = 15 * time.Second
+
+// Maildir feeds the messages delivered to a maildir into a Processor. Mail
+// fetched from IMAP by fetchmail, getmail or mbsync lands in such a
+// directory. Processed and rejected messages are moved to cur/ with the Seen
+// and Trashed flags respectively; messages that failed for a transient
+// reason stay in new/ and are retried on the next poll.
+type Maildir struct {
+	dir       string
+	processor *Processor
+}
+
+// NewMaildir creates a Maildir reading dir, which holds new/ and cur/.
+func NewMaildir(dir string, processor *Processor) *Maildir {
+	return &Maildir{dir: dir, processor: processor}
+}
+
+// Run processes new messages until ctx is cancelled.
+func (m *Maildir) Run(ctx context.Context) {
+	ticker := time.NewTicker(maildirPollInterval)
+	defer ticker.Stop()
+	for {
+		if _, err := m.ProcessNew(ctx); err != nil {
+			logger.Error("maildir scan failed", "dir", m.dir, "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessNew processes the messages in new/ in delivery order and returns
+// how many were handled for good.
+func (m *Maildir) ProcessNew(ctx context.Context) (int, error) {
+	entries, err := os.ReadDir(filepath.Join(m.dir, "new"))
+	if err != nil {
+		return 0, err
+	}
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if e.Type().IsRegular() && !strings.HasPrefix(e.Name(), ".") {
+			names = append(names, e.Name())
+		}
+	}
+	// Maildir names start with the delivery timestamp.
+	sort.Strings(names)
+
+	done := 0
+	for _, name := range names {
+		if ctx.Err() != nil {
+			return done, ctx.Err()
+		}
+		flags, err := m.processFile(ctx, name)
+		if err != nil {
+			logger.Warn("inbound email failed, will retry", "file", name, "error", err)
+			continue
+		}
+		if err := m.moveToCur(name, flags); err != nil {
+			return done, err
+		}
+		done++
+	}
+	return done, nil
+}
+
+// processFile returns the maildir flags the message is filed with.
+func (m *Maildir) processFile(ctx context.Context, name string) (string, error) {
+	f, err := os.Open(filepath.Join(m.dir, "new", name))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	res, err := m.processor.Process(ctx, f)
+	if errors.Is(err, ErrRejected) {
+		logger.Warn("inbound email rejected", "file", name, "error", err)
+		return "ST", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	logger.Info("inbound email processed", "file", name, "ticket_id", ticketRef(res.TicketID), "duplicate", res.Duplicate)
+	return "S", nil
+}
+
+func (m *Maildir) moveToCur(name, flags string) error {
+	base, _, _ := strings.Cut(name, ":")
+	return os.Rename(filepath.Join(m.dir, "new", name), filepath.Join(m.dir, "cur", base+":2,"+flags))
+}
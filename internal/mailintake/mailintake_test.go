@@ -0,0 +1,481 @@
+package mailintake
+
+import (
+	"context"
+	"errors"
+	"innotech/internal/access"
+	"innotech/internal/files"
+	"innotech/internal/storage/postgres"
+	"innotech/internal/tickets"
+	"innotech/pkg/auth"
+	"innotech/pkg/db"
+	"innotech/pkg/logger"
+	"io"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	logger.Init()
+	os.Exit(m.Run())
+}
+
+type mockRepo struct{ mock.Mock }
+
+func (m *mockRepo) CreateMailbox(ctx context.Context, mb *postgres.Mailbox) error {
+	return m.Called(ctx, mb).Error(0)
+}
+
+func (m *mockRepo) ListMailboxes(ctx context.Context) ([]postgres.Mailbox, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]postgres.Mailbox), args.Error(1)
+}
+
+func (m *mockRepo) DeleteMailbox(ctx context.Context, id int) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *mockRepo) MailboxFor(ctx context.Context, addresses []string) (*postgres.Mailbox, error) {
+	args := m.Called(ctx, addresses)
+	mb, _ := args.Get(0).(*postgres.Mailbox)
+	return mb, args.Error(1)
+}
+
+func (m *mockRepo) UserByEmail(ctx context.Context, email string) (string, error) {
+	args := m.Called(ctx, email)
+	return args.String(0), args.Error(1)
+}
+
+func (m *mockRepo) TicketProject(ctx context.Context, ticketID int) (int, error) {
+	args := m.Called(ctx, ticketID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *mockRepo) Claim(ctx context.Context, e *postgres.InboundEmail) (bool, error) {
+	args := m.Called(ctx, e)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *mockRepo) SetOutcome(ctx context.Context, e *postgres.InboundEmail) error {
+	return m.Called(ctx, e).Error(0)
+}
+
+func (m *mockRepo) GetInbound(ctx context.Context, messageID string) (*postgres.InboundEmail, error) {
+	args := m.Called(ctx, messageID)
+	e, _ := args.Get(0).(*postgres.InboundEmail)
+	return e, args.Error(1)
+}
+
+type fakeTickets struct {
+	created []*postgres.Ticket
+	actor   string
+	err     error
+}
+
+func (f *fakeTickets) Create(ctx context.Context, t *postgres.Ticket) error {
+	if f.err != nil {
+		return f.err
+	}
+	t.ID = 100 + len(f.created)
+	f.created = append(f.created, t)
+	f.actor = auth.SubjectFromContext(ctx)
+	return nil
+}
+
+type fakeChats struct{ created []*postgres.TicketChat }
+
+func (f *fakeChats) Create(_ context.Context, c *postgres.TicketChat) error {
+	c.ID = 500 + len(f.created)
+	f.created = append(f.created, c)
+	return nil
+}
+
+type fakeAttachments struct{ created []*postgres.TicketAttachment }
+
+func (f *fakeAttachments) Create(_ context.Context, a *postgres.TicketAttachment) error {
+	f.created = append(f.created, a)
+	return nil
+}
+
+type memStore struct {
+	objects map[string][]byte
+	types   map[string]string
+}
+
+func (s *memStore) Put(_ context.Context, name string, r io.Reader, _ int64, contentType string) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	s.objects[name] = data
+	s.types[name] = contentType
+	return nil
+}
+
+func (s *memStore) Delete(_ context.Context, name string) error {
+	delete(s.objects, name)
+	return nil
+}
+
+type fixture struct {
+	repo        *mockRepo
+	tickets     *fakeTickets
+	chats       *fakeChats
+	attachments *fakeAttachments
+	store       *memStore
+	tokens      *Tokens
+	processor   *Processor
+}
+
+func newFixture() *fixture {
+	f := &fixture{
+		repo:        new(mockRepo),
+		tickets:     &fakeTickets{},
+		chats:       &fakeChats{},
+		attachments: &fakeAttachments{},
+		store:       &memStore{objects: map[string][]byte{}, types: map[string]string{}},
+		tokens:      NewTokens("secret"),
+	}
+	f.processor = NewProcessor(f.repo, f.tickets, f.chats, f.attachments, f.store, f.tokens, "mx.acme.example", "mail-bot", db.NopTransactor{})
+	return f
+}
+
+const newTicketMail = "Authentication-Results: mx.acme.example; dkim=pass header.d=client.example; spf=pass smtp.mailfrom=ivan@client.example\r\n" +
+	"From: =?utf-8?q?=D0=98=D0=B2=D0=B0=D0=BD?= <Ivan@Client.example>\r\n" +
+	"To: Support <support@acme.example>\r\n" +
+	"Delivered-To: helpdesk+acme@acme.example\r\n" +
+	"Subject: =?utf-8?b?0J3QtSDRgNCw0LHQvtGC0LDQtdGCINCy0YXQvtC0?=\r\n" +
+	"Message-ID: <abc123@client.example>\r\n" +
+	"MIME-Version: 1.0\r\n" +
+	"Content-Type: multipart/mixed; boundary=\"outer\"\r\n" +
+	"\r\n" +
+	"--outer\r\n" +
+	"Content-Type: multipart/alternative; boundary=\"inner\"\r\n" +
+	"\r\n" +
+	"--inner\r\n" +
+	"Content-Type: text/plain; charset=utf-8\r\n" +
+	"Content-Transfer-Encoding: quoted-printable\r\n" +
+	"\r\n" +
+	"Login fails with =E2=80=9Cinvalid token=E2=80=9D.\r\n" +
+	"--inner\r\n" +
+	"Content-Type: text/html; charset=utf-8\r\n" +
+	"\r\n" +
+	"<p>Login fails</p>\r\n" +
+	"--inner--\r\n" +
+	"--outer\r\n" +
+	"Content-Type: text/plain; name=\"log.txt\"\r\n" +
+	"Content-Disposition: attachment; filename=\"../log.txt\"\r\n" +
+	"Content-Transfer-Encoding: base64\r\n" +
+	"\r\n" +
+	"ZXJyb3I6IGludmFs\r\n" +
+	"aWQgdG9rZW4=\r\n" +
+	"--outer--\r\n"
+
+func TestParse_MultipartMessage(t *testing.T) {
+	msg, err := Parse(strings.NewReader(newTicketMail))
+
+	require.NoError(t, err)
+	assert.Equal(t, "abc123@client.example", msg.ID)
+	assert.Equal(t, "Ivan@Client.example", msg.From.Address)
+	assert.Equal(t, "Иван", msg.From.Name)
+	assert.Equal(t, "Не работает вход", msg.Subject)
+	assert.Equal(t, []string{"helpdesk+acme@acme.example", "support@acme.example"}, msg.Recipients)
+	assert.Equal(t, "Login fails with “invalid token”.", msg.Text)
+	require.Len(t, msg.Attachments, 1)
+	assert.Equal(t, "../log.txt", msg.Attachments[0].Filename)
+	assert.Equal(t, "error: invalid token", string(msg.Attachments[0].Data))
+}
+
+func TestParse_HTMLOnlyLegacyCharset(t *testing.T) {
+	raw := "From: client@example.com\r\n" +
+		"To: support@acme.example\r\n" +
+		"Subject: Hello\r\n" +
+		"Content-Type: text/html; charset=koi8-r\r\n" +
+		"\r\n" +
+		"<html><head><style>p{}</style></head><body><p>\xf0\xd2\xc9\xd7\xc5\xd4</p><p>a &amp; b</p></body></html>\r\n"
+
+	msg, err := Parse(strings.NewReader(raw))
+
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(msg.ID, "sha256:"))
+	assert.Equal(t, "Привет\na & b", msg.Text)
+}
+
+func TestParse_RejectsMessageWithoutSender(t *testing.T) {
+	_, err := Parse(strings.NewReader("To: support@acme.example\r\nSubject: x\r\n\r\nbody"))
+	assert.ErrorIs(t, err, ErrRejected)
+}
+
+func TestStripQuoted(t *testing.T) {
+	text := "Thanks, it works now.\n\nOn Mon, 5 May 2025 at 10:00, Support <support@acme.example> wrote:\n> Please try again.\n"
+	assert.Equal(t, "Thanks, it works now.", StripQuoted(text))
+	assert.Equal(t, "Спасибо", StripQuoted("Спасибо\n> цитата"))
+}
+
+func TestTokens_FindVerifiesSignature(t *testing.T) {
+	tokens := NewTokens("secret")
+	token := tokens.Token(42)
+
+	id, ok := tokens.Find("Re: Ticket #42 [" + token + "]")
+	assert.True(t, ok)
+	assert.Equal(t, 42, id)
+
+	forged := strings.Replace(token, "FL-42-", "FL-43-", 1)
+	_, ok = tokens.Find(forged, "FL-43-000000000000")
+	assert.False(t, ok)
+	_, ok = NewTokens("other").Find(token)
+	assert.False(t, ok)
+
+	var disabled *Tokens
+	assert.Empty(t, disabled.Token(42))
+	_, ok = disabled.Find(token)
+	assert.False(t, ok)
+}
+
+func TestProcessor_Process_OpensTicketFromMailbox(t *testing.T) {
+	f := newFixture()
+	f.repo.On("Claim", mock.Anything, mock.MatchedBy(func(e *postgres.InboundEmail) bool {
+		return e.MessageID == "abc123@client.example" && e.Sender == "ivan@client.example"
+	})).Return(true, nil).Once()
+	f.repo.On("UserByEmail", mock.Anything, "Ivan@Client.example").Return("user-1", nil).Once()
+	f.repo.On("MailboxFor", mock.Anything, []string{"helpdesk+acme@acme.example", "support@acme.example"}).
+		Return(&postgres.Mailbox{ID: 3, ProjectID: 7, ContractID: 9}, nil).Once()
+	f.repo.On("SetOutcome", mock.Anything, mock.MatchedBy(func(e *postgres.InboundEmail) bool {
+		return *e.MailboxID == 3 && *e.TicketID == 100 && e.ChatID == nil
+	})).Return(nil).Once()
+
+	res, err := f.processor.Process(context.Background(), strings.NewReader(newTicketMail))
+
+	require.NoError(t, err)
+	assert.Equal(t, 100, *res.TicketID)
+	require.Len(t, f.tickets.created, 1)
+	ticket := f.tickets.created[0]
+	assert.Equal(t, 7, ticket.ProjectID)
+	assert.Equal(t, 9, ticket.ContractID)
+	assert.Equal(t, "user-1", ticket.CreatedBy)
+	assert.Equal(t, "Не работает вход", ticket.Title)
+	assert.Equal(t, "user-1", f.tickets.actor)
+
+	require.Len(t, f.attachments.created, 1)
+	att := f.attachments.created[0]
+	assert.Equal(t, 100, att.TicketID)
+	assert.Equal(t, "log.txt", *att.Description)
+	assert.True(t, strings.HasPrefix(att.FilePath, "projects/7/tickets/100/"))
+	assert.True(t, strings.HasSuffix(att.FilePath, "-log.txt"))
+	assert.Equal(t, "error: invalid token", string(f.store.objects[files.QuarantineKey(att.FilePath)]))
+	f.repo.AssertExpectations(t)
+}
+
+func TestProcessor_Process_AppendsTokenReplyToChat(t *testing.T) {
+	f := newFixture()
+	raw := "From: Stranger <stranger@example.com>\r\n" +
+		"To: support@acme.example\r\n" +
+		"Subject: Re: New message [" + f.tokens.Token(42) + "]\r\n" +
+		"Message-ID: <reply@example.com>\r\n" +
+		"\r\n" +
+		"Still broken.\r\n\r\nOn Tue, Support wrote:\r\n> Fixed?\r\n"
+
+	f.repo.On("Claim", mock.Anything, mock.Anything).Return(true, nil).Once()
+	f.repo.On("TicketProject", mock.Anything, 42).Return(7, nil).Once()
+	f.repo.On("SetOutcome", mock.Anything, mock.MatchedBy(func(e *postgres.InboundEmail) bool {
+		return *e.TicketID == 42 && *e.ChatID == 500
+	})).Return(nil).Once()
+
+	res, err := f.processor.Process(context.Background(), strings.NewReader(raw))
+
+	require.NoError(t, err)
+	assert.Equal(t, 500, *res.ChatID)
+	assert.Empty(t, f.tickets.created)
+	require.Len(t, f.chats.created, 1)
+	chat := f.chats.created[0]
+	assert.Equal(t, 42, chat.TicketID)
+	assert.Equal(t, "mail-bot", chat.SenderID)
+	assert.Equal(t, access.SenderClient, chat.SenderRole)
+	assert.Equal(t, `"Stranger" <stranger@example.com> (email): Still broken.`, chat.Message)
+	f.repo.AssertExpectations(t)
+}
+
+func TestProcessor_Process_ReplyMatchedByReferences(t *testing.T) {
+	f := newFixture()
+	raw := "Authentication-Results: mx.acme.example (spam filter); dmarc=pass (p=reject) header.from=acme.example\r\n" +
+		"From: dev@acme.example\r\n" +
+		"To: support@acme.example\r\n" +
+		"Subject: Re: something\r\n" +
+		"In-Reply-To: <" + f.tokens.Token(42) + ".9f8e@feedbacklab.local>\r\n" +
+		"\r\n" +
+		"On it.\r\n"
+
+	f.repo.On("Claim", mock.Anything, mock.Anything).Return(true, nil).Once()
+	f.repo.On("UserByEmail", mock.Anything, "dev@acme.example").Return("dev-1", nil).Once()
+	f.repo.On("TicketProject", mock.Anything, 42).Return(7, nil).Once()
+	f.repo.On("SetOutcome", mock.Anything, mock.Anything).Return(nil).Once()
+
+	_, err := f.processor.Process(context.Background(), strings.NewReader(raw))
+
+	require.NoError(t, err)
+	require.Len(t, f.chats.created, 1)
+	assert.Equal(t, "dev-1", f.chats.created[0].SenderID)
+	assert.Equal(t, access.SenderClient, f.chats.created[0].SenderRole, "email never speaks for support")
+	assert.Equal(t, "On it.", f.chats.created[0].Message)
+}
+
+func TestProcessor_Process_IgnoresUnverifiedSender(t *testing.T) {
+	f := newFixture()
+	raw := "Authentication-Results: mx.evil.example; dkim=pass header.d=acme.example\r\n" +
+		"Authentication-Results: mx.acme.example; dkim=fail header.d=acme.example; spf=pass smtp.mailfrom=evil.example\r\n" +
+		"From: dev@acme.example\r\n" +
+		"To: support@acme.example\r\n" +
+		"Subject: Re: [" + f.tokens.Token(42) + "]\r\n" +
+		"\r\n" +
+		"Closing this.\r\n"
+
+	f.repo.On("Claim", mock.Anything, mock.Anything).Return(true, nil).Once()
+	f.repo.On("TicketProject", mock.Anything, 42).Return(7, nil).Once()
+	f.repo.On("SetOutcome", mock.Anything, mock.Anything).Return(nil).Once()
+
+	_, err := f.processor.Process(context.Background(), strings.NewReader(raw))
+
+	require.NoError(t, err)
+	f.repo.AssertNotCalled(t, "UserByEmail", mock.Anything, mock.Anything)
+	require.Len(t, f.chats.created, 1)
+	assert.Equal(t, "mail-bot", f.chats.created[0].SenderID)
+	assert.Equal(t, access.SenderClient, f.chats.created[0].SenderRole)
+	assert.Equal(t, "<dev@acme.example> (email): Closing this.", f.chats.created[0].Message)
+}
+
+func TestProcessor_Process_StoresSniffedAttachmentType(t *testing.T) {
+	f := newFixture()
+	raw := "From: client@example.com\r\n" +
+		"To: support@acme.example\r\n" +
+		"Subject: Re: [" + f.tokens.Token(42) + "]\r\n" +
+		"Content-Type: multipart/mixed; boundary=\"b\"\r\n" +
+		"\r\n" +
+		"--b\r\n" +
+		"Content-Type: text/plain\r\n" +
+		"\r\n" +
+		"See attached.\r\n" +
+		"--b\r\n" +
+		"Content-Type: image/png\r\n" +
+		"Content-Disposition: attachment; filename=\"shot.png\"\r\n" +
+		"\r\n" +
+		"<html><script>alert(1)</script></html>\r\n" +
+		"--b--\r\n"
+
+	f.repo.On("Claim", mock.Anything, mock.Anything).Return(true, nil).Once()
+	f.repo.On("TicketProject", mock.Anything, 42).Return(7, nil).Once()
+	f.repo.On("SetOutcome", mock.Anything, mock.Anything).Return(nil).Once()
+
+	_, err := f.processor.Process(context.Background(), strings.NewReader(raw))
+
+	require.NoError(t, err)
+	require.Len(t, f.attachments.created, 1)
+	att := f.attachments.created[0]
+	assert.Equal(t, "text/html", *att.FileType)
+	assert.Equal(t, "text/html", f.store.types[files.QuarantineKey(att.FilePath)], "the declared image/png is ignored")
+}
+
+func TestSenderVerified(t *testing.T) {
+	msg := func(from string, results ...string) *Message {
+		return &Message{From: &mail.Address{Address: from}, AuthResults: results}
+	}
+	tests := []struct {
+		name string
+		msg  *Message
+		want bool
+	}{
+		{"dkim pass", msg("a@acme.example", "mx.acme.example; dkim=pass header.d=acme.example"), true},
+		{"dkim of parent domain", msg("a@mail.acme.example", "MX.acme.example 1; dkim=pass header.d=acme.example"), true},
+		{"spf pass", msg("a@acme.example", "mx.acme.example; spf=pass smtp.mailfrom=bounce@acme.example"), true},
+		{"dmarc pass", msg("a@acme.example", "mx.acme.example; dkim=none; dmarc=pass header.from=acme.example"), true},
+		{"dkim of other domain", msg("a@acme.example", "mx.acme.example; dkim=pass header.d=evil.example"), false},
+		{"dkim of lookalike domain", msg("a@notacme.example", "mx.acme.example; dkim=pass header.d=acme.example"), false},
+		{"dkim of top-level domain", msg("a@acme.example", "mx.acme.example; dkim=pass header.d=example"), false},
+		{"failed checks", msg("a@acme.example", "mx.acme.example; dkim=fail header.d=acme.example; spf=softfail smtp.mailfrom=acme.example"), false},
+		{"other authserv-id", msg("a@acme.example", "mx.evil.example; dkim=pass header.d=acme.example"), false},
+		{"pass inside a comment", msg("a@acme.example", "mx.acme.example; dkim=fail (dkim=pass header.d=acme.example)"), false},
+		{"no results", msg("a@acme.example"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, SenderVerified(tt.msg, "mx.acme.example"))
+		})
+	}
+	assert.False(t, SenderVerified(msg("a@acme.example", "; dkim=pass header.d=acme.example"), ""), "nothing is verified without an authserv-id")
+}
+
+func TestProcessor_Process_SkipsDuplicates(t *testing.T) {
+	f := newFixture()
+	ticketID := 100
+	f.repo.On("Claim", mock.Anything, mock.Anything).Return(false, nil).Once()
+	f.repo.On("GetInbound", mock.Anything, "abc123@client.example").
+		Return(&postgres.InboundEmail{MessageID: "abc123@client.example", TicketID: &ticketID}, nil).Once()
+
+	res, err := f.processor.Process(context.Background(), strings.NewReader(newTicketMail))
+
+	require.NoError(t, err)
+	assert.True(t, res.Duplicate)
+	assert.Equal(t, 100, *res.TicketID)
+	assert.Empty(t, f.tickets.created)
+	assert.Empty(t, f.store.objects)
+}
+
+func TestProcessor_Process_RejectsUnknownRecipient(t *testing.T) {
+	f := newFixture()
+	f.repo.On("Claim", mock.Anything, mock.Anything).Return(true, nil).Once()
+	f.repo.On("UserByEmail", mock.Anything, mock.Anything).Return("", nil).Once()
+	f.repo.On("MailboxFor", mock.Anything, mock.Anything).Return(nil, nil).Once()
+
+	_, err := f.processor.Process(context.Background(), strings.NewReader(newTicketMail))
+
+	assert.ErrorIs(t, err, ErrRejected)
+	assert.Empty(t, f.tickets.created)
+}
+
+func TestProcessor_Process_RejectsMailOutsideContract(t *testing.T) {
+	f := newFixture()
+	f.tickets.err = &tickets.ContractError{Code: tickets.CodeContractExpired, ContractID: 9}
+	f.repo.On("Claim", mock.Anything, mock.Anything).Return(true, nil).Once()
+	f.repo.On("UserByEmail", mock.Anything, mock.Anything).Return("user-1", nil).Once()
+	f.repo.On("MailboxFor", mock.Anything, mock.Anything).
+		Return(&postgres.Mailbox{ID: 3, ProjectID: 7, ContractID: 9}, nil).Once()
+
+	_, err := f.processor.Process(context.Background(), strings.NewReader(newTicketMail))
+
+	assert.ErrorIs(t, err, ErrRejected)
+	assert.Empty(t, f.store.objects)
+}
+
+func TestMaildir_ProcessNew_FilesMessages(t *testing.T) {
+	dir := t.TempDir()
+	for _, sub := range []string{"new", "cur", "tmp"} {
+		require.NoError(t, os.Mkdir(filepath.Join(dir, sub), 0o755))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "new", "1.host"), []byte(newTicketMail), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "new", "2.host"), []byte("not a message"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "new", "3.host"), []byte(strings.Replace(newTicketMail, "abc123", "def456", 1)), 0o600))
+
+	f := newFixture()
+	f.repo.On("Claim", mock.Anything, mock.MatchedBy(func(e *postgres.InboundEmail) bool { return e.MessageID == "abc123@client.example" })).
+		Return(true, nil).Once()
+	f.repo.On("Claim", mock.Anything, mock.MatchedBy(func(e *postgres.InboundEmail) bool { return e.MessageID == "def456@client.example" })).
+		Return(false, errors.New("connection reset")).Once()
+	f.repo.On("UserByEmail", mock.Anything, mock.Anything).Return("user-1", nil)
+	f.repo.On("MailboxFor", mock.Anything, mock.Anything).Return(&postgres.Mailbox{ID: 3, ProjectID: 7, ContractID: 9}, nil)
+	f.repo.On("SetOutcome", mock.Anything, mock.Anything).Return(nil)
+
+	n, err := NewMaildir(dir, f.processor).ProcessNew(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	cur, _ := filepath.Glob(filepath.Join(dir, "cur", "*"))
+	assert.ElementsMatch(t, []string{filepath.Join(dir, "cur", "1.host:2,S"), filepath.Join(dir, "cur", "2.host:2,ST")}, cur)
+	// The transient failure stays in new/ for the next poll.
+	assert.FileExists(t, filepath.Join(dir, "new", "3.host"))
+}
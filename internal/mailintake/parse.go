@@ -0,0 +1,287 @@
+package mailintake
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"regexp"
+	"strings"
+
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// Parsing limits.
+const (
+	MaxMessageSize = 32 << 20
+	maxMIMEDepth   = 8
+)
+
+// ErrRejected marks messages that can never be processed, such as malformed
+// ones or ones sent to an unknown address. Retrying them is pointless.
+var ErrRejected = errors.New("message rejected")
+
+// Message is a parsed inbound email.
+type Message struct {
+	// ID is the Message-ID without angle brackets, or a digest of the raw
+	// message when it has none.
+	ID   string
+	From *mail.Address
+	// Recipients are the lower-cased envelope and header recipients, most
+	// specific first.
+	Recipients []string
+	Subject    string
+	// References are the message ids from In-Reply-To and References.
+	References []string
+	// AuthResults are the Authentication-Results headers, topmost first.
+	AuthResults []string
+	Text        string
+	Attachments []Attachment
+}
+
+// Attachment is a file attached to an inbound email.
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+var wordDecoder = &mime.WordDecoder{CharsetReader: charsetReader}
+
+// Parse parses an RFC 5322 message with its MIME parts. The body is the
+// first plain-text part, or the first HTML part converted to text.
+func Parse(r io.Reader) (*Message, error) {
+	raw, err := io.ReadAll(io.LimitReader(r, MaxMessageSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) > MaxMessageSize {
+		return nil, fmt.Errorf("%w: message exceeds %d bytes", ErrRejected, MaxMessageSize)
+	}
+	m, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrRejected, err)
+	}
+
+	from, err := m.Header.AddressList("From")
+	if err != nil || len(from) == 0 {
+		return nil, fmt.Errorf("%w: missing or invalid From header", ErrRejected)
+	}
+	msg := &Message{From: from[0]}
+
+	msg.ID = strings.Trim(strings.TrimSpace(m.Header.Get("Message-Id")), "<>")
+	if msg.ID == "" {
+		sum := sha256.Sum256(raw)
+		msg.ID = "sha256:" + hex.EncodeToString(sum[:])
+	}
+	if subject, err := wordDecoder.DecodeHeader(m.Header.Get("Subject")); err == nil {
+		msg.Subject = strings.TrimSpace(subject)
+	} else {
+		msg.Subject = strings.TrimSpace(m.Header.Get("Subject"))
+	}
+	msg.Recipients = recipients(m.Header)
+	for _, h := range []string{"In-Reply-To", "References"} {
+		for _, id := range strings.Fields(m.Header.Get(h)) {
+			msg.References = append(msg.References, strings.Trim(id, "<>"))
+		}
+	}
+
+	msg.AuthResults = m.Header["Authentication-Results"]
+
+	p := &parser{msg: msg}
+	if err := p.walk(m.Header.Get("Content-Type"), m.Header.Get("Content-Transfer-Encoding"), "", m.Body, 0); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrRejected, err)
+	}
+	msg.Text = p.text
+	if msg.Text == "" && p.html != "" {
+		msg.Text = htmlToText(p.html)
+	}
+	msg.Text = strings.TrimSpace(strings.ReplaceAll(msg.Text, "\r\n", "\n"))
+	return msg, nil
+}
+
+// recipients collects the addresses the message was delivered to. Envelope
+// headers added by the MTA come first since To and Cc may list other people.
+func recipients(h mail.Header) []string {
+	var out []string
+	add := func(addr string) {
+		addr = strings.ToLower(strings.TrimSpace(addr))
+		for _, seen := range out {
+			if seen == addr {
+				return
+			}
+		}
+		if addr != "" {
+			out = append(out, addr)
+		}
+	}
+	for _, name := range []string{"Delivered-To", "X-Original-To"} {
+		for _, v := range h[textproto.CanonicalMIMEHeaderKey(name)] {
+			add(strings.Trim(v, "<> "))
+		}
+	}
+	for _, name := range []string{"To", "Cc"} {
+		list, _ := h.AddressList(name)
+		for _, a := range list {
+			add(a.Address)
+		}
+	}
+	return out
+}
+
+type parser struct {
+	msg  *Message
+	text string
+	html string
+}
+
+func (p *parser) walk(contentType, encoding, disposition string, body io.Reader, depth int) error {
+	if depth > maxMIMEDepth {
+		return errors.New("MIME structure too deep")
+	}
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType, params = "text/plain", map[string]string{}
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := mr.NextRawPart()
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			err = p.walk(part.Header.Get("Content-Type"), part.Header.Get("Content-Transfer-Encoding"),
+				part.Header.Get("Content-Disposition"), part, depth+1)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	data, err := decodeTransfer(encoding, body)
+	if err != nil {
+		return err
+	}
+	kind, dparams, _ := mime.ParseMediaType(disposition)
+	filename := dparams["filename"]
+	if filename == "" {
+		filename = params["name"]
+	}
+	if decoded, err := wordDecoder.DecodeHeader(filename); err == nil {
+		filename = decoded
+	}
+
+	switch {
+	case kind == "attachment" || filename != "" || mediaType == "message/rfc822":
+		if filename == "" {
+			filename = "attachment"
+			if mediaType == "message/rfc822" {
+				filename = "message.eml"
+			}
+		}
+		p.msg.Attachments = append(p.msg.Attachments, Attachment{Filename: filename, ContentType: mediaType, Data: data})
+	case mediaType == "text/plain" && p.text == "":
+		p.text, err = decodeCharset(params["charset"], data)
+	case mediaType == "text/html" && p.html == "":
+		p.html, err = decodeCharset(params["charset"], data)
+	}
+	return err
+}
+
+func decodeTransfer(encoding string, r io.Reader) ([]byte, error) {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		return io.ReadAll(base64.NewDecoder(base64.StdEncoding, &newlineStripper{r: r}))
+	case "quoted-printable":
+		return io.ReadAll(quotedprintable.NewReader(r))
+	default:
+		return io.ReadAll(r)
+	}
+}
+
+// newlineStripper drops the line breaks of base64 bodies.
+type newlineStripper struct {
+	r io.Reader
+}
+
+func (s *newlineStripper) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	out := p[:0]
+	for _, b := range p[:n] {
+		if b != '\r' && b != '\n' && b != ' ' && b != '\t' {
+			out = append(out, b)
+		}
+	}
+	return len(out), err
+}
+
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	enc, err := htmlindex.Get(charset)
+	if err != nil {
+		return nil, err
+	}
+	return enc.NewDecoder().Reader(input), nil
+}
+
+func decodeCharset(charset string, data []byte) (string, error) {
+	switch strings.ToLower(charset) {
+	case "", "utf-8", "utf8", "us-ascii":
+		return string(data), nil
+	}
+	enc, err := htmlindex.Get(charset)
+	if err != nil {
+		// An unknown charset should not lose the message.
+		return string(data), nil
+	}
+	out, err := enc.NewDecoder().Bytes(data)
+	return string(out), err
+}
+
+var (
+	htmlDropped = regexp.MustCompile(`(?is)<(script|style|head)[^>]*>.*?</(script|style|head)>`)
+	htmlBreaks  = regexp.MustCompile(`(?i)<(br|/p|/div|/li|/tr|/h[1-6])[^>]*>`)
+	htmlTags    = regexp.MustCompile(`<[^>]*>`)
+	blankLines  = regexp.MustCompile(`\n{3,}`)
+)
+
+// htmlToText keeps the text of an HTML body with its paragraph breaks.
+func htmlToText(s string) string {
+	s = htmlDropped.ReplaceAllString(s, "")
+	s = htmlBreaks.ReplaceAllString(s, "\n")
+	s = htmlTags.ReplaceAllString(s, "")
+	s = html.UnescapeString(s)
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimSpace(l)
+	}
+	return blankLines.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+}
+
+var quoteHeader = regexp.MustCompile(`(?m)^(On .+ wrote:|.+ (писал|написал)\(?а?\)?:|-{2,} ?Original Message ?-{2,}|-{2,} ?Исходное сообщение ?-{2,})\s*$`)
+
+// StripQuoted removes the quoted previous message from a reply.
+func StripQuoted(text string) string {
+	if loc := quoteHeader.FindStringIndex(text); loc != nil {
+		text = text[:loc[0]]
+	}
+	var kept []string
+	for _, l := range strings.Split(text, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(l), ">") {
+			kept = append(kept, l)
+		}
+	}
+	return strings.TrimSpace(strings.Join(kept, "\n"))
+}
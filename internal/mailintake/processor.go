@@ -0,0 +1,287 @@
+// Package mailintake turns inbound emails into tickets. A message sent to a
+// project mailbox opens a ticket under the mailbox's contract; a reply that
+// carries a ticket token in its subject or references is appended to the
+// ticket chat instead. Attachments are stored in object storage and linked
+// to the ticket.
+package mailintake
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"innotech/internal/access"
+	"innotech/internal/files"
+	"innotech/internal/storage/postgres"
+	"innotech/internal/tickets"
+	"innotech/pkg/auth"
+	"innotech/pkg/db"
+	"innotech/pkg/logger"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	maxTitleLength = 200
+	noSubject      = "(no subject)"
+)
+
+// TicketCreator opens tickets.
+type TicketCreator interface {
+	Create(ctx context.Context, t *postgres.Ticket) error
+}
+
+// ChatWriter posts messages to ticket chats.
+type ChatWriter interface {
+	Create(ctx context.Context, chat *postgres.TicketChat) error
+}
+
+// AttachmentWriter links stored files to tickets.
+type AttachmentWriter interface {
+	Create(ctx context.Context, att *postgres.TicketAttachment) error
+}
+
+// ObjectStore stores attachment contents.
+type ObjectStore interface {
+	Put(ctx context.Context, objectName string, r io.Reader, size int64, contentType string) error
+	Delete(ctx context.Context, objectName string) error
+}
+
+// Result describes what a processed message turned into.
+type Result struct {
+	TicketID  *int `json:"ticket_id,omitempty"`
+	ChatID    *int `json:"chat_id,omitempty"`
+	Duplicate bool `json:"duplicate,omitempty"`
+}
+
+// Processor ingests inbound emails.
+type Processor struct {
+	repo        Repository
+	tickets     TicketCreator
+	chats       ChatWriter
+	attachments AttachmentWriter
+	store       ObjectStore
+	tokens      *Tokens
+	authservID  string
+	senderID    string
+	tx          db.Transactor
+}
+
+// NewProcessor creates a Processor. Senders are only recognized as users
+// when the MTA stamping Authentication-Results as authservID verified their
+// address; other messages are posted under senderID with the address quoted.
+func NewProcessor(repo Repository, tickets TicketCreator, chats ChatWriter, attachments AttachmentWriter, store ObjectStore, tokens *Tokens, authservID, senderID string, tx db.Transactor) *Processor {
+	return &Processor{
+		repo:        repo,
+		tickets:     tickets,
+		chats:       chats,
+		attachments: attachments,
+		store:       store,
+		tokens:      tokens,
+		authservID:  authservID,
+		senderID:    senderID,
+		tx:          tx,
+	}
+}
+
+// Process parses and ingests one raw message. Redelivered messages are
+// recognized by their Message-ID and reported as duplicates. Errors wrapping
+// ErrRejected are permanent.
+func (p *Processor) Process(ctx context.Context, raw io.Reader) (*Result, error) {
+	msg, err := Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	var res Result
+	err = p.tx.WithinTx(ctx, func(ctx context.Context) error {
+		in := &postgres.InboundEmail{MessageID: msg.ID, Sender: strings.ToLower(msg.From.Address)}
+		fresh, err := p.repo.Claim(ctx, in)
+		if err != nil {
+			return err
+		}
+		if !fresh {
+			prev, err := p.repo.GetInbound(ctx, msg.ID)
+			if err != nil {
+				return err
+			}
+			res = Result{TicketID: prev.TicketID, ChatID: prev.ChatID, Duplicate: true}
+			return nil
+		}
+
+		var userID string
+		if SenderVerified(msg, p.authservID) {
+			if userID, err = p.repo.UserByEmail(ctx, msg.From.Address); err != nil {
+				return err
+			}
+		}
+		if userID != "" {
+			ctx = auth.WithIdentity(ctx, &auth.Identity{Subject: userID, Email: msg.From.Address})
+		}
+
+		if ticketID, ok := p.tokens.Find(append([]string{msg.Subject}, msg.References...)...); ok {
+			projectID, err := p.repo.TicketProject(ctx, ticketID)
+			if err != nil {
+				return err
+			}
+			if projectID != 0 {
+				chat, err := p.reply(ctx, msg, ticketID, projectID, userID)
+				if err != nil {
+					return err
+				}
+				in.TicketID, in.ChatID = &ticketID, &chat.ID
+				res = Result{TicketID: in.TicketID, ChatID: in.ChatID}
+				return p.repo.SetOutcome(ctx, in)
+			}
+		}
+
+		mailbox, err := p.repo.MailboxFor(ctx, msg.Recipients)
+		if err != nil {
+			return err
+		}
+		if mailbox == nil {
+			return fmt.Errorf("%w: no mailbox for %s", ErrRejected, strings.Join(msg.Recipients, ", "))
+		}
+		t, err := p.open(ctx, msg, mailbox, userID)
+		if err != nil {
+			return err
+		}
+		in.MailboxID, in.TicketID = &mailbox.ID, &t.ID
+		res = Result{TicketID: in.TicketID}
+		return p.repo.SetOutcome(ctx, in)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// open creates a ticket from msg under the mailbox's contract. Messages the
+// contract does not cover, such as those sent after it expired, are rejected.
+func (p *Processor) open(ctx context.Context, msg *Message, mailbox *postgres.Mailbox, userID string) (*postgres.Ticket, error) {
+	title := msg.Subject
+	if title == "" {
+		title = noSubject
+	}
+	body := msg.Text
+	createdBy := userID
+	if userID == "" {
+		createdBy = p.senderID
+		body = "From: " + msg.From.String() + "\n\n" + body
+	}
+	t := &postgres.Ticket{
+		ProjectID:  mailbox.ProjectID,
+		ContractID: mailbox.ContractID,
+		CreatedBy:  createdBy,
+		Title:      truncate(title, maxTitleLength),
+		Message:    body,
+	}
+	if err := p.tickets.Create(ctx, t); err != nil {
+		var ce *tickets.ContractError
+		if errors.As(err, &ce) {
+			return nil, fmt.Errorf("%w: %v", ErrRejected, err)
+		}
+		return nil, err
+	}
+	if err := p.attach(ctx, t.ProjectID, t.ID, createdBy, msg.Attachments); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// reply appends msg to the chat of a ticket, without the quoted history.
+// Email replies always count as client messages: even a verified address
+// is weaker proof than a support session, and a support message would
+// count as the first response of the ticket.
+func (p *Processor) reply(ctx context.Context, msg *Message, ticketID, projectID int, userID string) (*postgres.TicketChat, error) {
+	text := StripQuoted(msg.Text)
+	senderID := userID
+	if userID == "" {
+		senderID = p.senderID
+		text = fmt.Sprintf("%s (email): %s", msg.From.String(), text)
+	}
+	if strings.TrimSpace(text) == "" {
+		// Attachments alone still deserve a line in the chat.
+		text = fmt.Sprintf("(%d attachment(s) by email)", len(msg.Attachments))
+	}
+	chat := &postgres.TicketChat{
+		TicketID:    ticketID,
+		SenderID:    senderID,
+		SenderRole:  access.SenderClient,
+		Message:     text,
+		MessageType: "text",
+	}
+	if err := p.chats.Create(ctx, chat); err != nil {
+		return nil, err
+	}
+	if err := p.attach(ctx, projectID, ticketID, senderID, msg.Attachments); err != nil {
+		return nil, err
+	}
+	return chat, nil
+}
+
+// attach stores the attachments under the ticket, in quarantine until they
+// are scanned, and links them to it. The content type the sender declared is
+// ignored in favour of the sniffed one. Attachments refused for their size or
+// type are dropped.
+func (p *Processor) attach(ctx context.Context, projectID, ticketID int, uploadedBy string, attachments []Attachment) error {
+	for i, a := range attachments {
+		filename := sanitizeFilename(a.Filename)
+		name := fmt.Sprintf("projects/%d/tickets/%d/email-%d-%d-%s", projectID, ticketID, time.Now().UnixNano(), i, filename)
+		contentType := files.DetectContentType(a.Data)
+		if err := p.store.Put(ctx, files.QuarantineKey(name), bytes.NewReader(a.Data), int64(len(a.Data)), contentType); err != nil {
+			return err
+		}
+		err := p.attachments.Create(ctx, &postgres.TicketAttachment{
+			TicketID:    ticketID,
+			FilePath:    name,
+			UploadedBy:  uploadedBy,
+			FileType:    &contentType,
+			Description: &filename,
+		})
+		if errors.Is(err, files.ErrTooLarge) || errors.Is(err, files.ErrTypeNotAllowed) {
+			logger.Warn("email attachment refused", "ticket_id", ticketID, "filename", filename, "error", err)
+			if err := p.store.Delete(ctx, files.QuarantineKey(name)); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func sanitizeFilename(name string) string {
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == '/' || r == 0x7f {
+			return '_'
+		}
+		return r
+	}, name)
+	if name == "." || name == "" {
+		return "attachment"
+	}
+	return truncate(name, 120)
+}
+
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
+
+// ticketRef formats a ticket id for log lines.
+func ticketRef(id *int) string {
+	if id == nil {
+		return ""
+	}
+	return strconv.Itoa(*id)
+}
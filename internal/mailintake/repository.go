@@ -0,0 +1,138 @@
+package mailintake
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"innotech/internal/storage/postgres"
+	"innotech/pkg/db"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Repository defines the interface for inbound email data access operations.
+type Repository interface {
+	CreateMailbox(ctx context.Context, m *postgres.Mailbox) error
+	ListMailboxes(ctx context.Context) ([]postgres.Mailbox, error)
+	DeleteMailbox(ctx context.Context, id int) error
+	// MailboxFor returns the mailbox of the first address that has one, or
+	// nil when none does.
+	MailboxFor(ctx context.Context, addresses []string) (*postgres.Mailbox, error)
+	// UserByEmail returns the user known under email, or "" for strangers.
+	UserByEmail(ctx context.Context, email string) (string, error)
+	// TicketProject returns the project of a ticket, or 0 when it is gone.
+	TicketProject(ctx context.Context, ticketID int) (int, error)
+	// Claim records that a message is being processed. It reports false when
+	// the message was processed before.
+	Claim(ctx context.Context, e *postgres.InboundEmail) (bool, error)
+	SetOutcome(ctx context.Context, e *postgres.InboundEmail) error
+	GetInbound(ctx context.Context, messageID string) (*postgres.InboundEmail, error)
+}
+
+type repository struct {
+	db *sqlx.DB
+}
+
+// NewRepository creates a new Repository instance.
+func NewRepository(db *sqlx.DB) Repository {
+	return &repository{db: db}
+}
+
+// CreateMailbox stores m. It returns sql.ErrNoRows when the contract does not
+// belong to the project.
+func (r *repository) CreateMailbox(ctx context.Context, m *postgres.Mailbox) error {
+	query := `
+		INSERT INTO mailboxes (address, project_id, contract_id)
+		SELECT $1, $2, $3
+		WHERE EXISTS (SELECT 1 FROM contracts WHERE id = $3 AND project_id = $2)
+		RETURNING id, address, project_id, contract_id, date_created, date_updated
+	`
+	return db.Conn(ctx, r.db).GetContext(ctx, m, query, strings.ToLower(m.Address), m.ProjectID, m.ContractID)
+}
+
+func (r *repository) ListMailboxes(ctx context.Context) ([]postgres.Mailbox, error) {
+	list := []postgres.Mailbox{}
+	if err := db.Conn(ctx, r.db).SelectContext(ctx, &list, `SELECT * FROM mailboxes ORDER BY address`); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *repository) DeleteMailbox(ctx context.Context, id int) error {
+	res, err := db.Conn(ctx, r.db).ExecContext(ctx, `DELETE FROM mailboxes WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *repository) MailboxFor(ctx context.Context, addresses []string) (*postgres.Mailbox, error) {
+	query := `
+		SELECT * FROM mailboxes
+		WHERE address = ANY($1)
+		ORDER BY array_position($1, address)
+		LIMIT 1
+	`
+	var m postgres.Mailbox
+	err := db.Conn(ctx, r.db).GetContext(ctx, &m, query, postgres.StringArray(addresses))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (r *repository) UserByEmail(ctx context.Context, email string) (string, error) {
+	var userID string
+	err := db.Conn(ctx, r.db).GetContext(ctx, &userID,
+		`SELECT user_id FROM notification_preferences WHERE lower(email) = lower($1) ORDER BY date_updated DESC LIMIT 1`,
+		email,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return userID, err
+}
+
+func (r *repository) TicketProject(ctx context.Context, ticketID int) (int, error) {
+	var projectID int
+	err := db.Conn(ctx, r.db).GetContext(ctx, &projectID, `SELECT project_id FROM tickets WHERE id = $1`, ticketID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	return projectID, err
+}
+
+func (r *repository) Claim(ctx context.Context, e *postgres.InboundEmail) (bool, error) {
+	res, err := db.Conn(ctx, r.db).ExecContext(ctx,
+		`INSERT INTO inbound_emails (message_id, sender) VALUES ($1, $2) ON CONFLICT (message_id) DO NOTHING`,
+		e.MessageID, e.Sender,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+func (r *repository) SetOutcome(ctx context.Context, e *postgres.InboundEmail) error {
+	_, err := db.Conn(ctx, r.db).ExecContext(ctx,
+		`UPDATE inbound_emails SET mailbox_id = $2, ticket_id = $3, chat_id = $4 WHERE message_id = $1`,
+		e.MessageID, e.MailboxID, e.TicketID, e.ChatID,
+	)
+	return err
+}
+
+func (r *repository) GetInbound(ctx context.Context, messageID string) (*postgres.InboundEmail, error) {
+	var e postgres.InboundEmail
+	if err := db.Conn(ctx, r.db).GetContext(ctx, &e, `SELECT * FROM inbound_emails WHERE message_id = $1`, messageID); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
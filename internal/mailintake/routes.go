@@ -0,0 +1,21 @@
+package mailintake
+
+import (
+	"innotech/internal/access"
+	"innotech/internal/storage/transport"
+	"innotech/pkg/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RegisterRoutes registers HTTP routes of the email intake. The delivery
+// endpoint lives outside /api because the MTA authenticates with a shared
+// token rather than a Keycloak token.
+func RegisterRoutes(app *fiber.App, h *Handler, guard *access.Guard) {
+	app.Post("/webhooks/mail", h.Receive)
+
+	api := app.Group("/api/mailboxes")
+	api.Get("/", guard.Superuser(), h.ListMailboxes)
+	api.Post("/", guard.Superuser(), middleware.ValidateBody[transport.CreateMailboxDTO](h.CreateMailbox))
+	api.Delete("/:id", guard.Superuser(), h.DeleteMailbox)
+}
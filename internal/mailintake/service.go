@@ -0,0 +1,34 @@
+package mailintake
+
+import (
+	"context"
+	"innotech/internal/storage/postgres"
+)
+
+// Service defines the interface for mailbox management operations.
+type Service interface {
+	CreateMailbox(ctx context.Context, m *postgres.Mailbox) error
+	ListMailboxes(ctx context.Context) ([]postgres.Mailbox, error)
+	DeleteMailbox(ctx context.Context, id int) error
+}
+
+type service struct {
+	repo Repository
+}
+
+// NewService creates a new Service instance.
+func NewService(repo Repository) Service {
+	return &service{repo: repo}
+}
+
+func (s *service) CreateMailbox(ctx context.Context, m *postgres.Mailbox) error {
+	return s.repo.CreateMailbox(ctx, m)
+}
+
+func (s *service) ListMailboxes(ctx context.Context) ([]postgres.Mailbox, error) {
+	return s.repo.ListMailboxes(ctx)
+}
+
+func (s *service) DeleteMailbox(ctx context.Context, id int) error {
+	return s.repo.DeleteMailbox(ctx, id)
+}
@@ -0,0 +1,60 @@
+package mailintake
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strconv"
+)
+
+// macLength is the number of hex digits of the signature kept in a token.
+const macLength = 12
+
+var tokenPattern = regexp.MustCompile(`FL-(\d+)-([0-9a-f]{12})`)
+
+// Tokens issues and checks the ticket tokens carried by notification emails.
+// A token names the ticket and is signed, so a reply cannot be redirected to
+// another ticket by editing the subject. A nil *Tokens issues none and
+// accepts none.
+type Tokens struct {
+	secret []byte
+}
+
+// NewTokens creates a Tokens signing with secret.
+func NewTokens(secret string) *Tokens {
+	return &Tokens{secret: []byte(secret)}
+}
+
+// Token returns the token of a ticket, such as "FL-42-1a2b3c4d5e6f".
+func (t *Tokens) Token(ticketID int) string {
+	if t == nil {
+		return ""
+	}
+	id := strconv.Itoa(ticketID)
+	return "FL-" + id + "-" + t.mac(id)
+}
+
+// Find returns the ticket of the first valid token in texts.
+func (t *Tokens) Find(texts ...string) (int, bool) {
+	if t == nil {
+		return 0, false
+	}
+	for _, s := range texts {
+		for _, m := range tokenPattern.FindAllStringSubmatch(s, -1) {
+			if !hmac.Equal([]byte(m[2]), []byte(t.mac(m[1]))) {
+				continue
+			}
+			if id, err := strconv.Atoi(m[1]); err == nil {
+				return id, true
+			}
+		}
+	}
+	return 0, false
+}
+
+func (t *Tokens) mac(id string) string {
+	h := hmac.New(sha256.New, t.secret)
+	h.Write([]byte("ticket:" + id))
+	return hex.EncodeToString(h.Sum(nil))[:macLength]
+}
@@ -0,0 +1,123 @@
+// Package mattermost mirrors ticket chats into Mattermost threads.
+package mattermost
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const requestTimeout = 10 * time.Second
+
+// Post is the subset of a Mattermost post the integration uses.
+type Post struct {
+	ID        string         `json:"id,omitempty"`
+	ChannelID string         `json:"channel_id"`
+	RootID    string         `json:"root_id,omitempty"`
+	UserID    string         `json:"user_id,omitempty"`
+	Message   string         `json:"message"`
+	Props     map[string]any `json:"props,omitempty"`
+}
+
+// APIError is returned when Mattermost answers with a non-2xx status.
+type APIError struct {
+	Status  int
+	Message string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("mattermost: %d %s", e.Status, e.Message)
+}
+
+// Client is the part of the Mattermost API v4 the integration needs.
+type Client interface {
+	ChannelID(ctx context.Context, team, channel string) (string, error)
+	CreatePost(ctx context.Context, p *Post) (*Post, error)
+	GetPost(ctx context.Context, id string) (*Post, error)
+	Permalink(team, postID string) string
+}
+
+type client struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+// NewClient creates a Client for the Mattermost server at baseURL that
+// authenticates with a bot or personal access token.
+func NewClient(baseURL, token string) Client {
+	return &client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
+		http:    &http.Client{Timeout: requestTimeout},
+	}
+}
+
+func (c *client) ChannelID(ctx context.Context, team, channel string) (string, error) {
+	var ch struct {
+		ID string `json:"id"`
+	}
+	path := "/teams/name/" + url.PathEscape(team) + "/channels/name/" + url.PathEscape(channel)
+	if err := c.do(ctx, http.MethodGet, path, nil, &ch); err != nil {
+		return "", err
+	}
+	return ch.ID, nil
+}
+
+func (c *client) CreatePost(ctx context.Context, p *Post) (*Post, error) {
+	var out Post
+	if err := c.do(ctx, http.MethodPost, "/posts", p, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (c *client) GetPost(ctx context.Context, id string) (*Post, error) {
+	var out Post
+	if err := c.do(ctx, http.MethodGet, "/posts/"+url.PathEscape(id), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (c *client) Permalink(team, postID string) string {
+	return c.baseURL + "/" + url.PathEscape(team) + "/pl/" + url.PathEscape(postID)
+}
+
+func (c *client) do(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+"/api/v4"+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.token)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("mattermost: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+		return &APIError{Status: resp.StatusCode, Message: strings.TrimSpace(string(msg))}
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("mattermost: decode response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package mattermost
+
+import (
+	"crypto/subtle"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Handler handles the Mattermost outgoing webhook.
+type Handler struct {
+	receiver *Receiver
+	token    string
+}
+
+// NewHandler creates a new Handler instance. Deliveries must carry token; an
+// empty token rejects them all.
+func NewHandler(receiver *Receiver, token string) *Handler {
+	return &Handler{receiver: receiver, token: token}
+}
+
+// Webhook godoc
+// @Summary принять исходящий вебхук Mattermost (ответ в треде тикета)
+// @Tags Mattermost
+// @Accept json
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param payload body mattermost.OutgoingWebhook true "Outgoing webhook"
+// @Success 200 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /webhooks/mattermost [post]
+func (h *Handler) Webhook(c *fiber.Ctx) error {
+	var w OutgoingWebhook
+	if err := c.BodyParser(&w); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid payload"})
+	}
+	if h.token == "" || subtle.ConstantTimeCompare([]byte(w.Token), []byte(h.token)) != 1 {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "invalid token"})
+	}
+
+	if err := h.receiver.HandlePost(c.UserContext(), &w); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	// An empty response keeps Mattermost from posting a reply to the channel.
+	return c.JSON(fiber.Map{})
+}
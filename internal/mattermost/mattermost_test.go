@@ -0,0 +1,284 @@
+package mattermost
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"innotech/internal/storage/postgres"
+	"innotech/pkg/logger"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	logger.Init()
+	os.Exit(m.Run())
+}
+
+// stubServer is a minimal in-memory Mattermost API.
+type stubServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	auth     string
+	channels map[string]string
+	posts    map[string]*Post
+	order    []string
+}
+
+func newStubServer(t *testing.T) *stubServer {
+	s := &stubServer{channels: map[string]string{"acme/support": "ch-1"}, posts: map[string]*Post{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *stubServer) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.auth = r.Header.Get("Authorization")
+
+	path := strings.TrimPrefix(r.URL.Path, "/api/v4")
+	switch {
+	case r.Method == http.MethodGet && strings.HasPrefix(path, "/teams/name/"):
+		// /teams/name/{team}/channels/name/{channel}
+		parts := strings.Split(path, "/")
+		id, ok := s.channels[parts[3]+"/"+parts[6]]
+		if !ok {
+			writeJSON(w, http.StatusNotFound, map[string]string{"message": "channel not found"})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"id": id})
+	case r.Method == http.MethodPost && path == "/posts":
+		var p Post
+		_ = json.NewDecoder(r.Body).Decode(&p)
+		p.ID = fmt.Sprintf("post-%d", len(s.posts)+1)
+		s.posts[p.ID] = &p
+		s.order = append(s.order, p.ID)
+		writeJSON(w, http.StatusCreated, p)
+	case r.Method == http.MethodGet && strings.HasPrefix(path, "/posts/"):
+		p, ok := s.posts[strings.TrimPrefix(path, "/posts/")]
+		if !ok {
+			writeJSON(w, http.StatusNotFound, map[string]string{"message": "post not found"})
+			return
+		}
+		writeJSON(w, http.StatusOK, p)
+	default:
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "not found"})
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// reply simulates a user answering in a thread.
+func (s *stubServer) reply(rootID, message string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := fmt.Sprintf("user-post-%d", len(s.posts)+1)
+	s.posts[id] = &Post{ID: id, ChannelID: "ch-1", RootID: rootID, UserID: "u-jane", Message: message}
+	return id
+}
+
+type mockRepo struct{ mock.Mock }
+
+func (m *mockRepo) ProjectTeam(ctx context.Context, projectID int) (*string, error) {
+	args := m.Called(ctx, projectID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*string), args.Error(1)
+}
+
+func (m *mockRepo) CreateThread(ctx context.Context, th *postgres.MattermostThread, url string) error {
+	return m.Called(ctx, th, url).Error(0)
+}
+
+func (m *mockRepo) ThreadByTicket(ctx context.Context, ticketID int) (*postgres.MattermostThread, error) {
+	args := m.Called(ctx, ticketID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*postgres.MattermostThread), args.Error(1)
+}
+
+func (m *mockRepo) ThreadByRoot(ctx context.Context, rootID string) (*postgres.MattermostThread, error) {
+	args := m.Called(ctx, rootID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*postgres.MattermostThread), args.Error(1)
+}
+
+func (m *mockRepo) SetMessageID(ctx context.Context, chatID int, postID string) error {
+	return m.Called(ctx, chatID, postID).Error(0)
+}
+
+func (m *mockRepo) MessageOfChat(ctx context.Context, chatID int) (*string, error) {
+	args := m.Called(ctx, chatID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*string), args.Error(1)
+}
+
+func (m *mockRepo) MessageExists(ctx context.Context, postID string) (bool, error) {
+	args := m.Called(ctx, postID)
+	return args.Bool(0), args.Error(1)
+}
+
+type mockChats struct{ mock.Mock }
+
+func (m *mockChats) Create(ctx context.Context, chat *postgres.TicketChat) error {
+	return m.Called(ctx, chat).Error(0)
+}
+
+func ptr[T any](v T) *T { return &v }
+
+func TestMirror_TicketCreated_OpensThread(t *testing.T) {
+	srv := newStubServer(t)
+	repo := new(mockRepo)
+	m := NewMirror(repo, NewClient(srv.URL, "bot-token"), "support")
+	ctx := context.Background()
+
+	repo.On("ThreadByTicket", ctx, 7).Return(nil, nil).Once()
+	repo.On("ProjectTeam", ctx, 2).Return(ptr("acme"), nil).Once()
+	repo.On("CreateThread", ctx, &postgres.MattermostThread{TicketID: 7, ChannelID: "ch-1", RootID: "post-1"}, srv.URL+"/acme/pl/post-1").
+		Return(nil).Once()
+
+	ticket := &postgres.Ticket{ID: 7, ProjectID: 2, Title: "Login fails", Message: "500 on submit"}
+	require.NoError(t, m.TicketChanged(ctx, nil, ticket))
+	// A redelivered creation finds the thread and posts nothing.
+	repo.On("ThreadByTicket", ctx, 7).Return(&postgres.MattermostThread{TicketID: 7, RootID: "post-1"}, nil).Once()
+	require.NoError(t, m.TicketChanged(ctx, nil, ticket))
+
+	require.Len(t, srv.posts, 1)
+	assert.Equal(t, "#### Ticket #7: Login fails\n500 on submit", srv.posts["post-1"].Message)
+	assert.Equal(t, "Bearer bot-token", srv.auth)
+	repo.AssertExpectations(t)
+}
+
+func TestMirror_TicketChanged_SkipsUpdatesAndProjectsWithoutTeam(t *testing.T) {
+	srv := newStubServer(t)
+	repo := new(mockRepo)
+	m := NewMirror(repo, NewClient(srv.URL, "tok"), "support")
+	ctx := context.Background()
+
+	require.NoError(t, m.TicketChanged(ctx, &postgres.Ticket{ID: 7}, &postgres.Ticket{ID: 7}))
+
+	repo.On("ThreadByTicket", ctx, 8).Return(nil, nil).Once()
+	repo.On("ProjectTeam", ctx, 3).Return(nil, nil).Once()
+	require.NoError(t, m.TicketChanged(ctx, nil, &postgres.Ticket{ID: 8, ProjectID: 3}))
+
+	require.NoError(t, NewMirror(repo, nil, "support").TicketChanged(ctx, nil, &postgres.Ticket{ID: 9, ProjectID: 3}))
+
+	assert.Empty(t, srv.posts)
+	repo.AssertExpectations(t)
+}
+
+func TestMirror_MessageCreated_RepliesInThreadAndRecordsPost(t *testing.T) {
+	srv := newStubServer(t)
+	repo := new(mockRepo)
+	m := NewMirror(repo, NewClient(srv.URL, "tok"), "support")
+	ctx := context.Background()
+
+	repo.On("MessageOfChat", ctx, 15).Return(nil, nil).Once()
+	repo.On("ThreadByTicket", ctx, 7).Return(&postgres.MattermostThread{TicketID: 7, ChannelID: "ch-1", RootID: "root-1"}, nil).Once()
+	repo.On("SetMessageID", ctx, 15, "post-1").Return(nil).Once()
+
+	chat := &postgres.TicketChat{ID: 15, TicketID: 7, SenderRole: "client", Message: "still broken"}
+	require.NoError(t, m.MessageCreated(ctx, chat))
+	// Messages ingested from Mattermost are not posted again.
+	require.NoError(t, m.MessageCreated(ctx, &postgres.TicketChat{ID: 16, TicketID: 7, MattermostMessageID: ptr("user-post-9")}))
+	// Neither are redeliveries of a message that was posted already.
+	repo.On("MessageOfChat", ctx, 15).Return(ptr("post-1"), nil).Once()
+	require.NoError(t, m.MessageCreated(ctx, chat))
+
+	require.Len(t, srv.posts, 1)
+	p := srv.posts["post-1"]
+	assert.Equal(t, "root-1", p.RootID)
+	assert.Equal(t, "**client:** still broken", p.Message)
+	assert.Equal(t, float64(15), p.Props[chatProp])
+	repo.AssertExpectations(t)
+}
+
+func TestReceiver_HandlePost_IngestsThreadReply(t *testing.T) {
+	srv := newStubServer(t)
+	repo := new(mockRepo)
+	chats := new(mockChats)
+	r := NewReceiver(repo, NewClient(srv.URL, "tok"), chats, "00000000-0000-0000-0000-000000000000")
+	ctx := context.Background()
+
+	postID := srv.reply("root-1", "Deployed a fix")
+	repo.On("MessageExists", ctx, postID).Return(false, nil).Once()
+	repo.On("ThreadByRoot", ctx, "root-1").Return(&postgres.MattermostThread{TicketID: 7, RootID: "root-1"}, nil).Once()
+	chats.On("Create", ctx, mock.MatchedBy(func(c *postgres.TicketChat) bool {
+		return c.TicketID == 7 && c.SenderRole == "admin" && c.Message == "jane (Mattermost): Deployed a fix" &&
+			*c.MattermostMessageID == postID
+	})).Return(nil).Once()
+
+	require.NoError(t, r.HandlePost(ctx, &OutgoingWebhook{PostID: postID, UserName: "jane", Text: "Deployed a fix"}))
+	chats.AssertExpectations(t)
+}
+
+func TestReceiver_HandlePost_SkipsOwnTopLevelAndKnownPosts(t *testing.T) {
+	srv := newStubServer(t)
+	repo := new(mockRepo)
+	chats := new(mockChats)
+	client := NewClient(srv.URL, "tok")
+	r := NewReceiver(repo, client, chats, "sender")
+	ctx := context.Background()
+
+	own, err := client.CreatePost(ctx, &Post{ChannelID: "ch-1", RootID: "root-1", Message: "x", Props: map[string]any{chatProp: 15}})
+	require.NoError(t, err)
+	repo.On("MessageExists", ctx, own.ID).Return(false, nil).Once()
+	require.NoError(t, r.HandlePost(ctx, &OutgoingWebhook{PostID: own.ID, Text: "x"}))
+
+	top := srv.reply("", "hello channel")
+	repo.On("MessageExists", ctx, top).Return(false, nil).Once()
+	require.NoError(t, r.HandlePost(ctx, &OutgoingWebhook{PostID: top, Text: "hello channel"}))
+
+	repo.On("MessageExists", ctx, "post-old").Return(true, nil).Once()
+	require.NoError(t, r.HandlePost(ctx, &OutgoingWebhook{PostID: "post-old", Text: "again"}))
+
+	chats.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestHandler_Webhook_ChecksTokenFromForm(t *testing.T) {
+	srv := newStubServer(t)
+	repo := new(mockRepo)
+	chats := new(mockChats)
+	h := NewHandler(NewReceiver(repo, NewClient(srv.URL, "tok"), chats, "sender"), "hook-token")
+	app := fiber.New()
+	RegisterRoutes(app, h)
+
+	send := func(form url.Values) int {
+		req := httptest.NewRequest(http.MethodPost, "/webhooks/mattermost", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, send(url.Values{"token": {"nope"}, "post_id": {"p"}, "text": {"hi"}}))
+
+	postID := srv.reply("root-1", "hi")
+	repo.On("MessageExists", mock.Anything, postID).Return(false, nil).Once()
+	repo.On("ThreadByRoot", mock.Anything, "root-1").Return(&postgres.MattermostThread{TicketID: 7}, nil).Once()
+	chats.On("Create", mock.Anything, mock.Anything).Return(nil).Once()
+	assert.Equal(t, http.StatusOK, send(url.Values{"token": {"hook-token"}, "post_id": {postID}, "user_name": {"jane"}, "text": {"hi"}}))
+	chats.AssertExpectations(t)
+}
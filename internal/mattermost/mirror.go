@@ -0,0 +1,99 @@
+package mattermost
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"innotech/internal/storage/postgres"
+)
+
+// chatProp marks posts written by the integration with the chat message id,
+// so that they are not ingested back.
+const chatProp = "feedbacklab_chat_id"
+
+// Mirror opens a Mattermost thread for every new ticket of a project that has
+// a team and posts the ticket chat into it. It implements tickets.Mirror and
+// ticketchats.Mirror; a nil client disables it.
+type Mirror struct {
+	repo    Repository
+	client  Client
+	channel string
+}
+
+// NewMirror creates a Mirror that opens threads in the named channel of each
+// project's team.
+func NewMirror(repo Repository, client Client, channel string) *Mirror {
+	return &Mirror{repo: repo, client: client, channel: channel}
+}
+
+// TicketChanged opens the thread of a newly created ticket.
+func (m *Mirror) TicketChanged(ctx context.Context, before, after *postgres.Ticket) error {
+	if m.client == nil || before != nil {
+		return nil
+	}
+	return m.openThread(ctx, after)
+}
+
+// openThread posts the root of the ticket thread unless the ticket has one
+// already, e.g. from an earlier delivery of the same change.
+func (m *Mirror) openThread(ctx context.Context, t *postgres.Ticket) error {
+	th, err := m.repo.ThreadByTicket(ctx, t.ID)
+	if err != nil || th != nil {
+		return err
+	}
+	team, err := m.repo.ProjectTeam(ctx, t.ProjectID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil || team == nil || *team == "" {
+		return err
+	}
+	channelID, err := m.client.ChannelID(ctx, *team, m.channel)
+	if err != nil {
+		return err
+	}
+
+	root, err := m.client.CreatePost(ctx, &Post{
+		ChannelID: channelID,
+		Message:   fmt.Sprintf("#### Ticket #%d: %s\n%s", t.ID, t.Title, t.Message),
+		Props:     map[string]any{"feedbacklab_ticket_id": t.ID},
+	})
+	if err != nil {
+		return err
+	}
+
+	th = &postgres.MattermostThread{TicketID: t.ID, ChannelID: channelID, RootID: root.ID}
+	return m.repo.CreateThread(ctx, th, m.client.Permalink(*team, root.ID))
+}
+
+// MessageCreated posts a chat message as a reply in the ticket thread and
+// records the post id on the message. Messages ingested from Mattermost or
+// already posted are skipped.
+func (m *Mirror) MessageCreated(ctx context.Context, chat *postgres.TicketChat) error {
+	if m.client == nil || chat.MattermostMessageID != nil {
+		return nil
+	}
+	postID, err := m.repo.MessageOfChat(ctx, chat.ID)
+	if errors.Is(err, sql.ErrNoRows) || postID != nil {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	th, err := m.repo.ThreadByTicket(ctx, chat.TicketID)
+	if err != nil || th == nil {
+		return err
+	}
+
+	post, err := m.client.CreatePost(ctx, &Post{
+		ChannelID: th.ChannelID,
+		RootID:    th.RootID,
+		Message:   fmt.Sprintf("**%s:** %s", chat.SenderRole, chat.Message),
+		Props:     map[string]any{chatProp: chat.ID},
+	})
+	if err != nil {
+		return err
+	}
+	return m.repo.SetMessageID(ctx, chat.ID, post.ID)
+}
@@ -0,0 +1,70 @@
+package mattermost
+
+import (
+	"context"
+	"fmt"
+	"innotech/internal/access"
+	"innotech/internal/storage/postgres"
+)
+
+// OutgoingWebhook is the payload Mattermost sends to an outgoing webhook.
+// It does not say which thread the post belongs to, so the post is fetched.
+type OutgoingWebhook struct {
+	Token     string `json:"token" form:"token"`
+	ChannelID string `json:"channel_id" form:"channel_id"`
+	UserID    string `json:"user_id" form:"user_id"`
+	UserName  string `json:"user_name" form:"user_name"`
+	PostID    string `json:"post_id" form:"post_id"`
+	Text      string `json:"text" form:"text"`
+}
+
+// ChatWriter posts messages to ticket chats.
+type ChatWriter interface {
+	Create(ctx context.Context, chat *postgres.TicketChat) error
+}
+
+// Receiver ingests replies made in ticket threads into the ticket chats.
+type Receiver struct {
+	repo     Repository
+	client   Client
+	chats    ChatWriter
+	senderID string
+}
+
+// NewReceiver creates a Receiver. Replies are posted under senderID.
+func NewReceiver(repo Repository, client Client, chats ChatWriter, senderID string) *Receiver {
+	return &Receiver{repo: repo, client: client, chats: chats, senderID: senderID}
+}
+
+// HandlePost imports a reply from a ticket thread. Posts outside ticket
+// threads, posts written by the integration and redeliveries are skipped.
+func (r *Receiver) HandlePost(ctx context.Context, w *OutgoingWebhook) error {
+	if r.client == nil || w.PostID == "" || w.Text == "" {
+		return nil
+	}
+	exists, err := r.repo.MessageExists(ctx, w.PostID)
+	if err != nil || exists {
+		return err
+	}
+
+	post, err := r.client.GetPost(ctx, w.PostID)
+	if err != nil {
+		return err
+	}
+	if post.RootID == "" || post.Props[chatProp] != nil {
+		return nil
+	}
+	th, err := r.repo.ThreadByRoot(ctx, post.RootID)
+	if err != nil || th == nil {
+		return err
+	}
+
+	return r.chats.Create(ctx, &postgres.TicketChat{
+		TicketID:            th.TicketID,
+		SenderID:            r.senderID,
+		SenderRole:          access.SenderAdmin,
+		Message:             fmt.Sprintf("%s (Mattermost): %s", w.UserName, post.Message),
+		MessageType:         "text",
+		MattermostMessageID: &post.ID,
+	})
+}
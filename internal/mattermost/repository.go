@@ -0,0 +1,110 @@
+package mattermost
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"innotech/internal/storage/postgres"
+	"innotech/pkg/db"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Repository defines the data access operations of the Mattermost integration.
+type Repository interface {
+	ProjectTeam(ctx context.Context, projectID int) (*string, error)
+	CreateThread(ctx context.Context, th *postgres.MattermostThread, url string) error
+	ThreadByTicket(ctx context.Context, ticketID int) (*postgres.MattermostThread, error)
+	ThreadByRoot(ctx context.Context, rootID string) (*postgres.MattermostThread, error)
+	SetMessageID(ctx context.Context, chatID int, postID string) error
+	MessageOfChat(ctx context.Context, chatID int) (*string, error)
+	MessageExists(ctx context.Context, postID string) (bool, error)
+}
+
+type repository struct {
+	db *sqlx.DB
+}
+
+// NewRepository creates a new Repository instance.
+func NewRepository(db *sqlx.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) ProjectTeam(ctx context.Context, projectID int) (*string, error) {
+	var team *string
+	err := db.Conn(ctx, r.db).GetContext(ctx, &team,
+		`SELECT mattermost_team FROM projects WHERE id = $1`,
+		projectID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return team, nil
+}
+
+// CreateThread stores the thread and publishes its url on the ticket.
+func (r *repository) CreateThread(ctx context.Context, th *postgres.MattermostThread, url string) error {
+	query := `
+		WITH thread AS (
+			INSERT INTO mattermost_threads (ticket_id, channel_id, root_id)
+			VALUES ($1, $2, $3)
+			RETURNING date_created
+		), ticket AS (
+			UPDATE tickets SET mattermost_thread_url = $4 WHERE id = $1
+		)
+		SELECT date_created FROM thread
+	`
+	return db.Conn(ctx, r.db).QueryRowxContext(ctx, query, th.TicketID, th.ChannelID, th.RootID, url).
+		Scan(&th.DateCreated)
+}
+
+// ThreadByTicket returns the thread of the ticket, or nil if there is none.
+func (r *repository) ThreadByTicket(ctx context.Context, ticketID int) (*postgres.MattermostThread, error) {
+	return r.thread(ctx, `SELECT * FROM mattermost_threads WHERE ticket_id = $1`, ticketID)
+}
+
+// ThreadByRoot returns the thread started by the root post, or nil if there is none.
+func (r *repository) ThreadByRoot(ctx context.Context, rootID string) (*postgres.MattermostThread, error) {
+	return r.thread(ctx, `SELECT * FROM mattermost_threads WHERE root_id = $1`, rootID)
+}
+
+func (r *repository) thread(ctx context.Context, query string, arg any) (*postgres.MattermostThread, error) {
+	var th postgres.MattermostThread
+	err := db.Conn(ctx, r.db).GetContext(ctx, &th, query, arg)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &th, nil
+}
+
+func (r *repository) SetMessageID(ctx context.Context, chatID int, postID string) error {
+	_, err := db.Conn(ctx, r.db).ExecContext(ctx,
+		`UPDATE ticket_chats SET mattermost_message_id = $2 WHERE id = $1`,
+		chatID, postID,
+	)
+	return err
+}
+
+func (r *repository) MessageOfChat(ctx context.Context, chatID int) (*string, error) {
+	var id *string
+	err := db.Conn(ctx, r.db).GetContext(ctx, &id,
+		`SELECT mattermost_message_id FROM ticket_chats WHERE id = $1`,
+		chatID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return id, nil
+}
+
+func (r *repository) MessageExists(ctx context.Context, postID string) (bool, error) {
+	var exists bool
+	err := db.Conn(ctx, r.db).GetContext(ctx, &exists,
+		`SELECT EXISTS (SELECT 1 FROM ticket_chats WHERE mattermost_message_id = $1)`,
+		postID,
+	)
+	return exists, err
+}
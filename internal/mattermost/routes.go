@@ -0,0 +1,9 @@
+package mattermost
+
+import "github.com/gofiber/fiber/v2"
+
+// RegisterRoutes registers the Mattermost outgoing webhook. It lives outside
+// /api because Mattermost authenticates with the webhook token.
+func RegisterRoutes(app *fiber.App, h *Handler) {
+	app.Post("/webhooks/mattermost", h.Webhook)
+}
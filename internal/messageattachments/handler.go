@@ -2,8 +2,10 @@
 package messageattachments
 
 import (
+	"innotech/internal/files"
 	"innotech/internal/storage/postgres"
 	"innotech/internal/storage/transport"
+	"innotech/pkg/middleware"
 	"strconv"
 
 	"github.com/gofiber/fiber/v2"
@@ -33,12 +35,12 @@ func (h *Handler) Create(c *fiber.Ctx) error {
 	att := postgres.MessageAttachment{
 		ChatID:     dto.ChatID,
 		FilePath:   dto.FilePath,
-		UploadedBy: dto.UploadedBy,
+		UploadedBy: middleware.UserID(c),
 		FileType:   dto.FileType,
 	}
 
-	if err := h.service.Create(c.Context(), &att); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	if err := h.service.Create(c.UserContext(), &att); err != nil {
+		return c.Status(files.Status(err)).JSON(fiber.Map{"error": err.Error()})
 	}
 	return c.Status(fiber.StatusCreated).JSON(att)
 }
@@ -55,7 +57,7 @@ func (h *Handler) GetByID(c *fiber.Ctx) error {
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
 	}
-	att, err := h.service.GetByID(c.Context(), id)
+	att, err := h.service.GetByID(c.UserContext(), id)
 	if err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "not found"})
 	}
@@ -74,7 +76,7 @@ func (h *Handler) GetByChatID(c *fiber.Ctx) error {
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid chat id"})
 	}
-	list, err := h.service.GetByChatID(c.Context(), chatID)
+	list, err := h.service.GetByChatID(c.UserContext(), chatID)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
@@ -104,8 +106,8 @@ func (h *Handler) Update(c *fiber.Ctx) error {
 		FileType: dto.FileType,
 	}
 
-	if err := h.service.Update(c.Context(), &att); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	if err := h.service.Update(c.UserContext(), &att); err != nil {
+		return c.Status(files.Status(err)).JSON(fiber.Map{"error": err.Error()})
 	}
 	return c.JSON(att)
 }
@@ -121,7 +123,7 @@ func (h *Handler) Delete(c *fiber.Ctx) error {
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
 	}
-	if err := h.service.Delete(c.Context(), id); err != nil {
+	if err := h.service.Delete(c.UserContext(), id); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 	return c.SendStatus(fiber.StatusNoContent)
@@ -3,6 +3,7 @@ package messageattachments
 import (
 	"context"
 	"innotech/internal/storage/postgres"
+	"innotech/pkg/db"
 
 	"github.com/jmoiron/sqlx"
 )
@@ -12,6 +13,7 @@ type Repository interface {
 	Create(ctx context.Context, att *postgres.MessageAttachment) error
 	GetByID(ctx context.Context, id int) (*postgres.MessageAttachment, error)
 	GetByChatID(ctx context.Context, chatID int) ([]postgres.MessageAttachment, error)
+	TicketOf(ctx context.Context, chatID int) (int, error)
 	Update(ctx context.Context, att *postgres.MessageAttachment) error
 	Delete(ctx context.Context, id int) error
 }
@@ -27,13 +29,19 @@ func NewRepository(db *sqlx.DB) Repository {
 
 func (r *repository) Create(ctx context.Context, att *postgres.MessageAttachment) error {
 	query := `
-		INSERT INTO message_attachments (chat_id, file_path, uploaded_by, file_type)
-		VALUES (:chat_id, :file_path, :uploaded_by, :file_type)
-		RETURNING id, date_created, date_updated;
+		INSERT INTO message_attachments (chat_id, file_path, uploaded_by, file_type, size, checksum)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, date_created, date_updated, scan_status;
 	`
 
-	return r.db.QueryRowxContext(ctx, query, att).
-		StructScan(att)
+	return db.Conn(ctx, r.db).QueryRowxContext(ctx, query,
+		att.ChatID,
+		att.FilePath,
+		att.UploadedBy,
+		att.FileType,
+		att.Size,
+		att.Checksum,
+	).StructScan(att)
 }
 
 func (r *repository) GetByID(ctx context.Context, id int) (*postgres.MessageAttachment, error) {
@@ -57,17 +65,31 @@ func (r *repository) GetByChatID(ctx context.Context, chatID int) ([]postgres.Me
 	return list, err
 }
 
+// TicketOf returns the ticket the chat message belongs to.
+func (r *repository) TicketOf(ctx context.Context, chatID int) (int, error) {
+	var ticketID int
+	err := db.Conn(ctx, r.db).GetContext(ctx, &ticketID, `SELECT ticket_id FROM ticket_chats WHERE id = $1`, chatID)
+	return ticketID, err
+}
+
 func (r *repository) Update(ctx context.Context, att *postgres.MessageAttachment) error {
 	query := `
 		UPDATE message_attachments
-		SET file_path = :file_path,
-			file_type = :file_type
-		WHERE id = :id
-		RETURNING date_updated;
+		SET file_path = $1,
+			file_type = $2,
+			size = $3,
+			checksum = $4
+		WHERE id = $5
+		RETURNING date_updated, scan_status;
 	`
 
-	return r.db.QueryRowxContext(ctx, query, att).
-		Scan(&att.DateUpdated)
+	return db.Conn(ctx, r.db).QueryRowxContext(ctx, query,
+		att.FilePath,
+		att.FileType,
+		att.Size,
+		att.Checksum,
+		att.ID,
+	).Scan(&att.DateUpdated, &att.ScanStatus)
 }
 
 func (r *repository) Delete(ctx context.Context, id int) error {
@@ -33,13 +33,11 @@ func TestRepository_Create_WithReturning(t *testing.T) {
 		FileType:   ptr("image/png"),
 	}
 
-	mock.ExpectPrepare(`INSERT INTO message_attachments.*RETURNING.*`)
+	rows := sqlmock.NewRows([]string{"id", "date_created", "date_updated", "scan_status"}).
+		AddRow(42, now, now, "pending")
 
-	rows := sqlmock.NewRows([]string{"id", "date_created", "date_updated"}).
-		AddRow(42, now, now)
-
-	mock.ExpectQuery(`INSERT INTO message_attachments.*RETURNING.*`).
-		WithArgs(att.ChatID, att.FilePath, att.UploadedBy, att.FileType).
+	mock.ExpectQuery(`INSERT INTO message_attachments.*RETURNING id, date_created, date_updated, scan_status`).
+		WithArgs(att.ChatID, att.FilePath, att.UploadedBy, att.FileType, att.Size, att.Checksum).
 		WillReturnRows(rows)
 
 	err = repo.Create(ctx, att)
@@ -66,17 +64,16 @@ func TestRepository_Update_WithReturning(t *testing.T) {
 		FileType: ptr("application/pdf"),
 	}
 
-	mock.ExpectPrepare(`UPDATE message_attachments SET.*WHERE id = \?.*RETURNING date_updated`)
-
-	rows := sqlmock.NewRows([]string{"date_updated"}).AddRow(now)
+	rows := sqlmock.NewRows([]string{"date_updated", "scan_status"}).AddRow(now, "pending")
 
-	mock.ExpectQuery(`UPDATE message_attachments SET.*WHERE id = \?.*RETURNING date_updated`).
-		WithArgs(att.FilePath, att.FileType, att.ID).
+	mock.ExpectQuery(`UPDATE message_attachments\s+SET.*WHERE id = \$5\s+RETURNING date_updated, scan_status`).
+		WithArgs(att.FilePath, att.FileType, att.Size, att.Checksum, att.ID).
 		WillReturnRows(rows)
 
 	err = repo.Update(ctx, att)
 	assert.NoError(t, err)
 	assert.Equal(t, now, att.DateUpdated)
+	assert.Equal(t, "pending", att.ScanStatus)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
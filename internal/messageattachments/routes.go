@@ -1,6 +1,7 @@
 package messageattachments
 
 import (
+	"innotech/internal/access"
 	"innotech/internal/storage/transport"
 	"innotech/pkg/middleware"
 
@@ -8,12 +9,12 @@ import (
 )
 
 // RegisterRoutes registers HTTP routes for message attachment operations.
-func RegisterRoutes(app *fiber.App, h *Handler) {
+func RegisterRoutes(app *fiber.App, h *Handler, guard *access.Guard) {
 	api := app.Group("/api/message_attachments")
 
-	api.Get("/:id", h.GetByID)
-	api.Get("/chat/:chat_id", h.GetByChatID)
-	api.Post("/", middleware.ValidateBody[transport.CreateMessageAttachmentDTO](h.Create))
-	api.Put("/:id", middleware.ValidateBody[transport.UpdateMessageAttachmentDTO](h.Update))
-	api.Delete("/:id", h.Delete)
+	api.Get("/:id", guard.Require(access.AttachmentRead, guard.Param("id", access.ResourceMessageAttachment)), h.GetByID)
+	api.Get("/chat/:chat_id", guard.Require(access.AttachmentRead, guard.Param("chat_id", access.ResourceTicketChat)), h.GetByChatID)
+	api.Post("/", guard.Require(access.AttachmentWrite, guard.Body("chat_id", access.ResourceTicketChat)), middleware.ValidateBody[transport.CreateMessageAttachmentDTO](h.Create))
+	api.Put("/:id", guard.Require(access.AttachmentWrite, guard.Param("id", access.ResourceMessageAttachment)), middleware.ValidateBody[transport.UpdateMessageAttachmentDTO](h.Update))
+	api.Delete("/:id", guard.Require(access.AttachmentDelete, guard.Param("id", access.ResourceMessageAttachment)), h.Delete)
 }
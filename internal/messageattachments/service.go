@@ -3,9 +3,20 @@ package messageattachments
 import (
 	"context"
 	"errors"
+	"innotech/internal/files"
 	"innotech/internal/storage/postgres"
 )
 
+// ObjectVerifier checks that a storage key names a stored file of the ticket.
+type ObjectVerifier interface {
+	Verify(ctx context.Context, ticketID int, key string) (*files.Object, error)
+}
+
+// PreviewLinker fills in the preview links of attachments in responses.
+type PreviewLinker interface {
+	MessageAttachment(ctx context.Context, att *postgres.MessageAttachment)
+}
+
 // Service defines the interface for message attachment business logic operations.
 type Service interface {
 	Create(ctx context.Context, att *postgres.MessageAttachment) error
@@ -16,12 +27,15 @@ type Service interface {
 }
 
 type service struct {
-	repo Repository
+	repo     Repository
+	objects  ObjectVerifier
+	previews PreviewLinker
 }
 
-// NewService creates a new Service instance.
-func NewService(repo Repository) Service {
-	return &service{repo: repo}
+// NewService creates a new Service instance. Attachments must point at files
+// stored under the message's ticket.
+func NewService(repo Repository, objects ObjectVerifier, previews PreviewLinker) Service {
+	return &service{repo: repo, objects: objects, previews: previews}
 }
 
 func (s *service) Create(ctx context.Context, att *postgres.MessageAttachment) error {
@@ -31,21 +45,70 @@ func (s *service) Create(ctx context.Context, att *postgres.MessageAttachment) e
 	if att.ChatID == 0 {
 		return errors.New("chat_id is required")
 	}
-	return s.repo.Create(ctx, att)
+	if err := s.verify(ctx, att); err != nil {
+		return err
+	}
+	if err := s.repo.Create(ctx, att); err != nil {
+		return err
+	}
+	s.previews.MessageAttachment(ctx, att)
+	return nil
 }
 
 func (s *service) GetByID(ctx context.Context, id int) (*postgres.MessageAttachment, error) {
-	return s.repo.GetByID(ctx, id)
+	att, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	s.previews.MessageAttachment(ctx, att)
+	return att, nil
 }
 
 func (s *service) GetByChatID(ctx context.Context, chatID int) ([]postgres.MessageAttachment, error) {
-	return s.repo.GetByChatID(ctx, chatID)
+	list, err := s.repo.GetByChatID(ctx, chatID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range list {
+		s.previews.MessageAttachment(ctx, &list[i])
+	}
+	return list, nil
 }
 
+// Update replaces the file of an attachment. The new file is verified like
+// on creation; the previous one is removed from storage.
 func (s *service) Update(ctx context.Context, att *postgres.MessageAttachment) error {
-	return s.repo.Update(ctx, att)
+	current, err := s.repo.GetByID(ctx, att.ID)
+	if err != nil {
+		return err
+	}
+	att.ChatID, att.UploadedBy, att.DateCreated = current.ChatID, current.UploadedBy, current.DateCreated
+	if att.FilePath == "" || att.FilePath == current.FilePath {
+		att.FilePath, att.FileType, att.Size, att.Checksum = current.FilePath, current.FileType, current.Size, current.Checksum
+		att.ThumbnailPath, att.PreviewPath = current.ThumbnailPath, current.PreviewPath
+	} else if err := s.verify(ctx, att); err != nil {
+		return err
+	}
+	if err := s.repo.Update(ctx, att); err != nil {
+		return err
+	}
+	s.previews.MessageAttachment(ctx, att)
+	return nil
 }
 
 func (s *service) Delete(ctx context.Context, id int) error {
 	return s.repo.Delete(ctx, id)
 }
+
+func (s *service) verify(ctx context.Context, att *postgres.MessageAttachment) error {
+	ticketID, err := s.repo.TicketOf(ctx, att.ChatID)
+	if err != nil {
+		return err
+	}
+	obj, err := s.objects.Verify(ctx, ticketID, att.FilePath)
+	if err != nil {
+		return err
+	}
+	att.FileType, att.Size, att.Checksum = &obj.ContentType, &obj.Size, &obj.Checksum
+	return nil
+}
@@ -3,6 +3,7 @@ package messageattachments
 import (
 	"context"
 	"errors"
+	"innotech/internal/files"
 	"innotech/internal/storage/postgres"
 	"testing"
 
@@ -43,9 +44,31 @@ func (m *mockRepoMA) Delete(ctx context.Context, id int) error {
 	return m.Called(ctx, id).Error(0)
 }
 
+func (m *mockRepoMA) TicketOf(ctx context.Context, chatID int) (int, error) {
+	args := m.Called(ctx, chatID)
+	return args.Int(0), args.Error(1)
+}
+
+// stubVerifier accepts files of ticket 5 only.
+type stubVerifier struct{}
+
+func (stubVerifier) Verify(_ context.Context, ticketID int, key string) (*files.Object, error) {
+	if ticketID != 5 {
+		return nil, files.ErrForeignObject
+	}
+	return &files.Object{Key: key, Size: 4, Checksum: "c0ffee", ContentType: "image/png"}, nil
+}
+
+// stubLinks links previews to their keys.
+type stubLinks struct{}
+
+func (stubLinks) MessageAttachment(_ context.Context, att *postgres.MessageAttachment) {
+	att.ThumbnailURL, att.Icon = att.ThumbnailPath, files.Icon(att.FileType)
+}
+
 func TestCreate_ValidationFails_WhenMissingFields(t *testing.T) {
 	repo := new(mockRepoMA)
-	svc := NewService(repo)
+	svc := NewService(repo, stubVerifier{}, stubLinks{})
 	ctx := context.Background()
 
 	err := svc.Create(ctx, &postgres.MessageAttachment{ChatID: 1, FilePath: ""})
@@ -57,23 +80,27 @@ func TestCreate_ValidationFails_WhenMissingFields(t *testing.T) {
 
 func TestCreate_HappyPath_CallsRepo(t *testing.T) {
 	repo := new(mockRepoMA)
-	svc := NewService(repo)
+	svc := NewService(repo, stubVerifier{}, stubLinks{})
 	ctx := context.Background()
 
 	a := &postgres.MessageAttachment{ChatID: 2, FilePath: "p"}
+	repo.On("TicketOf", ctx, 2).Return(5, nil).Once()
 	repo.On("Create", ctx, a).Return(nil).Once()
 
 	err := svc.Create(ctx, a)
 	assert.NoError(t, err)
+	assert.Equal(t, "c0ffee", *a.Checksum)
+	assert.Equal(t, "image", a.Icon)
 	repo.AssertExpectations(t)
 }
 
 func TestCreate_RepoError_ReturnsError(t *testing.T) {
 	repo := new(mockRepoMA)
-	svc := NewService(repo)
+	svc := NewService(repo, stubVerifier{}, stubLinks{})
 	ctx := context.Background()
 
 	a := &postgres.MessageAttachment{ChatID: 2, FilePath: "p"}
+	repo.On("TicketOf", ctx, 2).Return(5, nil).Once()
 	repo.On("Create", ctx, a).Return(errors.New("db")).Once()
 
 	err := svc.Create(ctx, a)
@@ -83,12 +110,12 @@ func TestCreate_RepoError_ReturnsError(t *testing.T) {
 
 func TestGetUpdateDelete_PassesThrough(t *testing.T) {
 	repo := new(mockRepoMA)
-	svc := NewService(repo)
+	svc := NewService(repo, stubVerifier{}, stubLinks{})
 	ctx := context.Background()
 
 	exp := &postgres.MessageAttachment{ID: 11}
 
-	repo.On("GetByID", ctx, 11).Return(exp, nil).Once()
+	repo.On("GetByID", ctx, 11).Return(exp, nil).Twice()
 	repo.On("GetByChatID", ctx, 3).Return([]postgres.MessageAttachment{{ID: 1}}, nil).Once()
 	repo.On("Update", ctx, exp).Return(nil).Once()
 	repo.On("Delete", ctx, 11).Return(nil).Once()
@@ -105,3 +132,15 @@ func TestGetUpdateDelete_PassesThrough(t *testing.T) {
 	assert.NoError(t, svc.Delete(ctx, 11))
 	repo.AssertExpectations(t)
 }
+
+func TestCreate_RejectsFileOfAnotherTicket(t *testing.T) {
+	repo := new(mockRepoMA)
+	svc := NewService(repo, stubVerifier{}, stubLinks{})
+	ctx := context.Background()
+
+	repo.On("TicketOf", ctx, 3).Return(6, nil).Once()
+
+	err := svc.Create(ctx, &postgres.MessageAttachment{ChatID: 3, FilePath: "projects/1/tickets/5/x"})
+	assert.ErrorIs(t, err, files.ErrForeignObject)
+	repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
@@ -2,6 +2,7 @@
 package modules
 
 import (
+	"innotech/internal/access"
 	"innotech/internal/storage/postgres"
 	"innotech/internal/storage/transport"
 	"strconv"
@@ -38,7 +39,7 @@ func (h *Handler) Create(c *fiber.Ctx) error {
 		Description:       dto.Description,
 		ResponsibleUserID: dto.ResponsibleUserID,
 	}
-	if err := h.service.Create(c.Context(), &m); err != nil {
+	if err := h.service.Create(c.UserContext(), &m); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 	return c.Status(fiber.StatusCreated).JSON(m)
@@ -59,7 +60,7 @@ func (h *Handler) GetByID(c *fiber.Ctx) error {
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
 	}
-	m, err := h.service.GetByID(c.Context(), id)
+	m, err := h.service.GetByID(c.UserContext(), id)
 	if err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
 	}
@@ -75,10 +76,11 @@ func (h *Handler) GetByID(c *fiber.Ctx) error {
 // @Router /api/modules [get]
 // GetAll retrieves all modules.
 func (h *Handler) GetAll(c *fiber.Ctx) error {
-	ms, err := h.service.GetAll(c.Context())
+	ms, err := h.service.GetAll(c.UserContext())
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
+	ms = access.Filter(access.ScopeFrom(c), ms, func(m postgres.Module) int { return m.ProjectID })
 	return c.JSON(ms)
 }
 
@@ -106,7 +108,7 @@ func (h *Handler) Update(c *fiber.Ctx) error {
 		Description:       dto.Description,
 		ResponsibleUserID: dto.ResponsibleUserID,
 	}
-	if err := h.service.Update(c.Context(), &m); err != nil {
+	if err := h.service.Update(c.UserContext(), &m); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 	return c.JSON(m)
@@ -126,7 +128,7 @@ func (h *Handler) Delete(c *fiber.Ctx) error {
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
 	}
-	if err := h.service.Delete(c.Context(), id); err != nil {
+	if err := h.service.Delete(c.UserContext(), id); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 	return c.SendStatus(fiber.StatusNoContent)
@@ -1,6 +1,7 @@
 package modules
 
 import (
+	"innotech/internal/access"
 	"innotech/internal/storage/transport"
 	"innotech/pkg/middleware"
 
@@ -8,12 +9,12 @@ import (
 )
 
 // RegisterRoutes registers HTTP routes for module operations.
-func RegisterRoutes(app *fiber.App, h *Handler) {
+func RegisterRoutes(app *fiber.App, h *Handler, guard *access.Guard) {
 	api := app.Group("/api/modules")
 
-	api.Get("/", h.GetAll)
-	api.Get("/:id", h.GetByID)
-	api.Post("/", middleware.ValidateBody[transport.CreateModuleDTO](h.Create))
-	api.Put("/:id", middleware.ValidateBody[transport.UpdateModuleDTO](h.Update))
-	api.Delete("/:id", h.Delete)
+	api.Get("/", guard.Scoped(access.ModuleRead), h.GetAll)
+	api.Get("/:id", guard.Require(access.ModuleRead, guard.Param("id", access.ResourceModule)), h.GetByID)
+	api.Post("/", guard.Require(access.ModuleManage, guard.Body("project_id", access.ResourceProject)), middleware.ValidateBody[transport.CreateModuleDTO](h.Create))
+	api.Put("/:id", guard.Require(access.ModuleManage, guard.Param("id", access.ResourceModule)), middleware.ValidateBody[transport.UpdateModuleDTO](h.Update))
+	api.Delete("/:id", guard.Require(access.ModuleManage, guard.Param("id", access.ResourceModule)), h.Delete)
 }
@@ -0,0 +1,164 @@
+package notifications
+
+import (
+	"innotech/internal/storage/transport"
+	"innotech/pkg/auth"
+	"innotech/pkg/logger"
+	"innotech/pkg/middleware"
+	"strconv"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/text/language"
+)
+
+// Handler handles HTTP requests for notification settings and ticket watchers.
+type Handler struct {
+	service Service
+	// contacts caches the email and language last stored per user, so that
+	// TrackContact writes only when they change.
+	contacts sync.Map
+}
+
+// NewHandler creates a new Handler instance.
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+// TrackContact records the email address of authenticated users, which is
+// where their notifications are sent, and the language of their first
+// request. It must run after middleware.Auth.
+func (h *Handler) TrackContact(c *fiber.Ctx) error {
+	identity, ok := c.Locals("identity").(*auth.Identity)
+	if !ok || identity.Email == "" {
+		return c.Next()
+	}
+	if email, ok := h.contacts.Load(identity.Subject); ok && email == identity.Email {
+		return c.Next()
+	}
+	if err := h.service.RememberContact(c.UserContext(), identity.Subject, identity.Email, requestLanguage(c)); err != nil {
+		logger.Warn("failed to store notification contact", "user_id", identity.Subject, "error", err)
+		return c.Next()
+	}
+	h.contacts.Store(identity.Subject, identity.Email)
+	return c.Next()
+}
+
+// requestLanguage returns the base language picked by the I18n middleware.
+func requestLanguage(c *fiber.Ctx) string {
+	tag, _ := c.Locals("language").(string)
+	base, _ := language.Make(tag).Base()
+	if lang := base.String(); lang == "ru" || lang == "en" {
+		return lang
+	}
+	return DefaultLanguage
+}
+
+// GetPreferences godoc
+// @Summary получить настройки уведомлений текущего пользователя
+// @Tags Notifications
+// @Produce json
+// @Success 200 {object} postgres.NotificationPreferences
+// @Router /notifications/preferences [get]
+func (h *Handler) GetPreferences(c *fiber.Ctx) error {
+	p, err := h.service.Preferences(c.UserContext(), middleware.UserID(c))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(p)
+}
+
+// UpdatePreferences godoc
+// @Summary обновить настройки уведомлений текущего пользователя
+// @Description язык писем, включение, типы уведомлений и режим дайджеста
+// @Tags Notifications
+// @Accept json
+// @Produce json
+// @Param preferences body transport.UpdateNotificationPreferencesDTO true "Preferences"
+// @Success 200 {object} postgres.NotificationPreferences
+// @Failure 400 {object} map[string]string
+// @Router /notifications/preferences [put]
+func (h *Handler) UpdatePreferences(c *fiber.Ctx) error {
+	dto := c.Locals("body").(*transport.UpdateNotificationPreferencesDTO)
+
+	p, err := h.service.Preferences(c.UserContext(), middleware.UserID(c))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	if identity, ok := c.Locals("identity").(*auth.Identity); ok && identity.Email != "" {
+		p.Email = identity.Email
+	}
+	if p.Email == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "no email address in the access token"})
+	}
+	if dto.Language != "" {
+		p.Language = dto.Language
+	}
+	if dto.Enabled != nil {
+		p.Enabled = *dto.Enabled
+	}
+	if dto.Kinds != nil {
+		p.Kinds = dto.Kinds
+	}
+	if dto.Digest != "" {
+		p.Digest = dto.Digest
+	}
+
+	if err := h.service.SavePreferences(c.UserContext(), p); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(p)
+}
+
+// Watchers godoc
+// @Summary получить наблюдателей заявки
+// @Tags Notifications
+// @Produce json
+// @Param id path int true "Ticket ID"
+// @Success 200 {array} postgres.TicketWatcher
+// @Router /tickets/{id}/watchers [get]
+func (h *Handler) Watchers(c *fiber.Ctx) error {
+	ticketID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+	list, err := h.service.Watchers(c.UserContext(), ticketID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(list)
+}
+
+// Watch godoc
+// @Summary подписаться на уведомления по заявке
+// @Tags Notifications
+// @Param id path int true "Ticket ID"
+// @Success 204
+// @Router /tickets/{id}/watchers [post]
+func (h *Handler) Watch(c *fiber.Ctx) error {
+	ticketID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+	if err := h.service.Watch(c.UserContext(), ticketID, middleware.UserID(c)); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// Unwatch godoc
+// @Summary отписаться от уведомлений по заявке
+// @Tags Notifications
+// @Param id path int true "Ticket ID"
+// @Success 204
+// @Router /tickets/{id}/watchers [delete]
+func (h *Handler) Unwatch(c *fiber.Ctx) error {
+	ticketID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+	if err := h.service.Unwatch(c.UserContext(), ticketID, middleware.UserID(c)); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,129 @@
+package notifications
+
+import (
+	"context"
+	"innotech/internal/outbox"
+	"innotech/internal/storage/postgres"
+	"innotech/pkg/logger"
+	"time"
+)
+
+// Delivery tuning.
+const (
+	MaxAttempts  = 8
+	BatchSize    = 100
+	pollInterval = 30 * time.Second
+	claimLease   = 5 * time.Minute
+)
+
+// Mailer sends queued notifications.
+type Mailer struct {
+	repo     Repository
+	renderer *Renderer
+	sender   Sender
+}
+
+// NewMailer creates a new Mailer instance.
+func NewMailer(repo Repository, renderer *Renderer, sender Sender) *Mailer {
+	return &Mailer{repo: repo, renderer: renderer, sender: sender}
+}
+
+// Run sends due notifications until ctx is cancelled. Several replicas may run
+// it at once; each notification is claimed by one of them.
+func (m *Mailer) Run(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			n, err := m.SendDue(ctx)
+			if err != nil {
+				logger.Error("notification delivery failed", "error", err)
+			}
+			if err != nil || n < BatchSize {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SendDue sends one batch of due notifications and returns its size. Users
+// with instant delivery get an email per notification, digest subscribers a
+// single email with everything due for them.
+func (m *Mailer) SendDue(ctx context.Context) (int, error) {
+	due, err := m.repo.ClaimDue(ctx, BatchSize, claimLease)
+	if err != nil || len(due) == 0 {
+		return 0, err
+	}
+
+	var users []string
+	byUser := map[string][]postgres.Notification{}
+	for _, n := range due {
+		if _, ok := byUser[n.UserID]; !ok {
+			users = append(users, n.UserID)
+		}
+		byUser[n.UserID] = append(byUser[n.UserID], n)
+	}
+	prefs, err := m.repo.PreferencesOf(ctx, users)
+	if err != nil {
+		return 0, err
+	}
+	prefsByUser := make(map[string]*postgres.NotificationPreferences, len(prefs))
+	for i := range prefs {
+		prefsByUser[prefs[i].UserID] = &prefs[i]
+	}
+
+	for _, userID := range users {
+		items := byUser[userID]
+		p := prefsByUser[userID]
+		// Preferences may have changed since the notification was queued.
+		if p == nil || !p.Enabled || p.Email == "" {
+			if err := m.repo.MarkSkipped(ctx, ids(items)); err != nil {
+				logger.Error("failed to skip notifications", "user_id", userID, "error", err)
+			}
+			continue
+		}
+		if p.Digest == DigestInstant {
+			for i := range items {
+				m.deliver(ctx, p, items[i:i+1])
+			}
+			continue
+		}
+		m.deliver(ctx, p, items)
+	}
+	return len(due), nil
+}
+
+// deliver sends items to p as one email and records the outcome.
+func (m *Mailer) deliver(ctx context.Context, p *postgres.NotificationPreferences, items []postgres.Notification) {
+	email, err := m.renderer.Render(p.Language, p.UserID, items)
+	if err == nil {
+		email.To = p.Email
+		err = m.sender.Send(ctx, email)
+	}
+	if err == nil {
+		if err := m.repo.MarkSent(ctx, ids(items)); err != nil {
+			logger.Error("failed to mark notifications sent", "user_id", p.UserID, "error", err)
+		}
+		return
+	}
+
+	attempt := items[0].Attempts + 1
+	logger.Warn("notification email failed", "user_id", p.UserID, "attempt", attempt, "error", err)
+	if err := m.repo.RecordFailure(ctx, ids(items), err.Error(), MaxAttempts, outbox.Backoff(attempt)); err != nil {
+		logger.Error("failed to record notification failure", "user_id", p.UserID, "error", err)
+	}
+}
+
+func ids(items []postgres.Notification) []int64 {
+	out := make([]int64, len(items))
+	for i, n := range items {
+		out[i] = n.ID
+	}
+	return out
+}
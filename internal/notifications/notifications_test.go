@@ -0,0 +1,456 @@
+package notifications
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"innotech/internal/outbox"
+	"innotech/internal/storage/postgres"
+	"innotech/internal/tickets"
+	"innotech/pkg/i18n"
+	"innotech/pkg/logger"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	logger.Init()
+	os.Exit(m.Run())
+}
+
+type mockRepo struct{ mock.Mock }
+
+func (m *mockRepo) GetPreferences(ctx context.Context, userID string) (*postgres.NotificationPreferences, error) {
+	args := m.Called(ctx, userID)
+	p, _ := args.Get(0).(*postgres.NotificationPreferences)
+	return p, args.Error(1)
+}
+
+func (m *mockRepo) SavePreferences(ctx context.Context, p *postgres.NotificationPreferences) error {
+	return m.Called(ctx, p).Error(0)
+}
+
+func (m *mockRepo) RememberContact(ctx context.Context, userID, email, language string) error {
+	return m.Called(ctx, userID, email, language).Error(0)
+}
+
+func (m *mockRepo) PreferencesOf(ctx context.Context, userIDs []string) ([]postgres.NotificationPreferences, error) {
+	args := m.Called(ctx, userIDs)
+	return args.Get(0).([]postgres.NotificationPreferences), args.Error(1)
+}
+
+func (m *mockRepo) Watch(ctx context.Context, ticketID int, userID string) error {
+	return m.Called(ctx, ticketID, userID).Error(0)
+}
+
+func (m *mockRepo) Unwatch(ctx context.Context, ticketID int, userID string) error {
+	return m.Called(ctx, ticketID, userID).Error(0)
+}
+
+func (m *mockRepo) Watchers(ctx context.Context, ticketID int) ([]postgres.TicketWatcher, error) {
+	args := m.Called(ctx, ticketID)
+	return args.Get(0).([]postgres.TicketWatcher), args.Error(1)
+}
+
+func (m *mockRepo) Audience(ctx context.Context, ticketID int) (*Audience, error) {
+	args := m.Called(ctx, ticketID)
+	a, _ := args.Get(0).(*Audience)
+	return a, args.Error(1)
+}
+
+func (m *mockRepo) ProjectOwners(ctx context.Context, projectID int) ([]string, error) {
+	args := m.Called(ctx, projectID)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *mockRepo) Enqueue(ctx context.Context, n *postgres.Notification, userIDs []string) error {
+	return m.Called(ctx, n, userIDs).Error(0)
+}
+
+func (m *mockRepo) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]postgres.Notification, error) {
+	args := m.Called(ctx, limit, lease)
+	return args.Get(0).([]postgres.Notification), args.Error(1)
+}
+
+func (m *mockRepo) MarkSent(ctx context.Context, ids []int64) error {
+	return m.Called(ctx, ids).Error(0)
+}
+
+func (m *mockRepo) MarkSkipped(ctx context.Context, ids []int64) error {
+	return m.Called(ctx, ids).Error(0)
+}
+
+func (m *mockRepo) RecordFailure(ctx context.Context, ids []int64, errMsg string, maxAttempts int, retryIn time.Duration) error {
+	return m.Called(ctx, ids, errMsg, maxAttempts, retryIn).Error(0)
+}
+
+// smtpSink is a minimal local SMTP server that records received messages.
+type smtpSink struct {
+	ln       net.Listener
+	mu       sync.Mutex
+	messages []sinkMessage
+}
+
+type sinkMessage struct {
+	From string
+	To   []string
+	Data []byte
+}
+
+func newSMTPSink(t *testing.T) *smtpSink {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := &smtpSink{ln: ln}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *smtpSink) config() SMTPConfig {
+	addr := s.ln.Addr().(*net.TCPAddr)
+	return SMTPConfig{Host: addr.IP.String(), Port: addr.Port, From: "FeedbackLab <noreply@feedbacklab.test>"}
+}
+
+func (s *smtpSink) serve(conn net.Conn) {
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+	_ = tp.PrintfLine("220 sink ESMTP")
+	var msg sinkMessage
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch cmd {
+		case "EHLO", "HELO":
+			_ = tp.PrintfLine("250-sink")
+			_ = tp.PrintfLine("250 8BITMIME")
+		case "MAIL":
+			msg = sinkMessage{From: angleAddr(line)}
+			_ = tp.PrintfLine("250 OK")
+		case "RCPT":
+			msg.To = append(msg.To, angleAddr(line))
+			_ = tp.PrintfLine("250 OK")
+		case "DATA":
+			_ = tp.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+			data, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			msg.Data = data
+			s.mu.Lock()
+			s.messages = append(s.messages, msg)
+			s.mu.Unlock()
+			_ = tp.PrintfLine("250 OK")
+		case "QUIT":
+			_ = tp.PrintfLine("221 Bye")
+			return
+		default:
+			_ = tp.PrintfLine("250 OK")
+		}
+	}
+}
+
+// angleAddr returns the address between angle brackets in an SMTP command.
+func angleAddr(line string) string {
+	start, end := strings.Index(line, "<"), strings.Index(line, ">")
+	if start < 0 || end < start {
+		return ""
+	}
+	return line[start+1 : end]
+}
+
+func (s *smtpSink) received() []sinkMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]sinkMessage(nil), s.messages...)
+}
+
+// parsedEmail is a received message with decoded subject and parts.
+type parsedEmail struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+func parseEmail(t *testing.T, data []byte) parsedEmail {
+	m, err := mail.ReadMessage(bufio.NewReader(strings.NewReader(string(data))))
+	require.NoError(t, err)
+	subject, err := new(mime.WordDecoder).DecodeHeader(m.Header.Get("Subject"))
+	require.NoError(t, err)
+	out := parsedEmail{To: m.Header.Get("To"), Subject: subject}
+
+	mediaType, params, err := mime.ParseMediaType(m.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	require.Equal(t, "multipart/alternative", mediaType)
+	mr := multipart.NewReader(m.Body, params["boundary"])
+	for {
+		p, err := mr.NextRawPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		assert.Equal(t, "quoted-printable", p.Header.Get("Content-Transfer-Encoding"))
+		body, err := io.ReadAll(quotedprintable.NewReader(p))
+		require.NoError(t, err)
+		if strings.HasPrefix(p.Header.Get("Content-Type"), "text/html") {
+			out.HTML = string(body)
+		} else {
+			out.Text = string(body)
+		}
+	}
+	return out
+}
+
+func newRenderer(t *testing.T) *Renderer {
+	bundle := i18n.InitBundle()
+	require.NoError(t, i18n.LoadTranslations(bundle, "../../locales"))
+	r, err := NewRenderer(bundle, "https://support.example.com/", func(ticketID int) string {
+		return fmt.Sprintf("FL-%d-test", ticketID)
+	})
+	require.NoError(t, err)
+	return r
+}
+
+func notification(id int64, userID, kind string, data Data) postgres.Notification {
+	raw, _ := json.Marshal(data)
+	return postgres.Notification{ID: id, UserID: userID, Kind: kind, Data: raw, Status: StatusPending}
+}
+
+func strPtr(s string) *string { return &s }
+
+func TestAudience_Recipients_ExcludesActorAndDuplicates(t *testing.T) {
+	a := &Audience{CreatedBy: "client", AssignedTo: strPtr("dev"), Watchers: postgres.StringArray{"dev", "pm", "client"}}
+
+	assert.Equal(t, []string{"client", "pm"}, a.Recipients("dev"))
+	assert.Equal(t, []string{"client", "dev", "pm"}, a.Recipients(""))
+}
+
+func TestNotifier_TicketChanged_QueuesStatusAndAssignment(t *testing.T) {
+	repo := new(mockRepo)
+	before := &postgres.Ticket{ID: 7, Title: "Login fails", Status: "open", CreatedBy: "client"}
+	after := &postgres.Ticket{ID: 7, Title: "Login fails", Status: "in_progress", CreatedBy: "client", AssignedTo: strPtr("dev")}
+
+	repo.On("Audience", mock.Anything, 7).
+		Return(&Audience{Title: "Login fails", CreatedBy: "client", AssignedTo: strPtr("dev"), Watchers: postgres.StringArray{"pm"}}, nil).Once()
+	repo.On("Enqueue", mock.Anything, mock.MatchedBy(func(n *postgres.Notification) bool {
+		var d Data
+		_ = json.Unmarshal(n.Data, &d)
+		return n.Kind == KindStatusChanged && n.EventKey == "status_changed:0" && d.From == "open" && d.To == "in_progress"
+	}), []string{"client", "dev"}).Return(nil).Once()
+	repo.On("Enqueue", mock.Anything, mock.MatchedBy(func(n *postgres.Notification) bool {
+		var d Data
+		_ = json.Unmarshal(n.Data, &d)
+		return n.Kind == KindAssigned && *n.TicketID == 7 && d.AssignedTo == "dev"
+	}), []string{"client", "dev"}).Return(nil).Once()
+
+	err := NewNotifier(repo).TicketChanged(context.Background(), &tickets.TicketChange{Before: before, After: after, ActorID: "pm"})
+
+	require.NoError(t, err)
+	repo.AssertExpectations(t)
+}
+
+func TestNotifier_TicketChanged_IgnoresCreationAndOtherEdits(t *testing.T) {
+	repo := new(mockRepo)
+	n := NewNotifier(repo)
+	ticket := &postgres.Ticket{ID: 7, Title: "Login fails", Status: "open"}
+	edited := *ticket
+	edited.Title = "Login fails on Safari"
+
+	require.NoError(t, n.TicketChanged(context.Background(), &tickets.TicketChange{After: ticket}))
+	require.NoError(t, n.TicketChanged(context.Background(), &tickets.TicketChange{Before: ticket, After: &edited}))
+	repo.AssertNotCalled(t, "Audience", mock.Anything, mock.Anything)
+}
+
+func TestNotifier_MessageCreated_SkipsSenderAndDeletedTickets(t *testing.T) {
+	repo := new(mockRepo)
+	n := NewNotifier(repo)
+
+	repo.On("Audience", mock.Anything, 7).Return(&Audience{Title: "Login fails", CreatedBy: "client", Watchers: postgres.StringArray{"pm"}}, nil).Once()
+	repo.On("Enqueue", mock.Anything, mock.MatchedBy(func(n *postgres.Notification) bool {
+		var d Data
+		_ = json.Unmarshal(n.Data, &d)
+		return n.Kind == KindChatMessage && d.Message == "Any news?" && d.Title == "Login fails"
+	}), []string{"pm"}).Return(nil).Once()
+	repo.On("Audience", mock.Anything, 8).Return(nil, sql.ErrNoRows).Once()
+
+	require.NoError(t, n.MessageCreated(context.Background(), &postgres.TicketChat{TicketID: 7, SenderID: "client", SenderRole: "client", Message: "Any news?"}))
+	require.NoError(t, n.MessageCreated(context.Background(), &postgres.TicketChat{TicketID: 8, SenderID: "client"}))
+	repo.AssertExpectations(t)
+}
+
+func TestNotifier_ContractExpiring_QueuesForProjectOwners(t *testing.T) {
+	repo := new(mockRepo)
+	validTo := time.Date(2026, 3, 5, 0, 0, 0, 0, time.UTC)
+	c := &postgres.Contract{ID: 2, ProjectID: 3, Name: "Support", ValidTo: &validTo}
+
+	repo.On("ProjectOwners", mock.Anything, 3).Return([]string{"owner"}, nil).Once()
+	repo.On("Enqueue", mock.Anything, mock.MatchedBy(func(n *postgres.Notification) bool {
+		var d Data
+		_ = json.Unmarshal(n.Data, &d)
+		return n.Kind == KindContractExpiring && n.EventKey == "contract_expiring:2:2026-03-05:7" &&
+			n.TicketID == nil && d.ContractID == 2 && d.DaysLeft == 4 && d.ValidTo == "2026-03-05"
+	}), []string{"owner"}).Return(nil).Once()
+
+	require.NoError(t, NewNotifier(repo).ContractExpiring(context.Background(), c, 4, 7))
+	repo.AssertExpectations(t)
+}
+
+func TestRenderer_Render_ContractReminder(t *testing.T) {
+	r := newRenderer(t)
+	n := notification(1, "owner", KindContractExpiring, Data{ContractID: 2, Title: "Support", ValidTo: "2026-03-05", DaysLeft: 4})
+
+	en, err := r.Render("en", "owner", []postgres.Notification{n})
+
+	require.NoError(t, err)
+	assert.Equal(t, "Contract «Support» expires on 2026-03-05", en.Subject)
+	assert.Empty(t, en.ThreadTag)
+	assert.Contains(t, en.Text, "Days left: 4.")
+	assert.Contains(t, en.Text, "Open contract: https://support.example.com/contracts/2")
+	assert.Contains(t, en.Text, "because you own the project")
+}
+
+func TestRenderer_Render_LocalizesAndEscapes(t *testing.T) {
+	r := newRenderer(t)
+	chat := notification(1, "u1", KindChatMessage, Data{TicketID: 7, Title: "Login fails", Message: "<b>hi</b>", SenderRole: "client"})
+
+	ru, err := r.Render("ru", "u1", []postgres.Notification{chat})
+	require.NoError(t, err)
+	assert.Equal(t, "Новое сообщение в заявке #7: Login fails [FL-7-test]", ru.Subject)
+	assert.Equal(t, "FL-7-test", ru.ThreadTag)
+	assert.Contains(t, ru.Text, "Клиент пишет:")
+	assert.Contains(t, ru.Text, "https://support.example.com/tickets/7")
+	assert.Contains(t, ru.HTML, "&lt;b&gt;hi&lt;/b&gt;")
+	assert.NotContains(t, ru.HTML, "<b>hi</b>")
+
+	status := notification(2, "u1", KindStatusChanged, Data{TicketID: 7, Title: "Login fails", From: "open", To: "in_progress"})
+	assigned := notification(3, "u1", KindAssigned, Data{TicketID: 7, Title: "Login fails", AssignedTo: "u1"})
+	en, err := r.Render("en", "u1", []postgres.Notification{status, assigned})
+	require.NoError(t, err)
+	assert.Equal(t, "Updates on your tickets: 2 [FL-7-test]", en.Subject)
+	assert.Contains(t, en.Text, "The status changed from «open» to «in progress».")
+	assert.Contains(t, en.Text, "The ticket was assigned to you.")
+}
+
+func TestSMTPSender_Send_DeliversMultipartMessage(t *testing.T) {
+	sink := newSMTPSink(t)
+	sender := NewSMTPSender(sink.config())
+
+	err := sender.Send(context.Background(), &Email{To: "client@example.com", Subject: "Заявка #7", Text: "plain", HTML: "<p>html</p>"})
+
+	require.NoError(t, err)
+	got := sink.received()
+	require.Len(t, got, 1)
+	assert.Equal(t, "noreply@feedbacklab.test", got[0].From)
+	assert.Equal(t, []string{"client@example.com"}, got[0].To)
+	e := parseEmail(t, got[0].Data)
+	assert.Equal(t, "Заявка #7", e.Subject)
+	assert.Equal(t, "plain", e.Text)
+	assert.Equal(t, "<p>html</p>", e.HTML)
+}
+
+func TestMailer_SendDue_SendsInstantAndDigestEmails(t *testing.T) {
+	sink := newSMTPSink(t)
+	repo := new(mockRepo)
+	mailer := NewMailer(repo, newRenderer(t), NewSMTPSender(sink.config()))
+
+	repo.On("ClaimDue", mock.Anything, BatchSize, claimLease).Return([]postgres.Notification{
+		notification(1, "instant", KindChatMessage, Data{TicketID: 7, Title: "Login fails", Message: "first", SenderRole: "admin"}),
+		notification(2, "instant", KindChatMessage, Data{TicketID: 7, Title: "Login fails", Message: "second", SenderRole: "admin"}),
+		notification(3, "daily", KindChatMessage, Data{TicketID: 7, Title: "Login fails", Message: "first", SenderRole: "admin"}),
+		notification(4, "daily", KindStatusChanged, Data{TicketID: 7, Title: "Login fails", From: "open", To: "resolved"}),
+		notification(5, "gone", KindAssigned, Data{TicketID: 7, Title: "Login fails"}),
+	}, nil).Once()
+	repo.On("PreferencesOf", mock.Anything, []string{"instant", "daily", "gone"}).Return([]postgres.NotificationPreferences{
+		{UserID: "instant", Email: "instant@example.com", Language: "en", Enabled: true, Digest: DigestInstant},
+		{UserID: "daily", Email: "daily@example.com", Language: "ru", Enabled: true, Digest: DigestDaily},
+	}, nil).Once()
+	repo.On("MarkSent", mock.Anything, []int64{1}).Return(nil).Once()
+	repo.On("MarkSent", mock.Anything, []int64{2}).Return(nil).Once()
+	repo.On("MarkSent", mock.Anything, []int64{3, 4}).Return(nil).Once()
+	repo.On("MarkSkipped", mock.Anything, []int64{5}).Return(nil).Once()
+
+	n, err := mailer.SendDue(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, 5, n)
+	got := sink.received()
+	require.Len(t, got, 3)
+
+	first := parseEmail(t, got[0].Data)
+	assert.Equal(t, "instant@example.com", first.To)
+	assert.Equal(t, "New message in ticket #7: Login fails [FL-7-test]", first.Subject)
+	assert.Contains(t, first.Text, "Support wrote:")
+	assert.Contains(t, first.Text, "first")
+	assert.Contains(t, parseEmail(t, got[1].Data).Text, "second")
+
+	digest := parseEmail(t, got[2].Data)
+	assert.Equal(t, "daily@example.com", digest.To)
+	assert.Equal(t, "Обновления по вашим заявкам: 2 [FL-7-test]", digest.Subject)
+	assert.Contains(t, digest.HTML, "Статус изменён с «открыта» на «решена».")
+	repo.AssertExpectations(t)
+}
+
+func TestMailer_SendDue_RetriesFailedDelivery(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().(*net.TCPAddr)
+	ln.Close()
+
+	repo := new(mockRepo)
+	sender := NewSMTPSender(SMTPConfig{Host: addr.IP.String(), Port: addr.Port, From: "noreply@feedbacklab.test"})
+	mailer := NewMailer(repo, newRenderer(t), sender)
+
+	item := notification(9, "u1", KindAssigned, Data{TicketID: 7, Title: "Login fails", AssignedTo: "u1"})
+	item.Attempts = 2
+	repo.On("ClaimDue", mock.Anything, BatchSize, claimLease).Return([]postgres.Notification{item}, nil).Once()
+	repo.On("PreferencesOf", mock.Anything, []string{"u1"}).Return([]postgres.NotificationPreferences{
+		{UserID: "u1", Email: "u1@example.com", Language: "en", Enabled: true, Digest: DigestInstant},
+	}, nil).Once()
+	repo.On("RecordFailure", mock.Anything, []int64{9}, mock.Anything, MaxAttempts, outbox.Backoff(3)).Return(nil).Once()
+
+	_, err = mailer.SendDue(context.Background())
+
+	require.NoError(t, err)
+	repo.AssertExpectations(t)
+}
+
+func TestService_Preferences_DefaultsForNewUsers(t *testing.T) {
+	repo := new(mockRepo)
+	repo.On("GetPreferences", mock.Anything, "u1").Return(nil, sql.ErrNoRows).Once()
+
+	p, err := NewService(repo).Preferences(context.Background(), "u1")
+
+	require.NoError(t, err)
+	assert.True(t, p.Enabled)
+	assert.Equal(t, DigestInstant, p.Digest)
+	assert.Equal(t, DefaultLanguage, p.Language)
+	assert.ElementsMatch(t, Kinds, []string(p.Kinds))
+}
@@ -0,0 +1,164 @@
+// Package notifications emails ticket creators, assignees and watchers about
+// activity on their tickets, and project owners about expiring contracts.
+// Outbox consumers and the contract monitor queue a notification per
+// recipient, and the Mailer renders and sends them according to each user's
+// preferences, either right away or batched into hourly or daily digests.
+package notifications
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"innotech/internal/outbox"
+	"innotech/internal/storage/postgres"
+	"innotech/internal/tickets"
+	"time"
+)
+
+// Notification kinds. Users pick the kinds they want in their preferences.
+// Contract reminders go to project owners.
+const (
+	KindChatMessage      = "chat_message"
+	KindStatusChanged    = "status_changed"
+	KindAssigned         = "assigned"
+	KindContractExpiring = "contract_expiring"
+)
+
+// Digest modes from notification_digest_enum.
+const (
+	DigestInstant = "instant"
+	DigestHourly  = "hourly"
+	DigestDaily   = "daily"
+)
+
+// Kinds lists every notification kind.
+var Kinds = []string{KindChatMessage, KindStatusChanged, KindAssigned, KindContractExpiring}
+
+// Data is the snapshot of the event stored with a notification, so that a
+// digest renders what happened even if the ticket or contract changed again
+// since. Title is the name of the contract in contract notifications.
+type Data struct {
+	TicketID   int    `json:"ticket_id"`
+	Title      string `json:"title"`
+	Message    string `json:"message,omitempty"`
+	SenderRole string `json:"sender_role,omitempty"`
+	From       string `json:"from,omitempty"`
+	To         string `json:"to,omitempty"`
+	AssignedTo string `json:"assigned_to,omitempty"`
+	ContractID int    `json:"contract_id,omitempty"`
+	ValidTo    string `json:"valid_to,omitempty"`
+	DaysLeft   int    `json:"days_left,omitempty"`
+}
+
+// Notifier turns committed ticket and chat changes, and contract expiry
+// reminders, into queued notifications.
+type Notifier struct {
+	repo Repository
+}
+
+// NewNotifier creates a new Notifier instance.
+func NewNotifier(repo Repository) *Notifier {
+	return &Notifier{repo: repo}
+}
+
+// TicketChanged notifies about status changes and new assignees. The user who
+// made the change is not notified.
+func (n *Notifier) TicketChanged(ctx context.Context, c *tickets.TicketChange) error {
+	if c.Before == nil || c.After == nil {
+		return nil
+	}
+	before, after := c.Before, c.After
+	statusChanged := before.Status != after.Status
+	assigned := after.AssignedTo != nil && (before.AssignedTo == nil || *before.AssignedTo != *after.AssignedTo)
+	if !statusChanged && !assigned {
+		return nil
+	}
+
+	audience, err := n.audience(ctx, after.ID)
+	if err != nil || audience == nil {
+		return err
+	}
+	recipients := audience.Recipients(c.ActorID)
+
+	if statusChanged {
+		data := Data{TicketID: after.ID, Title: after.Title, From: before.Status, To: after.Status}
+		if err := n.enqueue(ctx, KindStatusChanged, messageKey(ctx, KindStatusChanged), data, recipients); err != nil {
+			return err
+		}
+	}
+	if assigned {
+		data := Data{TicketID: after.ID, Title: after.Title, AssignedTo: *after.AssignedTo}
+		if err := n.enqueue(ctx, KindAssigned, messageKey(ctx, KindAssigned), data, recipients); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MessageCreated notifies about a new chat message everyone but its sender.
+func (n *Notifier) MessageCreated(ctx context.Context, chat *postgres.TicketChat) error {
+	audience, err := n.audience(ctx, chat.TicketID)
+	if err != nil || audience == nil {
+		return err
+	}
+	data := Data{
+		TicketID:   chat.TicketID,
+		Title:      audience.Title,
+		Message:    chat.Message,
+		SenderRole: chat.SenderRole,
+	}
+	return n.enqueue(ctx, KindChatMessage, messageKey(ctx, KindChatMessage), data, audience.Recipients(chat.SenderID))
+}
+
+// ContractExpiring reminds the owners of the contract's project that it ends
+// in daysLeft days. The reminder is keyed by the window and the end date, so
+// each window is only reminded about once until the contract is renewed.
+func (n *Notifier) ContractExpiring(ctx context.Context, c *postgres.Contract, daysLeft, window int) error {
+	owners, err := n.repo.ProjectOwners(ctx, c.ProjectID)
+	if err != nil {
+		return err
+	}
+	validTo := c.ValidTo.Format(time.DateOnly)
+	data := Data{Title: c.Name, ContractID: c.ID, ValidTo: validTo, DaysLeft: daysLeft}
+	key := fmt.Sprintf("%s:%d:%s:%d", KindContractExpiring, c.ID, validTo, window)
+	return n.enqueue(ctx, KindContractExpiring, key, data, owners)
+}
+
+// audience returns nil when the ticket has been deleted in the meantime.
+func (n *Notifier) audience(ctx context.Context, ticketID int) (*Audience, error) {
+	a, err := n.repo.Audience(ctx, ticketID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return a, err
+}
+
+// messageKey keys a notification by the outbox message, so a redelivered
+// message does not notify anyone twice.
+func messageKey(ctx context.Context, kind string) string {
+	return fmt.Sprintf("%s:%d", kind, outbox.MessageID(ctx))
+}
+
+// enqueue queues a notification under key, which no recipient is notified
+// about twice.
+func (n *Notifier) enqueue(ctx context.Context, kind, key string, data Data, recipients []string) error {
+	if len(recipients) == 0 {
+		return nil
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	var ticketID *int
+	if data.TicketID != 0 {
+		ticketID = &data.TicketID
+	}
+	return n.repo.Enqueue(ctx, &postgres.Notification{
+		EventKey: key,
+		Kind:     kind,
+		TicketID: ticketID,
+		Data:     raw,
+	}, recipients)
+}
@@ -0,0 +1,177 @@
+package notifications
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	htmltemplate "html/template"
+	"innotech/internal/storage/postgres"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+
+	goi18n "github.com/nicksnyder/go-i18n/v2/i18n"
+)
+
+//go:embed templates/*.tmpl
+var templates embed.FS
+
+// Renderer renders notifications as localized emails.
+type Renderer struct {
+	bundle  *goi18n.Bundle
+	baseURL string
+	tag     func(ticketID int) string
+	html    *htmltemplate.Template
+	text    *texttemplate.Template
+}
+
+// NewRenderer creates a Renderer. Texts come from the bundle in the
+// recipient's language; ticket links point to baseURL. Emails about a single
+// ticket carry the reply token returned by tag, if any, so that replies can
+// be matched to the ticket.
+func NewRenderer(bundle *goi18n.Bundle, baseURL string, tag func(ticketID int) string) (*Renderer, error) {
+	html, err := htmltemplate.ParseFS(templates, "templates/email.html.tmpl")
+	if err != nil {
+		return nil, err
+	}
+	text, err := texttemplate.ParseFS(templates, "templates/email.txt.tmpl")
+	if err != nil {
+		return nil, err
+	}
+	return &Renderer{bundle: bundle, baseURL: strings.TrimRight(baseURL, "/"), tag: tag, html: html, text: text}, nil
+}
+
+type view struct {
+	Language string
+	Subject  string
+	Intro    string
+	Items    []viewItem
+	Footer   string
+}
+
+type viewItem struct {
+	Heading   string
+	Body      string
+	Quote     string
+	Link      string
+	LinkLabel string
+}
+
+// Render renders the notifications of one recipient as a single email. A
+// lone notification is titled by its heading, several make up a digest.
+func (r *Renderer) Render(language, userID string, items []postgres.Notification) (*Email, error) {
+	l := localizer{goi18n.NewLocalizer(r.bundle, language)}
+	v := view{Language: language}
+	tickets := map[int]bool{}
+	for _, n := range items {
+		item, ticketID, err := r.item(l, userID, &n)
+		if err != nil {
+			return nil, err
+		}
+		v.Items = append(v.Items, item)
+		if ticketID != 0 {
+			tickets[ticketID] = true
+		}
+	}
+	if len(tickets) > 0 {
+		v.Footer = l.t("notification.footer", nil)
+	} else {
+		v.Footer = l.t("notification.footer_owner", nil)
+	}
+	if len(v.Items) == 1 {
+		v.Subject = v.Items[0].Heading
+	} else {
+		count := map[string]any{"Count": len(v.Items)}
+		v.Subject = l.t("notification.digest.subject", count)
+		v.Intro = l.t("notification.digest.intro", count)
+	}
+
+	var threadTag string
+	if len(tickets) == 1 && r.tag != nil {
+		for ticketID := range tickets {
+			threadTag = r.tag(ticketID)
+		}
+		if threadTag != "" {
+			v.Subject += " [" + threadTag + "]"
+		}
+	}
+
+	var text, html bytes.Buffer
+	if err := r.text.Execute(&text, v); err != nil {
+		return nil, err
+	}
+	if err := r.html.Execute(&html, v); err != nil {
+		return nil, err
+	}
+	return &Email{Subject: v.Subject, Text: text.String(), HTML: html.String(), ThreadTag: threadTag}, nil
+}
+
+// item renders one notification and returns the ticket it is about, or 0
+// for contract reminders.
+func (r *Renderer) item(l localizer, userID string, n *postgres.Notification) (viewItem, int, error) {
+	var d Data
+	if err := json.Unmarshal(n.Data, &d); err != nil {
+		return viewItem{}, 0, err
+	}
+	if n.Kind == KindContractExpiring {
+		args := map[string]any{"Title": d.Title, "ValidTo": d.ValidTo, "DaysLeft": d.DaysLeft}
+		return viewItem{
+			Heading:   l.t("notification.contract_expiring.heading", args),
+			Body:      l.t("notification.contract_expiring.body", args),
+			Link:      r.baseURL + "/contracts/" + strconv.Itoa(d.ContractID),
+			LinkLabel: l.t("notification.open_contract", nil),
+		}, 0, nil
+	}
+	args := map[string]any{
+		"TicketID": d.TicketID,
+		"Title":    d.Title,
+		"From":     l.status(d.From),
+		"To":       l.status(d.To),
+		"Sender":   l.t("notification.sender."+d.SenderRole, nil),
+	}
+	item := viewItem{
+		Heading:   l.t("notification."+n.Kind+".heading", args),
+		Link:      r.baseURL + "/tickets/" + strconv.Itoa(d.TicketID),
+		LinkLabel: l.t("notification.open_ticket", nil),
+	}
+	switch n.Kind {
+	case KindChatMessage:
+		item.Body = l.t("notification.chat_message.body", args)
+		item.Quote = d.Message
+	case KindAssigned:
+		if d.AssignedTo == userID {
+			item.Body = l.t("notification.assigned.body_you", args)
+		} else {
+			item.Body = l.t("notification.assigned.body", args)
+		}
+	default:
+		item.Body = l.t("notification."+n.Kind+".body", args)
+	}
+	return item, d.TicketID, nil
+}
+
+type localizer struct {
+	*goi18n.Localizer
+}
+
+// t translates messageID, falling back to the ID itself.
+func (l localizer) t(messageID string, data map[string]any) string {
+	msg, err := l.Localize(&goi18n.LocalizeConfig{MessageID: messageID, TemplateData: data})
+	if err != nil {
+		return messageID
+	}
+	return msg
+}
+
+// status translates a ticket status. Statuses of custom workflows without
+// a translation are shown as is.
+func (l localizer) status(s string) string {
+	if s == "" {
+		return ""
+	}
+	msg, err := l.Localize(&goi18n.LocalizeConfig{MessageID: "ticket.status." + s})
+	if err != nil {
+		return s
+	}
+	return msg
+}
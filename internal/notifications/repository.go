@@ -0,0 +1,263 @@
+package notifications
+
+import (
+	"context"
+	"innotech/internal/storage/postgres"
+	"innotech/pkg/db"
+	"strconv"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Notification statuses from notification_status_enum.
+const (
+	StatusPending = "pending"
+	StatusSent    = "sent"
+	StatusSkipped = "skipped"
+	StatusFailed  = "failed"
+)
+
+// Audience lists the people following a ticket.
+type Audience struct {
+	Title      string               `db:"title"`
+	CreatedBy  string               `db:"created_by"`
+	AssignedTo *string              `db:"assigned_to"`
+	Watchers   postgres.StringArray `db:"watchers"`
+}
+
+// Recipients returns everyone in the audience except the user who caused the
+// change.
+func (a *Audience) Recipients(except string) []string {
+	var out []string
+	add := func(id string) {
+		if id == "" || id == except {
+			return
+		}
+		for _, seen := range out {
+			if seen == id {
+				return
+			}
+		}
+		out = append(out, id)
+	}
+	add(a.CreatedBy)
+	if a.AssignedTo != nil {
+		add(*a.AssignedTo)
+	}
+	for _, w := range a.Watchers {
+		add(w)
+	}
+	return out
+}
+
+// Repository defines the interface for notification data access operations.
+type Repository interface {
+	GetPreferences(ctx context.Context, userID string) (*postgres.NotificationPreferences, error)
+	SavePreferences(ctx context.Context, p *postgres.NotificationPreferences) error
+	RememberContact(ctx context.Context, userID, email, language string) error
+	PreferencesOf(ctx context.Context, userIDs []string) ([]postgres.NotificationPreferences, error)
+
+	Watch(ctx context.Context, ticketID int, userID string) error
+	Unwatch(ctx context.Context, ticketID int, userID string) error
+	Watchers(ctx context.Context, ticketID int) ([]postgres.TicketWatcher, error)
+	Audience(ctx context.Context, ticketID int) (*Audience, error)
+	ProjectOwners(ctx context.Context, projectID int) ([]string, error)
+
+	Enqueue(ctx context.Context, n *postgres.Notification, userIDs []string) error
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]postgres.Notification, error)
+	MarkSent(ctx context.Context, ids []int64) error
+	MarkSkipped(ctx context.Context, ids []int64) error
+	RecordFailure(ctx context.Context, ids []int64, errMsg string, maxAttempts int, retryIn time.Duration) error
+}
+
+type repository struct {
+	db *sqlx.DB
+}
+
+// NewRepository creates a new Repository instance.
+func NewRepository(db *sqlx.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) GetPreferences(ctx context.Context, userID string) (*postgres.NotificationPreferences, error) {
+	var p postgres.NotificationPreferences
+	err := db.Conn(ctx, r.db).GetContext(ctx, &p, `SELECT * FROM notification_preferences WHERE user_id = $1`, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *repository) SavePreferences(ctx context.Context, p *postgres.NotificationPreferences) error {
+	query := `
+		INSERT INTO notification_preferences (user_id, email, language, enabled, kinds, digest)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (user_id) DO UPDATE
+		SET email = EXCLUDED.email, language = EXCLUDED.language, enabled = EXCLUDED.enabled,
+		    kinds = EXCLUDED.kinds, digest = EXCLUDED.digest
+		RETURNING date_created, date_updated
+	`
+	return db.Conn(ctx, r.db).QueryRowxContext(ctx, query, p.UserID, p.Email, p.Language, p.Enabled, p.Kinds, p.Digest).
+		Scan(&p.DateCreated, &p.DateUpdated)
+}
+
+// RememberContact stores the email address of a user, keeping the rest of
+// their preferences. The language is only used for users seen the first time.
+func (r *repository) RememberContact(ctx context.Context, userID, email, language string) error {
+	query := `
+		INSERT INTO notification_preferences (user_id, email, language)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE SET email = EXCLUDED.email
+		WHERE notification_preferences.email <> EXCLUDED.email
+	`
+	_, err := db.Conn(ctx, r.db).ExecContext(ctx, query, userID, email, language)
+	return err
+}
+
+func (r *repository) PreferencesOf(ctx context.Context, userIDs []string) ([]postgres.NotificationPreferences, error) {
+	list := []postgres.NotificationPreferences{}
+	err := db.Conn(ctx, r.db).SelectContext(ctx, &list,
+		`SELECT * FROM notification_preferences WHERE user_id::text = ANY($1)`,
+		postgres.StringArray(userIDs),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *repository) Watch(ctx context.Context, ticketID int, userID string) error {
+	_, err := db.Conn(ctx, r.db).ExecContext(ctx,
+		`INSERT INTO ticket_watchers (ticket_id, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`,
+		ticketID, userID,
+	)
+	return err
+}
+
+func (r *repository) Unwatch(ctx context.Context, ticketID int, userID string) error {
+	_, err := db.Conn(ctx, r.db).ExecContext(ctx,
+		`DELETE FROM ticket_watchers WHERE ticket_id = $1 AND user_id = $2`,
+		ticketID, userID,
+	)
+	return err
+}
+
+func (r *repository) Watchers(ctx context.Context, ticketID int) ([]postgres.TicketWatcher, error) {
+	list := []postgres.TicketWatcher{}
+	err := db.Conn(ctx, r.db).SelectContext(ctx, &list,
+		`SELECT * FROM ticket_watchers WHERE ticket_id = $1 ORDER BY date_created`,
+		ticketID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *repository) Audience(ctx context.Context, ticketID int) (*Audience, error) {
+	query := `
+		SELECT t.title, t.created_by, t.assigned_to,
+		       ARRAY(SELECT w.user_id::text FROM ticket_watchers w WHERE w.ticket_id = t.id ORDER BY w.date_created) AS watchers
+		FROM tickets t
+		WHERE t.id = $1
+	`
+	var a Audience
+	if err := db.Conn(ctx, r.db).GetContext(ctx, &a, query, ticketID); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (r *repository) ProjectOwners(ctx context.Context, projectID int) ([]string, error) {
+	var owners []string
+	err := db.Conn(ctx, r.db).SelectContext(ctx, &owners,
+		`SELECT user_id::text FROM user_projects WHERE project_id = $1 AND role = 'owner' ORDER BY user_id`,
+		projectID,
+	)
+	return owners, err
+}
+
+// Enqueue queues n for every listed user who has notifications of its kind
+// enabled. Digest subscribers get it at the end of their current period.
+// Users already notified about the same event are skipped.
+func (r *repository) Enqueue(ctx context.Context, n *postgres.Notification, userIDs []string) error {
+	query := `
+		INSERT INTO notifications (user_id, event_key, kind, ticket_id, data, send_after)
+		SELECT p.user_id, $2, $3, $4, $5,
+		       CASE p.digest
+		           WHEN 'hourly' THEN date_trunc('hour', NOW()) + INTERVAL '1 hour'
+		           WHEN 'daily' THEN date_trunc('day', NOW()) + INTERVAL '1 day'
+		           ELSE NOW()
+		       END
+		FROM notification_preferences p
+		WHERE p.user_id::text = ANY($1) AND p.enabled AND $3 = ANY(p.kinds)
+		ON CONFLICT (user_id, event_key) DO NOTHING
+	`
+	_, err := db.Conn(ctx, r.db).ExecContext(ctx, query,
+		postgres.StringArray(userIDs), n.EventKey, n.Kind, n.TicketID, n.Data,
+	)
+	return err
+}
+
+// ClaimDue leases up to limit due notifications. Concurrent mailers skip rows
+// another one has locked, and the rows of a crashed mailer become due again
+// once the lease runs out.
+func (r *repository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]postgres.Notification, error) {
+	query := `
+		UPDATE notifications
+		SET send_after = NOW() + make_interval(secs => $2)
+		WHERE id IN (
+			SELECT id FROM notifications
+			WHERE status = 'pending' AND send_after <= NOW()
+			ORDER BY user_id, id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *
+	`
+	list := []postgres.Notification{}
+	if err := db.Conn(ctx, r.db).SelectContext(ctx, &list, query, limit, lease.Seconds()); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *repository) MarkSent(ctx context.Context, ids []int64) error {
+	_, err := db.Conn(ctx, r.db).ExecContext(ctx,
+		`UPDATE notifications SET status = 'sent', attempts = attempts + 1, last_error = NULL, date_sent = NOW() WHERE id = ANY($1::bigint[])`,
+		int64Array(ids),
+	)
+	return err
+}
+
+func (r *repository) MarkSkipped(ctx context.Context, ids []int64) error {
+	_, err := db.Conn(ctx, r.db).ExecContext(ctx,
+		`UPDATE notifications SET status = 'skipped' WHERE id = ANY($1::bigint[])`,
+		int64Array(ids),
+	)
+	return err
+}
+
+// RecordFailure schedules another attempt, or gives up once maxAttempts is reached.
+func (r *repository) RecordFailure(ctx context.Context, ids []int64, errMsg string, maxAttempts int, retryIn time.Duration) error {
+	query := `
+		UPDATE notifications
+		SET attempts = attempts + 1,
+		    status = CASE WHEN attempts + 1 >= $3 THEN 'failed' ELSE 'pending' END::notification_status_enum,
+		    last_error = $2,
+		    send_after = NOW() + make_interval(secs => $4)
+		WHERE id = ANY($1::bigint[])
+	`
+	_, err := db.Conn(ctx, r.db).ExecContext(ctx, query, int64Array(ids), errMsg, maxAttempts, retryIn.Seconds())
+	return err
+}
+
+// int64Array binds ids as a text array; queries cast it to bigint[].
+func int64Array(ids []int64) postgres.StringArray {
+	out := make(postgres.StringArray, len(ids))
+	for i, id := range ids {
+		out[i] = strconv.FormatInt(id, 10)
+	}
+	return out
+}
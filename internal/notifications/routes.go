@@ -0,0 +1,21 @@
+package notifications
+
+import (
+	"innotech/internal/access"
+	"innotech/internal/storage/transport"
+	"innotech/pkg/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RegisterRoutes registers HTTP routes for notification settings and ticket watchers.
+func RegisterRoutes(app *fiber.App, h *Handler, guard *access.Guard) {
+	api := app.Group("/api/notifications")
+	api.Get("/preferences", h.GetPreferences)
+	api.Put("/preferences", middleware.ValidateBody[transport.UpdateNotificationPreferencesDTO](h.UpdatePreferences))
+
+	tickets := app.Group("/api/tickets")
+	tickets.Get("/:id/watchers", guard.Require(access.TicketRead, guard.Param("id", access.ResourceTicket)), h.Watchers)
+	tickets.Post("/:id/watchers", guard.Require(access.TicketRead, guard.Param("id", access.ResourceTicket)), h.Watch)
+	tickets.Delete("/:id/watchers", guard.Require(access.TicketRead, guard.Param("id", access.ResourceTicket)), h.Unwatch)
+}
@@ -0,0 +1,66 @@
+package notifications
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"innotech/internal/storage/postgres"
+)
+
+// DefaultLanguage is used for users whose language is not known yet.
+const DefaultLanguage = "ru"
+
+// Service defines the interface for notification settings and ticket watchers.
+type Service interface {
+	Preferences(ctx context.Context, userID string) (*postgres.NotificationPreferences, error)
+	SavePreferences(ctx context.Context, p *postgres.NotificationPreferences) error
+	RememberContact(ctx context.Context, userID, email, language string) error
+	Watch(ctx context.Context, ticketID int, userID string) error
+	Unwatch(ctx context.Context, ticketID int, userID string) error
+	Watchers(ctx context.Context, ticketID int) ([]postgres.TicketWatcher, error)
+}
+
+type service struct {
+	repo Repository
+}
+
+// NewService creates a new Service instance.
+func NewService(repo Repository) Service {
+	return &service{repo: repo}
+}
+
+// Preferences returns the stored preferences of the user, or the defaults
+// when they have none yet.
+func (s *service) Preferences(ctx context.Context, userID string) (*postgres.NotificationPreferences, error) {
+	p, err := s.repo.GetPreferences(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return &postgres.NotificationPreferences{
+			UserID:   userID,
+			Language: DefaultLanguage,
+			Enabled:  true,
+			Kinds:    append(postgres.StringArray(nil), Kinds...),
+			Digest:   DigestInstant,
+		}, nil
+	}
+	return p, err
+}
+
+func (s *service) SavePreferences(ctx context.Context, p *postgres.NotificationPreferences) error {
+	return s.repo.SavePreferences(ctx, p)
+}
+
+func (s *service) RememberContact(ctx context.Context, userID, email, language string) error {
+	return s.repo.RememberContact(ctx, userID, email, language)
+}
+
+func (s *service) Watch(ctx context.Context, ticketID int, userID string) error {
+	return s.repo.Watch(ctx, ticketID, userID)
+}
+
+func (s *service) Unwatch(ctx context.Context, ticketID int, userID string) error {
+	return s.repo.Unwatch(ctx, ticketID, userID)
+}
+
+func (s *service) Watchers(ctx context.Context, ticketID int) ([]postgres.TicketWatcher, error) {
+	return s.repo.Watchers(ctx, ticketID)
+}
@@ -0,0 +1,169 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"innotech/internal/storage/postgres"
+	"innotech/pkg/logger"
+	"slices"
+	"sync/atomic"
+	"time"
+)
+
+// Dispatch tuning.
+const (
+	MaxAttempts   = 10
+	BatchSize     = 50
+	pollInterval  = 5 * time.Second
+	claimLease    = 2 * time.Minute
+	baseBackoff   = 5 * time.Second
+	maxBackoff    = time.Hour
+	retention     = 7 * 24 * time.Hour
+	pruneInterval = time.Hour
+)
+
+// Backoff returns the wait before the attempt that follows attempt number
+// attempt (1-based): 5s, 10s, 20s, ... capped at 1h.
+func Backoff(attempt int) time.Duration {
+	d := baseBackoff
+	for i := 1; i < attempt && d < maxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, maxBackoff)
+}
+
+// Metrics reports how far delivery is behind. Pending, Dead and LagSeconds
+// cover the whole outbox; the counters are those of this replica since start.
+type Metrics struct {
+	Stats
+	Delivered uint64 `json:"delivered"`
+	Failures  uint64 `json:"failures"`
+}
+
+type consumer struct {
+	name string
+	fn   Consumer
+}
+
+// Dispatcher delivers committed messages to the consumers of their topic.
+type Dispatcher struct {
+	repo      Repository
+	hub       Hub
+	consumers map[string][]consumer
+
+	delivered atomic.Uint64
+	failures  atomic.Uint64
+}
+
+// NewDispatcher creates a Dispatcher. Consumers are registered before Run.
+func NewDispatcher(repo Repository, hub Hub) *Dispatcher {
+	return &Dispatcher{repo: repo, hub: hub, consumers: map[string][]consumer{}}
+}
+
+// Register adds a consumer of topic. The name identifies the consumer in the
+// progress stored with each message and must be unique within the topic.
+func (d *Dispatcher) Register(topic, name string, fn Consumer) {
+	d.consumers[topic] = append(d.consumers[topic], consumer{name: name, fn: fn})
+}
+
+// Run dispatches due messages until ctx is cancelled. It wakes up as soon as
+// a message is committed on any replica and polls as a fallback. Several
+// replicas may run it at once; each message is claimed by one of them.
+func (d *Dispatcher) Run(ctx context.Context) {
+	wake := d.hub.Subscribe(WakeTopic)
+	defer wake.Close()
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	prune := time.NewTicker(pruneInterval)
+	defer prune.Stop()
+
+	for {
+		for {
+			n, err := d.DispatchDue(ctx)
+			if err != nil {
+				logger.Error("outbox dispatch failed", "error", err)
+			}
+			if err != nil || n < BatchSize {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-wake.C:
+		case <-ticker.C:
+		case <-prune.C:
+			if n, err := d.repo.Prune(ctx, retention); err != nil {
+				logger.Error("outbox prune failed", "error", err)
+			} else if n > 0 {
+				logger.Info("outbox pruned", "deleted", n)
+			}
+		}
+	}
+}
+
+// DispatchDue processes one batch of due messages in commit order and
+// returns its size.
+func (d *Dispatcher) DispatchDue(ctx context.Context) (int, error) {
+	msgs, err := d.repo.ClaimDue(ctx, BatchSize, claimLease)
+	if err != nil {
+		return 0, err
+	}
+	for i := range msgs {
+		d.process(ctx, &msgs[i])
+	}
+	return len(msgs), nil
+}
+
+// process runs the consumers that have not handled m yet. When some fail the
+// message is retried with backoff for those only, and given up on after
+// MaxAttempts.
+func (d *Dispatcher) process(ctx context.Context, m *postgres.OutboxMessage) {
+	completed := slices.Clone([]string(m.Completed))
+	var errs []error
+	cctx := context.WithValue(ctx, messageIDKey{}, m.ID)
+	for _, c := range d.consumers[m.Topic] {
+		if slices.Contains(completed, c.name) {
+			continue
+		}
+		if err := c.fn(cctx, json.RawMessage(m.Payload)); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", c.name, err))
+			continue
+		}
+		completed = append(completed, c.name)
+	}
+
+	if len(errs) == 0 {
+		if err := d.repo.MarkDone(ctx, m.ID); err != nil {
+			logger.Error("failed to mark outbox message done", "message_id", m.ID, "error", err)
+			return
+		}
+		d.delivered.Add(1)
+		return
+	}
+
+	d.failures.Add(1)
+	msg := errors.Join(errs...).Error()
+	attempt := m.Attempts + 1
+	status := StatusPending
+	if attempt >= MaxAttempts {
+		status = StatusDead
+		logger.Error("outbox message given up", "message_id", m.ID, "topic", m.Topic, "attempts", attempt, "error", msg)
+	} else {
+		logger.Warn("outbox message failed", "message_id", m.ID, "topic", m.Topic, "attempt", attempt, "error", msg)
+	}
+	if err := d.repo.RecordFailure(ctx, m.ID, completed, status, msg, Backoff(attempt)); err != nil {
+		logger.Error("failed to record outbox failure", "message_id", m.ID, "error", err)
+	}
+}
+
+// Metrics returns the current outbox backlog and delivery counters.
+func (d *Dispatcher) Metrics(ctx context.Context) (*Metrics, error) {
+	stats, err := d.repo.Stats(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &Metrics{Stats: *stats, Delivered: d.delivered.Load(), Failures: d.failures.Load()}, nil
+}
@@ -0,0 +1,29 @@
+package outbox
+
+import "github.com/gofiber/fiber/v2"
+
+// Handler handles HTTP requests for outbox monitoring.
+type Handler struct {
+	dispatcher *Dispatcher
+}
+
+// NewHandler creates a new Handler instance.
+func NewHandler(dispatcher *Dispatcher) *Handler {
+	return &Handler{dispatcher: dispatcher}
+}
+
+// Metrics godoc
+// @Summary метрики отставания outbox
+// @Description количество недоставленных сообщений, возраст самого старого и счётчики доставки
+// @Tags Outbox
+// @Produce json
+// @Success 200 {object} outbox.Metrics
+// @Failure 403 {object} map[string]string
+// @Router /outbox/metrics [get]
+func (h *Handler) Metrics(c *fiber.Ctx) error {
+	m, err := h.dispatcher.Metrics(c.UserContext())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(m)
+}
@@ -0,0 +1,75 @@
+// Package outbox implements a transactional outbox. Services write messages
+// in the same transaction as the change they describe, and a background
+// dispatcher hands them to the registered consumers once committed, so a
+// crash between the commit and the side effect loses nothing.
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"innotech/pkg/pgnotify"
+)
+
+// WakeTopic is the hub topic that tells dispatchers new messages were committed.
+const WakeTopic = "outbox"
+
+// Hub fans out notifications to every application replica.
+type Hub interface {
+	Subscribe(topic string) *pgnotify.Subscription
+	Publish(ctx context.Context, topic string, data any) error
+}
+
+// Consumer handles the payload of a message. Delivery is at least once: a
+// consumer may see the same message again after a crash or a failure of
+// another consumer's attempt, and must be idempotent.
+type Consumer func(ctx context.Context, payload json.RawMessage) error
+
+type messageIDKey struct{}
+
+// MessageID returns the id of the message being consumed, or 0 outside a
+// consumer. It stays the same across redeliveries, which makes it a natural
+// idempotency key.
+func MessageID(ctx context.Context) int64 {
+	id, _ := ctx.Value(messageIDKey{}).(int64)
+	return id
+}
+
+// Decode adapts a typed handler to a Consumer.
+func Decode[T any](fn func(ctx context.Context, v *T) error) Consumer {
+	return func(ctx context.Context, payload json.RawMessage) error {
+		var v T
+		if err := json.Unmarshal(payload, &v); err != nil {
+			return err
+		}
+		return fn(ctx, &v)
+	}
+}
+
+// Writer records outbox messages.
+type Writer interface {
+	Write(ctx context.Context, topic string, payload any) error
+}
+
+type writer struct {
+	repo Repository
+	hub  Hub
+}
+
+// NewWriter creates a new Writer instance. Write must run inside the
+// transaction bound with db.Transactor that makes the change; the message and
+// the dispatcher wake-up then commit or roll back together with it.
+func NewWriter(repo Repository, hub Hub) Writer {
+	return &writer{repo: repo, hub: hub}
+}
+
+func (w *writer) Write(ctx context.Context, topic string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	id, err := w.repo.Add(ctx, topic, data)
+	if err != nil {
+		return err
+	}
+	return w.hub.Publish(ctx, WakeTopic, id)
+}
@@ -0,0 +1,180 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"innotech/internal/storage/postgres"
+	"innotech/pkg/logger"
+	"innotech/pkg/pgnotify"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	logger.Init()
+	os.Exit(m.Run())
+}
+
+type mockRepo struct{ mock.Mock }
+
+func (m *mockRepo) Add(ctx context.Context, topic string, payload []byte) (int64, error) {
+	args := m.Called(ctx, topic, payload)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *mockRepo) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]postgres.OutboxMessage, error) {
+	args := m.Called(ctx, limit, lease)
+	return args.Get(0).([]postgres.OutboxMessage), args.Error(1)
+}
+
+func (m *mockRepo) MarkDone(ctx context.Context, id int64) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *mockRepo) RecordFailure(ctx context.Context, id int64, completed []string, status, errMsg string, retryIn time.Duration) error {
+	return m.Called(ctx, id, completed, status, errMsg, retryIn).Error(0)
+}
+
+func (m *mockRepo) Stats(ctx context.Context) (*Stats, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(*Stats), args.Error(1)
+}
+
+func (m *mockRepo) Prune(ctx context.Context, olderThan time.Duration) (int64, error) {
+	args := m.Called(ctx, olderThan)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+type localHub struct{ *pgnotify.Broker }
+
+func (h localHub) Publish(_ context.Context, topic string, data any) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	h.Dispatch(pgnotify.Message{Topic: topic, Data: raw})
+	return nil
+}
+
+type countingConsumer struct {
+	calls     int
+	messageID int64
+	err       error
+}
+
+func (c *countingConsumer) consume(ctx context.Context, _ json.RawMessage) error {
+	c.calls++
+	c.messageID = MessageID(ctx)
+	return c.err
+}
+
+func TestWriter_Write_StoresMessageAndWakesDispatchers(t *testing.T) {
+	repo := new(mockRepo)
+	hub := localHub{pgnotify.NewBroker()}
+	sub := hub.Subscribe(WakeTopic)
+	defer sub.Close()
+
+	repo.On("Add", mock.Anything, "ticket.changed", []byte(`{"id":3}`)).Return(int64(41), nil).Once()
+
+	require.NoError(t, NewWriter(repo, hub).Write(context.Background(), "ticket.changed", map[string]int{"id": 3}))
+
+	m := <-sub.C
+	assert.JSONEq(t, `41`, string(m.Data))
+	repo.AssertExpectations(t)
+}
+
+func TestDecode_PassesTypedPayload(t *testing.T) {
+	var got struct {
+		ID int `json:"id"`
+	}
+	consume := Decode(func(_ context.Context, v *struct {
+		ID int `json:"id"`
+	}) error {
+		got = *v
+		return nil
+	})
+
+	require.NoError(t, consume(context.Background(), json.RawMessage(`{"id":9}`)))
+	assert.Equal(t, 9, got.ID)
+	assert.Error(t, consume(context.Background(), json.RawMessage(`[`)))
+}
+
+func TestBackoff_DoublesUpToCap(t *testing.T) {
+	assert.Equal(t, 5*time.Second, Backoff(1))
+	assert.Equal(t, 20*time.Second, Backoff(3))
+	assert.Equal(t, time.Hour, Backoff(30))
+}
+
+func TestDispatcher_DispatchDue_MarksDeliveredMessagesDone(t *testing.T) {
+	repo := new(mockRepo)
+	d := NewDispatcher(repo, localHub{pgnotify.NewBroker()})
+	first, second := &countingConsumer{}, &countingConsumer{}
+	d.Register("ticket.changed", "first", first.consume)
+	d.Register("ticket.changed", "second", second.consume)
+
+	repo.On("ClaimDue", mock.Anything, BatchSize, claimLease).Return([]postgres.OutboxMessage{
+		{ID: 1, Topic: "ticket.changed", Payload: postgres.JSON(`{}`)},
+		// Topics without consumers are simply completed.
+		{ID: 2, Topic: "unknown", Payload: postgres.JSON(`{}`)},
+	}, nil).Once()
+	repo.On("MarkDone", mock.Anything, int64(1)).Return(nil).Once()
+	repo.On("MarkDone", mock.Anything, int64(2)).Return(nil).Once()
+
+	n, err := d.DispatchDue(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, 1, first.calls)
+	assert.Equal(t, 1, second.calls)
+	assert.Equal(t, int64(1), first.messageID)
+	repo.AssertExpectations(t)
+}
+
+func TestDispatcher_DispatchDue_RetriesOnlyFailedConsumers(t *testing.T) {
+	repo := new(mockRepo)
+	d := NewDispatcher(repo, localHub{pgnotify.NewBroker()})
+	done, ok, failing := &countingConsumer{}, &countingConsumer{}, &countingConsumer{err: errors.New("unavailable")}
+	d.Register("chat.message_created", "gitlab", done.consume)
+	d.Register("chat.message_created", "mattermost", ok.consume)
+	d.Register("chat.message_created", "email", failing.consume)
+
+	repo.On("ClaimDue", mock.Anything, BatchSize, claimLease).Return([]postgres.OutboxMessage{
+		{ID: 5, Topic: "chat.message_created", Payload: postgres.JSON(`{}`), Attempts: 2, Completed: postgres.StringArray{"gitlab"}},
+	}, nil).Once()
+	repo.On("RecordFailure", mock.Anything, int64(5), []string{"gitlab", "mattermost"}, StatusPending, "email: unavailable", Backoff(3)).
+		Return(nil).Once()
+
+	_, err := d.DispatchDue(context.Background())
+
+	require.NoError(t, err)
+	assert.Zero(t, done.calls)
+	assert.Equal(t, 1, ok.calls)
+	assert.Equal(t, 1, failing.calls)
+	repo.AssertExpectations(t)
+}
+
+func TestDispatcher_DispatchDue_GivesUpAfterMaxAttempts(t *testing.T) {
+	repo := new(mockRepo)
+	d := NewDispatcher(repo, localHub{pgnotify.NewBroker()})
+	d.Register("ticket.changed", "gitlab", (&countingConsumer{err: errors.New("boom")}).consume)
+
+	repo.On("ClaimDue", mock.Anything, BatchSize, claimLease).Return([]postgres.OutboxMessage{
+		{ID: 6, Topic: "ticket.changed", Payload: postgres.JSON(`{}`), Attempts: MaxAttempts - 1},
+	}, nil).Once()
+	repo.On("RecordFailure", mock.Anything, int64(6), []string(nil), StatusDead, "gitlab: boom", mock.Anything).Return(nil).Once()
+	repo.On("Stats", mock.Anything).Return(&Stats{Pending: 3, Dead: 1, LagSeconds: 12.5}, nil).Once()
+
+	_, err := d.DispatchDue(context.Background())
+	require.NoError(t, err)
+
+	m, err := d.Metrics(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, Metrics{Stats: Stats{Pending: 3, Dead: 1, LagSeconds: 12.5}, Failures: 1}, *m)
+	repo.AssertExpectations(t)
+}
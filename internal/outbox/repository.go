@@ -0,0 +1,130 @@
+package outbox
+
+import (
+	"context"
+	"innotech/internal/storage/postgres"
+	"innotech/pkg/db"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Message statuses from outbox_status_enum.
+const (
+	StatusPending = "pending"
+	StatusDone    = "done"
+	StatusDead    = "dead"
+)
+
+// Stats describes the backlog of the outbox.
+type Stats struct {
+	Pending    int     `db:"pending" json:"pending"`
+	Dead       int     `db:"dead" json:"dead"`
+	LagSeconds float64 `db:"lag_seconds" json:"lag_seconds"`
+}
+
+// Repository defines the interface for outbox data access operations.
+type Repository interface {
+	Add(ctx context.Context, topic string, payload []byte) (int64, error)
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]postgres.OutboxMessage, error)
+	MarkDone(ctx context.Context, id int64) error
+	RecordFailure(ctx context.Context, id int64, completed []string, status, errMsg string, retryIn time.Duration) error
+	Stats(ctx context.Context) (*Stats, error)
+	Prune(ctx context.Context, olderThan time.Duration) (int64, error)
+}
+
+type repository struct {
+	db *sqlx.DB
+}
+
+// NewRepository creates a new Repository instance.
+func NewRepository(db *sqlx.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) Add(ctx context.Context, topic string, payload []byte) (int64, error) {
+	var id int64
+	err := db.Conn(ctx, r.db).QueryRowxContext(ctx,
+		`INSERT INTO outbox (topic, payload) VALUES ($1, $2) RETURNING id`,
+		topic, string(payload),
+	).Scan(&id)
+	return id, err
+}
+
+// ClaimDue leases up to limit due messages, oldest first, by pushing their
+// next attempt past the lease. Concurrent dispatchers skip rows another one
+// has locked, and the messages of a crashed dispatcher become due again once
+// the lease runs out.
+func (r *repository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]postgres.OutboxMessage, error) {
+	query := `
+		UPDATE outbox
+		SET next_attempt_at = NOW() + make_interval(secs => $2)
+		WHERE id IN (
+			SELECT id FROM outbox
+			WHERE status = 'pending' AND next_attempt_at <= NOW()
+			ORDER BY id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *
+	`
+	list := []postgres.OutboxMessage{}
+	if err := db.Conn(ctx, r.db).SelectContext(ctx, &list, query, limit, lease.Seconds()); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *repository) MarkDone(ctx context.Context, id int64) error {
+	_, err := db.Conn(ctx, r.db).ExecContext(ctx,
+		`UPDATE outbox SET status = 'done', attempts = attempts + 1, last_error = NULL, date_processed = NOW() WHERE id = $1`,
+		id,
+	)
+	return err
+}
+
+// RecordFailure stores the consumers that already handled the message so that
+// only the remaining ones run on the next attempt.
+func (r *repository) RecordFailure(ctx context.Context, id int64, completed []string, status, errMsg string, retryIn time.Duration) error {
+	query := `
+		UPDATE outbox
+		SET status = $2::outbox_status_enum,
+		    completed = $3,
+		    attempts = attempts + 1,
+		    last_error = $4,
+		    next_attempt_at = NOW() + make_interval(secs => $5)
+		WHERE id = $1
+	`
+	_, err := db.Conn(ctx, r.db).ExecContext(ctx, query, id, status, postgres.StringArray(completed), errMsg, retryIn.Seconds())
+	return err
+}
+
+// Stats counts undelivered messages; the lag is the age of the oldest
+// pending one.
+func (r *repository) Stats(ctx context.Context) (*Stats, error) {
+	query := `
+		SELECT
+			COUNT(*) FILTER (WHERE status = 'pending') AS pending,
+			COUNT(*) FILTER (WHERE status = 'dead') AS dead,
+			COALESCE(EXTRACT(EPOCH FROM NOW() - MIN(date_created) FILTER (WHERE status = 'pending')), 0)::float8 AS lag_seconds
+		FROM outbox
+		WHERE status <> 'done'
+	`
+	var s Stats
+	if err := db.Conn(ctx, r.db).GetContext(ctx, &s, query); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Prune deletes delivered messages processed more than olderThan ago.
+func (r *repository) Prune(ctx context.Context, olderThan time.Duration) (int64, error) {
+	res, err := db.Conn(ctx, r.db).ExecContext(ctx,
+		`DELETE FROM outbox WHERE status = 'done' AND date_processed < NOW() - make_interval(secs => $1)`,
+		olderThan.Seconds(),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
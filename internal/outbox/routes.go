@@ -0,0 +1,12 @@
+package outbox
+
+import (
+	"innotech/internal/access"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RegisterRoutes registers HTTP routes for outbox monitoring.
+func RegisterRoutes(app *fiber.App, h *Handler, guard *access.Guard) {
+	app.Get("/api/outbox/metrics", guard.Superuser(), h.Metrics)
+}
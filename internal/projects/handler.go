@@ -2,6 +2,7 @@
 package projects
 
 import (
+	"innotech/internal/access"
 	"innotech/internal/storage/postgres"
 	"innotech/internal/storage/transport"
 	"innotech/pkg/logger"
@@ -46,7 +47,7 @@ func (h *Handler) Create(c *fiber.Ctx) error {
 		"path", c.Path(),
 	)
 
-	if err := h.service.Create(c.Context(), &p); err != nil {
+	if err := h.service.Create(c.UserContext(), &p); err != nil {
 		logger.Error("handler: create project failed",
 			"error", err.Error(),
 			"name", p.Name,
@@ -82,7 +83,7 @@ func (h *Handler) GetByID(c *fiber.Ctx) error {
 
 	logger.Debug("handler: get project by id", "id", id)
 
-	p, err := h.service.GetByID(c.Context(), id)
+	p, err := h.service.GetByID(c.UserContext(), id)
 	if err != nil {
 		logger.Error("handler: get by id failed",
 			"id", id,
@@ -109,13 +110,14 @@ func (h *Handler) GetByID(c *fiber.Ctx) error {
 func (h *Handler) GetAll(c *fiber.Ctx) error {
 	logger.Debug("handler: get all projects")
 
-	ps, err := h.service.GetAll(c.Context())
+	ps, err := h.service.GetAll(c.UserContext())
 	if err != nil {
 		logger.Error("handler: get all failed",
 			"error", err.Error(),
 		)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
+	ps = access.Filter(access.ScopeFrom(c), ps, func(p postgres.Project) int { return p.ID })
 
 	logger.Info("handler: projects list retrieved",
 		"count", len(ps),
@@ -158,7 +160,7 @@ func (h *Handler) Update(c *fiber.Ctx) error {
 		"name", p.Name,
 	)
 
-	if err := h.service.Update(c.Context(), &p); err != nil {
+	if err := h.service.Update(c.UserContext(), &p); err != nil {
 		logger.Error("handler: update project failed",
 			"id", p.ID,
 			"error", err.Error(),
@@ -193,7 +195,7 @@ func (h *Handler) Delete(c *fiber.Ctx) error {
 
 	logger.Warn("handler: delete project request", "id", id)
 
-	if err := h.service.Delete(c.Context(), id); err != nil {
+	if err := h.service.Delete(c.UserContext(), id); err != nil {
 		logger.Error("handler: delete project failed",
 			"id", id,
 			"error", err.Error(),
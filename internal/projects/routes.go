@@ -1,6 +1,7 @@
 package projects
 
 import (
+	"innotech/internal/access"
 	"innotech/internal/storage/transport"
 	"innotech/pkg/logger"
 	"innotech/pkg/middleware"
@@ -9,7 +10,7 @@ import (
 )
 
 // RegisterRoutes registers HTTP routes for project operations.
-func RegisterRoutes(app *fiber.App, h *Handler) {
+func RegisterRoutes(app *fiber.App, h *Handler, guard *access.Guard) {
 	api := app.Group("/api/projects")
 
 	// Middleware для логирования всех запросов
@@ -23,11 +24,11 @@ func RegisterRoutes(app *fiber.App, h *Handler) {
 		return c.Next()
 	})
 
-	api.Get("/", h.GetAll)
-	api.Get("/:id", h.GetByID)
-	api.Post("/", middleware.ValidateBody[transport.CreateProjectDTO](h.Create))
-	api.Put("/:id", middleware.ValidateBody[transport.UpdateProjectDTO](h.Update))
-	api.Delete("/:id", h.Delete)
+	api.Get("/", guard.Scoped(access.ProjectRead), h.GetAll)
+	api.Get("/:id", guard.Require(access.ProjectRead, guard.Param("id", access.ResourceProject)), h.GetByID)
+	api.Post("/", guard.Superuser(), middleware.ValidateBody[transport.CreateProjectDTO](h.Create))
+	api.Put("/:id", guard.Require(access.ProjectManage, guard.Param("id", access.ResourceProject)), middleware.ValidateBody[transport.UpdateProjectDTO](h.Update))
+	api.Delete("/:id", guard.Require(access.ProjectManage, guard.Param("id", access.ResourceProject)), h.Delete)
 
 	logger.Info("project routes registration completed",
 		"total_routes", 5,
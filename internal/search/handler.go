@@ -0,0 +1,70 @@
+// Package search provides full-text search over tickets and chat messages.
+package search
+
+import (
+	"errors"
+	"innotech/internal/access"
+	"innotech/internal/storage/transport"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Handler handles HTTP requests for search operations.
+type Handler struct {
+	service Service
+}
+
+// NewHandler creates a new Handler instance.
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+// Search godoc
+// @Summary полнотекстовый поиск по тикетам и сообщениям
+// @Tags Search
+// @Produce json
+// @Security BearerAuth
+// @Param q query string true "Search text (websearch syntax)"
+// @Param kind query string false "Limit to ticket or chat hits"
+// @Param lang query string false "Text search language (ru, en); defaults to Accept-Language"
+// @Param limit query int false "Page size" default(20)
+// @Param offset query int false "Offset" default(0)
+// @Success 200 {array} search.Hit
+// @Failure 400 {object} map[string]string
+// @Router /search [get]
+func (h *Handler) Search(c *fiber.Ctx) error {
+	dto := c.Locals("query").(*transport.SearchQueryDTO)
+
+	lang := dto.Lang
+	if lang == "" {
+		lang = requestLang(c)
+	}
+
+	hits, err := h.service.Search(c.UserContext(), Query{
+		Text:           dto.Q,
+		Lang:           lang,
+		IncludeTickets: dto.Kind == "" || dto.Kind == KindTicket,
+		IncludeChats:   dto.Kind == "" || dto.Kind == KindChat,
+		Limit:          dto.Limit,
+		Offset:         dto.Offset,
+	})
+	if err != nil {
+		if errors.Is(err, access.ErrUnauthenticated) {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(hits)
+}
+
+// requestLang maps the locale negotiated by the i18n middleware to a search language.
+func requestLang(c *fiber.Ctx) string {
+	tag, _ := c.Locals("language").(string)
+	if code, _, _ := strings.Cut(tag, "-"); code != "" {
+		if _, ok := Languages[code]; ok {
+			return code
+		}
+	}
+	return DefaultLang
+}
@@ -0,0 +1,66 @@
+package search
+
+import (
+	"encoding/json"
+	"innotech/internal/access"
+	"innotech/pkg/logger"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	logger.Init()
+	os.Exit(m.Run())
+}
+
+func TestHandler_Search_ReturnsEscapedSnippets(t *testing.T) {
+	repo := new(mockRepo)
+	app := fiber.New()
+	RegisterRoutes(app, NewHandler(NewService(repo, stubScopes{
+		access.TicketRead: {ProjectIDs: []int{1}},
+		access.ChatRead:   {ProjectIDs: []int{1}},
+	})))
+
+	text := `<img src=x onerror=alert(1)> invoice`
+	snippet := `&lt;img src=x onerror=alert(1)&gt; <mark>invoice</mark>`
+	repo.On("Search", mock.Anything, mock.MatchedBy(func(q Query) bool {
+		return q.Text == text && q.Lang == "en" && q.IncludeTickets && !q.IncludeChats
+	})).Return([]Hit{{Kind: KindTicket, TicketID: 5, ProjectID: 1, Snippet: snippet}}, nil).Once()
+
+	query := url.Values{"q": {text}, "lang": {"en"}, "kind": {KindTicket}}
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/search?"+query.Encode(), nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	var hits []Hit
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&hits))
+	require.Len(t, hits, 1)
+	assert.Equal(t, snippet, hits[0].Snippet)
+	repo.AssertExpectations(t)
+}
+
+func TestHandler_Search_ValidatesQuery(t *testing.T) {
+	repo := new(mockRepo)
+	app := fiber.New()
+	RegisterRoutes(app, NewHandler(NewService(repo, stubScopes{})))
+
+	get := func(query url.Values) int {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/search?"+query.Encode(), nil))
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, fiber.StatusBadRequest, get(url.Values{}))
+	assert.Equal(t, fiber.StatusBadRequest, get(url.Values{"q": {"x"}}))
+	assert.Equal(t, fiber.StatusBadRequest, get(url.Values{"q": {"invoice"}, "kind": {"file"}}))
+	assert.Equal(t, fiber.StatusBadRequest, get(url.Values{"q": {"invoice"}, "lang": {"de"}}))
+	repo.AssertNotCalled(t, "Search", mock.Anything, mock.Anything)
+}
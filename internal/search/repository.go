@@ -0,0 +1,150 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"innotech/internal/access"
+	"innotech/pkg/db"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Hit kinds.
+const (
+	KindTicket = "ticket"
+	KindChat   = "chat"
+)
+
+// Hit is a single ranked search result.
+type Hit struct {
+	Kind        string    `db:"kind" json:"kind"`
+	TicketID    int       `db:"ticket_id" json:"ticket_id"`
+	ChatID      *int      `db:"chat_id" json:"chat_id,omitempty"`
+	ProjectID   int       `db:"project_id" json:"project_id"`
+	Title       string    `db:"title" json:"title"`
+	Snippet     string    `db:"snippet" json:"snippet"`
+	Rank        float64   `db:"rank" json:"rank"`
+	DateCreated time.Time `db:"date_created" json:"date_created"`
+}
+
+// Language selects the text search configuration and the matching tsvector
+// column for one of the supported i18n locales.
+type Language struct {
+	Config string
+	Column string
+}
+
+// Languages maps locale codes to their search configuration.
+var Languages = map[string]Language{
+	"ru": {Config: "russian", Column: "search_ru"},
+	"en": {Config: "english", Column: "search_en"},
+}
+
+// Query describes a search request. A kind whose scope allows no projects is
+// left out of the results.
+type Query struct {
+	Text           string
+	Lang           string
+	IncludeTickets bool
+	IncludeChats   bool
+	TicketScope    access.Scope
+	ChatScope      access.Scope
+	Limit          int
+	Offset         int
+}
+
+// Repository defines the interface for full-text search operations.
+type Repository interface {
+	Search(ctx context.Context, q Query) ([]Hit, error)
+}
+
+type repository struct {
+	db *sqlx.DB
+}
+
+// NewRepository creates a new Repository instance.
+func NewRepository(db *sqlx.DB) Repository {
+	return &repository{db: db}
+}
+
+// headlineOptions marks matched terms in snippets.
+const headlineOptions = "StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=30, MinWords=10"
+
+// escapedBody HTML-escapes the text snippets are cut from, so that the only
+// markup in a snippet is the one added by ts_headline. The text search parser
+// keeps entities as tokens of their own, which leaves matching unaffected.
+const escapedBody = `replace(replace(replace(replace(replace(h.body, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&quot;'), '''', '&#39;')`
+
+func (r *repository) Search(ctx context.Context, q Query) ([]Hit, error) {
+	hits := []Hit{}
+
+	query, args := searchQuery(q)
+	if query == "" {
+		return hits, nil
+	}
+	if err := db.Conn(ctx, r.db).SelectContext(ctx, &hits, query, args...); err != nil {
+		return nil, err
+	}
+	return hits, nil
+}
+
+// searchQuery builds a ranked union over tickets and chat messages. Snippets
+// are only highlighted for the rows of the requested page and are safe to
+// render as HTML.
+func searchQuery(q Query) (string, []any) {
+	lang, ok := Languages[q.Lang]
+	if !ok {
+		return "", nil
+	}
+
+	args := []any{lang.Config, q.Text}
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	scope := func(s access.Scope) string {
+		if s.All {
+			return ""
+		}
+		ph := make([]string, len(s.ProjectIDs))
+		for i, id := range s.ProjectIDs {
+			ph[i] = arg(id)
+		}
+		return " AND t.project_id IN (" + strings.Join(ph, ", ") + ")"
+	}
+	column := lang.Column
+
+	var parts []string
+	if q.IncludeTickets && (q.TicketScope.All || len(q.TicketScope.ProjectIDs) > 0) {
+		parts = append(parts, `
+			SELECT 'ticket' AS kind, t.id AS ticket_id, NULL::int AS chat_id, t.project_id, t.title,
+			       coalesce(t.message, '') AS body, ts_rank(t.`+column+`, q.query) AS rank, t.date_created
+			FROM tickets t, q
+			WHERE t.`+column+` @@ q.query`+scope(q.TicketScope))
+	}
+	if q.IncludeChats && (q.ChatScope.All || len(q.ChatScope.ProjectIDs) > 0) {
+		parts = append(parts, `
+			SELECT 'chat' AS kind, c.ticket_id, c.id AS chat_id, t.project_id, t.title,
+			       c.message AS body, ts_rank(c.`+column+`, q.query) AS rank, c.date_created
+			FROM ticket_chats c JOIN tickets t ON t.id = c.ticket_id, q
+			WHERE c.`+column+` @@ q.query`+scope(q.ChatScope))
+	}
+	if len(parts) == 0 {
+		return "", nil
+	}
+
+	query := `
+		WITH q AS (SELECT websearch_to_tsquery($1::regconfig, $2) AS query),
+		hits AS (` + strings.Join(parts, "\n\t\t\tUNION ALL") + `
+			ORDER BY rank DESC, date_created DESC
+			LIMIT ` + arg(q.Limit) + ` OFFSET ` + arg(q.Offset) + `
+		)
+		SELECT h.kind, h.ticket_id, h.chat_id, h.project_id, h.title,
+		       ts_headline($1::regconfig, ` + escapedBody + `, q.query, '` + headlineOptions + `') AS snippet,
+		       h.rank, h.date_created
+		FROM hits h, q
+		ORDER BY h.rank DESC, h.date_created DESC`
+	return query, args
+}
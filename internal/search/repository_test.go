@@ -0,0 +1,65 @@
+package search
+
+import (
+	"context"
+	"innotech/internal/access"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepository_Search_UnionsScopedTicketsAndChats(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = mockDB.Close() }()
+
+	repo := NewRepository(sqlx.NewDb(mockDB, "sqlmock"))
+
+	rows := sqlmock.NewRows([]string{"kind", "ticket_id", "chat_id", "project_id", "title", "snippet", "rank", "date_created"}).
+		AddRow("chat", 4, 9, 2, "Invoice", "the <mark>invoice</mark> is late", 0.6, time.Now())
+	mock.ExpectQuery(`websearch_to_tsquery\(\$1::regconfig, \$2\).*FROM tickets t, q\s+WHERE t.search_en @@ q.query AND t.project_id IN \(\$3, \$4\).*UNION ALL.*WHERE c.search_en @@ q.query AND t.project_id IN \(\$5\).*LIMIT \$6 OFFSET \$7.*ts_headline\(\$1::regconfig, replace\(.*h.body, '&', '&amp;'\), '<', '&lt;'\)`).
+		WithArgs("english", "invoice", 1, 2, 2, 20, 0).
+		WillReturnRows(rows)
+
+	hits, err := repo.Search(context.Background(), Query{
+		Text:           "invoice",
+		Lang:           "en",
+		IncludeTickets: true,
+		IncludeChats:   true,
+		TicketScope:    access.Scope{ProjectIDs: []int{1, 2}},
+		ChatScope:      access.Scope{ProjectIDs: []int{2}},
+		Limit:          20,
+	})
+
+	require.NoError(t, err)
+	require.Len(t, hits, 1)
+	assert.Equal(t, KindChat, hits[0].Kind)
+	assert.Equal(t, 9, *hits[0].ChatID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_Search_NoVisibleProjectsSkipsQuery(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = mockDB.Close() }()
+
+	repo := NewRepository(sqlx.NewDb(mockDB, "sqlmock"))
+
+	hits, err := repo.Search(context.Background(), Query{
+		Text:           "invoice",
+		Lang:           "ru",
+		IncludeTickets: true,
+		IncludeChats:   true,
+		TicketScope:    access.Scope{ProjectIDs: []int{}},
+		ChatScope:      access.Scope{ProjectIDs: []int{}},
+		Limit:          20,
+	})
+
+	require.NoError(t, err)
+	assert.Empty(t, hits)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
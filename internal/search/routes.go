@@ -0,0 +1,16 @@
+package search
+
+import (
+	"innotech/internal/storage/transport"
+	"innotech/pkg/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RegisterRoutes registers HTTP routes for search operations. Results are
+// scoped to the caller's projects by the service.
+func RegisterRoutes(app *fiber.App, h *Handler) {
+	api := app.Group("/api/search")
+
+	api.Get("/", middleware.ValidateQuery[transport.SearchQueryDTO](h.Search))
+}
@@ -0,0 +1,59 @@
+package search
+
+import (
+	"context"
+	"innotech/internal/access"
+)
+
+// Result paging limits.
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+	DefaultLang  = "ru"
+)
+
+// ScopeResolver returns the projects the caller in ctx holds perm in.
+type ScopeResolver interface {
+	Scope(ctx context.Context, perm access.Permission) (access.Scope, error)
+}
+
+// Service defines the interface for full-text search business logic.
+type Service interface {
+	Search(ctx context.Context, q Query) ([]Hit, error)
+}
+
+type service struct {
+	repo   Repository
+	scopes ScopeResolver
+}
+
+// NewService creates a new Service instance.
+func NewService(repo Repository, scopes ScopeResolver) Service {
+	return &service{repo: repo, scopes: scopes}
+}
+
+// Search limits tickets to projects the caller may read tickets in and chat
+// messages to projects the caller may read chats in.
+func (s *service) Search(ctx context.Context, q Query) ([]Hit, error) {
+	if _, ok := Languages[q.Lang]; !ok {
+		q.Lang = DefaultLang
+	}
+	if q.Limit <= 0 {
+		q.Limit = DefaultLimit
+	}
+	q.Limit = min(q.Limit, MaxLimit)
+	q.Offset = max(q.Offset, 0)
+
+	var err error
+	if q.IncludeTickets {
+		if q.TicketScope, err = s.scopes.Scope(ctx, access.TicketRead); err != nil {
+			return nil, err
+		}
+	}
+	if q.IncludeChats {
+		if q.ChatScope, err = s.scopes.Scope(ctx, access.ChatRead); err != nil {
+			return nil, err
+		}
+	}
+	return s.repo.Search(ctx, q)
+}
@@ -0,0 +1,59 @@
+package search
+
+import (
+	"context"
+	"innotech/internal/access"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type mockRepo struct {
+	mock.Mock
+}
+
+func (m *mockRepo) Search(ctx context.Context, q Query) ([]Hit, error) {
+	args := m.Called(ctx, q)
+	return args.Get(0).([]Hit), args.Error(1)
+}
+
+type stubScopes map[access.Permission]access.Scope
+
+func (s stubScopes) Scope(_ context.Context, perm access.Permission) (access.Scope, error) {
+	return s[perm], nil
+}
+
+func TestService_Search_ScopesEachKindByItsPermission(t *testing.T) {
+	repo := new(mockRepo)
+	svc := NewService(repo, stubScopes{
+		access.TicketRead: {ProjectIDs: []int{1, 2}},
+		access.ChatRead:   {ProjectIDs: []int{2}},
+	})
+
+	repo.On("Search", mock.Anything, mock.MatchedBy(func(q Query) bool {
+		return assert.ObjectsAreEqual([]int{1, 2}, q.TicketScope.ProjectIDs) &&
+			assert.ObjectsAreEqual([]int{2}, q.ChatScope.ProjectIDs)
+	})).Return([]Hit{{Kind: KindTicket, TicketID: 5}}, nil).Once()
+
+	hits, err := svc.Search(context.Background(), Query{Text: "оплата", Lang: "ru", IncludeTickets: true, IncludeChats: true})
+
+	require.NoError(t, err)
+	assert.Len(t, hits, 1)
+	repo.AssertExpectations(t)
+}
+
+func TestService_Search_AppliesDefaults(t *testing.T) {
+	repo := new(mockRepo)
+	svc := NewService(repo, stubScopes{})
+
+	repo.On("Search", mock.Anything, mock.MatchedBy(func(q Query) bool {
+		return q.Lang == DefaultLang && q.Limit == MaxLimit && q.Offset == 0
+	})).Return([]Hit{}, nil).Once()
+
+	_, err := svc.Search(context.Background(), Query{Text: "x", Lang: "de", Limit: 1000, Offset: -3})
+
+	require.NoError(t, err)
+	repo.AssertExpectations(t)
+}
@@ -0,0 +1,141 @@
+// Package sla tracks response and resolution deadlines of tickets under the
+// SLA policies of their contracts.
+package sla
+
+import (
+	"errors"
+	"fmt"
+	"innotech/internal/storage/postgres"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const minutesPerDay = 24 * 60
+
+// maxDays bounds the search for business time, so that a schedule made of
+// holidays alone cannot loop forever.
+const maxDays = 3660
+
+// ErrInvalidSchedule is returned for business hours or time zones that
+// cannot be used.
+var ErrInvalidSchedule = errors.New("invalid business hours")
+
+// window is a span of working time in minutes since midnight.
+type window struct {
+	from, to int
+}
+
+// Calendar measures business time under a policy: the working hours of its
+// weekly schedule in its time zone, except on holidays.
+type Calendar struct {
+	loc      *time.Location
+	week     [7][]window
+	holidays map[string]bool
+}
+
+// NewCalendar creates the Calendar of p.
+func NewCalendar(p *postgres.SLAPolicy) (*Calendar, error) {
+	loc, err := time.LoadLocation(p.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unknown time zone %q", ErrInvalidSchedule, p.Timezone)
+	}
+	c := &Calendar{loc: loc, holidays: make(map[string]bool, len(p.Holidays))}
+	if len(p.BusinessHours) == 0 {
+		for d := range c.week {
+			c.week[d] = []window{{0, minutesPerDay}}
+		}
+	}
+	for _, h := range p.BusinessHours {
+		from, errFrom := parseClock(h.From)
+		to, errTo := parseClock(h.To)
+		if h.Day < 0 || h.Day > 6 || errFrom != nil || errTo != nil || from >= to {
+			return nil, fmt.Errorf("%w: day %d from %q to %q", ErrInvalidSchedule, h.Day, h.From, h.To)
+		}
+		c.week[h.Day] = append(c.week[h.Day], window{from, to})
+	}
+	for d, day := range c.week {
+		slices.SortFunc(day, func(a, b window) int { return a.from - b.from })
+		for i := 1; i < len(day); i++ {
+			if day[i].from < day[i-1].to {
+				return nil, fmt.Errorf("%w: overlapping hours on day %d", ErrInvalidSchedule, d)
+			}
+		}
+	}
+	for _, h := range p.Holidays {
+		c.holidays[h.Day] = true
+	}
+	return c, nil
+}
+
+// parseClock parses a time of day such as "09:30" or "24:00" into minutes
+// since midnight.
+func parseClock(s string) (int, error) {
+	hh, mm, ok := strings.Cut(s, ":")
+	h, errH := strconv.Atoi(hh)
+	m, errM := strconv.Atoi(mm)
+	if !ok || len(hh) != 2 || len(mm) != 2 || errH != nil || errM != nil || m > 59 || h*60+m > minutesPerDay {
+		return 0, fmt.Errorf("invalid time of day %q", s)
+	}
+	return h*60 + m, nil
+}
+
+// Add returns the moment d of business time after start, in UTC. A zero d
+// gives the first business moment at or after start.
+func (c *Calendar) Add(start time.Time, d time.Duration) time.Time {
+	t := start.In(c.loc)
+	for range maxDays {
+		day := c.at(t, 0)
+		if !c.holidays[day.Format(time.DateOnly)] {
+			for _, w := range c.week[day.Weekday()] {
+				from, to := c.at(day, w.from), c.at(day, w.to)
+				if !to.After(t) {
+					continue
+				}
+				if from.Before(t) {
+					from = t
+				}
+				avail := to.Sub(from)
+				if d <= avail {
+					return from.Add(d).UTC()
+				}
+				d -= avail
+			}
+		}
+		t = c.at(day, minutesPerDay)
+	}
+	return start.Add(d).UTC()
+}
+
+// Between returns the business time from from to to.
+func (c *Calendar) Between(from, to time.Time) time.Duration {
+	var total time.Duration
+	t := from.In(c.loc)
+	for i := 0; t.Before(to) && i < maxDays; i++ {
+		day := c.at(t, 0)
+		if !c.holidays[day.Format(time.DateOnly)] {
+			for _, w := range c.week[day.Weekday()] {
+				lo, hi := c.at(day, w.from), c.at(day, w.to)
+				if lo.Before(t) {
+					lo = t
+				}
+				if hi.After(to) {
+					hi = to
+				}
+				if hi.After(lo) {
+					total += hi.Sub(lo)
+				}
+			}
+		}
+		t = c.at(day, minutesPerDay)
+	}
+	return total
+}
+
+// at returns the given minute of the calendar day of t. Minute 1440 is the
+// next midnight.
+func (c *Calendar) at(t time.Time, minute int) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, minute/60, minute%60, 0, 0, c.loc)
+}
@@ -0,0 +1,102 @@
+package sla
+
+import (
+	"innotech/internal/storage/postgres"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var moscow = time.FixedZone("MSK", 3*60*60)
+
+// officePolicy works 09:00-18:00 Moscow time on weekdays, with New Year's
+// Friday off. 1 January 2026 is a Thursday.
+func officePolicy() *postgres.SLAPolicy {
+	p := &postgres.SLAPolicy{
+		Timezone: "Europe/Moscow",
+		Holidays: []postgres.SLAHoliday{{Day: "2026-01-02"}},
+	}
+	for day := 1; day <= 5; day++ {
+		p.BusinessHours = append(p.BusinessHours, postgres.BusinessWindow{Day: day, From: "09:00", To: "18:00"})
+	}
+	return p
+}
+
+func TestCalendar_Add_SkipsNightsWeekendsAndHolidays(t *testing.T) {
+	cal, err := NewCalendar(officePolicy())
+	require.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		start time.Time
+		d     time.Duration
+		want  time.Time
+	}{
+		{"within the day", time.Date(2026, 1, 1, 10, 0, 0, 0, moscow), time.Hour, time.Date(2026, 1, 1, 11, 0, 0, 0, moscow)},
+		{"ends at closing", time.Date(2026, 1, 1, 17, 0, 0, 0, moscow), time.Hour, time.Date(2026, 1, 1, 18, 0, 0, 0, moscow)},
+		{"over holiday and weekend", time.Date(2026, 1, 1, 17, 0, 0, 0, moscow), 2 * time.Hour, time.Date(2026, 1, 5, 10, 0, 0, 0, moscow)},
+		{"before opening", time.Date(2026, 1, 5, 7, 30, 0, 0, moscow), 30 * time.Minute, time.Date(2026, 1, 5, 9, 30, 0, 0, moscow)},
+		{"zero on a weekend", time.Date(2026, 1, 3, 12, 0, 0, 0, moscow), 0, time.Date(2026, 1, 5, 9, 0, 0, 0, moscow)},
+		{"several days", time.Date(2026, 1, 5, 9, 0, 0, 0, moscow), 20 * time.Hour, time.Date(2026, 1, 7, 11, 0, 0, 0, moscow)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := cal.Add(tt.start, tt.d)
+			assert.True(t, tt.want.Equal(got), "want %s, got %s", tt.want, got)
+			assert.Equal(t, time.UTC, got.Location())
+		})
+	}
+}
+
+func TestCalendar_Between_CountsBusinessTimeOnly(t *testing.T) {
+	cal, err := NewCalendar(officePolicy())
+	require.NoError(t, err)
+
+	assert.Equal(t, 2*time.Hour, cal.Between(
+		time.Date(2026, 1, 1, 17, 0, 0, 0, moscow),
+		time.Date(2026, 1, 5, 10, 0, 0, 0, moscow),
+	))
+	assert.Equal(t, time.Duration(0), cal.Between(
+		time.Date(2026, 1, 2, 10, 0, 0, 0, moscow),
+		time.Date(2026, 1, 4, 23, 0, 0, 0, moscow),
+	))
+	assert.Equal(t, time.Duration(0), cal.Between(
+		time.Date(2026, 1, 5, 12, 0, 0, 0, moscow),
+		time.Date(2026, 1, 5, 11, 0, 0, 0, moscow),
+	))
+}
+
+func TestCalendar_RoundTheClock(t *testing.T) {
+	cal, err := NewCalendar(&postgres.SLAPolicy{Timezone: "UTC"})
+	require.NoError(t, err)
+
+	start := time.Date(2026, 1, 3, 23, 30, 0, 0, time.UTC)
+	assert.Equal(t, start.Add(90*time.Minute), cal.Add(start, 90*time.Minute))
+	assert.Equal(t, 90*time.Minute, cal.Between(start, start.Add(90*time.Minute)))
+}
+
+func TestNewCalendar_RejectsUnusableSchedules(t *testing.T) {
+	tests := []struct {
+		name  string
+		tz    string
+		hours postgres.BusinessHours
+	}{
+		{"unknown zone", "Mars/Olympus", nil},
+		{"ends before start", "UTC", postgres.BusinessHours{{Day: 1, From: "18:00", To: "09:00"}}},
+		{"past midnight", "UTC", postgres.BusinessHours{{Day: 1, From: "09:00", To: "25:00"}}},
+		{"not a time", "UTC", postgres.BusinessHours{{Day: 1, From: "9am", To: "18:00"}}},
+		{"no such day", "UTC", postgres.BusinessHours{{Day: 7, From: "09:00", To: "18:00"}}},
+		{"overlap", "UTC", postgres.BusinessHours{{Day: 1, From: "09:00", To: "13:00"}, {Day: 1, From: "12:00", To: "18:00"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewCalendar(&postgres.SLAPolicy{Timezone: tt.tz, BusinessHours: tt.hours})
+			assert.ErrorIs(t, err, ErrInvalidSchedule)
+		})
+	}
+
+	_, err := NewCalendar(&postgres.SLAPolicy{Timezone: "UTC", BusinessHours: postgres.BusinessHours{{Day: 0, From: "20:00", To: "24:00"}}})
+	assert.NoError(t, err, "24:00 closes the day")
+}
@@ -0,0 +1,191 @@
+package sla
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"innotech/internal/storage/postgres"
+	"innotech/internal/tickets"
+	"slices"
+	"time"
+)
+
+// pausedStatuses stop the clock: the ticket waits on the client, or is done
+// unless it is reopened.
+var pausedStatuses = []string{tickets.StatusWaiting, tickets.StatusResolved, tickets.StatusClosed}
+
+// doneStatuses meet the resolution deadline.
+var doneStatuses = []string{tickets.StatusResolved, tickets.StatusClosed}
+
+// Clock keeps the deadlines of tickets under the SLA policies of their
+// contracts and the priorities of tickets. It implements tickets.SLATracker.
+type Clock struct {
+	repo Repository
+	now  func() time.Time
+}
+
+// NewClock creates a Clock.
+func NewClock(repo Repository) *Clock {
+	return &Clock{repo: repo, now: time.Now}
+}
+
+// Start sets the deadlines of a new ticket from the targets for its
+// priority. Tickets whose contract has no policy, or whose policy has no
+// target for their priority, get none.
+func (c *Clock) Start(ctx context.Context, t *postgres.Ticket) error {
+	p, err := c.repo.ForContract(ctx, t.ContractID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	target, ok := targetFor(p, t.Priority)
+	if !ok {
+		return nil
+	}
+	cal, err := NewCalendar(p)
+	if err != nil {
+		return err
+	}
+
+	now := c.now().UTC()
+	s := postgres.TicketSLA{SLAPolicyID: &p.ID}
+	s.FirstResponseDue, s.FirstResponseWarnAt = deadline(cal, now, minutes(target.FirstResponseMinutes), 0, p.WarningPercent)
+	s.ResolveDue, s.ResolveWarnAt = deadline(cal, now, minutes(target.ResolutionMinutes), 0, p.WarningPercent)
+	s.FirstResponseSLA, s.ResolveSLA = ptr(StateOnTrack), ptr(StateOnTrack)
+	if err := c.repo.SetTicketClock(ctx, t.ID, &s); err != nil {
+		return err
+	}
+	t.TicketSLA = s
+	return nil
+}
+
+// TicketChanged carries the deadlines of before over to after and moves
+// them along with the status and priority. The clock stops while the ticket
+// waits on the client or is done; when it runs again, the deadlines move out
+// by the business time that passed. Reopening a ticket restarts the
+// resolution deadline unless it was breached. A new priority switches the
+// running deadlines to its targets, counting the business time already
+// spent.
+func (c *Clock) TicketChanged(ctx context.Context, before, after *postgres.Ticket) error {
+	after.TicketSLA = before.TicketSLA
+	statusChanged := before.Status != after.Status
+	retarget := after.Priority != "" && after.Priority != before.Priority
+	if before.SLAPolicyID == nil || !statusChanged && !retarget {
+		return nil
+	}
+	p, err := c.repo.GetByID(ctx, *before.SLAPolicyID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	cal, err := NewCalendar(p)
+	if err != nil {
+		return err
+	}
+	s := &after.TicketSLA
+	now := c.now().UTC()
+
+	if statusChanged {
+		done := slices.Contains(doneStatuses, after.Status)
+		switch {
+		case done && pending(s.ResolveSLA):
+			s.ResolveSLA = ptr(StateMet)
+		case !done && s.ResolveSLA != nil && *s.ResolveSLA == StateMet:
+			s.ResolveSLA = ptr(StateOnTrack)
+		}
+
+		wasPaused, paused := slices.Contains(pausedStatuses, before.Status), slices.Contains(pausedStatuses, after.Status)
+		switch {
+		case paused && !wasPaused:
+			s.SLAPausedAt = &now
+		case !paused && wasPaused && s.SLAPausedAt != nil:
+			resume(cal, s, now)
+		}
+	}
+	if retarget {
+		from, okFrom := targetFor(p, before.Priority)
+		to, okTo := targetFor(p, after.Priority)
+		if okFrom && okTo {
+			reschedule(cal, s, from, to, p.WarningPercent, now)
+		}
+	}
+	return c.repo.SetTicketClock(ctx, after.ID, s)
+}
+
+// resume moves the running deadlines of s out by the business time between
+// the pause and now.
+func resume(cal *Calendar, s *postgres.TicketSLA, now time.Time) {
+	pausedAt := *s.SLAPausedAt
+	shift := func(at *time.Time) *time.Time {
+		if at == nil || !at.After(pausedAt) {
+			return at
+		}
+		moved := cal.Add(now, cal.Between(pausedAt, *at))
+		return &moved
+	}
+	if pending(s.FirstResponseSLA) {
+		s.FirstResponseDue, s.FirstResponseWarnAt = shift(s.FirstResponseDue), shift(s.FirstResponseWarnAt)
+	}
+	if pending(s.ResolveSLA) {
+		s.ResolveDue, s.ResolveWarnAt = shift(s.ResolveDue), shift(s.ResolveWarnAt)
+	}
+	s.SLAPausedAt = nil
+}
+
+// reschedule moves the running deadlines of s from the targets from to the
+// targets to. The business time spent so far is what the old target allowed
+// less what was left of it, as of now or of the pause.
+func reschedule(cal *Calendar, s *postgres.TicketSLA, from, to postgres.SLATarget, warningPercent int, now time.Time) {
+	ref := now
+	if s.SLAPausedAt != nil {
+		ref = *s.SLAPausedAt
+	}
+	spent := func(due *time.Time, allowed int) time.Duration {
+		return minutes(allowed) - cal.Between(ref, *due)
+	}
+	if pending(s.FirstResponseSLA) && s.FirstResponseDue != nil {
+		used := spent(s.FirstResponseDue, from.FirstResponseMinutes)
+		s.FirstResponseDue, s.FirstResponseWarnAt = deadline(cal, ref, minutes(to.FirstResponseMinutes), used, warningPercent)
+	}
+	if pending(s.ResolveSLA) && s.ResolveDue != nil {
+		used := spent(s.ResolveDue, from.ResolutionMinutes)
+		s.ResolveDue, s.ResolveWarnAt = deadline(cal, ref, minutes(to.ResolutionMinutes), used, warningPercent)
+	}
+}
+
+// deadline returns when a target allowing d is due, and when its warning
+// is due, given that spent of it was used up before start.
+func deadline(cal *Calendar, start time.Time, d, spent time.Duration, warningPercent int) (due, warn *time.Time) {
+	left := func(d time.Duration) time.Duration {
+		return max(d-spent, 0)
+	}
+	dueAt := cal.Add(start, left(d))
+	warnAt := cal.Add(start, left(d*time.Duration(warningPercent)/100))
+	return &dueAt, &warnAt
+}
+
+// targetFor returns the targets of p for priority.
+func targetFor(p *postgres.SLAPolicy, priority string) (postgres.SLATarget, bool) {
+	i := slices.IndexFunc(p.Targets, func(t postgres.SLATarget) bool { return t.Priority == priority })
+	if i < 0 {
+		return postgres.SLATarget{}, false
+	}
+	return p.Targets[i], true
+}
+
+func minutes(n int) time.Duration {
+	return time.Duration(n) * time.Minute
+}
+
+// pending reports whether a deadline in state is still running.
+func pending(state *string) bool {
+	return state != nil && (*state == StateOnTrack || *state == StateAtRisk)
+}
+
+func ptr(s string) *string {
+	return &s
+}
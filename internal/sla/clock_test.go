@@ -0,0 +1,232 @@
+package sla
+
+import (
+	"context"
+	"database/sql"
+	"innotech/internal/events"
+	"innotech/internal/storage/postgres"
+	"innotech/internal/tickets"
+	"innotech/pkg/db"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeRepo struct {
+	Repository
+	policies  map[int]*postgres.SLAPolicy
+	contracts map[int]int
+	clocks    map[int]postgres.TicketSLA
+	flagged   map[string][]Deadline
+}
+
+func (r *fakeRepo) GetByID(_ context.Context, id int) (*postgres.SLAPolicy, error) {
+	if p, ok := r.policies[id]; ok {
+		return p, nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (r *fakeRepo) ForContract(ctx context.Context, contractID int) (*postgres.SLAPolicy, error) {
+	id, ok := r.contracts[contractID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return r.GetByID(ctx, id)
+}
+
+func (r *fakeRepo) SetTicketClock(_ context.Context, ticketID int, s *postgres.TicketSLA) error {
+	r.clocks[ticketID] = *s
+	return nil
+}
+
+func (r *fakeRepo) Flag(_ context.Context, target, state string, _ time.Time) ([]Deadline, error) {
+	return r.flagged[target+"/"+state], nil
+}
+
+type published struct {
+	projectID int
+	eventType string
+	payload   any
+}
+
+type fakeActivity struct {
+	events []published
+}
+
+func (a *fakeActivity) Publish(_ context.Context, projectID int, eventType string, payload any) error {
+	a.events = append(a.events, published{projectID, eventType, payload})
+	return nil
+}
+
+func newClockRepo() *fakeRepo {
+	p := officePolicy()
+	p.ID = 4
+	p.WarningPercent = 50
+	p.Targets = []postgres.SLATarget{
+		{Priority: "P1", FirstResponseMinutes: 15, ResolutionMinutes: 120},
+		{Priority: "P3", FirstResponseMinutes: 60, ResolutionMinutes: 480},
+	}
+	return &fakeRepo{
+		policies:  map[int]*postgres.SLAPolicy{4: p},
+		contracts: map[int]int{7: 4},
+		clocks:    map[int]postgres.TicketSLA{},
+	}
+}
+
+func at(c *Clock, t time.Time) {
+	c.now = func() time.Time { return t }
+}
+
+func assertTime(t *testing.T, want time.Time, got *time.Time, msg string) {
+	t.Helper()
+	require.NotNil(t, got, msg)
+	assert.True(t, want.Equal(*got), "%s: want %s, got %s", msg, want, *got)
+}
+
+func TestClock_Start_SetsDeadlinesFromContractPolicy(t *testing.T) {
+	repo := newClockRepo()
+	clock := NewClock(repo)
+	at(clock, time.Date(2026, 1, 5, 9, 0, 0, 0, moscow))
+
+	ticket := &postgres.Ticket{ID: 1, ContractID: 7, Status: tickets.StatusOpen, Priority: tickets.PriorityP3}
+	require.NoError(t, clock.Start(context.Background(), ticket))
+
+	s := ticket.TicketSLA
+	assert.Equal(t, 4, *s.SLAPolicyID)
+	assertTime(t, time.Date(2026, 1, 5, 10, 0, 0, 0, moscow), s.FirstResponseDue, "first response due")
+	assertTime(t, time.Date(2026, 1, 5, 9, 30, 0, 0, moscow), s.FirstResponseWarnAt, "first response warning")
+	assertTime(t, time.Date(2026, 1, 5, 17, 0, 0, 0, moscow), s.ResolveDue, "resolve due")
+	assertTime(t, time.Date(2026, 1, 5, 13, 0, 0, 0, moscow), s.ResolveWarnAt, "resolve warning")
+	assert.Equal(t, StateOnTrack, *s.FirstResponseSLA)
+	assert.Equal(t, StateOnTrack, *s.ResolveSLA)
+	assert.Equal(t, s, repo.clocks[1])
+}
+
+func TestClock_Start_WithoutPolicyLeavesTicketAlone(t *testing.T) {
+	repo := newClockRepo()
+	ticket := &postgres.Ticket{ID: 1, ContractID: 8}
+
+	require.NoError(t, NewClock(repo).Start(context.Background(), ticket))
+
+	assert.Nil(t, ticket.SLAPolicyID)
+	assert.Empty(t, repo.clocks)
+}
+
+func TestClock_TicketChanged_PausesWhileWaitingAndOnceDone(t *testing.T) {
+	repo := newClockRepo()
+	clock := NewClock(repo)
+	ctx := context.Background()
+	move := func(t *testing.T, current *postgres.Ticket, status string, now time.Time) *postgres.Ticket {
+		t.Helper()
+		at(clock, now)
+		next := &postgres.Ticket{ID: current.ID, Status: status}
+		require.NoError(t, clock.TicketChanged(ctx, current, next))
+		return next
+	}
+
+	at(clock, time.Date(2026, 1, 5, 9, 0, 0, 0, moscow))
+	ticket := &postgres.Ticket{ID: 1, ContractID: 7, Status: tickets.StatusOpen, Priority: tickets.PriorityP3}
+	require.NoError(t, clock.Start(ctx, ticket))
+
+	waiting := move(t, ticket, tickets.StatusWaiting, time.Date(2026, 1, 5, 9, 15, 0, 0, moscow))
+	assertTime(t, time.Date(2026, 1, 5, 9, 15, 0, 0, moscow), waiting.SLAPausedAt, "paused")
+	assertTime(t, time.Date(2026, 1, 5, 10, 0, 0, 0, moscow), waiting.FirstResponseDue, "due unchanged while paused")
+
+	// A business day later the same business time is left.
+	resumed := move(t, waiting, tickets.StatusInProgress, time.Date(2026, 1, 6, 9, 15, 0, 0, moscow))
+	assert.Nil(t, resumed.SLAPausedAt)
+	assertTime(t, time.Date(2026, 1, 6, 10, 0, 0, 0, moscow), resumed.FirstResponseDue, "first response due")
+	assertTime(t, time.Date(2026, 1, 6, 9, 30, 0, 0, moscow), resumed.FirstResponseWarnAt, "first response warning")
+	assertTime(t, time.Date(2026, 1, 6, 17, 0, 0, 0, moscow), resumed.ResolveDue, "resolve due")
+	assert.Equal(t, resumed.TicketSLA, repo.clocks[1])
+
+	resolved := move(t, resumed, tickets.StatusResolved, time.Date(2026, 1, 6, 12, 0, 0, 0, moscow))
+	assert.Equal(t, StateMet, *resolved.ResolveSLA)
+	assert.NotNil(t, resolved.SLAPausedAt)
+
+	reopened := move(t, resolved, tickets.StatusOpen, time.Date(2026, 1, 7, 9, 0, 0, 0, moscow))
+	assert.Equal(t, StateOnTrack, *reopened.ResolveSLA)
+	assertTime(t, time.Date(2026, 1, 7, 14, 0, 0, 0, moscow), reopened.ResolveDue, "resolve due after reopen")
+}
+
+func TestClock_TicketChanged_KeepsBreachedDeadlines(t *testing.T) {
+	repo := newClockRepo()
+	clock := NewClock(repo)
+	at(clock, time.Date(2026, 1, 6, 9, 0, 0, 0, moscow))
+	due := time.Date(2026, 1, 5, 17, 0, 0, 0, moscow)
+	policyID := 4
+	current := &postgres.Ticket{ID: 2, Status: tickets.StatusInProgress, TicketSLA: postgres.TicketSLA{
+		SLAPolicyID: &policyID,
+		ResolveDue:  &due,
+		ResolveSLA:  ptr(StateBreached),
+	}}
+	next := &postgres.Ticket{ID: 2, Status: tickets.StatusResolved}
+
+	require.NoError(t, clock.TicketChanged(context.Background(), current, next))
+
+	assert.Equal(t, StateBreached, *next.ResolveSLA)
+	assertTime(t, due, next.ResolveDue, "resolve due")
+}
+
+func TestClock_TicketChanged_CarriesDeadlinesOnOtherUpdates(t *testing.T) {
+	repo := newClockRepo()
+	due := time.Date(2026, 1, 5, 17, 0, 0, 0, moscow)
+	policyID := 4
+	current := &postgres.Ticket{ID: 3, Status: tickets.StatusOpen, TicketSLA: postgres.TicketSLA{SLAPolicyID: &policyID, ResolveDue: &due}}
+	next := &postgres.Ticket{ID: 3, Status: tickets.StatusOpen, Title: "renamed"}
+
+	require.NoError(t, NewClock(repo).TicketChanged(context.Background(), current, next))
+
+	assert.Equal(t, current.TicketSLA, next.TicketSLA)
+	assert.Empty(t, repo.clocks, "nothing to store")
+}
+
+func TestClock_TicketChanged_RetargetsOnNewPriority(t *testing.T) {
+	repo := newClockRepo()
+	clock := NewClock(repo)
+	ctx := context.Background()
+
+	at(clock, time.Date(2026, 1, 5, 9, 0, 0, 0, moscow))
+	ticket := &postgres.Ticket{ID: 1, ContractID: 7, Status: tickets.StatusOpen, Priority: tickets.PriorityP3}
+	require.NoError(t, clock.Start(ctx, ticket))
+
+	// Ten business minutes in, P1 leaves 5 of its 15 minutes to respond.
+	at(clock, time.Date(2026, 1, 5, 9, 10, 0, 0, moscow))
+	next := &postgres.Ticket{ID: 1, Status: tickets.StatusOpen, Priority: tickets.PriorityP1}
+	require.NoError(t, clock.TicketChanged(ctx, ticket, next))
+
+	s := next.TicketSLA
+	assertTime(t, time.Date(2026, 1, 5, 9, 15, 0, 0, moscow), s.FirstResponseDue, "first response due")
+	assertTime(t, time.Date(2026, 1, 5, 9, 10, 0, 0, moscow), s.FirstResponseWarnAt, "first response warning")
+	assertTime(t, time.Date(2026, 1, 5, 11, 0, 0, 0, moscow), s.ResolveDue, "resolve due")
+	assertTime(t, time.Date(2026, 1, 5, 10, 0, 0, 0, moscow), s.ResolveWarnAt, "resolve warning")
+	assert.Equal(t, s, repo.clocks[1])
+}
+
+func TestMonitor_Check_PublishesFlaggedDeadlines(t *testing.T) {
+	due := time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC)
+	repo := &fakeRepo{flagged: map[string][]Deadline{
+		TargetFirstResponse + "/" + StateBreached: {{TicketID: 1, ProjectID: 3, Due: due}},
+		TargetResolution + "/" + StateAtRisk:      {{TicketID: 2, ProjectID: 5, Due: due}, {TicketID: 4, ProjectID: 5, Due: due}},
+	}}
+	activity := &fakeActivity{}
+	m := NewMonitor(repo, activity, db.NopTransactor{}, time.Minute, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	n, err := m.Check(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+	require.Len(t, activity.events, 3)
+	assert.Equal(t, published{3, events.TicketSLABreached, map[string]any{
+		"ticket_id": 1,
+		"target":    TargetFirstResponse,
+		"due":       due,
+	}}, activity.events[0])
+	assert.Equal(t, events.TicketSLAAtRisk, activity.events[1].eventType)
+	assert.Equal(t, 5, activity.events[2].projectID)
+}
@@ -0,0 +1,165 @@
+package sla
+
+import (
+	"database/sql"
+	"errors"
+	"innotech/internal/storage/postgres"
+	"innotech/internal/storage/transport"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Handler handles HTTP requests for SLA policy operations.
+type Handler struct {
+	service Service
+}
+
+// NewHandler creates a new Handler instance.
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+// Create godoc
+// @Summary Создать SLA-политику
+// @Tags SLA
+// @Accept json
+// @Produce json
+// @Param policy body transport.SLAPolicyDTO true "SLA Policy Data"
+// @Success 201 {object} postgres.SLAPolicy
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/sla-policies [post]
+// Create handles the creation of a new SLA policy.
+func (h *Handler) Create(c *fiber.Ctx) error {
+	p := policyFromDTO(c.Locals("body").(*transport.SLAPolicyDTO))
+	err := h.service.Create(c.UserContext(), p)
+	if errors.Is(err, ErrInvalidSchedule) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(fiber.StatusCreated).JSON(p)
+}
+
+// GetByID godoc
+// @Summary Получить SLA-политику по ID
+// @Tags SLA
+// @Produce json
+// @Param id path int true "SLA Policy ID"
+// @Success 200 {object} postgres.SLAPolicy
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/sla-policies/{id} [get]
+// GetByID retrieves an SLA policy by its ID.
+func (h *Handler) GetByID(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+	p, err := h.service.GetByID(c.UserContext(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "not found"})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(p)
+}
+
+// GetAll godoc
+// @Summary Получить все SLA-политики
+// @Tags SLA
+// @Produce json
+// @Success 200 {array} postgres.SLAPolicy
+// @Failure 500 {object} map[string]string
+// @Router /api/sla-policies [get]
+// GetAll retrieves all SLA policies.
+func (h *Handler) GetAll(c *fiber.Ctx) error {
+	list, err := h.service.GetAll(c.UserContext())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(list)
+}
+
+// Update godoc
+// @Summary Обновить SLA-политику
+// @Description заменяет цели и праздники политики; сроки уже открытых заявок не пересчитываются
+// @Tags SLA
+// @Accept json
+// @Produce json
+// @Param id path int true "SLA Policy ID"
+// @Param policy body transport.SLAPolicyDTO true "SLA Policy Data"
+// @Success 200 {object} postgres.SLAPolicy
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/sla-policies/{id} [put]
+// Update handles the update of an existing SLA policy.
+func (h *Handler) Update(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+	p := policyFromDTO(c.Locals("body").(*transport.SLAPolicyDTO))
+	p.ID = id
+	err = h.service.Update(c.UserContext(), p)
+	if errors.Is(err, ErrInvalidSchedule) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "not found"})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(p)
+}
+
+// Delete godoc
+// @Summary Удалить SLA-политику
+// @Description договоры и заявки политики остаются без SLA
+// @Tags SLA
+// @Param id path int true "SLA Policy ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/sla-policies/{id} [delete]
+// Delete handles the deletion of an SLA policy.
+func (h *Handler) Delete(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+	if err := h.service.Delete(c.UserContext(), id); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func policyFromDTO(dto *transport.SLAPolicyDTO) *postgres.SLAPolicy {
+	p := &postgres.SLAPolicy{
+		Name:           dto.Name,
+		Timezone:       dto.Timezone,
+		BusinessHours:  postgres.BusinessHours{},
+		WarningPercent: dto.WarningPercent,
+		Targets:        []postgres.SLATarget{},
+		Holidays:       []postgres.SLAHoliday{},
+	}
+	for _, w := range dto.BusinessHours {
+		p.BusinessHours = append(p.BusinessHours, postgres.BusinessWindow{Day: w.Day, From: w.From, To: w.To})
+	}
+	for _, t := range dto.Targets {
+		p.Targets = append(p.Targets, postgres.SLATarget{
+			Priority:             t.Priority,
+			FirstResponseMinutes: t.FirstResponseMinutes,
+			ResolutionMinutes:    t.ResolutionMinutes,
+		})
+	}
+	for _, d := range dto.Holidays {
+		p.Holidays = append(p.Holidays, postgres.SLAHoliday{Day: d.Day, Name: d.Name})
+	}
+	return p
+}
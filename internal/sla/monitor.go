@@ -0,0 +1,81 @@
+package sla
+
+import (
+	"context"
+	"innotech/internal/events"
+	"innotech/pkg/db"
+	"log/slog"
+	"time"
+)
+
+// Activity records events in the activity log of a project.
+type Activity interface {
+	Publish(ctx context.Context, projectID int, eventType string, payload any) error
+}
+
+// Monitor flags ticket deadlines that are close to due or overdue and
+// announces them on the project feed.
+type Monitor struct {
+	repo     Repository
+	activity Activity
+	tx       db.Transactor
+	interval time.Duration
+	logger   *slog.Logger
+	now      func() time.Time
+}
+
+// NewMonitor creates a Monitor that checks deadlines every interval.
+func NewMonitor(repo Repository, activity Activity, tx db.Transactor, interval time.Duration, logger *slog.Logger) *Monitor {
+	return &Monitor{repo: repo, activity: activity, tx: tx, interval: interval, logger: logger, now: time.Now}
+}
+
+// Run checks deadlines until ctx is done.
+func (m *Monitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := m.Check(ctx); err != nil {
+			m.logger.Error("SLA check failed", "err", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check flags the deadlines that were breached or came at risk since the
+// last check and returns how many it flagged. Breaches are flagged first,
+// so a deadline missed in one go is not reported at risk as well.
+func (m *Monitor) Check(ctx context.Context) (int, error) {
+	var n int
+	err := m.tx.WithinTx(ctx, func(ctx context.Context) error {
+		now := m.now().UTC()
+		for _, step := range []struct{ state, event string }{
+			{StateBreached, events.TicketSLABreached},
+			{StateAtRisk, events.TicketSLAAtRisk},
+		} {
+			for _, target := range []string{TargetFirstResponse, TargetResolution} {
+				list, err := m.repo.Flag(ctx, target, step.state, now)
+				if err != nil {
+					return err
+				}
+				for _, d := range list {
+					err := m.activity.Publish(ctx, d.ProjectID, step.event, map[string]any{
+						"ticket_id": d.TicketID,
+						"target":    target,
+						"due":       d.Due,
+					})
+					if err != nil {
+						return err
+					}
+				}
+				n += len(list)
+			}
+		}
+		return nil
+	})
+	return n, err
+}
@@ -0,0 +1,223 @@
+package sla
+
+import (
+	"context"
+	"innotech/internal/storage/postgres"
+	"innotech/pkg/db"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Deadline targets of a ticket.
+const (
+	TargetFirstResponse = "first_response"
+	TargetResolution    = "resolution"
+)
+
+// SLA states of a deadline.
+const (
+	StateOnTrack  = "on_track"
+	StateAtRisk   = "at_risk"
+	StateBreached = "breached"
+	StateMet      = "met"
+)
+
+// columnPrefix maps deadline targets to their ticket columns.
+var columnPrefix = map[string]string{
+	TargetFirstResponse: "first_response",
+	TargetResolution:    "resolve",
+}
+
+// Deadline is a ticket deadline that changed state.
+type Deadline struct {
+	TicketID  int       `db:"id"`
+	ProjectID int       `db:"project_id"`
+	Due       time.Time `db:"due"`
+}
+
+// Repository defines the interface for SLA policy and ticket deadline data
+// access operations.
+type Repository interface {
+	Create(ctx context.Context, p *postgres.SLAPolicy) error
+	GetByID(ctx context.Context, id int) (*postgres.SLAPolicy, error)
+	GetAll(ctx context.Context) ([]postgres.SLAPolicy, error)
+	ForContract(ctx context.Context, contractID int) (*postgres.SLAPolicy, error)
+	Update(ctx context.Context, p *postgres.SLAPolicy) error
+	Delete(ctx context.Context, id int) error
+	SetTicketClock(ctx context.Context, ticketID int, s *postgres.TicketSLA) error
+	Flag(ctx context.Context, target, state string, now time.Time) ([]Deadline, error)
+}
+
+type repository struct {
+	db *sqlx.DB
+}
+
+// NewRepository creates a new Repository instance.
+func NewRepository(db *sqlx.DB) Repository {
+	return &repository{db: db}
+}
+
+// Create stores the policy with its targets and holidays. It must run in a
+// transaction.
+func (r *repository) Create(ctx context.Context, p *postgres.SLAPolicy) error {
+	query := `
+		INSERT INTO sla_policies (name, timezone, business_hours, warning_percent)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, date_created, date_updated
+	`
+	err := db.Conn(ctx, r.db).QueryRowxContext(ctx, query, p.Name, p.Timezone, p.BusinessHours, p.WarningPercent).
+		Scan(&p.ID, &p.DateCreated, &p.DateUpdated)
+	if err != nil {
+		return err
+	}
+	return r.replaceRules(ctx, p)
+}
+
+func (r *repository) GetByID(ctx context.Context, id int) (*postgres.SLAPolicy, error) {
+	var p postgres.SLAPolicy
+	if err := db.Conn(ctx, r.db).GetContext(ctx, &p, `SELECT * FROM sla_policies WHERE id = $1`, id); err != nil {
+		return nil, err
+	}
+	return &p, r.loadRules(ctx, &p)
+}
+
+func (r *repository) GetAll(ctx context.Context) ([]postgres.SLAPolicy, error) {
+	list := []postgres.SLAPolicy{}
+	if err := db.Conn(ctx, r.db).SelectContext(ctx, &list, `SELECT * FROM sla_policies ORDER BY id`); err != nil {
+		return nil, err
+	}
+	for i := range list {
+		if err := r.loadRules(ctx, &list[i]); err != nil {
+			return nil, err
+		}
+	}
+	return list, nil
+}
+
+// ForContract returns the policy of a contract, or sql.ErrNoRows when the
+// contract has none.
+func (r *repository) ForContract(ctx context.Context, contractID int) (*postgres.SLAPolicy, error) {
+	var p postgres.SLAPolicy
+	err := db.Conn(ctx, r.db).GetContext(ctx, &p, `
+		SELECT p.* FROM sla_policies p
+		JOIN contracts c ON c.sla_policy_id = p.id
+		WHERE c.id = $1`, contractID)
+	if err != nil {
+		return nil, err
+	}
+	return &p, r.loadRules(ctx, &p)
+}
+
+// Update stores the policy and replaces its targets and holidays. It must
+// run in a transaction and returns sql.ErrNoRows when the policy does not
+// exist.
+func (r *repository) Update(ctx context.Context, p *postgres.SLAPolicy) error {
+	query := `
+		UPDATE sla_policies
+		SET name = $2, timezone = $3, business_hours = $4, warning_percent = $5
+		WHERE id = $1
+		RETURNING date_created, date_updated
+	`
+	err := db.Conn(ctx, r.db).QueryRowxContext(ctx, query, p.ID, p.Name, p.Timezone, p.BusinessHours, p.WarningPercent).
+		Scan(&p.DateCreated, &p.DateUpdated)
+	if err != nil {
+		return err
+	}
+	return r.replaceRules(ctx, p)
+}
+
+func (r *repository) Delete(ctx context.Context, id int) error {
+	_, err := db.Conn(ctx, r.db).ExecContext(ctx, `DELETE FROM sla_policies WHERE id = $1`, id)
+	return err
+}
+
+func (r *repository) loadRules(ctx context.Context, p *postgres.SLAPolicy) error {
+	conn := db.Conn(ctx, r.db)
+	p.Targets = []postgres.SLATarget{}
+	err := conn.SelectContext(ctx, &p.Targets,
+		`SELECT * FROM sla_targets WHERE policy_id = $1 ORDER BY priority`, p.ID)
+	if err != nil {
+		return err
+	}
+	p.Holidays = []postgres.SLAHoliday{}
+	return conn.SelectContext(ctx, &p.Holidays, `
+		SELECT policy_id, to_char(day, 'YYYY-MM-DD') AS day, name
+		FROM sla_holidays WHERE policy_id = $1 ORDER BY day`, p.ID)
+}
+
+func (r *repository) replaceRules(ctx context.Context, p *postgres.SLAPolicy) error {
+	conn := db.Conn(ctx, r.db)
+	if _, err := conn.ExecContext(ctx, `DELETE FROM sla_targets WHERE policy_id = $1`, p.ID); err != nil {
+		return err
+	}
+	if _, err := conn.ExecContext(ctx, `DELETE FROM sla_holidays WHERE policy_id = $1`, p.ID); err != nil {
+		return err
+	}
+	for i := range p.Targets {
+		t := &p.Targets[i]
+		t.PolicyID = p.ID
+		_, err := conn.ExecContext(ctx, `
+			INSERT INTO sla_targets (policy_id, priority, first_response_minutes, resolution_minutes)
+			VALUES ($1, $2, $3, $4)`, p.ID, t.Priority, t.FirstResponseMinutes, t.ResolutionMinutes)
+		if err != nil {
+			return err
+		}
+	}
+	for i := range p.Holidays {
+		h := &p.Holidays[i]
+		h.PolicyID = p.ID
+		_, err := conn.ExecContext(ctx,
+			`INSERT INTO sla_holidays (policy_id, day, name) VALUES ($1, $2::date, $3)`, p.ID, h.Day, h.Name)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetTicketClock stores the deadlines of a ticket. Breached deadlines stay
+// breached, and a first response recorded meanwhile is kept; s receives the
+// states actually stored.
+func (r *repository) SetTicketClock(ctx context.Context, ticketID int, s *postgres.TicketSLA) error {
+	query := `
+		UPDATE tickets
+		SET sla_policy_id = $2,
+		    first_response_due = $3,
+		    first_response_warn_at = $4,
+		    first_response_sla = CASE
+		        WHEN first_response_sla IN ('met', 'breached') THEN first_response_sla
+		        ELSE $5::sla_state_enum
+		    END,
+		    resolve_due = $6,
+		    resolve_warn_at = $7,
+		    resolve_sla = CASE WHEN resolve_sla = 'breached' THEN resolve_sla ELSE $8::sla_state_enum END,
+		    sla_paused_at = $9
+		WHERE id = $1
+		RETURNING first_responded_at, first_response_sla, resolve_sla
+	`
+	return db.Conn(ctx, r.db).QueryRowxContext(ctx, query, ticketID, s.SLAPolicyID,
+		s.FirstResponseDue, s.FirstResponseWarnAt, s.FirstResponseSLA,
+		s.ResolveDue, s.ResolveWarnAt, s.ResolveSLA, s.SLAPausedAt,
+	).Scan(&s.FirstRespondedAt, &s.FirstResponseSLA, &s.ResolveSLA)
+}
+
+// Flag moves running deadlines of target that reached their warning time
+// (state at_risk) or their due time (state breached) as of now to state,
+// and returns them.
+func (r *repository) Flag(ctx context.Context, target, state string, now time.Time) ([]Deadline, error) {
+	col := columnPrefix[target]
+	cond := col + `_sla = 'on_track' AND ` + col + `_warn_at <= $2`
+	if state == StateBreached {
+		cond = col + `_sla IN ('on_track', 'at_risk') AND ` + col + `_due <= $2`
+	}
+	list := []Deadline{}
+	err := db.Conn(ctx, r.db).SelectContext(ctx, &list, `
+		UPDATE tickets SET `+col+`_sla = $1
+		WHERE `+cond+` AND sla_paused_at IS NULL
+		RETURNING id, project_id, `+col+`_due AS due`, state, now)
+	if err != nil {
+		return nil, err
+	}
+	return list, nil
+}
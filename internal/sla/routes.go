@@ -0,0 +1,21 @@
+package sla
+
+import (
+	"innotech/internal/access"
+	"innotech/internal/storage/transport"
+	"innotech/pkg/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RegisterRoutes registers HTTP routes for SLA policy operations. Policies
+// are shared by all projects, so only superusers may change them.
+func RegisterRoutes(app *fiber.App, h *Handler, guard *access.Guard) {
+	api := app.Group("/api/sla-policies")
+
+	api.Get("/", h.GetAll)
+	api.Get("/:id", h.GetByID)
+	api.Post("/", guard.Superuser(), middleware.ValidateBody[transport.SLAPolicyDTO](h.Create))
+	api.Put("/:id", guard.Superuser(), middleware.ValidateBody[transport.SLAPolicyDTO](h.Update))
+	api.Delete("/:id", guard.Superuser(), h.Delete)
+}
@@ -0,0 +1,72 @@
+package sla
+
+import (
+	"context"
+	"innotech/internal/storage/postgres"
+	"innotech/pkg/db"
+)
+
+// DefaultWarningPercent is the share of a target after which a deadline is
+// at risk, when a policy does not set one.
+const DefaultWarningPercent = 80
+
+// Service defines the interface for SLA policy business logic operations.
+type Service interface {
+	Create(ctx context.Context, p *postgres.SLAPolicy) error
+	GetByID(ctx context.Context, id int) (*postgres.SLAPolicy, error)
+	GetAll(ctx context.Context) ([]postgres.SLAPolicy, error)
+	Update(ctx context.Context, p *postgres.SLAPolicy) error
+	Delete(ctx context.Context, id int) error
+}
+
+type service struct {
+	repo Repository
+	tx   db.Transactor
+}
+
+// NewService creates a new Service instance.
+func NewService(repo Repository, tx db.Transactor) Service {
+	return &service{repo: repo, tx: tx}
+}
+
+// Create stores a policy. Schedules that cannot be used are rejected with
+// ErrInvalidSchedule.
+func (s *service) Create(ctx context.Context, p *postgres.SLAPolicy) error {
+	if err := prepare(p); err != nil {
+		return err
+	}
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		return s.repo.Create(ctx, p)
+	})
+}
+
+func (s *service) GetByID(ctx context.Context, id int) (*postgres.SLAPolicy, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *service) GetAll(ctx context.Context) ([]postgres.SLAPolicy, error) {
+	return s.repo.GetAll(ctx)
+}
+
+// Update replaces a policy. Deadlines already set on tickets are kept.
+func (s *service) Update(ctx context.Context, p *postgres.SLAPolicy) error {
+	if err := prepare(p); err != nil {
+		return err
+	}
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		return s.repo.Update(ctx, p)
+	})
+}
+
+func (s *service) Delete(ctx context.Context, id int) error {
+	return s.repo.Delete(ctx, id)
+}
+
+// prepare fills in defaults and checks that the schedule of p is usable.
+func prepare(p *postgres.SLAPolicy) error {
+	if p.WarningPercent == 0 {
+		p.WarningPercent = DefaultWarningPercent
+	}
+	_, err := NewCalendar(p)
+	return err
+}
@@ -0,0 +1,17 @@
+package postgres
+
+import "time"
+
+// Contract represents a client contract of a project in the database.
+type Contract struct {
+	ID            int        `db:"id" json:"id"`
+	ProjectID     int        `db:"project_id" json:"project_id"`
+	Name          string     `db:"name" json:"name"`
+	ValidFrom     *time.Time `db:"valid_from" json:"valid_from,omitempty"`
+	ValidTo       *time.Time `db:"valid_to" json:"valid_to,omitempty"`
+	ClientCompany *string    `db:"client_company" json:"client_company,omitempty"`
+	GraceDays     int        `db:"grace_period_days" json:"grace_period_days"`
+	SLAPolicyID   *int       `db:"sla_policy_id" json:"sla_policy_id,omitempty"`
+	DateCreated   time.Time  `db:"date_created" json:"date_created"`
+	DateUpdated   time.Time  `db:"date_updated" json:"date_updated"`
+}
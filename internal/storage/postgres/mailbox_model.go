@@ -0,0 +1,23 @@
+package postgres
+
+import "time"
+
+// Mailbox represents an inbound email address routed to a project contract in the database.
+type Mailbox struct {
+	ID          int       `db:"id" json:"id"`
+	Address     string    `db:"address" json:"address"`
+	ProjectID   int       `db:"project_id" json:"project_id"`
+	ContractID  int       `db:"contract_id" json:"contract_id"`
+	DateCreated time.Time `db:"date_created" json:"date_created"`
+	DateUpdated time.Time `db:"date_updated" json:"date_updated"`
+}
+
+// InboundEmail represents a processed inbound email in the database.
+type InboundEmail struct {
+	MessageID    string    `db:"message_id" json:"message_id"`
+	Sender       string    `db:"sender" json:"sender"`
+	MailboxID    *int      `db:"mailbox_id" json:"mailbox_id,omitempty"`
+	TicketID     *int      `db:"ticket_id" json:"ticket_id,omitempty"`
+	ChatID       *int      `db:"chat_id" json:"chat_id,omitempty"`
+	DateReceived time.Time `db:"date_received" json:"date_received"`
+}
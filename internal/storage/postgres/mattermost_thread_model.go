@@ -0,0 +1,11 @@
+package postgres
+
+import "time"
+
+// MattermostThread links a ticket to the Mattermost thread its chat is mirrored to.
+type MattermostThread struct {
+	TicketID    int       `db:"ticket_id" json:"ticket_id"`
+	ChannelID   string    `db:"channel_id" json:"channel_id"`
+	RootID      string    `db:"root_id" json:"root_id"`
+	DateCreated time.Time `db:"date_created" json:"date_created"`
+}
@@ -0,0 +1,38 @@
+package postgres
+
+import "time"
+
+// NotificationPreferences represents how a user wants to be notified in the database.
+type NotificationPreferences struct {
+	UserID      string      `db:"user_id" json:"user_id"`
+	Email       string      `db:"email" json:"email"`
+	Language    string      `db:"language" json:"language"`
+	Enabled     bool        `db:"enabled" json:"enabled"`
+	Kinds       StringArray `db:"kinds" json:"kinds"`
+	Digest      string      `db:"digest" json:"digest"`
+	DateCreated time.Time   `db:"date_created" json:"date_created"`
+	DateUpdated time.Time   `db:"date_updated" json:"date_updated"`
+}
+
+// TicketWatcher represents a user following a ticket in the database.
+type TicketWatcher struct {
+	TicketID    int       `db:"ticket_id" json:"ticket_id"`
+	UserID      string    `db:"user_id" json:"user_id"`
+	DateCreated time.Time `db:"date_created" json:"date_created"`
+}
+
+// Notification represents an email notification queued for a user in the database.
+type Notification struct {
+	ID          int64      `db:"id" json:"id"`
+	UserID      string     `db:"user_id" json:"user_id"`
+	EventKey    string     `db:"event_key" json:"event_key"`
+	Kind        string     `db:"kind" json:"kind"`
+	TicketID    *int       `db:"ticket_id" json:"ticket_id,omitempty"`
+	Data        JSON       `db:"data" json:"data"`
+	Status      string     `db:"status" json:"status"`
+	Attempts    int        `db:"attempts" json:"attempts"`
+	SendAfter   time.Time  `db:"send_after" json:"send_after"`
+	LastError   *string    `db:"last_error" json:"last_error,omitempty"`
+	DateCreated time.Time  `db:"date_created" json:"date_created"`
+	DateSent    *time.Time `db:"date_sent" json:"date_sent,omitempty"`
+}
@@ -0,0 +1,17 @@
+package postgres
+
+import "time"
+
+// OutboxMessage represents a message of the transactional outbox in the database.
+type OutboxMessage struct {
+	ID            int64       `db:"id" json:"id"`
+	Topic         string      `db:"topic" json:"topic"`
+	Payload       JSON        `db:"payload" json:"payload"`
+	Status        string      `db:"status" json:"status"`
+	Completed     StringArray `db:"completed" json:"completed"`
+	Attempts      int         `db:"attempts" json:"attempts"`
+	NextAttemptAt time.Time   `db:"next_attempt_at" json:"next_attempt_at"`
+	LastError     *string     `db:"last_error" json:"last_error,omitempty"`
+	DateCreated   time.Time   `db:"date_created" json:"date_created"`
+	DateProcessed *time.Time  `db:"date_processed" json:"date_processed,omitempty"`
+}
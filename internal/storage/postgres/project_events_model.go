@@ -0,0 +1,16 @@
+package postgres
+
+import "time"
+
+// ProjectEvent represents an entry of the project activity log in the database.
+// Seq orders the events of a project by commit; it is assigned once the event
+// has committed and is nil until then.
+type ProjectEvent struct {
+	ID          int64     `db:"id" json:"id"`
+	Seq         *int64    `db:"seq" json:"seq,omitempty"`
+	ProjectID   int       `db:"project_id" json:"project_id"`
+	Type        string    `db:"type" json:"type"`
+	ActorID     *string   `db:"actor_id" json:"actor_id,omitempty"`
+	Payload     JSON      `db:"payload" json:"payload"`
+	DateCreated time.Time `db:"date_created" json:"date_created"`
+}
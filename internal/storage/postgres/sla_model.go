@@ -0,0 +1,78 @@
+package postgres
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// SLAPolicy represents a support tier attached to contracts in the database.
+type SLAPolicy struct {
+	ID             int           `db:"id" json:"id"`
+	Name           string        `db:"name" json:"name"`
+	Timezone       string        `db:"timezone" json:"timezone"`
+	BusinessHours  BusinessHours `db:"business_hours" json:"business_hours"`
+	WarningPercent int           `db:"warning_percent" json:"warning_percent"`
+	Targets        []SLATarget   `db:"-" json:"targets"`
+	Holidays       []SLAHoliday  `db:"-" json:"holidays"`
+	DateCreated    time.Time     `db:"date_created" json:"date_created"`
+	DateUpdated    time.Time     `db:"date_updated" json:"date_updated"`
+}
+
+// SLATarget holds the business minutes allowed for tickets of a priority.
+type SLATarget struct {
+	PolicyID             int    `db:"policy_id" json:"-"`
+	Priority             string `db:"priority" json:"priority"`
+	FirstResponseMinutes int    `db:"first_response_minutes" json:"first_response_minutes"`
+	ResolutionMinutes    int    `db:"resolution_minutes" json:"resolution_minutes"`
+}
+
+// SLAHoliday is a day on which the clock of a policy stands still. Day is
+// formatted as 2006-01-02.
+type SLAHoliday struct {
+	PolicyID int     `db:"policy_id" json:"-"`
+	Day      string  `db:"day" json:"day"`
+	Name     *string `db:"name" json:"name,omitempty"`
+}
+
+// BusinessWindow is a span of working hours on a weekday (Sunday is 0),
+// with times formatted as 15:04. To may be 24:00.
+type BusinessWindow struct {
+	Day  int    `json:"day"`
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// BusinessHours maps the JSONB weekly schedule of a policy. An empty
+// schedule means round the clock.
+type BusinessHours []BusinessWindow
+
+// Value implements driver.Valuer.
+func (h BusinessHours) Value() (driver.Value, error) {
+	if h == nil {
+		h = BusinessHours{}
+	}
+	buf, err := json.Marshal([]BusinessWindow(h))
+	if err != nil {
+		return nil, err
+	}
+	return string(buf), nil
+}
+
+// Scan implements sql.Scanner.
+func (h *BusinessHours) Scan(src any) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*h = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("scan business hours: unsupported type %T", src)
+	}
+	return json.Unmarshal(data, (*[]BusinessWindow)(h))
+}
@@ -4,12 +4,20 @@ import "time"
 
 // TicketAttachment represents a file attachment for a ticket in the database.
 type TicketAttachment struct {
-	ID          int       `db:"id" json:"id"`
-	TicketID    int       `db:"ticket_id" json:"ticket_id"`
-	FilePath    string    `db:"file_path" json:"file_path"`
-	UploadedBy  string    `db:"uploaded_by" json:"uploaded_by"`
-	FileType    *string   `db:"file_type" json:"file_type,omitempty"`
-	Description *string   `db:"description" json:"description,omitempty"`
-	DateCreated time.Time `db:"date_created" json:"date_created"`
-	DateUpdated time.Time `db:"date_updated" json:"date_updated"`
+	ID            int       `db:"id" json:"id"`
+	TicketID      int       `db:"ticket_id" json:"ticket_id"`
+	FilePath      string    `db:"file_path" json:"file_path"`
+	UploadedBy    string    `db:"uploaded_by" json:"uploaded_by"`
+	FileType      *string   `db:"file_type" json:"file_type,omitempty"`
+	Description   *string   `db:"description" json:"description,omitempty"`
+	Size          *int64    `db:"size" json:"size,omitempty"`
+	Checksum      *string   `db:"checksum" json:"checksum,omitempty"`
+	ScanStatus    string    `db:"scan_status" json:"scan_status"`
+	ThumbnailPath *string   `db:"thumbnail_path" json:"-"`
+	PreviewPath   *string   `db:"preview_path" json:"-"`
+	ThumbnailURL  *string   `db:"-" json:"thumbnail_url,omitempty"`
+	PreviewURL    *string   `db:"-" json:"preview_url,omitempty"`
+	Icon          string    `db:"-" json:"icon,omitempty"`
+	DateCreated   time.Time `db:"date_created" json:"date_created"`
+	DateUpdated   time.Time `db:"date_updated" json:"date_updated"`
 }
@@ -11,6 +11,7 @@ type TicketChat struct {
 	Message             string    `db:"message" json:"message"`
 	MessageType         string    `db:"message_type" json:"message_type"`
 	MattermostMessageID *string   `db:"mattermost_message_id" json:"mattermost_message_id,omitempty"`
+	GitlabNoteID        *int64    `db:"gitlab_note_id" json:"gitlab_note_id,omitempty"`
 	DateCreated         time.Time `db:"date_created" json:"date_created"`
 	DateUpdated         time.Time `db:"date_updated" json:"date_updated"`
 }
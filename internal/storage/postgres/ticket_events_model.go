@@ -0,0 +1,14 @@
+package postgres
+
+import "time"
+
+// TicketEvent represents a single field-level change of a ticket in the database.
+type TicketEvent struct {
+	ID          int       `db:"id" json:"id"`
+	TicketID    int       `db:"ticket_id" json:"ticket_id"`
+	ActorID     *string   `db:"actor_id" json:"actor_id,omitempty"`
+	Field       string    `db:"field" json:"field"`
+	OldValue    *string   `db:"old_value" json:"old_value,omitempty"`
+	NewValue    *string   `db:"new_value" json:"new_value,omitempty"`
+	DateCreated time.Time `db:"date_created" json:"date_created"`
+}
@@ -13,8 +13,29 @@ type Ticket struct {
 	Title               string    `db:"title" json:"title"`
 	Message             string    `db:"message" json:"message"`
 	Status              string    `db:"status" json:"status"`
+	Resolution          *string   `db:"resolution" json:"resolution,omitempty"`
+	Priority            string    `db:"priority" json:"priority"`
+	ProposedPriority    *string   `db:"proposed_priority" json:"proposed_priority,omitempty"`
+	Severity            string    `db:"severity" json:"severity"`
 	GitlabIssueURL      *string   `db:"gitlab_issue_url" json:"gitlab_issue_url,omitempty"`
+	GitlabIssueIID      *int      `db:"gitlab_issue_iid" json:"gitlab_issue_iid,omitempty"`
 	MattermostThreadURL *string   `db:"mattermost_thread_url" json:"mattermost_thread_url,omitempty"`
 	DateCreated         time.Time `db:"date_created" json:"date_created"`
 	DateUpdated         time.Time `db:"date_updated" json:"date_updated"`
+	TicketSLA
+}
+
+// TicketSLA holds the SLA deadlines of a ticket. States are on_track,
+// at_risk, breached or met; all fields are empty for tickets whose contract
+// has no SLA policy.
+type TicketSLA struct {
+	SLAPolicyID         *int       `db:"sla_policy_id" json:"sla_policy_id,omitempty"`
+	FirstResponseDue    *time.Time `db:"first_response_due" json:"first_response_due,omitempty"`
+	FirstResponseWarnAt *time.Time `db:"first_response_warn_at" json:"-"`
+	FirstRespondedAt    *time.Time `db:"first_responded_at" json:"first_responded_at,omitempty"`
+	FirstResponseSLA    *string    `db:"first_response_sla" json:"first_response_sla,omitempty"`
+	ResolveDue          *time.Time `db:"resolve_due" json:"resolve_due,omitempty"`
+	ResolveWarnAt       *time.Time `db:"resolve_warn_at" json:"-"`
+	ResolveSLA          *string    `db:"resolve_sla" json:"resolve_sla,omitempty"`
+	SLAPausedAt         *time.Time `db:"sla_paused_at" json:"sla_paused_at,omitempty"`
 }
@@ -0,0 +1,81 @@
+package postgres
+
+import (
+	"database/sql/driver"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// StringArray maps a PostgreSQL TEXT[] column.
+//
+// database/sql cannot bind or scan a plain slice, so values are converted
+// to and from the array text representation through pgtype. A fresh type map
+// is used per call because pgtype.Map is not safe for concurrent use.
+type StringArray []string
+
+// Value implements driver.Valuer.
+func (a StringArray) Value() (driver.Value, error) {
+	if a == nil {
+		a = StringArray{}
+	}
+	buf, err := pgtype.NewMap().Encode(pgtype.TextArrayOID, pgtype.TextFormatCode, []string(a), nil)
+	if err != nil {
+		return nil, fmt.Errorf("encode text array: %w", err)
+	}
+	return string(buf), nil
+}
+
+// Scan implements sql.Scanner.
+func (a *StringArray) Scan(src any) error {
+	if src == nil {
+		*a = nil
+		return nil
+	}
+	var out []string
+	if err := pgtype.NewMap().SQLScanner(&out).Scan(src); err != nil {
+		return fmt.Errorf("scan text array: %w", err)
+	}
+	*a = out
+	return nil
+}
+
+// JSON maps a PostgreSQL JSONB column holding an arbitrary JSON document.
+type JSON []byte
+
+// Value implements driver.Valuer.
+func (j JSON) Value() (driver.Value, error) {
+	if len(j) == 0 {
+		return nil, nil
+	}
+	return string(j), nil
+}
+
+// Scan implements sql.Scanner.
+func (j *JSON) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*j = nil
+	case []byte:
+		*j = append(JSON(nil), v...)
+	case string:
+		*j = JSON(v)
+	default:
+		return fmt.Errorf("scan json: unsupported type %T", src)
+	}
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (j JSON) MarshalJSON() ([]byte, error) {
+	if len(j) == 0 {
+		return []byte("null"), nil
+	}
+	return j, nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *JSON) UnmarshalJSON(data []byte) error {
+	*j = append(JSON(nil), data...)
+	return nil
+}
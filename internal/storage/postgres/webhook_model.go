@@ -0,0 +1,33 @@
+package postgres
+
+import "time"
+
+// WebhookSubscription represents an outbound webhook of a project in the database.
+type WebhookSubscription struct {
+	ID          int         `db:"id" json:"id"`
+	ProjectID   int         `db:"project_id" json:"project_id"`
+	URL         string      `db:"url" json:"url"`
+	Secret      string      `db:"secret" json:"-"`
+	Events      StringArray `db:"events" json:"events"`
+	Active      bool        `db:"active" json:"active"`
+	CreatedBy   string      `db:"created_by" json:"created_by"`
+	DateCreated time.Time   `db:"date_created" json:"date_created"`
+	DateUpdated time.Time   `db:"date_updated" json:"date_updated"`
+}
+
+// WebhookDelivery represents a single event delivery to a webhook in the database.
+type WebhookDelivery struct {
+	ID             int64      `db:"id" json:"id"`
+	SubscriptionID int        `db:"subscription_id" json:"subscription_id"`
+	EventID        *int64     `db:"event_id" json:"event_id,omitempty"`
+	EventType      string     `db:"event_type" json:"event_type"`
+	Payload        JSON       `db:"payload" json:"payload"`
+	Status         string     `db:"status" json:"status"`
+	Attempts       int        `db:"attempts" json:"attempts"`
+	NextAttemptAt  time.Time  `db:"next_attempt_at" json:"next_attempt_at"`
+	LastStatusCode *int       `db:"last_status_code" json:"last_status_code,omitempty"`
+	LastError      *string    `db:"last_error" json:"last_error,omitempty"`
+	RedeliveryOf   *int64     `db:"redelivery_of" json:"redelivery_of,omitempty"`
+	DateCreated    time.Time  `db:"date_created" json:"date_created"`
+	DateDelivered  *time.Time `db:"date_delivered" json:"date_delivered,omitempty"`
+}
@@ -0,0 +1,35 @@
+package transport
+
+import "time"
+
+// CreateContractDTO represents the data structure for creating a contract.
+// Either end of the validity period may be left open; when both are given
+// valid_to must come after valid_from. Tickets are accepted for grace_period_days after valid_to
+// and get deadlines from the SLA policy given as sla_policy_id.
+type CreateContractDTO struct {
+	ProjectID     int        `json:"project_id" validate:"required"`
+	Name          string     `json:"name" validate:"required,min=2,max=255"`
+	ValidFrom     *time.Time `json:"valid_from,omitempty"`
+	ValidTo       *time.Time `json:"valid_to,omitempty"`
+	ClientCompany *string    `json:"client_company,omitempty" validate:"omitempty,max=255"`
+	GraceDays     int        `json:"grace_period_days,omitempty" validate:"min=0,max=365"`
+	SLAPolicyID   *int       `json:"sla_policy_id,omitempty" validate:"omitempty,min=1"`
+}
+
+// UpdateContractDTO represents the data structure for updating a contract.
+type UpdateContractDTO struct {
+	Name          string     `json:"name" validate:"required,min=2,max=255"`
+	ValidFrom     *time.Time `json:"valid_from,omitempty"`
+	ValidTo       *time.Time `json:"valid_to,omitempty"`
+	ClientCompany *string    `json:"client_company,omitempty" validate:"omitempty,max=255"`
+	GraceDays     int        `json:"grace_period_days,omitempty" validate:"min=0,max=365"`
+	SLAPolicyID   *int       `json:"sla_policy_id,omitempty" validate:"omitempty,min=1"`
+}
+
+// ExpiringContractsQueryDTO represents the query parameters of the contract
+// expiry report: contracts ending within days from today, and those that
+// ended within days before it, optionally of one project.
+type ExpiringContractsQueryDTO struct {
+	Days      int  `query:"days" validate:"omitempty,min=1,max=365"`
+	ProjectID *int `query:"project_id" validate:"omitempty,min=1"`
+}
@@ -0,0 +1,16 @@
+package transport
+
+// StartUploadDTO represents the data structure for requesting a direct upload into a ticket.
+type StartUploadDTO struct {
+	TicketID    int    `json:"ticket_id" validate:"required,gt=0"`
+	ContentType string `json:"content_type" validate:"required,max=255"`
+	Size        int64  `json:"size" validate:"required,gt=0"`
+}
+
+// CompleteUploadDTO represents the data structure for attaching a directly uploaded file.
+type CompleteUploadDTO struct {
+	TicketID    int     `json:"ticket_id" validate:"required,gt=0"`
+	ChatID      *int    `json:"chat_id,omitempty" validate:"omitempty,gt=0"`
+	Key         string  `json:"key" validate:"required,max=1024"`
+	Description *string `json:"description,omitempty" validate:"omitempty,max=1000"`
+}
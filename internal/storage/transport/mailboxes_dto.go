@@ -0,0 +1,8 @@
+package transport
+
+// CreateMailboxDTO represents the data structure for routing an inbound email address to a contract.
+type CreateMailboxDTO struct {
+	Address    string `json:"address" validate:"required,email,max=320"`
+	ProjectID  int    `json:"project_id" validate:"required,gt=0"`
+	ContractID int    `json:"contract_id" validate:"required,gt=0"`
+}
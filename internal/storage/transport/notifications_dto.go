@@ -0,0 +1,10 @@
+package transport
+
+// UpdateNotificationPreferencesDTO represents the data structure for updating notification preferences.
+// Omitted fields keep their current values.
+type UpdateNotificationPreferencesDTO struct {
+	Language string   `json:"language,omitempty" validate:"omitempty,oneof=ru en"`
+	Enabled  *bool    `json:"enabled,omitempty"`
+	Kinds    []string `json:"kinds,omitempty" validate:"omitempty,dive,oneof=chat_message status_changed assigned contract_expiring"`
+	Digest   string   `json:"digest,omitempty" validate:"omitempty,oneof=instant hourly daily"`
+}
@@ -0,0 +1,10 @@
+package transport
+
+// SearchQueryDTO represents the query parameters of the full-text search.
+type SearchQueryDTO struct {
+	Q      string `query:"q" validate:"required,min=2,max=200"`
+	Kind   string `query:"kind" validate:"omitempty,oneof=ticket chat"`
+	Lang   string `query:"lang" validate:"omitempty,oneof=ru en"`
+	Limit  int    `query:"limit" validate:"omitempty,min=1,max=100"`
+	Offset int    `query:"offset" validate:"omitempty,min=0,max=10000"`
+}
@@ -0,0 +1,32 @@
+package transport
+
+// SLAPolicyDTO represents the data structure for creating or replacing an
+// SLA policy. Targets and holidays replace those stored before.
+type SLAPolicyDTO struct {
+	Name           string              `json:"name" validate:"required,min=2,max=255"`
+	Timezone       string              `json:"timezone" validate:"required,timezone"`
+	BusinessHours  []BusinessWindowDTO `json:"business_hours" validate:"dive"`
+	WarningPercent int                 `json:"warning_percent,omitempty" validate:"omitempty,min=1,max=99"`
+	Targets        []SLATargetDTO      `json:"targets" validate:"required,min=1,unique=Priority,dive"`
+	Holidays       []SLAHolidayDTO     `json:"holidays" validate:"unique=Day,dive"`
+}
+
+// BusinessWindowDTO is a span of working hours on a weekday; Sunday is 0.
+type BusinessWindowDTO struct {
+	Day  int    `json:"day" validate:"min=0,max=6"`
+	From string `json:"from" validate:"required,len=5"`
+	To   string `json:"to" validate:"required,len=5"`
+}
+
+// SLATargetDTO holds the business minutes allowed for a priority.
+type SLATargetDTO struct {
+	Priority             string `json:"priority" validate:"required,oneof=P1 P2 P3 P4"`
+	FirstResponseMinutes int    `json:"first_response_minutes" validate:"required,min=1"`
+	ResolutionMinutes    int    `json:"resolution_minutes" validate:"required,gtefield=FirstResponseMinutes"`
+}
+
+// SLAHolidayDTO is a day on which the clock stands still.
+type SLAHolidayDTO struct {
+	Day  string  `json:"day" validate:"required,datetime=2006-01-02"`
+	Name *string `json:"name,omitempty" validate:"omitempty,max=255"`
+}
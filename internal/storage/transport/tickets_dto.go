@@ -1,25 +1,57 @@
 package transport
 
-// CreateTicketDTO represents the data structure for creating a ticket.
+// CreateTicketDTO represents the data structure for creating a ticket. A
+// priority given by a client is only recorded as a proposal.
 type CreateTicketDTO struct {
 	ProjectID           int     `json:"project_id" validate:"required"`
 	ModuleID            *int    `json:"module_id,omitempty"`
 	ContractID          int     `json:"contract_id" validate:"required"`
-	CreatedBy           string  `json:"created_by" validate:"required,uuid4"`
 	AssignedTo          *string `json:"assigned_to,omitempty" validate:"omitempty,uuid4"`
 	Title               string  `json:"title" validate:"required,min=3,max=255"`
 	Message             string  `json:"message" validate:"required"`
-	Status              string  `json:"status" validate:"omitempty,oneof=open in_progress resolved closed"`
+	Status              string  `json:"status" validate:"omitempty,oneof=open in_progress waiting resolved closed"`
+	Priority            string  `json:"priority,omitempty" validate:"omitempty,oneof=P1 P2 P3 P4"`
+	Severity            string  `json:"severity,omitempty" validate:"omitempty,oneof=critical major minor trivial"`
 	GitlabIssueURL      *string `json:"gitlab_issue_url,omitempty" validate:"omitempty,url"`
 	MattermostThreadURL *string `json:"mattermost_thread_url,omitempty" validate:"omitempty,url"`
 }
 
-// UpdateTicketDTO represents the data structure for updating a ticket.
+// UpdateTicketDTO represents the data structure for updating a ticket. An
+// empty priority or severity keeps the current one.
 type UpdateTicketDTO struct {
+	ModuleID            *int    `json:"module_id,omitempty"`
 	AssignedTo          *string `json:"assigned_to,omitempty" validate:"omitempty,uuid4"`
 	Title               string  `json:"title" validate:"required,min=3,max=255"`
 	Message             string  `json:"message" validate:"required"`
-	Status              string  `json:"status" validate:"required,oneof=open in_progress resolved closed"`
+	Status              string  `json:"status" validate:"required,oneof=open in_progress waiting resolved closed"`
+	Priority            string  `json:"priority,omitempty" validate:"omitempty,oneof=P1 P2 P3 P4"`
+	Severity            string  `json:"severity,omitempty" validate:"omitempty,oneof=critical major minor trivial"`
 	GitlabIssueURL      *string `json:"gitlab_issue_url,omitempty" validate:"omitempty,url"`
 	MattermostThreadURL *string `json:"mattermost_thread_url,omitempty" validate:"omitempty,url"`
 }
+
+// TicketTransitionDTO represents a request to apply a workflow transition to a ticket.
+type TicketTransitionDTO struct {
+	Transition     string `json:"transition" validate:"required"`
+	ResolutionNote string `json:"resolution_note,omitempty" validate:"omitempty,max=5000"`
+}
+
+// TicketListQueryDTO represents the query parameters of the ticket listing.
+type TicketListQueryDTO struct {
+	ProjectID   *int     `query:"project_id"`
+	ContractID  *int     `query:"contract_id"`
+	ModuleID    *int     `query:"module_id"`
+	Status      []string `query:"status" validate:"omitempty,dive,oneof=open in_progress waiting resolved closed"`
+	Priority    []string `query:"priority" validate:"omitempty,dive,oneof=P1 P2 P3 P4"`
+	Severity    []string `query:"severity" validate:"omitempty,dive,oneof=critical major minor trivial"`
+	AssignedTo  *string  `query:"assigned_to" validate:"omitempty,uuid"`
+	CreatedBy   *string  `query:"created_by" validate:"omitempty,uuid"`
+	CreatedFrom string   `query:"created_from" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	CreatedTo   string   `query:"created_to" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	UpdatedFrom string   `query:"updated_from" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	UpdatedTo   string   `query:"updated_to" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	Title       string   `query:"title" validate:"omitempty,max=255"`
+	Sort        string   `query:"sort" validate:"omitempty,oneof=date_created -date_created date_updated -date_updated title -title priority -priority severity -severity"`
+	Limit       int      `query:"limit" validate:"omitempty,min=1,max=200"`
+	Cursor      string   `query:"cursor" validate:"omitempty,max=1024"`
+}
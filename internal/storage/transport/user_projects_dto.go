@@ -4,12 +4,14 @@ package transport
 type CreateUserProjectDTO struct {
 	UserID      string   `json:"user_id" validate:"required,uuid4"`
 	ProjectID   int      `json:"project_id" validate:"required"`
+	ContractID  *int     `json:"contract_id,omitempty"`
 	Role        string   `json:"role" validate:"required,oneof=viewer editor admin owner"`
-	Permissions []string `json:"permissions" validate:"required,dive,alpha"`
+	Permissions []string `json:"permissions" validate:"omitempty,dive,permission"`
 }
 
 // UpdateUserProjectDTO represents the data structure for updating a user-project relationship.
 type UpdateUserProjectDTO struct {
+	ContractID  *int     `json:"contract_id,omitempty"`
 	Role        string   `json:"role" validate:"required,oneof=viewer editor admin owner"`
-	Permissions []string `json:"permissions" validate:"required,dive,alpha"`
+	Permissions []string `json:"permissions" validate:"omitempty,dive,permission"`
 }
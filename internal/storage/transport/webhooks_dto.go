@@ -0,0 +1,15 @@
+package transport
+
+// CreateWebhookDTO represents the data structure for creating a webhook subscription.
+type CreateWebhookDTO struct {
+	URL    string   `json:"url" validate:"required,http_url,webhook_url,max=2048"`
+	Secret string   `json:"secret,omitempty" validate:"omitempty,min=16,max=256"`
+	Events []string `json:"events" validate:"required,min=1,dive,webhook_event"`
+}
+
+// UpdateWebhookDTO represents the data structure for updating a webhook subscription.
+type UpdateWebhookDTO struct {
+	URL    string   `json:"url" validate:"required,http_url,webhook_url,max=2048"`
+	Events []string `json:"events" validate:"required,min=1,dive,webhook_event"`
+	Active *bool    `json:"active,omitempty"`
+}
@@ -2,8 +2,10 @@
 package ticketattachments
 
 import (
+	"innotech/internal/files"
 	"innotech/internal/storage/postgres"
 	"innotech/internal/storage/transport"
+	"innotech/pkg/middleware"
 	"strconv"
 
 	"github.com/gofiber/fiber/v2"
@@ -33,13 +35,13 @@ func (h *Handler) Create(c *fiber.Ctx) error {
 	att := postgres.TicketAttachment{
 		TicketID:    dto.TicketID,
 		FilePath:    dto.FilePath,
-		UploadedBy:  dto.UploadedBy,
+		UploadedBy:  middleware.UserID(c),
 		FileType:    dto.FileType,
 		Description: dto.Description,
 	}
 
-	if err := h.service.Create(c.Context(), &att); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	if err := h.service.Create(c.UserContext(), &att); err != nil {
+		return c.Status(files.Status(err)).JSON(fiber.Map{"error": err.Error()})
 	}
 	return c.Status(fiber.StatusCreated).JSON(att)
 }
@@ -56,7 +58,7 @@ func (h *Handler) GetByID(c *fiber.Ctx) error {
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
 	}
-	att, err := h.service.GetByID(c.Context(), id)
+	att, err := h.service.GetByID(c.UserContext(), id)
 	if err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "not found"})
 	}
@@ -75,7 +77,7 @@ func (h *Handler) GetByTicketID(c *fiber.Ctx) error {
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid ticket id"})
 	}
-	list, err := h.service.GetByTicketID(c.Context(), ticketID)
+	list, err := h.service.GetByTicketID(c.UserContext(), ticketID)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
@@ -106,8 +108,8 @@ func (h *Handler) Update(c *fiber.Ctx) error {
 		Description: dto.Description,
 	}
 
-	if err := h.service.Update(c.Context(), &att); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	if err := h.service.Update(c.UserContext(), &att); err != nil {
+		return c.Status(files.Status(err)).JSON(fiber.Map{"error": err.Error()})
 	}
 	return c.JSON(att)
 }
@@ -123,7 +125,7 @@ func (h *Handler) Delete(c *fiber.Ctx) error {
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
 	}
-	if err := h.service.Delete(c.Context(), id); err != nil {
+	if err := h.service.Delete(c.UserContext(), id); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 	return c.SendStatus(fiber.StatusNoContent)
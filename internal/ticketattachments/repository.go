@@ -3,6 +3,7 @@ package ticketattachments
 import (
 	"context"
 	"innotech/internal/storage/postgres"
+	"innotech/pkg/db"
 
 	"github.com/jmoiron/sqlx"
 )
@@ -27,23 +28,25 @@ func NewRepository(db *sqlx.DB) Repository {
 
 func (r *repository) Create(ctx context.Context, att *postgres.TicketAttachment) error {
 	query := `
-		INSERT INTO ticket_attachments (ticket_id, file_path, uploaded_by, file_type, description)
-		VALUES ($1, $2, $3, $4, $5)
-		RETURNING id, date_created, date_updated
+		INSERT INTO ticket_attachments (ticket_id, file_path, uploaded_by, file_type, description, size, checksum)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, date_created, date_updated, scan_status
 	`
 
-	return r.db.QueryRowxContext(ctx, query,
+	return db.Conn(ctx, r.db).QueryRowxContext(ctx, query,
 		att.TicketID,
 		att.FilePath,
 		att.UploadedBy,
 		att.FileType,
 		att.Description,
+		att.Size,
+		att.Checksum,
 	).StructScan(att)
 }
 
 func (r *repository) GetByID(ctx context.Context, id int) (*postgres.TicketAttachment, error) {
 	var att postgres.TicketAttachment
-	err := r.db.GetContext(ctx, &att, `SELECT * FROM ticket_attachments WHERE id = $1`, id)
+	err := db.Conn(ctx, r.db).GetContext(ctx, &att, `SELECT * FROM ticket_attachments WHERE id = $1`, id)
 	if err != nil {
 		return nil, err
 	}
@@ -52,7 +55,7 @@ func (r *repository) GetByID(ctx context.Context, id int) (*postgres.TicketAttac
 
 func (r *repository) GetByTicketID(ctx context.Context, ticketID int) ([]postgres.TicketAttachment, error) {
 	var list []postgres.TicketAttachment
-	err := r.db.SelectContext(ctx, &list, `SELECT * FROM ticket_attachments WHERE ticket_id = $1 ORDER BY date_created`, ticketID)
+	err := db.Conn(ctx, r.db).SelectContext(ctx, &list, `SELECT * FROM ticket_attachments WHERE ticket_id = $1 ORDER BY date_created`, ticketID)
 	if err != nil {
 		return nil, err
 	}
@@ -64,20 +67,24 @@ func (r *repository) Update(ctx context.Context, att *postgres.TicketAttachment)
 		UPDATE ticket_attachments
 		SET file_path = $1,
 		    file_type = $2,
-		    description = $3
-		WHERE id = $4
-		RETURNING date_updated
+		    description = $3,
+		    size = $4,
+		    checksum = $5
+		WHERE id = $6
+		RETURNING date_updated, scan_status
 	`
 
-	return r.db.QueryRowxContext(ctx, query,
+	return db.Conn(ctx, r.db).QueryRowxContext(ctx, query,
 		att.FilePath,
 		att.FileType,
 		att.Description,
+		att.Size,
+		att.Checksum,
 		att.ID,
-	).Scan(&att.DateUpdated)
+	).Scan(&att.DateUpdated, &att.ScanStatus)
 }
 
 func (r *repository) Delete(ctx context.Context, id int) error {
-	_, err := r.db.ExecContext(ctx, `DELETE FROM ticket_attachments WHERE id = $1`, id)
+	_, err := db.Conn(ctx, r.db).ExecContext(ctx, `DELETE FROM ticket_attachments WHERE id = $1`, id)
 	return err
 }
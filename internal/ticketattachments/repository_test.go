@@ -31,13 +31,11 @@ func TestRepository_Create_WithReturning(t *testing.T) {
 		Description: ptr("Screenshot"),
 	}
 
-	mock.ExpectPrepare(`INSERT INTO ticket_attachments.*RETURNING.*`)
+	rows := sqlmock.NewRows([]string{"id", "date_created", "date_updated", "scan_status"}).
+		AddRow(42, now, now, "pending")
 
-	rows := sqlmock.NewRows([]string{"id", "date_created", "date_updated"}).
-		AddRow(42, now, now)
-
-	mock.ExpectQuery(`INSERT INTO ticket_attachments.*RETURNING.*`).
-		WithArgs(att.TicketID, att.FilePath, att.UploadedBy, att.FileType, att.Description).
+	mock.ExpectQuery(`INSERT INTO ticket_attachments.*RETURNING id, date_created, date_updated, scan_status`).
+		WithArgs(att.TicketID, att.FilePath, att.UploadedBy, att.FileType, att.Description, att.Size, att.Checksum).
 		WillReturnRows(rows)
 
 	err = repo.Create(context.Background(), att)
@@ -65,18 +63,17 @@ func TestRepository_Update_WithReturning(t *testing.T) {
 		Description: ptr("Updated doc"),
 	}
 
-	mock.ExpectPrepare(`UPDATE ticket_attachments SET.*WHERE id = \?.*RETURNING date_updated`)
-
-	rows := sqlmock.NewRows([]string{"date_updated"}).AddRow(now)
+	rows := sqlmock.NewRows([]string{"date_updated", "scan_status"}).AddRow(now, "pending")
 
-	mock.ExpectQuery(`UPDATE ticket_attachments SET.*WHERE id = \?.*RETURNING date_updated`).
-		WithArgs(att.FilePath, att.FileType, att.Description, att.ID).
+	mock.ExpectQuery(`UPDATE ticket_attachments\s+SET.*WHERE id = \$6\s+RETURNING date_updated, scan_status`).
+		WithArgs(att.FilePath, att.FileType, att.Description, att.Size, att.Checksum, att.ID).
 		WillReturnRows(rows)
 
 	err = repo.Update(context.Background(), att)
 
 	assert.NoError(t, err)
 	assert.Equal(t, now, att.DateUpdated)
+	assert.Equal(t, "pending", att.ScanStatus)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
@@ -1,6 +1,7 @@
 package ticketattachments
 
 import (
+	"innotech/internal/access"
 	"innotech/internal/storage/transport"
 	"innotech/pkg/middleware"
 
@@ -8,12 +9,12 @@ import (
 )
 
 // RegisterRoutes registers HTTP routes for ticket attachment operations.
-func RegisterRoutes(app *fiber.App, h *Handler) {
+func RegisterRoutes(app *fiber.App, h *Handler, guard *access.Guard) {
 	api := app.Group("/api/ticket_attachments")
 
-	api.Get("/:id", h.GetByID)
-	api.Get("/ticket/:ticket_id", h.GetByTicketID)
-	api.Post("/", middleware.ValidateBody[transport.CreateTicketAttachmentDTO](h.Create))
-	api.Put("/:id", middleware.ValidateBody[transport.UpdateTicketAttachmentDTO](h.Update))
-	api.Delete("/:id", h.Delete)
+	api.Get("/:id", guard.Require(access.AttachmentRead, guard.Param("id", access.ResourceTicketAttachment)), h.GetByID)
+	api.Get("/ticket/:ticket_id", guard.Require(access.AttachmentRead, guard.Param("ticket_id", access.ResourceTicket)), h.GetByTicketID)
+	api.Post("/", guard.Require(access.AttachmentWrite, guard.Body("ticket_id", access.ResourceTicket)), middleware.ValidateBody[transport.CreateTicketAttachmentDTO](h.Create))
+	api.Put("/:id", guard.Require(access.AttachmentWrite, guard.Param("id", access.ResourceTicketAttachment)), middleware.ValidateBody[transport.UpdateTicketAttachmentDTO](h.Update))
+	api.Delete("/:id", guard.Require(access.AttachmentDelete, guard.Param("id", access.ResourceTicketAttachment)), h.Delete)
 }
@@ -3,9 +3,27 @@ package ticketattachments
 import (
 	"context"
 	"errors"
+	"innotech/internal/events"
+	"innotech/internal/files"
 	"innotech/internal/storage/postgres"
+	"innotech/pkg/db"
 )
 
+// ActivityPublisher appends events to the activity feed of a ticket's project.
+type ActivityPublisher interface {
+	PublishForTicket(ctx context.Context, ticketID int, eventType string, payload any) error
+}
+
+// ObjectVerifier checks that a storage key names a stored file of the ticket.
+type ObjectVerifier interface {
+	Verify(ctx context.Context, ticketID int, key string) (*files.Object, error)
+}
+
+// PreviewLinker fills in the preview links of attachments in responses.
+type PreviewLinker interface {
+	TicketAttachment(ctx context.Context, att *postgres.TicketAttachment)
+}
+
 // Service defines the interface for ticket attachment business logic operations.
 type Service interface {
 	Create(ctx context.Context, att *postgres.TicketAttachment) error
@@ -16,34 +34,95 @@ type Service interface {
 }
 
 type service struct {
-	repo Repository
+	repo     Repository
+	activity ActivityPublisher
+	objects  ObjectVerifier
+	previews PreviewLinker
+	tx       db.Transactor
 }
 
-// NewService creates a new Service instance.
-func NewService(repo Repository) Service {
-	return &service{repo: repo}
+// NewService creates a new Service instance. Attachments must point at files
+// stored under their ticket; size, checksum and type are taken from the file.
+func NewService(repo Repository, activity ActivityPublisher, objects ObjectVerifier, previews PreviewLinker, tx db.Transactor) Service {
+	return &service{repo: repo, activity: activity, objects: objects, previews: previews, tx: tx}
 }
 
 func (s *service) Create(ctx context.Context, att *postgres.TicketAttachment) error {
 	if att.FilePath == "" {
 		return errors.New("file_path cannot be empty")
 	}
-	// Pass the context to the repository
-	return s.repo.Create(ctx, att)
+	if err := s.verify(ctx, att); err != nil {
+		return err
+	}
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Create(ctx, att); err != nil {
+			return err
+		}
+		return s.activity.PublishForTicket(ctx, att.TicketID, events.AttachmentAdded, map[string]any{
+			"attachment_id": att.ID,
+			"ticket_id":     att.TicketID,
+			"file_path":     att.FilePath,
+			"file_type":     att.FileType,
+			"uploaded_by":   att.UploadedBy,
+		})
+	})
+	if err != nil {
+		return err
+	}
+	s.previews.TicketAttachment(ctx, att)
+	return nil
 }
 
 func (s *service) GetByID(ctx context.Context, id int) (*postgres.TicketAttachment, error) {
-	return s.repo.GetByID(ctx, id)
+	att, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	s.previews.TicketAttachment(ctx, att)
+	return att, nil
 }
 
 func (s *service) GetByTicketID(ctx context.Context, ticketID int) ([]postgres.TicketAttachment, error) {
-	return s.repo.GetByTicketID(ctx, ticketID)
+	list, err := s.repo.GetByTicketID(ctx, ticketID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range list {
+		s.previews.TicketAttachment(ctx, &list[i])
+	}
+	return list, nil
 }
 
+// Update changes the description and file of an attachment. A new file is
+// verified like on creation; the previous one is removed from storage.
 func (s *service) Update(ctx context.Context, att *postgres.TicketAttachment) error {
-	return s.repo.Update(ctx, att)
+	current, err := s.repo.GetByID(ctx, att.ID)
+	if err != nil {
+		return err
+	}
+	att.TicketID, att.UploadedBy, att.DateCreated = current.TicketID, current.UploadedBy, current.DateCreated
+	if att.FilePath == "" || att.FilePath == current.FilePath {
+		att.FilePath, att.FileType, att.Size, att.Checksum = current.FilePath, current.FileType, current.Size, current.Checksum
+		att.ThumbnailPath, att.PreviewPath = current.ThumbnailPath, current.PreviewPath
+	} else if err := s.verify(ctx, att); err != nil {
+		return err
+	}
+	if err := s.repo.Update(ctx, att); err != nil {
+		return err
+	}
+	s.previews.TicketAttachment(ctx, att)
+	return nil
 }
 
 func (s *service) Delete(ctx context.Context, id int) error {
 	return s.repo.Delete(ctx, id)
 }
+
+func (s *service) verify(ctx context.Context, att *postgres.TicketAttachment) error {
+	obj, err := s.objects.Verify(ctx, att.TicketID, att.FilePath)
+	if err != nil {
+		return err
+	}
+	att.FileType, att.Size, att.Checksum = &obj.ContentType, &obj.Size, &obj.Checksum
+	return nil
+}
@@ -3,7 +3,10 @@ package ticketattachments
 import (
 	"context"
 	"errors"
+	"innotech/internal/events"
+	"innotech/internal/files"
 	"innotech/internal/storage/postgres"
+	"innotech/pkg/db"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -36,9 +39,38 @@ func (m *mockRepoTA) Delete(ctx context.Context, id int) error {
 	return m.Called(ctx, id).Error(0)
 }
 
+type mockActivity struct{ mock.Mock }
+
+func (m *mockActivity) PublishForTicket(ctx context.Context, ticketID int, eventType string, payload any) error {
+	return m.Called(ctx, ticketID, eventType, payload).Error(0)
+}
+
+// stubVerifier accepts every key except "missing".
+type stubVerifier struct{}
+
+func (stubVerifier) Verify(_ context.Context, _ int, key string) (*files.Object, error) {
+	if key == "missing" {
+		return nil, files.ErrNotFound
+	}
+	return &files.Object{Key: key, Size: 4, Checksum: "c0ffee", ContentType: "image/png"}, nil
+}
+
+// stubLinks links previews to their keys.
+type stubLinks struct{}
+
+func (stubLinks) TicketAttachment(_ context.Context, att *postgres.TicketAttachment) {
+	att.ThumbnailURL, att.Icon = att.ThumbnailPath, files.Icon(att.FileType)
+}
+
+func newTestService(repo Repository) Service {
+	activity := new(mockActivity)
+	activity.On("PublishForTicket", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	return NewService(repo, activity, stubVerifier{}, stubLinks{}, db.NopTransactor{})
+}
+
 func TestCreate_ValidationFails_WhenEmptyFilePath(t *testing.T) {
 	repo := new(mockRepoTA)
-	svc := NewService(repo)
+	svc := newTestService(repo)
 
 	err := svc.Create(context.Background(), &postgres.TicketAttachment{FilePath: ""})
 	assert.Error(t, err)
@@ -46,7 +78,7 @@ func TestCreate_ValidationFails_WhenEmptyFilePath(t *testing.T) {
 
 func TestCreate_HappyPath_CallsRepo(t *testing.T) {
 	repo := new(mockRepoTA)
-	svc := NewService(repo)
+	svc := newTestService(repo)
 
 	att := &postgres.TicketAttachment{TicketID: 1, FilePath: "p"}
 	repo.On("Create", mock.Anything, att).Return(nil).Once()
@@ -58,7 +90,7 @@ func TestCreate_HappyPath_CallsRepo(t *testing.T) {
 
 func TestCreate_RepoError_ReturnsError(t *testing.T) {
 	repo := new(mockRepoTA)
-	svc := NewService(repo)
+	svc := newTestService(repo)
 
 	att := &postgres.TicketAttachment{TicketID: 1, FilePath: "p"}
 	repo.On("Create", mock.Anything, att).Return(errors.New("db")).Once()
@@ -70,12 +102,12 @@ func TestCreate_RepoError_ReturnsError(t *testing.T) {
 
 func TestGetAndUpdateDelete_PassesThrough(t *testing.T) {
 	repo := new(mockRepoTA)
-	svc := NewService(repo)
+	svc := newTestService(repo)
 	ctx := context.Background()
 
 	exp := &postgres.TicketAttachment{ID: 10}
 
-	repo.On("GetByID", mock.Anything, 10).Return(exp, nil).Once()
+	repo.On("GetByID", mock.Anything, 10).Return(exp, nil).Twice()
 	repo.On("GetByTicketID", mock.Anything, 2).Return([]postgres.TicketAttachment{{ID: 1}}, nil).Once()
 	repo.On("Update", mock.Anything, exp).Return(nil).Once()
 	repo.On("Delete", mock.Anything, 10).Return(nil).Once()
@@ -92,3 +124,84 @@ func TestGetAndUpdateDelete_PassesThrough(t *testing.T) {
 	assert.NoError(t, svc.Delete(ctx, 10))
 	repo.AssertExpectations(t)
 }
+
+func TestCreate_PublishesAttachmentAdded(t *testing.T) {
+	repo := new(mockRepoTA)
+	activity := new(mockActivity)
+	svc := NewService(repo, activity, stubVerifier{}, stubLinks{}, db.NopTransactor{})
+
+	att := &postgres.TicketAttachment{TicketID: 3, FilePath: "p"}
+	repo.On("Create", mock.Anything, att).Return(nil).Once()
+	activity.On("PublishForTicket", mock.Anything, 3, events.AttachmentAdded, mock.Anything).Return(nil).Once()
+
+	assert.NoError(t, svc.Create(context.Background(), att))
+	activity.AssertExpectations(t)
+}
+
+func TestCreate_TakesMetadataFromStoredFile(t *testing.T) {
+	repo := new(mockRepoTA)
+	svc := newTestService(repo)
+
+	clientType := "text/plain"
+	att := &postgres.TicketAttachment{TicketID: 1, FilePath: "p", FileType: &clientType}
+	repo.On("Create", mock.Anything, att).Return(nil).Once()
+
+	assert.NoError(t, svc.Create(context.Background(), att))
+	assert.Equal(t, "image/png", *att.FileType)
+	assert.Equal(t, int64(4), *att.Size)
+	assert.Equal(t, "c0ffee", *att.Checksum)
+}
+
+func TestCreate_RejectsUnverifiedFile(t *testing.T) {
+	repo := new(mockRepoTA)
+	svc := newTestService(repo)
+
+	err := svc.Create(context.Background(), &postgres.TicketAttachment{TicketID: 1, FilePath: "missing"})
+	assert.ErrorIs(t, err, files.ErrNotFound)
+	repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestUpdate_VerifiesNewFileAndKeepsOld(t *testing.T) {
+	repo := new(mockRepoTA)
+	svc := newTestService(repo)
+	ctx := context.Background()
+
+	size, sum, typ := int64(9), "old", "application/pdf"
+	current := &postgres.TicketAttachment{ID: 4, TicketID: 2, FilePath: "old", UploadedBy: "u", FileType: &typ, Size: &size, Checksum: &sum}
+	repo.On("GetByID", mock.Anything, 4).Return(current, nil)
+	repo.On("Update", mock.Anything, mock.Anything).Return(nil)
+
+	desc := "renamed"
+	kept := &postgres.TicketAttachment{ID: 4, Description: &desc}
+	assert.NoError(t, svc.Update(ctx, kept))
+	assert.Equal(t, "old", kept.FilePath)
+	assert.Equal(t, &sum, kept.Checksum)
+
+	replaced := &postgres.TicketAttachment{ID: 4, FilePath: "new"}
+	assert.NoError(t, svc.Update(ctx, replaced))
+	assert.Equal(t, 2, replaced.TicketID)
+	assert.Equal(t, "c0ffee", *replaced.Checksum)
+
+	err := svc.Update(ctx, &postgres.TicketAttachment{ID: 4, FilePath: "missing"})
+	assert.ErrorIs(t, err, files.ErrNotFound)
+}
+
+func TestGet_FillsPreviewLinks(t *testing.T) {
+	repo := new(mockRepoTA)
+	svc := newTestService(repo)
+	ctx := context.Background()
+
+	png, thumb := "image/png", "projects/1/tickets/2/a.thumb.jpg"
+	repo.On("GetByID", mock.Anything, 10).Return(&postgres.TicketAttachment{ID: 10, FileType: &png, ThumbnailPath: &thumb}, nil).Once()
+	repo.On("GetByTicketID", mock.Anything, 2).Return([]postgres.TicketAttachment{{ID: 1}}, nil).Once()
+
+	got, err := svc.GetByID(ctx, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, &thumb, got.ThumbnailURL)
+	assert.Equal(t, "image", got.Icon)
+
+	list, err := svc.GetByTicketID(ctx, 2)
+	assert.NoError(t, err)
+	assert.Nil(t, list[0].ThumbnailURL)
+	assert.Equal(t, "file", list[0].Icon)
+}
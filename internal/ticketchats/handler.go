@@ -2,21 +2,31 @@
 package ticketchats
 
 import (
+	"context"
+	"errors"
+	"innotech/internal/access"
 	"innotech/internal/storage/postgres"
 	"innotech/internal/storage/transport"
+	"innotech/pkg/middleware"
 	"strconv"
 
 	"github.com/gofiber/fiber/v2"
 )
 
+// SenderRoles tells whether the caller acts as a client or an admin in a project.
+type SenderRoles interface {
+	SenderRole(ctx context.Context, projectID int) (string, error)
+}
+
 // Handler handles HTTP requests for ticket chat operations.
 type Handler struct {
 	service Service
+	roles   SenderRoles
 }
 
 // NewHandler creates a new Handler instance.
-func NewHandler(service Service) *Handler {
-	return &Handler{service: service}
+func NewHandler(service Service, roles SenderRoles) *Handler {
+	return &Handler{service: service, roles: roles}
 }
 
 // Create godoc
@@ -27,20 +37,31 @@ func NewHandler(service Service) *Handler {
 // @Param chat body transport.CreateTicketChatDTO true "Chat"
 // @Success 201 {object} postgres.TicketChat
 // @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
 // @Router /ticket_chats/ [post]
 func (h *Handler) Create(c *fiber.Ctx) error {
 	dto := c.Locals("body").(*transport.CreateTicketChatDTO)
 
+	// The sender role comes from the caller's membership in the ticket's
+	// project, located by the access guard, never from the request.
+	projectID, _ := c.Locals("project_id").(int)
+	role, err := h.roles.SenderRole(c.UserContext(), projectID)
+	if err != nil {
+		if errors.Is(err, access.ErrUnauthenticated) {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
 	chat := postgres.TicketChat{
-		TicketID:            dto.TicketID,
-		SenderID:            dto.SenderID,
-		SenderRole:          dto.SenderRole,
-		Message:             dto.Message,
-		MessageType:         dto.MessageType,
-		MattermostMessageID: dto.MattermostMessageID,
+		TicketID:    dto.TicketID,
+		SenderID:    middleware.UserID(c),
+		SenderRole:  role,
+		Message:     dto.Message,
+		MessageType: dto.MessageType,
 	}
 
-	if err := h.service.Create(c.Context(), &chat); err != nil {
+	if err := h.service.Create(c.UserContext(), &chat); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 	return c.Status(fiber.StatusCreated).JSON(chat)
@@ -59,7 +80,7 @@ func (h *Handler) GetByID(c *fiber.Ctx) error {
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
 	}
-	chat, err := h.service.GetByID(c.Context(), id)
+	chat, err := h.service.GetByID(c.UserContext(), id)
 	if err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "not found"})
 	}
@@ -78,7 +99,7 @@ func (h *Handler) GetByTicketID(c *fiber.Ctx) error {
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid ticket id"})
 	}
-	chats, err := h.service.GetByTicketID(c.Context(), ticketID)
+	chats, err := h.service.GetByTicketID(c.UserContext(), ticketID)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
@@ -108,7 +129,7 @@ func (h *Handler) Update(c *fiber.Ctx) error {
 		MessageType: dto.MessageType,
 	}
 
-	if err := h.service.Update(c.Context(), &chat); err != nil {
+	if err := h.service.Update(c.UserContext(), &chat); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 	return c.JSON(chat)
@@ -125,7 +146,7 @@ func (h *Handler) Delete(c *fiber.Ctx) error {
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
 	}
-	if err := h.service.Delete(c.Context(), id); err != nil {
+	if err := h.service.Delete(c.UserContext(), id); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 	return c.SendStatus(fiber.StatusNoContent)
@@ -0,0 +1,86 @@
+package ticketchats
+
+import (
+	"context"
+	"encoding/json"
+	"innotech/internal/access"
+	"innotech/internal/storage/postgres"
+	"innotech/pkg/auth"
+	"innotech/pkg/logger"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	logger.Init()
+	os.Exit(m.Run())
+}
+
+// memberships serves project 7 memberships; every ticket belongs to it.
+type memberships map[string]access.Role
+
+func (m memberships) GetMembership(_ context.Context, userID string, projectID int) (*access.Membership, error) {
+	role, ok := m[userID]
+	if !ok || projectID != 7 {
+		return nil, nil
+	}
+	return &access.Membership{UserID: userID, ProjectID: projectID, Role: role}, nil
+}
+
+func (m memberships) ListMemberships(context.Context, string) ([]access.Membership, error) {
+	return nil, nil
+}
+
+func (memberships) ProjectOf(context.Context, access.Resource, int) (int, error) {
+	return 7, nil
+}
+
+func TestHandler_Create_TakesSenderRoleFromMembership(t *testing.T) {
+	members := memberships{"client-1": access.RoleEditor, "support-1": access.RoleAdmin}
+	policy := access.NewPolicy(members)
+
+	repo := new(mockRepo)
+	repo.On("Create", mock.Anything, mock.Anything).Return(nil)
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		sub := c.Get("X-Test-Subject")
+		c.Locals("user_id", sub)
+		c.SetUserContext(auth.WithIdentity(c.UserContext(), &auth.Identity{Subject: sub}))
+		return c.Next()
+	})
+	RegisterRoutes(app, NewHandler(newTestService(repo), policy), nil, access.NewGuard(policy, members))
+
+	post := func(subject, body string) (int, postgres.TicketChat) {
+		req := httptest.NewRequest(http.MethodPost, "/api/ticket_chats", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Test-Subject", subject)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		var chat postgres.TicketChat
+		_ = json.NewDecoder(resp.Body).Decode(&chat)
+		return resp.StatusCode, chat
+	}
+
+	// A forged role in the body is ignored.
+	status, chat := post("client-1", `{"ticket_id":3,"message":"hi","message_type":"text","sender_role":"admin"}`)
+	require.Equal(t, fiber.StatusCreated, status)
+	assert.Equal(t, access.SenderClient, chat.SenderRole)
+	assert.Equal(t, "client-1", chat.SenderID)
+
+	// So is a claimed Mattermost post, which would keep the message out of the thread.
+	status, chat = post("client-1", `{"ticket_id":3,"message":"hi","message_type":"text","mattermost_message_id":"p1"}`)
+	require.Equal(t, fiber.StatusCreated, status)
+	assert.Nil(t, chat.MattermostMessageID)
+
+	status, chat = post("support-1", `{"ticket_id":3,"message":"on it","message_type":"text"}`)
+	require.Equal(t, fiber.StatusCreated, status)
+	assert.Equal(t, access.SenderAdmin, chat.SenderRole, "records the first response")
+}
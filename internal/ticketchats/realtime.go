@@ -0,0 +1,181 @@
+package ticketchats
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"innotech/internal/storage/postgres"
+	"innotech/pkg/logger"
+	"innotech/pkg/pgnotify"
+	"slices"
+	"strconv"
+	"sync"
+)
+
+// Real-time event types pushed to chat subscribers.
+const (
+	EventMessageCreated = "message.created"
+	EventMessageUpdated = "message.updated"
+	EventMessageDeleted = "message.deleted"
+	EventTyping         = "typing"
+	EventPresenceJoin   = "presence.join"
+	EventPresenceLeave  = "presence.leave"
+	EventPresenceState  = "presence.state"
+)
+
+// Event is a single real-time update of a ticket chat.
+type Event struct {
+	Type     string               `json:"type"`
+	TicketID int                  `json:"ticket_id"`
+	Message  *postgres.TicketChat `json:"message,omitempty"`
+	// Truncated is set when the message body did not fit into a notification;
+	// clients should fetch the message by ID.
+	Truncated bool     `json:"truncated,omitempty"`
+	UserID    string   `json:"user_id,omitempty"`
+	Users     []string `json:"users,omitempty"`
+	Replica   string   `json:"replica,omitempty"`
+}
+
+// Hub fans out events to every application replica.
+type Hub interface {
+	Subscribe(topic string) *pgnotify.Subscription
+	Publish(ctx context.Context, topic string, data any) error
+}
+
+// Topic returns the hub topic carrying the events of a ticket chat.
+func Topic(ticketID int) string {
+	return "ticket_chat:" + strconv.Itoa(ticketID)
+}
+
+// Realtime publishes chat changes and tracks who is connected to each ticket
+// chat on this replica. Presence across replicas is assembled by clients from
+// presence.state answers that every replica sends when someone joins.
+type Realtime struct {
+	hub     Hub
+	replica string
+
+	mu    sync.Mutex
+	rooms map[int]*room
+}
+
+type room struct {
+	users map[string]int
+	sub   *pgnotify.Subscription
+}
+
+// NewRealtime creates a Realtime on top of hub.
+func NewRealtime(hub Hub) *Realtime {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return &Realtime{hub: hub, replica: hex.EncodeToString(b), rooms: map[int]*room{}}
+}
+
+// Notify publishes a message change. It implements Notifier.
+func (r *Realtime) Notify(ctx context.Context, eventType string, chat *postgres.TicketChat) error {
+	e := Event{Type: eventType, TicketID: chat.TicketID, Message: chat}
+	err := r.hub.Publish(ctx, Topic(chat.TicketID), e)
+	if errors.Is(err, pgnotify.ErrPayloadTooLarge) {
+		short := *chat
+		short.Message = ""
+		e.Message, e.Truncated = &short, true
+		err = r.hub.Publish(ctx, Topic(chat.TicketID), e)
+	}
+	return err
+}
+
+// Subscribe returns a subscription to the events of a ticket chat.
+func (r *Realtime) Subscribe(ticketID int) *pgnotify.Subscription {
+	return r.hub.Subscribe(Topic(ticketID))
+}
+
+// Join registers a connection of userID and announces it to all replicas. It
+// returns the users connected to the ticket chat on this replica, including
+// userID itself.
+func (r *Realtime) Join(ctx context.Context, ticketID int, userID string) []string {
+	r.mu.Lock()
+	rm, ok := r.rooms[ticketID]
+	if !ok {
+		rm = &room{users: map[string]int{}, sub: r.hub.Subscribe(Topic(ticketID))}
+		r.rooms[ticketID] = rm
+		go r.answerJoins(ticketID, rm.sub)
+	}
+	rm.users[userID]++
+	first := rm.users[userID] == 1
+	local := r.localUsers(rm)
+	r.mu.Unlock()
+
+	if first {
+		r.publish(ctx, ticketID, Event{Type: EventPresenceJoin, UserID: userID, Replica: r.replica})
+	}
+	return local
+}
+
+// Leave unregisters a connection of userID, announcing the departure once the
+// user has no connections left on this replica.
+func (r *Realtime) Leave(ctx context.Context, ticketID int, userID string) {
+	r.mu.Lock()
+	rm, ok := r.rooms[ticketID]
+	if !ok {
+		r.mu.Unlock()
+		return
+	}
+	rm.users[userID]--
+	last := rm.users[userID] <= 0
+	if last {
+		delete(rm.users, userID)
+	}
+	if len(rm.users) == 0 {
+		delete(r.rooms, ticketID)
+		rm.sub.Close()
+	}
+	r.mu.Unlock()
+
+	if last {
+		r.publish(ctx, ticketID, Event{Type: EventPresenceLeave, UserID: userID, Replica: r.replica})
+	}
+}
+
+// Typing announces that userID is typing in the ticket chat.
+func (r *Realtime) Typing(ctx context.Context, ticketID int, userID string) {
+	r.publish(ctx, ticketID, Event{Type: EventTyping, UserID: userID})
+}
+
+// answerJoins replies to joins seen on other replicas with the users connected
+// here, so the newcomer learns the full presence list.
+func (r *Realtime) answerJoins(ticketID int, sub *pgnotify.Subscription) {
+	for m := range sub.C {
+		var e Event
+		if err := json.Unmarshal(m.Data, &e); err != nil || e.Type != EventPresenceJoin || e.Replica == r.replica {
+			continue
+		}
+
+		r.mu.Lock()
+		var users []string
+		if rm, ok := r.rooms[ticketID]; ok {
+			users = r.localUsers(rm)
+		}
+		r.mu.Unlock()
+
+		if len(users) > 0 {
+			r.publish(context.Background(), ticketID, Event{Type: EventPresenceState, Users: users, Replica: r.replica})
+		}
+	}
+}
+
+func (r *Realtime) localUsers(rm *room) []string {
+	users := make([]string, 0, len(rm.users))
+	for u := range rm.users {
+		users = append(users, u)
+	}
+	slices.Sort(users)
+	return users
+}
+
+func (r *Realtime) publish(ctx context.Context, ticketID int, e Event) {
+	e.TicketID = ticketID
+	if err := r.hub.Publish(ctx, Topic(ticketID), e); err != nil {
+		logger.Warn("failed to publish chat event", "ticket_id", ticketID, "type", e.Type, "error", err)
+	}
+}
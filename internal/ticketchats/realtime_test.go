@@ -0,0 +1,106 @@
+package ticketchats
+
+import (
+	"context"
+	"encoding/json"
+	"innotech/internal/storage/postgres"
+	"innotech/pkg/logger"
+	"innotech/pkg/pgnotify"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// localHub delivers published events in-process, standing in for LISTEN/NOTIFY.
+type localHub struct {
+	*pgnotify.Broker
+}
+
+func (h localHub) Publish(_ context.Context, topic string, data any) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if len(raw) > pgnotify.MaxPayload {
+		return pgnotify.ErrPayloadTooLarge
+	}
+	h.Dispatch(pgnotify.Message{Topic: topic, Data: raw})
+	return nil
+}
+
+func next(t *testing.T, sub *pgnotify.Subscription) Event {
+	t.Helper()
+	select {
+	case m := <-sub.C:
+		var e Event
+		require.NoError(t, json.Unmarshal(m.Data, &e))
+		return e
+	case <-time.After(time.Second):
+		t.Fatal("no event received")
+		return Event{}
+	}
+}
+
+func TestRealtime_Notify_TruncatesOversizedMessage(t *testing.T) {
+	hub := localHub{pgnotify.NewBroker()}
+	rt := NewRealtime(hub)
+	sub := rt.Subscribe(3)
+	defer sub.Close()
+
+	chat := &postgres.TicketChat{ID: 1, TicketID: 3, Message: strings.Repeat("x", pgnotify.MaxPayload)}
+	require.NoError(t, rt.Notify(context.Background(), EventMessageCreated, chat))
+
+	e := next(t, sub)
+	assert.Equal(t, EventMessageCreated, e.Type)
+	assert.True(t, e.Truncated)
+	assert.Equal(t, 1, e.Message.ID)
+	assert.Empty(t, e.Message.Message)
+}
+
+func TestRealtime_PresenceAcrossReplicas(t *testing.T) {
+	logger.Init()
+	hub := localHub{pgnotify.NewBroker()}
+	a, b := NewRealtime(hub), NewRealtime(hub)
+	ctx := context.Background()
+
+	assert.Equal(t, []string{"alice"}, a.Join(ctx, 7, "alice"))
+
+	sub := b.Subscribe(7)
+	defer sub.Close()
+	assert.Equal(t, []string{"bob"}, b.Join(ctx, 7, "bob"))
+
+	join := next(t, sub)
+	assert.Equal(t, EventPresenceJoin, join.Type)
+	assert.Equal(t, "bob", join.UserID)
+
+	state := next(t, sub)
+	assert.Equal(t, EventPresenceState, state.Type)
+	assert.Equal(t, []string{"alice"}, state.Users)
+
+	a.Leave(ctx, 7, "alice")
+	leave := next(t, sub)
+	assert.Equal(t, EventPresenceLeave, leave.Type)
+	assert.Equal(t, "alice", leave.UserID)
+}
+
+func TestRealtime_SecondConnectionDoesNotRejoin(t *testing.T) {
+	hub := localHub{pgnotify.NewBroker()}
+	rt := NewRealtime(hub)
+	ctx := context.Background()
+
+	rt.Join(ctx, 7, "alice")
+	sub := rt.Subscribe(7)
+	defer sub.Close()
+
+	assert.Equal(t, []string{"alice"}, rt.Join(ctx, 7, "alice"))
+	rt.Leave(ctx, 7, "alice")
+
+	select {
+	case m := <-sub.C:
+		t.Fatalf("unexpected event %s", m.Data)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -3,6 +3,7 @@ package ticketchats
 import (
 	"context"
 	"innotech/internal/storage/postgres"
+	"innotech/pkg/db"
 
 	"github.com/jmoiron/sqlx"
 )
@@ -16,6 +17,11 @@ type Repository interface {
 	Delete(ctx context.Context, id int) error
 }
 
+// chatColumns lists the columns of postgres.TicketChat; the table also carries
+// full-text search vectors that are never read back.
+const chatColumns = `id, ticket_id, sender_id, sender_role, message, message_type, mattermost_message_id,
+	gitlab_note_id, date_created, date_updated`
+
 type repository struct {
 	db *sqlx.DB
 }
@@ -27,19 +33,23 @@ func NewRepository(db *sqlx.DB) Repository {
 
 func (r *repository) Create(ctx context.Context, chat *postgres.TicketChat) error {
 	query := `
-		INSERT INTO ticket_chats (ticket_id, sender_id, sender_role, message, message_type, mattermost_message_id)
-		VALUES (:ticket_id, :sender_id, :sender_role, :message, :message_type, :mattermost_message_id)
+		INSERT INTO ticket_chats (ticket_id, sender_id, sender_role, message, message_type, mattermost_message_id, gitlab_note_id)
+		VALUES (:ticket_id, :sender_id, :sender_role, :message, :message_type, :mattermost_message_id, :gitlab_note_id)
 		RETURNING id, date_created, date_updated;
 	`
 
-	return r.db.QueryRowxContext(ctx, query, chat).
-		StructScan(chat)
+	q, args, err := sqlx.Named(query, chat)
+	if err != nil {
+		return err
+	}
+	return db.Conn(ctx, r.db).QueryRowxContext(ctx, r.db.Rebind(q), args...).
+		Scan(&chat.ID, &chat.DateCreated, &chat.DateUpdated)
 }
 
 func (r *repository) GetByID(ctx context.Context, id int) (*postgres.TicketChat, error) {
 	var chat postgres.TicketChat
-	err := r.db.GetContext(ctx, &chat,
-		`SELECT * FROM ticket_chats WHERE id = $1`,
+	err := db.Conn(ctx, r.db).GetContext(ctx, &chat,
+		`SELECT `+chatColumns+` FROM ticket_chats WHERE id = $1`,
 		id,
 	)
 	if err != nil {
@@ -50,8 +60,8 @@ func (r *repository) GetByID(ctx context.Context, id int) (*postgres.TicketChat,
 
 func (r *repository) GetByTicketID(ctx context.Context, ticketID int) ([]postgres.TicketChat, error) {
 	var chats []postgres.TicketChat
-	err := r.db.SelectContext(ctx, &chats,
-		`SELECT * FROM ticket_chats WHERE ticket_id = $1 ORDER BY date_created`,
+	err := db.Conn(ctx, r.db).SelectContext(ctx, &chats,
+		`SELECT `+chatColumns+` FROM ticket_chats WHERE ticket_id = $1 ORDER BY date_created ASC`,
 		ticketID,
 	)
 	if err != nil {
@@ -70,12 +80,16 @@ func (r *repository) Update(ctx context.Context, chat *postgres.TicketChat) erro
 		RETURNING date_updated;
 	`
 
-	return r.db.QueryRowxContext(ctx, query, chat).
+	q, args, err := sqlx.Named(query, chat)
+	if err != nil {
+		return err
+	}
+	return db.Conn(ctx, r.db).QueryRowxContext(ctx, r.db.Rebind(q), args...).
 		Scan(&chat.DateUpdated)
 }
 
 func (r *repository) Delete(ctx context.Context, id int) error {
-	_, err := r.db.ExecContext(ctx,
+	_, err := db.Conn(ctx, r.db).ExecContext(ctx,
 		`DELETE FROM ticket_chats WHERE id = $1`,
 		id,
 	)
@@ -50,6 +50,7 @@ func TestRepository_Create_WithReturning(t *testing.T) {
 	assert.Equal(t, now, chat.DateCreated)
 	assert.Equal(t, now, chat.DateUpdated)
 	assert.NoError(t, mock.ExpectationsWereMet())
+	mock.ExpectClose()
 }
 
 func TestRepository_Update_WithReturning(t *testing.T) {
@@ -102,7 +103,7 @@ func TestRepository_GetByTicketID_WithOrder(t *testing.T) {
 		"text", "mm-123", now.Add(time.Hour), now.Add(time.Hour),
 	)
 
-	mock.ExpectQuery(`SELECT \* FROM ticket_chats WHERE ticket_id = \$1 ORDER BY date_created ASC`).
+	mock.ExpectQuery(`SELECT id, ticket_id, .* FROM ticket_chats WHERE ticket_id = \$1 ORDER BY date_created ASC`).
 		WithArgs(42).
 		WillReturnRows(rows)
 
@@ -134,7 +135,7 @@ func TestRepository_GetByID(t *testing.T) {
 		"text", "mm-123", now, now,
 	)
 
-	mock.ExpectQuery(`SELECT \* FROM ticket_chats WHERE id = \$1`).
+	mock.ExpectQuery(`SELECT id, ticket_id, .* FROM ticket_chats WHERE id = \$1`).
 		WithArgs(1).
 		WillReturnRows(rows)
 
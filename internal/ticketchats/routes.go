@@ -1,19 +1,22 @@
 package ticketchats
 
 import (
+	"innotech/internal/access"
 	"innotech/internal/storage/transport"
 	"innotech/pkg/middleware"
 
+	"github.com/gofiber/contrib/websocket"
 	"github.com/gofiber/fiber/v2"
 )
 
 // RegisterRoutes registers HTTP routes for ticket chat operations.
-func RegisterRoutes(app *fiber.App, h *Handler) {
+func RegisterRoutes(app *fiber.App, h *Handler, stream *Stream, guard *access.Guard) {
 	api := app.Group("/api/ticket_chats")
 
-	api.Get("/:id", h.GetByID)
-	api.Get("/ticket/:ticket_id", h.GetByTicketID)
-	api.Post("/", middleware.ValidateBody[transport.CreateTicketChatDTO](h.Create))
-	api.Put("/:id", middleware.ValidateBody[transport.UpdateTicketChatDTO](h.Update))
-	api.Delete("/:id", h.Delete)
+	api.Get("/:id", guard.Require(access.ChatRead, guard.Param("id", access.ResourceTicketChat)), h.GetByID)
+	api.Get("/ticket/:ticket_id", guard.Require(access.ChatRead, guard.Param("ticket_id", access.ResourceTicket)), h.GetByTicketID)
+	api.Get("/ticket/:ticket_id/ws", stream.Upgrade, guard.Require(access.ChatRead, guard.Param("ticket_id", access.ResourceTicket)), websocket.New(stream.Serve))
+	api.Post("/", guard.Require(access.ChatWrite, guard.Body("ticket_id", access.ResourceTicket)), middleware.ValidateBody[transport.CreateTicketChatDTO](h.Create))
+	api.Put("/:id", guard.Require(access.ChatWrite, guard.Param("id", access.ResourceTicketChat)), middleware.ValidateBody[transport.UpdateTicketChatDTO](h.Update))
+	api.Delete("/:id", guard.Require(access.ChatDelete, guard.Param("id", access.ResourceTicketChat)), h.Delete)
 }
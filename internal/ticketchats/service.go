@@ -3,9 +3,43 @@ package ticketchats
 import (
 	"context"
 	"errors"
+	"innotech/internal/events"
+	"innotech/internal/outbox"
 	"innotech/internal/storage/postgres"
+	"innotech/pkg/db"
 )
 
+// TopicMessageCreated is the outbox topic of committed new chat messages; the
+// payload is the message.
+const TopicMessageCreated = "chat.message_created"
+
+// Notifier publishes committed chat message changes to real-time subscribers.
+type Notifier interface {
+	Notify(ctx context.Context, eventType string, chat *postgres.TicketChat) error
+}
+
+// ActivityPublisher appends events to the activity feed of a ticket's project.
+type ActivityPublisher interface {
+	PublishForTicket(ctx context.Context, ticketID int, eventType string, payload any) error
+}
+
+// Outbox records messages to be delivered once the transaction commits.
+type Outbox interface {
+	Write(ctx context.Context, topic string, payload any) error
+}
+
+// Mirror copies new chat messages to an external system. Messages are
+// delivered through the outbox, possibly more than once; an error makes the
+// outbox retry.
+type Mirror interface {
+	MessageCreated(ctx context.Context, chat *postgres.TicketChat) error
+}
+
+// MirrorConsumer adapts m to an outbox consumer of TopicMessageCreated.
+func MirrorConsumer(m Mirror) outbox.Consumer {
+	return outbox.Decode(m.MessageCreated)
+}
+
 // Service defines the interface for ticket chat business logic operations.
 type Service interface {
 	Create(ctx context.Context, chat *postgres.TicketChat) error
@@ -16,19 +50,43 @@ type Service interface {
 }
 
 type service struct {
-	repo Repository
+	repo     Repository
+	notifier Notifier
+	activity ActivityPublisher
+	outbox   Outbox
+	tx       db.Transactor
 }
 
-// NewService creates a new Service instance.
-func NewService(repo Repository) Service {
-	return &service{repo: repo}
+// NewService creates a new Service instance. Notifications are sent within
+// the same transaction as the change, so subscribers only hear about it once
+// it is committed.
+func NewService(repo Repository, notifier Notifier, activity ActivityPublisher, outbox Outbox, tx db.Transactor) Service {
+	return &service{repo: repo, notifier: notifier, activity: activity, outbox: outbox, tx: tx}
 }
 
 func (s *service) Create(ctx context.Context, chat *postgres.TicketChat) error {
 	if chat.Message == "" {
 		return errors.New("message cannot be empty")
 	}
-	return s.repo.Create(ctx, chat)
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Create(ctx, chat); err != nil {
+			return err
+		}
+		if err := s.notifier.Notify(ctx, EventMessageCreated, chat); err != nil {
+			return err
+		}
+		err := s.activity.PublishForTicket(ctx, chat.TicketID, events.ChatMessageCreated, map[string]any{
+			"chat_id":     chat.ID,
+			"ticket_id":   chat.TicketID,
+			"sender_id":   chat.SenderID,
+			"sender_role": chat.SenderRole,
+			"message":     chat.Message,
+		})
+		if err != nil {
+			return err
+		}
+		return s.outbox.Write(ctx, TopicMessageCreated, chat)
+	})
 }
 
 func (s *service) GetByID(ctx context.Context, id int) (*postgres.TicketChat, error) {
@@ -40,9 +98,28 @@ func (s *service) GetByTicketID(ctx context.Context, ticketID int) ([]postgres.T
 }
 
 func (s *service) Update(ctx context.Context, chat *postgres.TicketChat) error {
-	return s.repo.Update(ctx, chat)
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Update(ctx, chat); err != nil {
+			return err
+		}
+		updated, err := s.repo.GetByID(ctx, chat.ID)
+		if err != nil {
+			return err
+		}
+		*chat = *updated
+		return s.notifier.Notify(ctx, EventMessageUpdated, chat)
+	})
 }
 
 func (s *service) Delete(ctx context.Context, id int) error {
-	return s.repo.Delete(ctx, id)
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		chat, err := s.repo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := s.repo.Delete(ctx, id); err != nil {
+			return err
+		}
+		return s.notifier.Notify(ctx, EventMessageDeleted, &postgres.TicketChat{ID: chat.ID, TicketID: chat.TicketID})
+	})
 }
@@ -3,7 +3,9 @@ package ticketchats
 import (
 	"context"
 	"errors"
+	"innotech/internal/events"
 	"innotech/internal/storage/postgres"
+	"innotech/pkg/db"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -45,9 +47,39 @@ func (m *mockRepo) Delete(ctx context.Context, id int) error {
 	return args.Error(0)
 }
 
+type mockNotifier struct {
+	mock.Mock
+}
+
+func (m *mockNotifier) Notify(ctx context.Context, eventType string, chat *postgres.TicketChat) error {
+	args := m.Called(ctx, eventType, chat)
+	return args.Error(0)
+}
+
+type nopActivity struct{}
+
+func (nopActivity) PublishForTicket(context.Context, int, string, any) error { return nil }
+
+type recordingOutbox struct {
+	created []*postgres.TicketChat
+}
+
+func (o *recordingOutbox) Write(_ context.Context, topic string, payload any) error {
+	if topic == TopicMessageCreated {
+		o.created = append(o.created, payload.(*postgres.TicketChat))
+	}
+	return nil
+}
+
+func newTestService(repo Repository) Service {
+	n := new(mockNotifier)
+	n.On("Notify", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	return NewService(repo, n, nopActivity{}, &recordingOutbox{}, db.NopTransactor{})
+}
+
 func TestCreate_ValidationFails_WhenEmptyMessage(t *testing.T) {
 	repo := new(mockRepo)
-	svc := NewService(repo)
+	svc := newTestService(repo)
 
 	ctx := context.Background()
 	err := svc.Create(ctx, &postgres.TicketChat{Message: ""})
@@ -56,7 +88,7 @@ func TestCreate_ValidationFails_WhenEmptyMessage(t *testing.T) {
 
 func TestCreate_HappyPath_CallsRepo(t *testing.T) {
 	repo := new(mockRepo)
-	svc := NewService(repo)
+	svc := newTestService(repo)
 
 	ctx := context.Background()
 	chat := &postgres.TicketChat{TicketID: 1, SenderID: "u", Message: "hi"}
@@ -69,7 +101,7 @@ func TestCreate_HappyPath_CallsRepo(t *testing.T) {
 
 func TestCreate_RepoError_ReturnsError(t *testing.T) {
 	repo := new(mockRepo)
-	svc := NewService(repo)
+	svc := newTestService(repo)
 
 	ctx := context.Background()
 	chat := &postgres.TicketChat{TicketID: 1, SenderID: "u", Message: "hi"}
@@ -82,7 +114,7 @@ func TestCreate_RepoError_ReturnsError(t *testing.T) {
 
 func TestGetByID_PassesThrough(t *testing.T) {
 	repo := new(mockRepo)
-	svc := NewService(repo)
+	svc := newTestService(repo)
 
 	ctx := context.Background()
 	exp := &postgres.TicketChat{ID: 2}
@@ -96,7 +128,7 @@ func TestGetByID_PassesThrough(t *testing.T) {
 
 func TestGetByTicketID_PassesThrough(t *testing.T) {
 	repo := new(mockRepo)
-	svc := NewService(repo)
+	svc := newTestService(repo)
 
 	ctx := context.Background()
 	list := []postgres.TicketChat{{ID: 1}, {ID: 2}}
@@ -110,11 +142,12 @@ func TestGetByTicketID_PassesThrough(t *testing.T) {
 
 func TestUpdate_Delete_PassThrough(t *testing.T) {
 	repo := new(mockRepo)
-	svc := NewService(repo)
+	svc := newTestService(repo)
 
 	ctx := context.Background()
 	c := &postgres.TicketChat{ID: 3, Message: "ok"}
 	repo.On("Update", ctx, c).Return(nil).Once()
+	repo.On("GetByID", ctx, 3).Return(&postgres.TicketChat{ID: 3, TicketID: 1, Message: "ok"}, nil).Twice()
 	repo.On("Delete", ctx, 3).Return(nil).Once()
 
 	assert.NoError(t, svc.Update(ctx, c))
@@ -124,7 +157,7 @@ func TestUpdate_Delete_PassThrough(t *testing.T) {
 
 func TestService_Methods_UseContext(t *testing.T) {
 	repo := new(mockRepo)
-	svc := NewService(repo)
+	svc := newTestService(repo)
 
 	type contextKey string
 	const testKey contextKey = "test"
@@ -133,7 +166,7 @@ func TestService_Methods_UseContext(t *testing.T) {
 	chat := &postgres.TicketChat{ID: 1, Message: "test"}
 
 	repo.On("Create", ctx, chat).Return(nil).Once()
-	repo.On("GetByID", ctx, 1).Return(chat, nil).Once()
+	repo.On("GetByID", ctx, 1).Return(chat, nil).Times(3)
 	repo.On("GetByTicketID", ctx, 1).Return([]postgres.TicketChat{*chat}, nil).Once()
 	repo.On("Update", ctx, chat).Return(nil).Once()
 	repo.On("Delete", ctx, 1).Return(nil).Once()
@@ -153,3 +186,91 @@ func TestService_Methods_UseContext(t *testing.T) {
 
 	repo.AssertExpectations(t)
 }
+
+func TestCreate_NotifiesCreatedMessage(t *testing.T) {
+	repo := new(mockRepo)
+	n := new(mockNotifier)
+	svc := NewService(repo, n, nopActivity{}, &recordingOutbox{}, db.NopTransactor{})
+
+	ctx := context.Background()
+	chat := &postgres.TicketChat{TicketID: 1, SenderID: "u", Message: "hi"}
+	repo.On("Create", ctx, chat).Return(nil).Once()
+	n.On("Notify", ctx, EventMessageCreated, chat).Return(nil).Once()
+
+	assert.NoError(t, svc.Create(ctx, chat))
+	n.AssertExpectations(t)
+}
+
+func TestDelete_NotifiesTicketOfDeletedMessage(t *testing.T) {
+	repo := new(mockRepo)
+	n := new(mockNotifier)
+	svc := NewService(repo, n, nopActivity{}, &recordingOutbox{}, db.NopTransactor{})
+
+	ctx := context.Background()
+	repo.On("GetByID", ctx, 4).Return(&postgres.TicketChat{ID: 4, TicketID: 9, Message: "bye"}, nil).Once()
+	repo.On("Delete", ctx, 4).Return(nil).Once()
+	n.On("Notify", ctx, EventMessageDeleted, &postgres.TicketChat{ID: 4, TicketID: 9}).Return(nil).Once()
+
+	assert.NoError(t, svc.Delete(ctx, 4))
+	n.AssertExpectations(t)
+}
+
+func TestDelete_RepoError_DoesNotNotify(t *testing.T) {
+	repo := new(mockRepo)
+	n := new(mockNotifier)
+	svc := NewService(repo, n, nopActivity{}, &recordingOutbox{}, db.NopTransactor{})
+
+	ctx := context.Background()
+	repo.On("GetByID", ctx, 4).Return(&postgres.TicketChat{ID: 4, TicketID: 9}, nil).Once()
+	repo.On("Delete", ctx, 4).Return(errors.New("db")).Once()
+
+	assert.Error(t, svc.Delete(ctx, 4))
+	n.AssertNotCalled(t, "Notify", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCreate_QueuesOnlySuccessfulMessages(t *testing.T) {
+	repo := new(mockRepo)
+	n := new(mockNotifier)
+	ob := &recordingOutbox{}
+	svc := NewService(repo, n, nopActivity{}, ob, db.NopTransactor{})
+
+	ctx := context.Background()
+	ok := &postgres.TicketChat{TicketID: 1, SenderID: "u", Message: "hi"}
+	failed := &postgres.TicketChat{TicketID: 1, SenderID: "u", Message: "lost"}
+	repo.On("Create", ctx, ok).Return(nil).Once()
+	repo.On("Create", ctx, failed).Return(nil).Once()
+	n.On("Notify", ctx, EventMessageCreated, ok).Return(nil).Once()
+	n.On("Notify", ctx, EventMessageCreated, failed).Return(errors.New("notify")).Once()
+
+	assert.NoError(t, svc.Create(ctx, ok))
+	assert.Error(t, svc.Create(ctx, failed))
+	assert.Equal(t, []*postgres.TicketChat{ok}, ob.created)
+}
+
+type mockActivity struct {
+	mock.Mock
+}
+
+func (m *mockActivity) PublishForTicket(ctx context.Context, ticketID int, eventType string, payload any) error {
+	return m.Called(ctx, ticketID, eventType, payload).Error(0)
+}
+
+func TestCreate_PublishesActivityEvent(t *testing.T) {
+	repo := new(mockRepo)
+	n := new(mockNotifier)
+	activity := new(mockActivity)
+	svc := NewService(repo, n, activity, &recordingOutbox{}, db.NopTransactor{})
+
+	ctx := context.Background()
+	chat := &postgres.TicketChat{TicketID: 3, SenderID: "u", SenderRole: "client", Message: "hi"}
+	repo.On("Create", ctx, chat).Run(func(args mock.Arguments) {
+		args.Get(1).(*postgres.TicketChat).ID = 12
+	}).Return(nil).Once()
+	n.On("Notify", ctx, EventMessageCreated, chat).Return(nil).Once()
+	activity.On("PublishForTicket", ctx, 3, events.ChatMessageCreated, map[string]any{
+		"chat_id": 12, "ticket_id": 3, "sender_id": "u", "sender_role": "client", "message": "hi",
+	}).Return(nil).Once()
+
+	assert.NoError(t, svc.Create(ctx, chat))
+	activity.AssertExpectations(t)
+}
@@ -0,0 +1,132 @@
+package ticketchats
+
+import (
+	"context"
+	"encoding/json"
+	"innotech/pkg/logger"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/contrib/websocket"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	pingInterval   = 30 * time.Second
+	readTimeout    = 2 * pingInterval
+	writeTimeout   = 10 * time.Second
+	typingCooldown = 2 * time.Second
+)
+
+// clientFrame is a message sent by a WebSocket client.
+type clientFrame struct {
+	Type string `json:"type"`
+}
+
+// Stream serves the real-time ticket chat over WebSocket.
+type Stream struct {
+	rt *Realtime
+}
+
+// NewStream creates a new Stream instance.
+func NewStream(rt *Realtime) *Stream {
+	return &Stream{rt: rt}
+}
+
+// Upgrade rejects requests that are not WebSocket handshakes.
+func (s *Stream) Upgrade(c *fiber.Ctx) error {
+	if websocket.IsWebSocketUpgrade(c) {
+		return c.Next()
+	}
+	return fiber.ErrUpgradeRequired
+}
+
+// Serve godoc
+// @Summary WebSocket с событиями чата тикета
+// @Description Пушит события message.created, message.updated, message.deleted, typing и presence.*.
+// @Description Клиент может отправлять {"type":"typing"}. Токен можно передать в параметре access_token.
+// @Tags TicketChats
+// @Param ticket_id path int true "Ticket ID"
+// @Param access_token query string false "Bearer token for browsers"
+// @Success 101
+// @Failure 426 {object} map[string]string
+// @Router /ticket_chats/ticket/{ticket_id}/ws [get]
+func (s *Stream) Serve(conn *websocket.Conn) {
+	ticketID, err := strconv.Atoi(conn.Params("ticket_id"))
+	if err != nil {
+		return
+	}
+	userID, _ := conn.Locals("user_id").(string)
+	ctx := context.Background()
+
+	sub := s.rt.Subscribe(ticketID)
+	defer sub.Close()
+
+	local := s.rt.Join(ctx, ticketID, userID)
+	defer s.rt.Leave(ctx, ticketID, userID)
+
+	if err := s.write(conn, Event{Type: EventPresenceState, TicketID: ticketID, Users: local}); err != nil {
+		return
+	}
+
+	done := make(chan struct{})
+	go s.read(ctx, conn, ticketID, userID, done)
+
+	ping := time.NewTicker(pingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case m, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			_ = conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+			if err := conn.WriteMessage(websocket.TextMessage, m.Data); err != nil {
+				return
+			}
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeTimeout)); err != nil {
+				return
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
+// read handles client frames until the connection fails or is closed.
+func (s *Stream) read(ctx context.Context, conn *websocket.Conn, ticketID int, userID string, done chan<- struct{}) {
+	defer close(done)
+
+	_ = conn.SetReadDeadline(time.Now().Add(readTimeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(readTimeout))
+	})
+
+	var lastTyping time.Time
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				logger.Debug("chat websocket closed", "ticket_id", ticketID, "error", err.Error())
+			}
+			return
+		}
+		_ = conn.SetReadDeadline(time.Now().Add(readTimeout))
+
+		var f clientFrame
+		if err := json.Unmarshal(data, &f); err != nil {
+			continue
+		}
+		if f.Type == EventTyping && time.Since(lastTyping) >= typingCooldown {
+			lastTyping = time.Now()
+			s.rt.Typing(ctx, ticketID, userID)
+		}
+	}
+}
+
+func (s *Stream) write(conn *websocket.Conn, e Event) error {
+	_ = conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	return conn.WriteJSON(e)
+}
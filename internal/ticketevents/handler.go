@@ -0,0 +1,38 @@
+// Package ticketevents provides the ticket change history (audit trail).
+package ticketevents
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Handler handles HTTP requests for ticket history operations.
+type Handler struct {
+	service Service
+}
+
+// NewHandler creates a new Handler instance.
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+// GetHistory godoc
+// @Summary получить историю изменений тикета
+// @Tags Tickets
+// @Produce json
+// @Param id path int true "ID"
+// @Success 200 {array} postgres.TicketEvent
+// @Failure 400 {object} map[string]string
+// @Router /tickets/{id}/history [get]
+func (h *Handler) GetHistory(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+	list, err := h.service.GetHistory(c.UserContext(), id)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(list)
+}
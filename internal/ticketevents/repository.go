@@ -0,0 +1,52 @@
+package ticketevents
+
+import (
+	"context"
+	"innotech/internal/storage/postgres"
+	"innotech/pkg/db"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Repository defines the interface for ticket event data access operations.
+type Repository interface {
+	Create(ctx context.Context, e *postgres.TicketEvent) error
+	GetByTicketID(ctx context.Context, ticketID int) ([]postgres.TicketEvent, error)
+}
+
+type repository struct {
+	db *sqlx.DB
+}
+
+// NewRepository creates a new Repository instance.
+func NewRepository(db *sqlx.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) Create(ctx context.Context, e *postgres.TicketEvent) error {
+	query := `
+		INSERT INTO ticket_events (ticket_id, actor_id, field, old_value, new_value)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, date_created
+	`
+
+	return db.Conn(ctx, r.db).QueryRowxContext(ctx, query,
+		e.TicketID,
+		e.ActorID,
+		e.Field,
+		e.OldValue,
+		e.NewValue,
+	).Scan(&e.ID, &e.DateCreated)
+}
+
+func (r *repository) GetByTicketID(ctx context.Context, ticketID int) ([]postgres.TicketEvent, error) {
+	list := []postgres.TicketEvent{}
+	err := db.Conn(ctx, r.db).SelectContext(ctx, &list,
+		`SELECT * FROM ticket_events WHERE ticket_id = $1 ORDER BY date_created, id`,
+		ticketID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return list, nil
+}
@@ -0,0 +1,53 @@
+package ticketevents
+
+import (
+	"context"
+	"innotech/internal/storage/postgres"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepository_Create_ReturnsID(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = mockDB.Close() }()
+
+	repo := NewRepository(sqlx.NewDb(mockDB, "sqlmock"))
+
+	actor, from, to := "u1", "open", "resolved"
+	now := time.Now()
+	mock.ExpectQuery(`INSERT INTO ticket_events`).
+		WithArgs(4, &actor, FieldStatus, &from, &to).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "date_created"}).AddRow(10, now))
+
+	e := &postgres.TicketEvent{TicketID: 4, ActorID: &actor, Field: FieldStatus, OldValue: &from, NewValue: &to}
+	err = repo.Create(context.Background(), e)
+
+	require.NoError(t, err)
+	assert.Equal(t, 10, e.ID)
+	assert.Equal(t, now, e.DateCreated)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_GetByTicketID_EmptyTimeline(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = mockDB.Close() }()
+
+	repo := NewRepository(sqlx.NewDb(mockDB, "sqlmock"))
+
+	mock.ExpectQuery(`SELECT \* FROM ticket_events WHERE ticket_id = \$1 ORDER BY date_created, id`).
+		WithArgs(4).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "ticket_id", "actor_id", "field", "old_value", "new_value", "date_created"}))
+
+	list, err := repo.GetByTicketID(context.Background(), 4)
+
+	require.NoError(t, err)
+	assert.NotNil(t, list)
+	assert.Empty(t, list)
+}
@@ -0,0 +1,14 @@
+package ticketevents
+
+import (
+	"innotech/internal/access"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RegisterRoutes registers HTTP routes for ticket history operations.
+func RegisterRoutes(app *fiber.App, h *Handler, guard *access.Guard) {
+	api := app.Group("/api/tickets")
+
+	api.Get("/:id/history", guard.Require(access.TicketRead, guard.Param("id", access.ResourceTicket)), h.GetHistory)
+}
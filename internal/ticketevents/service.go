@@ -0,0 +1,103 @@
+package ticketevents
+
+import (
+	"context"
+	"innotech/internal/storage/postgres"
+	"innotech/pkg/auth"
+	"strconv"
+)
+
+// Tracked ticket fields.
+const (
+	FieldTitle      = "title"
+	FieldMessage    = "message"
+	FieldStatus     = "status"
+	FieldAssignedTo = "assigned_to"
+	FieldModuleID   = "module_id"
+	FieldResolution = "resolution"
+	FieldPriority   = "priority"
+	FieldSeverity   = "severity"
+)
+
+// Service defines the interface for ticket history business logic operations.
+type Service interface {
+	RecordChanges(ctx context.Context, before, after *postgres.Ticket) error
+	GetHistory(ctx context.Context, ticketID int) ([]postgres.TicketEvent, error)
+}
+
+type service struct {
+	repo Repository
+}
+
+// NewService creates a new Service instance.
+func NewService(repo Repository) Service {
+	return &service{repo: repo}
+}
+
+// RecordChanges stores one event per field that differs between before and
+// after, attributed to the caller in ctx. A nil before marks a newly created
+// ticket, for which only the initial status is recorded.
+func (s *service) RecordChanges(ctx context.Context, before, after *postgres.Ticket) error {
+	var actor *string
+	if sub := auth.SubjectFromContext(ctx); sub != "" {
+		actor = &sub
+	}
+
+	for _, e := range Diff(before, after) {
+		e.TicketID = after.ID
+		e.ActorID = actor
+		if err := s.repo.Create(ctx, &e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *service) GetHistory(ctx context.Context, ticketID int) ([]postgres.TicketEvent, error) {
+	return s.repo.GetByTicketID(ctx, ticketID)
+}
+
+// Diff returns the field-level changes between two versions of a ticket.
+func Diff(before, after *postgres.Ticket) []postgres.TicketEvent {
+	if before == nil {
+		return []postgres.TicketEvent{{Field: FieldStatus, NewValue: &after.Status}}
+	}
+
+	fields := []struct {
+		name     string
+		from, to *string
+	}{
+		{FieldTitle, &before.Title, &after.Title},
+		{FieldMessage, &before.Message, &after.Message},
+		{FieldStatus, &before.Status, &after.Status},
+		{FieldAssignedTo, before.AssignedTo, after.AssignedTo},
+		{FieldModuleID, itoa(before.ModuleID), itoa(after.ModuleID)},
+		{FieldResolution, before.Resolution, after.Resolution},
+		{FieldPriority, &before.Priority, &after.Priority},
+		{FieldSeverity, &before.Severity, &after.Severity},
+	}
+
+	var events []postgres.TicketEvent
+	for _, f := range fields {
+		if equal(f.from, f.to) {
+			continue
+		}
+		events = append(events, postgres.TicketEvent{Field: f.name, OldValue: f.from, NewValue: f.to})
+	}
+	return events
+}
+
+func itoa(v *int) *string {
+	if v == nil {
+		return nil
+	}
+	s := strconv.Itoa(*v)
+	return &s
+}
+
+func equal(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,85 @@
+package ticketevents
+
+import (
+	"context"
+	"innotech/internal/storage/postgres"
+	"innotech/pkg/auth"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type mockRepo struct {
+	mock.Mock
+}
+
+func (m *mockRepo) Create(ctx context.Context, e *postgres.TicketEvent) error {
+	args := m.Called(ctx, e)
+	return args.Error(0)
+}
+
+func (m *mockRepo) GetByTicketID(ctx context.Context, ticketID int) ([]postgres.TicketEvent, error) {
+	args := m.Called(ctx, ticketID)
+	return args.Get(0).([]postgres.TicketEvent), args.Error(1)
+}
+
+func ptr[T any](v T) *T { return &v }
+
+func TestDiff_ReportsOnlyChangedFields(t *testing.T) {
+	before := &postgres.Ticket{ID: 1, Title: "a", Message: "m", Status: "open", ModuleID: ptr(2), Priority: "P3", Severity: "minor"}
+	after := &postgres.Ticket{ID: 1, Title: "b", Message: "m", Status: "open", ModuleID: ptr(3), AssignedTo: ptr("u2"), Priority: "P1", Severity: "minor"}
+
+	events := Diff(before, after)
+
+	require.Len(t, events, 4)
+	assert.Equal(t, FieldTitle, events[0].Field)
+	assert.Equal(t, "a", *events[0].OldValue)
+	assert.Equal(t, "b", *events[0].NewValue)
+	assert.Equal(t, FieldAssignedTo, events[1].Field)
+	assert.Nil(t, events[1].OldValue)
+	assert.Equal(t, FieldModuleID, events[2].Field)
+	assert.Equal(t, "2", *events[2].OldValue)
+	assert.Equal(t, "3", *events[2].NewValue)
+	assert.Equal(t, FieldPriority, events[3].Field)
+	assert.Equal(t, "P1", *events[3].NewValue)
+}
+
+func TestDiff_NewTicketRecordsInitialStatus(t *testing.T) {
+	events := Diff(nil, &postgres.Ticket{ID: 1, Status: "open"})
+
+	require.Len(t, events, 1)
+	assert.Equal(t, FieldStatus, events[0].Field)
+	assert.Nil(t, events[0].OldValue)
+	assert.Equal(t, "open", *events[0].NewValue)
+}
+
+func TestService_RecordChanges_AttributesActor(t *testing.T) {
+	repo := new(mockRepo)
+	svc := NewService(repo)
+	ctx := auth.WithIdentity(context.Background(), &auth.Identity{Subject: "u1"})
+
+	repo.On("Create", mock.Anything, mock.MatchedBy(func(e *postgres.TicketEvent) bool {
+		return e.TicketID == 7 && e.Field == FieldStatus && e.ActorID != nil && *e.ActorID == "u1"
+	})).Return(nil).Once()
+
+	err := svc.RecordChanges(ctx,
+		&postgres.Ticket{ID: 7, Status: "open"},
+		&postgres.Ticket{ID: 7, Status: "in_progress"},
+	)
+
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+}
+
+func TestService_RecordChanges_NoChangesNoWrites(t *testing.T) {
+	repo := new(mockRepo)
+	svc := NewService(repo)
+
+	tk := &postgres.Ticket{ID: 7, Title: "t", Status: "open"}
+	err := svc.RecordChanges(context.Background(), tk, tk)
+
+	assert.NoError(t, err)
+	repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,86 @@
+package tickets
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"innotech/internal/contract"
+	"innotech/internal/storage/postgres"
+	"innotech/pkg/auth"
+	"time"
+)
+
+// Machine-readable codes of ContractError.
+const (
+	CodeContractNotInProject = "contract_not_in_project"
+	CodeContractNotStarted   = "contract_not_started"
+	CodeContractExpired      = "contract_expired"
+	CodeContractNotAssigned  = "contract_not_assigned"
+)
+
+// ContractDirectory looks up the contracts tickets are filed under.
+type ContractDirectory interface {
+	GetByID(ctx context.Context, id int) (*postgres.Contract, error)
+	IsAssigned(ctx context.Context, contractID int, userID string) (bool, error)
+}
+
+// ContractError describes a ticket refused because of its contract.
+type ContractError struct {
+	Code       string     `json:"code"`
+	ContractID int        `json:"contract_id"`
+	ValidFrom  *time.Time `json:"valid_from,omitempty"`
+	ValidTo    *time.Time `json:"valid_to,omitempty"`
+	GraceDays  int        `json:"grace_period_days,omitempty"`
+}
+
+func (e *ContractError) Error() string {
+	switch e.Code {
+	case CodeContractNotStarted:
+		return fmt.Sprintf("contract %d is not in effect yet", e.ContractID)
+	case CodeContractExpired:
+		return fmt.Sprintf("contract %d has expired", e.ContractID)
+	case CodeContractNotAssigned:
+		return fmt.Sprintf("reporter is not attached to contract %d", e.ContractID)
+	}
+	return fmt.Sprintf("contract %d does not belong to the project", e.ContractID)
+}
+
+// checkContract makes sure t is filed under a contract of its project that
+// is active on day. Reporters acting as clients must also be attached to the
+// contract; support staff and integrations may file under any contract.
+func (s *ticketService) checkContract(ctx context.Context, t *postgres.Ticket, day time.Time) error {
+	c, err := s.contracts.GetByID(ctx, t.ContractID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return &ContractError{Code: CodeContractNotInProject, ContractID: t.ContractID}
+	}
+	if err != nil {
+		return err
+	}
+	if c.ProjectID != t.ProjectID {
+		return &ContractError{Code: CodeContractNotInProject, ContractID: t.ContractID}
+	}
+
+	switch contract.PeriodOn(c, day) {
+	case contract.PeriodNotStarted:
+		return &ContractError{Code: CodeContractNotStarted, ContractID: c.ID, ValidFrom: c.ValidFrom, ValidTo: c.ValidTo}
+	case contract.PeriodExpired:
+		return &ContractError{Code: CodeContractExpired, ContractID: c.ID, ValidFrom: c.ValidFrom, ValidTo: c.ValidTo, GraceDays: c.GraceDays}
+	}
+
+	if _, ok := auth.FromContext(ctx); !ok {
+		return nil
+	}
+	actor, err := s.actors.SenderRole(ctx, t.ProjectID)
+	if err != nil || actor != ActorClient {
+		return err
+	}
+	assigned, err := s.contracts.IsAssigned(ctx, c.ID, t.CreatedBy)
+	if err != nil {
+		return err
+	}
+	if !assigned {
+		return &ContractError{Code: CodeContractNotAssigned, ContractID: c.ID}
+	}
+	return nil
+}
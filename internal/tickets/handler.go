@@ -2,10 +2,14 @@
 package tickets
 
 import (
+	"errors"
+	"innotech/internal/access"
 	"innotech/internal/storage/postgres"
 	"innotech/internal/storage/transport"
+	"innotech/pkg/middleware"
 	"log/slog"
 	"strconv"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -21,12 +25,15 @@ func NewHandler(service Service, log *slog.Logger) *Handler {
 
 // Create godoc
 // @Summary создать тикет
+// @Description приоритет, указанный клиентом, сохраняется как предложение; окончательный приоритет задаёт поддержка
 // @Tags Tickets
 // @Accept json
 // @Produce json
 // @Param ticket body transport.CreateTicketDTO true "Ticket"
 // @Success 201 {object} postgres.Ticket
 // @Failure 400 {object} map[string]string
+// @Failure 403 {object} tickets.ContractError
+// @Failure 422 {object} tickets.ContractError
 // @Router /tickets [post]
 func (h *Handler) Create(c *fiber.Ctx) error {
 	dto := c.Locals("body").(*transport.CreateTicketDTO)
@@ -35,16 +42,30 @@ func (h *Handler) Create(c *fiber.Ctx) error {
 		ProjectID:           dto.ProjectID,
 		ModuleID:            dto.ModuleID,
 		ContractID:          dto.ContractID,
-		CreatedBy:           dto.CreatedBy,
+		CreatedBy:           middleware.UserID(c),
 		AssignedTo:          dto.AssignedTo,
 		Title:               dto.Title,
 		Message:             dto.Message,
 		Status:              dto.Status,
+		Priority:            dto.Priority,
+		Severity:            dto.Severity,
 		GitlabIssueURL:      dto.GitlabIssueURL,
 		MattermostThreadURL: dto.MattermostThreadURL,
 	}
 
-	if err := h.service.Create(c.Context(), &t); err != nil {
+	if err := h.service.Create(c.UserContext(), &t); err != nil {
+		var te *TransitionError
+		if errors.As(err, &te) {
+			return workflowError(c, te)
+		}
+		var ce *ContractError
+		if errors.As(err, &ce) {
+			return contractError(c, ce)
+		}
+		var me *ModuleError
+		if errors.As(err, &me) {
+			return moduleError(c, me)
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 
@@ -64,36 +85,116 @@ func (h *Handler) GetByID(c *fiber.Ctx) error {
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
 	}
-	t, err := h.service.GetByID(c.Context(), id)
+	t, err := h.service.GetByID(c.UserContext(), id)
 	if err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
 	}
 	return c.JSON(t)
 }
 
-// GetAll godoc
-// @Summary получить все тикеты
+// List godoc
+// @Summary получить список тикетов с фильтрами и курсорной пагинацией
 // @Tags Tickets
 // @Produce json
-// @Success 200 {object} postgres.Ticket
-// @Failure 404 {object} map[string]string
+// @Param project_id query int false "Project ID"
+// @Param contract_id query int false "Contract ID"
+// @Param module_id query int false "Module ID"
+// @Param status query []string false "Status (repeatable)" collectionFormat(multi)
+// @Param priority query []string false "Priority P1-P4 (repeatable)" collectionFormat(multi)
+// @Param severity query []string false "Severity (repeatable)" collectionFormat(multi)
+// @Param assigned_to query string false "Assignee UUID"
+// @Param created_by query string false "Author UUID"
+// @Param created_from query string false "Created at or after (RFC3339)"
+// @Param created_to query string false "Created before (RFC3339)"
+// @Param updated_from query string false "Updated at or after (RFC3339)"
+// @Param updated_to query string false "Updated before (RFC3339)"
+// @Param title query string false "Title substring"
+// @Param sort query string false "Sort key, prefix with - for descending" default(priority)
+// @Param limit query int false "Page size" default(50)
+// @Param cursor query string false "next_cursor of the previous page"
+// @Success 200 {object} tickets.Page
+// @Failure 400 {object} map[string]string
 // @Router /tickets/ [get]
-func (h *Handler) GetAll(c *fiber.Ctx) error {
-	tickets, err := h.service.GetAll(c.Context())
+func (h *Handler) List(c *fiber.Ctx) error {
+	q := c.Locals("query").(*transport.TicketListQueryDTO)
+
+	f, err := listFilter(q)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if scope := access.ScopeFrom(c); !scope.All {
+		f.ProjectIDs = scope.ProjectIDs
+	}
+
+	page, err := h.service.List(c.UserContext(), f)
 	if err != nil {
+		if errors.Is(err, ErrInvalidCursor) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
-	return c.JSON(tickets)
+	return c.JSON(page)
+}
+
+// listFilter converts validated query parameters into a ListFilter.
+func listFilter(q *transport.TicketListQueryDTO) (ListFilter, error) {
+	sort, err := ParseSort(q.Sort)
+	if err != nil {
+		return ListFilter{}, err
+	}
+
+	f := ListFilter{
+		ProjectID:  q.ProjectID,
+		ContractID: q.ContractID,
+		ModuleID:   q.ModuleID,
+		Statuses:   q.Status,
+		Priorities: q.Priority,
+		Severities: q.Severity,
+		AssignedTo: q.AssignedTo,
+		CreatedBy:  q.CreatedBy,
+		Title:      q.Title,
+		Sort:       sort,
+		Limit:      q.Limit,
+	}
+
+	dates := []struct {
+		raw string
+		dst **time.Time
+	}{
+		{q.CreatedFrom, &f.CreatedFrom},
+		{q.CreatedTo, &f.CreatedTo},
+		{q.UpdatedFrom, &f.UpdatedFrom},
+		{q.UpdatedTo, &f.UpdatedTo},
+	}
+	for _, d := range dates {
+		if d.raw == "" {
+			continue
+		}
+		ts, err := time.Parse(time.RFC3339, d.raw)
+		if err != nil {
+			return ListFilter{}, err
+		}
+		*d.dst = &ts
+	}
+
+	if q.Cursor != "" {
+		if f.After, err = DecodeCursor(q.Cursor); err != nil {
+			return ListFilter{}, err
+		}
+	}
+	return f, nil
 }
 
 // Update godoc
 // @Summary обновить тикет
+// @Description клиент может только предложить приоритет; приоритет, заданный поддержкой, закрывает предложение
 // @Tags Tickets
 // @Accept json
 // @Produce json
 // @Param id path int true "ID"
 // @Param ticket body transport.UpdateTicketDTO true "Ticket"
 // @Success 200 {object} postgres.Ticket
+// @Failure 422 {object} tickets.ModuleError
 // @Router /tickets/{id} [put]
 func (h *Handler) Update(c *fiber.Ctx) error {
 	id, err := strconv.Atoi(c.Params("id"))
@@ -105,15 +206,26 @@ func (h *Handler) Update(c *fiber.Ctx) error {
 
 	t := postgres.Ticket{
 		ID:                  id,
+		ModuleID:            dto.ModuleID,
 		Title:               dto.Title,
 		Message:             dto.Message,
 		Status:              dto.Status,
 		AssignedTo:          dto.AssignedTo,
+		Priority:            dto.Priority,
+		Severity:            dto.Severity,
 		GitlabIssueURL:      dto.GitlabIssueURL,
 		MattermostThreadURL: dto.MattermostThreadURL,
 	}
 
-	if err := h.service.Update(c.Context(), &t); err != nil {
+	if err := h.service.Update(c.UserContext(), &t); err != nil {
+		var te *TransitionError
+		if errors.As(err, &te) {
+			return workflowError(c, te)
+		}
+		var me *ModuleError
+		if errors.As(err, &me) {
+			return moduleError(c, me)
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 
@@ -131,8 +243,108 @@ func (h *Handler) Delete(c *fiber.Ctx) error {
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
 	}
-	if err := h.service.Delete(c.Context(), id); err != nil {
+	if err := h.service.Delete(c.UserContext(), id); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 	return c.SendStatus(fiber.StatusNoContent)
 }
+
+// Transition godoc
+// @Summary применить переход статуса тикета
+// @Tags Tickets
+// @Accept json
+// @Produce json
+// @Param id path int true "ID"
+// @Param transition body transport.TicketTransitionDTO true "Transition"
+// @Success 200 {object} postgres.Ticket
+// @Failure 403 {object} tickets.TransitionError
+// @Failure 409 {object} tickets.TransitionError
+// @Failure 422 {object} tickets.TransitionError
+// @Router /tickets/{id}/transitions [post]
+func (h *Handler) Transition(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+
+	dto := c.Locals("body").(*transport.TicketTransitionDTO)
+
+	t, err := h.service.Transition(c.UserContext(), id, dto.Transition, dto.ResolutionNote)
+	if err != nil {
+		var te *TransitionError
+		if errors.As(err, &te) {
+			return workflowError(c, te)
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(t)
+}
+
+// GetTransitions godoc
+// @Summary получить доступные переходы статуса тикета
+// @Tags Tickets
+// @Produce json
+// @Param id path int true "ID"
+// @Success 200 {array} tickets.Transition
+// @Router /tickets/{id}/transitions [get]
+func (h *Handler) GetTransitions(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+	list, err := h.service.AvailableTransitions(c.UserContext(), id)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(list)
+}
+
+// workflowError renders a TransitionError as a structured, localized response.
+func workflowError(c *fiber.Ctx, te *TransitionError) error {
+	status := fiber.StatusConflict
+	switch te.Code {
+	case CodeTransitionForbidden:
+		status = fiber.StatusForbidden
+	case CodeResolutionRequired:
+		status = fiber.StatusUnprocessableEntity
+	}
+
+	return c.Status(status).JSON(fiber.Map{
+		"error": fiber.Map{
+			"code":       te.Code,
+			"message":    middleware.Localize(c, "ticket.error."+te.Code),
+			"from":       te.From,
+			"to":         te.To,
+			"transition": te.Transition,
+			"allowed":    te.Allowed,
+		},
+	})
+}
+
+func contractError(c *fiber.Ctx, ce *ContractError) error {
+	status := fiber.StatusUnprocessableEntity
+	if ce.Code == CodeContractNotAssigned {
+		status = fiber.StatusForbidden
+	}
+
+	return c.Status(status).JSON(fiber.Map{
+		"error": fiber.Map{
+			"code":              ce.Code,
+			"message":           middleware.Localize(c, "ticket.error."+ce.Code),
+			"contract_id":       ce.ContractID,
+			"valid_from":        ce.ValidFrom,
+			"valid_to":          ce.ValidTo,
+			"grace_period_days": ce.GraceDays,
+		},
+	})
+}
+
+func moduleError(c *fiber.Ctx, me *ModuleError) error {
+	return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+		"error": fiber.Map{
+			"code":      me.Code,
+			"message":   middleware.Localize(c, "ticket.error."+me.Code),
+			"module_id": me.ModuleID,
+		},
+	})
+}
@@ -0,0 +1,61 @@
+package tickets
+
+import (
+	"encoding/json"
+	"innotech/internal/storage/postgres"
+	"innotech/internal/storage/transport"
+	"innotech/pkg/db"
+	"innotech/pkg/logger"
+	"innotech/pkg/middleware"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	logger.Init()
+	os.Exit(m.Run())
+}
+
+func TestHandler_RejectsModuleOfAnotherProject(t *testing.T) {
+	repo := new(mockRepository)
+	repo.On("GetByID", mock.Anything, 5).Return(&postgres.Ticket{ID: 5, ProjectID: 7, Status: StatusOpen}, nil)
+	repo.On("GetModule", mock.Anything, 2).Return(&postgres.Module{ID: 2, ProjectID: 8}, nil)
+	svc := NewService(repo, DefaultWorkflow(), stubActors(ActorAdmin), openContracts(7), nopSLA{}, new(mockHistory), nopActivity(), &recordingOutbox{}, db.NopTransactor{})
+	h := NewHandler(svc, nil)
+
+	app := fiber.New()
+	app.Post("/tickets", middleware.ValidateBody[transport.CreateTicketDTO](h.Create))
+	app.Put("/tickets/:id", middleware.ValidateBody[transport.UpdateTicketDTO](h.Update))
+
+	send := func(method, target, body string) (int, map[string]any) {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		var out struct {
+			Error map[string]any `json:"error"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+		return resp.StatusCode, out.Error
+	}
+
+	status, body := send(http.MethodPost, "/tickets", `{"project_id":7,"contract_id":1,"module_id":2,"title":"Сбой","message":"m","status":"open"}`)
+	assert.Equal(t, fiber.StatusUnprocessableEntity, status)
+	assert.Equal(t, CodeModuleNotInProject, body["code"])
+	assert.EqualValues(t, 2, body["module_id"])
+
+	status, body = send(http.MethodPut, "/tickets/5", `{"module_id":2,"title":"Сбой","message":"m","status":"open"}`)
+	assert.Equal(t, fiber.StatusUnprocessableEntity, status)
+	assert.Equal(t, CodeModuleNotInProject, body["code"])
+
+	repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
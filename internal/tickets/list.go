@@ -0,0 +1,143 @@
+package tickets
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"innotech/internal/storage/postgres"
+	"strings"
+	"time"
+)
+
+// Listing limits.
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 200
+)
+
+// ErrInvalidCursor is returned when a pagination cursor cannot be decoded or
+// was issued for a different sort order.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// sortColumns lists the columns tickets can be ordered by.
+var sortColumns = map[string]bool{
+	"date_created": true,
+	"date_updated": true,
+	"title":        true,
+	"priority":     true,
+	"severity":     true,
+}
+
+// Sort is an ordering of the ticket listing. Ties are broken by id.
+type Sort struct {
+	Column string
+	Desc   bool
+}
+
+// DefaultSort orders tickets as a support queue: most urgent first, and
+// within a priority the oldest first.
+var DefaultSort = Sort{Column: "priority"}
+
+// ParseSort parses a sort key such as "priority" or "-date_created".
+func ParseSort(key string) (Sort, error) {
+	if key == "" {
+		return DefaultSort, nil
+	}
+	s := Sort{Column: strings.TrimPrefix(key, "-"), Desc: strings.HasPrefix(key, "-")}
+	if !sortColumns[s.Column] {
+		return Sort{}, fmt.Errorf("unknown sort key %q", key)
+	}
+	return s, nil
+}
+
+func (s Sort) String() string {
+	if s.Desc {
+		return "-" + s.Column
+	}
+	return s.Column
+}
+
+// value returns the sort column of t in its cursor representation.
+func (s Sort) value(t *postgres.Ticket) string {
+	switch s.Column {
+	case "date_updated":
+		return t.DateUpdated.Format(time.RFC3339Nano)
+	case "title":
+		return t.Title
+	case "priority":
+		return t.Priority
+	case "severity":
+		return t.Severity
+	default:
+		return t.DateCreated.Format(time.RFC3339Nano)
+	}
+}
+
+// arg converts a cursor value back to a query argument for the sort column.
+func (s Sort) arg(v string) (any, error) {
+	switch s.Column {
+	case "title", "priority", "severity":
+		return v, nil
+	}
+	ts, err := time.Parse(time.RFC3339Nano, v)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return ts, nil
+}
+
+// Cursor marks the last ticket of a page for keyset pagination.
+type Cursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	ID    int    `json:"id"`
+}
+
+// Encode returns the opaque string form of the cursor.
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor parses a cursor produced by Cursor.Encode.
+func DecodeCursor(raw string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c Cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == 0 {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// ListFilter narrows and orders the ticket listing.
+type ListFilter struct {
+	// ProjectIDs restricts the listing to the given projects; nil means no
+	// restriction and an empty slice matches nothing.
+	ProjectIDs  []int
+	ProjectID   *int
+	ContractID  *int
+	ModuleID    *int
+	Statuses    []string
+	Priorities  []string
+	Severities  []string
+	AssignedTo  *string
+	CreatedBy   *string
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	UpdatedFrom *time.Time
+	UpdatedTo   *time.Time
+	Title       string
+	Sort        Sort
+	Limit       int
+	After       *Cursor
+}
+
+// Page is one page of the ticket listing.
+type Page struct {
+	Items      []postgres.Ticket `json:"items"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+}
@@ -0,0 +1,41 @@
+package tickets
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"innotech/internal/storage/postgres"
+)
+
+// CodeModuleNotInProject is the machine-readable code of ModuleError.
+const CodeModuleNotInProject = "module_not_in_project"
+
+// ModuleError describes a ticket refused because its module belongs to
+// another project.
+type ModuleError struct {
+	Code     string `json:"code"`
+	ModuleID int    `json:"module_id"`
+}
+
+func (e *ModuleError) Error() string {
+	return fmt.Sprintf("module %d does not belong to the project", e.ModuleID)
+}
+
+// checkModule makes sure the module of t, if any, is one of its project.
+func (s *ticketService) checkModule(ctx context.Context, t *postgres.Ticket) error {
+	if t.ModuleID == nil {
+		return nil
+	}
+	m, err := s.repo.GetModule(ctx, *t.ModuleID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return &ModuleError{Code: CodeModuleNotInProject, ModuleID: *t.ModuleID}
+	}
+	if err != nil {
+		return err
+	}
+	if m.ProjectID != t.ProjectID {
+		return &ModuleError{Code: CodeModuleNotInProject, ModuleID: m.ID}
+	}
+	return nil
+}
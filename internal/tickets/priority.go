@@ -0,0 +1,68 @@
+package tickets
+
+import (
+	"context"
+	"innotech/internal/storage/postgres"
+	"innotech/pkg/auth"
+)
+
+// Ticket priorities from ticket_priority_enum; P1 is the most urgent.
+const (
+	PriorityP1 = "P1"
+	PriorityP2 = "P2"
+	PriorityP3 = "P3"
+	PriorityP4 = "P4"
+
+	DefaultPriority = PriorityP3
+)
+
+// Ticket severities from ticket_severity_enum, worst first.
+const (
+	SeverityCritical = "critical"
+	SeverityMajor    = "major"
+	SeverityMinor    = "minor"
+	SeverityTrivial  = "trivial"
+
+	DefaultSeverity = SeverityMinor
+)
+
+// triage settles the priority and severity of t, which carries the values
+// requested by the caller; empty values keep those of current, or the
+// defaults for a new ticket (current is nil). Clients only propose a
+// priority, and withdraw their proposal by requesting the current one.
+// Support staff and integrations set it, which settles any open proposal.
+func (s *ticketService) triage(ctx context.Context, current, t *postgres.Ticket) error {
+	requested, projectID := t.Priority, t.ProjectID
+	if current == nil {
+		t.Priority, t.ProposedPriority = DefaultPriority, nil
+		if t.Severity == "" {
+			t.Severity = DefaultSeverity
+		}
+	} else {
+		t.Priority, t.ProposedPriority = current.Priority, current.ProposedPriority
+		projectID = current.ProjectID
+		if t.Severity == "" {
+			t.Severity = current.Severity
+		}
+	}
+	if requested == "" || (requested == t.Priority && t.ProposedPriority == nil) {
+		return nil
+	}
+
+	actor := ActorAdmin
+	if _, ok := auth.FromContext(ctx); ok {
+		var err error
+		if actor, err = s.actors.SenderRole(ctx, projectID); err != nil {
+			return err
+		}
+	}
+	if actor == ActorClient {
+		t.ProposedPriority = &requested
+		if requested == t.Priority {
+			t.ProposedPriority = nil
+		}
+		return nil
+	}
+	t.Priority, t.ProposedPriority = requested, nil
+	return nil
+}
@@ -2,7 +2,10 @@ package tickets
 
 import (
 	"context"
+	"fmt"
 	"innotech/internal/storage/postgres"
+	"innotech/pkg/db"
+	"strings"
 
 	"github.com/jmoiron/sqlx"
 )
@@ -11,11 +14,20 @@ import (
 type Repository interface {
 	Create(ctx context.Context, t *postgres.Ticket) error
 	GetByID(ctx context.Context, id int) (*postgres.Ticket, error)
-	GetAll(ctx context.Context) ([]postgres.Ticket, error)
+	List(ctx context.Context, f ListFilter) ([]postgres.Ticket, error)
 	Update(ctx context.Context, t *postgres.Ticket) error
+	UpdateStatus(ctx context.Context, t *postgres.Ticket) error
 	Delete(ctx context.Context, id int) error
+	GetModule(ctx context.Context, id int) (*postgres.Module, error)
 }
 
+// ticketColumns lists the columns of postgres.Ticket; the table also carries
+// full-text search vectors that are never read back.
+const ticketColumns = `id, project_id, module_id, contract_id, created_by, assigned_to, title, message,
+	status, resolution, priority, proposed_priority, severity, gitlab_issue_url, gitlab_issue_iid, mattermost_thread_url, date_created, date_updated,
+	sla_policy_id, first_response_due, first_response_warn_at, first_responded_at, first_response_sla,
+	resolve_due, resolve_warn_at, resolve_sla, sla_paused_at`
+
 type ticketRepository struct {
 	db *sqlx.DB
 }
@@ -27,11 +39,13 @@ func NewRepository(db *sqlx.DB) Repository {
 
 func (r *ticketRepository) Create(ctx context.Context, t *postgres.Ticket) error {
 	query := `
-		INSERT INTO tickets (project_id, module_id, contract_id, created_by, assigned_to, title, message, status)
-		VALUES (:project_id, :module_id, :contract_id, :created_by, :assigned_to, :title, :message, :status)
+		INSERT INTO tickets (project_id, module_id, contract_id, created_by, assigned_to, title, message, status,
+			priority, proposed_priority, severity)
+		VALUES (:project_id, :module_id, :contract_id, :created_by, :assigned_to, :title, :message, :status,
+			:priority, :proposed_priority, :severity)
 		RETURNING id, date_created, date_updated
 	`
-	stmt, err := r.db.PrepareNamedContext(ctx, query)
+	stmt, err := db.Conn(ctx, r.db).PrepareNamedContext(ctx, query)
 	if err != nil {
 		return err
 	}
@@ -40,30 +54,148 @@ func (r *ticketRepository) Create(ctx context.Context, t *postgres.Ticket) error
 
 func (r *ticketRepository) GetByID(ctx context.Context, id int) (*postgres.Ticket, error) {
 	var t postgres.Ticket
-	err := r.db.GetContext(ctx, &t, "SELECT * FROM tickets WHERE id=$1", id)
+	err := db.Conn(ctx, r.db).GetContext(ctx, &t, "SELECT "+ticketColumns+" FROM tickets WHERE id=$1", id)
 	if err != nil {
 		return nil, err
 	}
 	return &t, nil
 }
 
-func (r *ticketRepository) GetAll(ctx context.Context) ([]postgres.Ticket, error) {
-	var tickets []postgres.Ticket
-	err := r.db.SelectContext(ctx, &tickets, "SELECT * FROM tickets ORDER BY date_created DESC")
+func (r *ticketRepository) List(ctx context.Context, f ListFilter) ([]postgres.Ticket, error) {
+	tickets := []postgres.Ticket{}
+	if f.ProjectIDs != nil && len(f.ProjectIDs) == 0 {
+		return tickets, nil
+	}
+
+	query, args, err := listQuery(f)
 	if err != nil {
 		return nil, err
 	}
+	if err := db.Conn(ctx, r.db).SelectContext(ctx, &tickets, query, args...); err != nil {
+		return nil, err
+	}
 	return tickets, nil
 }
 
+// listQuery builds the filtered, keyset-paginated ticket listing query. Plain
+// equality predicates are used so the per-column indexes stay usable.
+func listQuery(f ListFilter) (string, []any, error) {
+	var (
+		where []string
+		args  []any
+	)
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	in := func(column string, n int, value func(i int) any) {
+		ph := make([]string, n)
+		for i := range ph {
+			ph[i] = arg(value(i))
+		}
+		where = append(where, column+" IN ("+strings.Join(ph, ", ")+")")
+	}
+
+	if f.ProjectIDs != nil {
+		in("project_id", len(f.ProjectIDs), func(i int) any { return f.ProjectIDs[i] })
+	}
+	if f.ProjectID != nil {
+		where = append(where, "project_id = "+arg(*f.ProjectID))
+	}
+	if f.ContractID != nil {
+		where = append(where, "contract_id = "+arg(*f.ContractID))
+	}
+	if f.ModuleID != nil {
+		where = append(where, "module_id = "+arg(*f.ModuleID))
+	}
+	if len(f.Statuses) > 0 {
+		in("status", len(f.Statuses), func(i int) any { return f.Statuses[i] })
+	}
+	if len(f.Priorities) > 0 {
+		in("priority", len(f.Priorities), func(i int) any { return f.Priorities[i] })
+	}
+	if len(f.Severities) > 0 {
+		in("severity", len(f.Severities), func(i int) any { return f.Severities[i] })
+	}
+	if f.AssignedTo != nil {
+		where = append(where, "assigned_to = "+arg(*f.AssignedTo))
+	}
+	if f.CreatedBy != nil {
+		where = append(where, "created_by = "+arg(*f.CreatedBy))
+	}
+	if f.CreatedFrom != nil {
+		where = append(where, "date_created >= "+arg(*f.CreatedFrom))
+	}
+	if f.CreatedTo != nil {
+		where = append(where, "date_created < "+arg(*f.CreatedTo))
+	}
+	if f.UpdatedFrom != nil {
+		where = append(where, "date_updated >= "+arg(*f.UpdatedFrom))
+	}
+	if f.UpdatedTo != nil {
+		where = append(where, "date_updated < "+arg(*f.UpdatedTo))
+	}
+	if f.Title != "" {
+		where = append(where, `title ILIKE `+arg("%"+escapeLike(f.Title)+"%")+` ESCAPE '\'`)
+	}
+
+	sort := f.Sort
+	if sort.Column == "" {
+		sort = DefaultSort
+	}
+	if !sortColumns[sort.Column] {
+		return "", nil, fmt.Errorf("unknown sort column %q", sort.Column)
+	}
+	dir, cmp := "ASC", ">"
+	if sort.Desc {
+		dir, cmp = "DESC", "<"
+	}
+	if f.After != nil {
+		v, err := sort.arg(f.After.Value)
+		if err != nil {
+			return "", nil, err
+		}
+		where = append(where, fmt.Sprintf("(%s, id) %s (%s, %s)", sort.Column, cmp, arg(v), arg(f.After.ID)))
+	}
+
+	query := "SELECT " + ticketColumns + " FROM tickets"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += fmt.Sprintf(" ORDER BY %s %s, id %s", sort.Column, dir, dir)
+	if f.Limit > 0 {
+		query += " LIMIT " + arg(f.Limit)
+	}
+	return query, args, nil
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
 func (r *ticketRepository) Update(ctx context.Context, t *postgres.Ticket) error {
 	query := `
 		UPDATE tickets
-		SET title=:title, message=:message, status=:status, assigned_to=:assigned_to, module_id=:module_id
+		SET title=:title, message=:message, status=:status, assigned_to=:assigned_to, module_id=:module_id,
+			priority=:priority, proposed_priority=:proposed_priority, severity=:severity
+		WHERE id=:id
+		RETURNING date_updated
+	`
+	stmt, err := db.Conn(ctx, r.db).PrepareNamedContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	return stmt.GetContext(ctx, &t.DateUpdated, t)
+}
+
+func (r *ticketRepository) UpdateStatus(ctx context.Context, t *postgres.Ticket) error {
+	query := `
+		UPDATE tickets
+		SET status=:status, resolution=:resolution
 		WHERE id=:id
 		RETURNING date_updated
 	`
-	stmt, err := r.db.PrepareNamedContext(ctx, query)
+	stmt, err := db.Conn(ctx, r.db).PrepareNamedContext(ctx, query)
 	if err != nil {
 		return err
 	}
@@ -71,6 +203,14 @@ func (r *ticketRepository) Update(ctx context.Context, t *postgres.Ticket) error
 }
 
 func (r *ticketRepository) Delete(ctx context.Context, id int) error {
-	_, err := r.db.ExecContext(ctx, "DELETE FROM tickets WHERE id=$1", id)
+	_, err := db.Conn(ctx, r.db).ExecContext(ctx, "DELETE FROM tickets WHERE id=$1", id)
 	return err
 }
+
+func (r *ticketRepository) GetModule(ctx context.Context, id int) (*postgres.Module, error) {
+	var m postgres.Module
+	if err := db.Conn(ctx, r.db).GetContext(ctx, &m, `SELECT * FROM modules WHERE id = $1`, id); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
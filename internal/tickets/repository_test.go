@@ -29,15 +29,17 @@ func TestTicketRepository_Create_WithReturning(t *testing.T) {
 		Title:      "Test Ticket",
 		Message:    "Details",
 		Status:     "open",
+		Priority:   "P2",
+		Severity:   "major",
 	}
 
-	mock.ExpectPrepare(`INSERT INTO tickets \(project_id, module_id, contract_id, created_by, assigned_to, title, message, status\).*RETURNING.*`)
+	mock.ExpectPrepare(`INSERT INTO tickets \(project_id, module_id, contract_id, created_by, assigned_to, title, message, status,\s+priority, proposed_priority, severity\).*RETURNING.*`)
 
 	rows := sqlmock.NewRows([]string{"id", "date_created", "date_updated"}).
 		AddRow(123, now, now)
 
-	mock.ExpectQuery(`INSERT INTO tickets \(project_id, module_id, contract_id, created_by, assigned_to, title, message, status\).*RETURNING.*`).
-		WithArgs(ticket.ProjectID, ticket.ModuleID, ticket.ContractID, ticket.CreatedBy, nil, ticket.Title, ticket.Message, ticket.Status).
+	mock.ExpectQuery(`INSERT INTO tickets \(project_id, module_id, contract_id, created_by, assigned_to, title, message, status,\s+priority, proposed_priority, severity\).*RETURNING.*`).
+		WithArgs(ticket.ProjectID, ticket.ModuleID, ticket.ContractID, ticket.CreatedBy, nil, ticket.Title, ticket.Message, ticket.Status, "P2", nil, "major").
 		WillReturnRows(rows)
 
 	err = repo.Create(context.Background(), ticket)
@@ -102,7 +104,7 @@ func TestTicketRepository_GetByID_HandlesNullableFields(t *testing.T) {
 		"http://mattermost.com/1", now, now,
 	)
 
-	mock.ExpectQuery(`SELECT \* FROM tickets WHERE id=\$1`).
+	mock.ExpectQuery(`SELECT id, project_id, .* FROM tickets WHERE id=\$1`).
 		WithArgs(1).
 		WillReturnRows(rows)
 
@@ -115,3 +117,73 @@ func TestTicketRepository_GetByID_HandlesNullableFields(t *testing.T) {
 	assert.Equal(t, "http://gitlab.com/1", *ticket.GitlabIssueURL)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestTicketRepository_List_BuildsFilteredKeysetQuery(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = mockDB.Close() }()
+
+	repo := NewRepository(sqlx.NewDb(mockDB, "sqlmock"))
+
+	project, assignee := 4, "7b1f2a52-9f7e-4c57-8f4e-1f1b7c1d8a11"
+	after := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(`SELECT id, project_id, .* FROM tickets WHERE project_id IN \(\$1, \$2\) AND project_id = \$3 AND status IN \(\$4, \$5\) AND assigned_to = \$6 AND title ILIKE \$7 ESCAPE '\\' AND \(date_created, id\) < \(\$8, \$9\) ORDER BY date_created DESC, id DESC LIMIT \$10`).
+		WithArgs(4, 5, project, "open", "in_progress", assignee, `%50\%%`, after, 12, 21).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "project_id", "title"}).AddRow(11, 4, "50% off"))
+
+	list, err := repo.List(context.Background(), ListFilter{
+		ProjectIDs: []int{4, 5},
+		ProjectID:  &project,
+		Statuses:   []string{"open", "in_progress"},
+		AssignedTo: &assignee,
+		Title:      "50%",
+		Sort:       Sort{Column: "date_created", Desc: true},
+		Limit:      21,
+		After:      &Cursor{Sort: "-date_created", Value: after.Format(time.RFC3339Nano), ID: 12},
+	})
+
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, 11, list[0].ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTicketRepository_List_OrdersQueueByPriority(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = mockDB.Close() }()
+
+	repo := NewRepository(sqlx.NewDb(mockDB, "sqlmock"))
+
+	mock.ExpectQuery(`SELECT id, project_id, .* FROM tickets WHERE priority IN \(\$1, \$2\) AND severity IN \(\$3\) AND \(priority, id\) > \(\$4, \$5\) ORDER BY priority ASC, id ASC LIMIT \$6`).
+		WithArgs("P1", "P2", "critical", "P1", 30, 51).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "priority", "severity"}).AddRow(31, "P2", "critical"))
+
+	list, err := repo.List(context.Background(), ListFilter{
+		Priorities: []string{"P1", "P2"},
+		Severities: []string{"critical"},
+		Sort:       DefaultSort,
+		Limit:      51,
+		After:      &Cursor{Sort: "priority", Value: "P1", ID: 30},
+	})
+
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, "P2", list[0].Priority)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTicketRepository_List_EmptyScopeSkipsQuery(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = mockDB.Close() }()
+
+	repo := NewRepository(sqlx.NewDb(mockDB, "sqlmock"))
+
+	list, err := repo.List(context.Background(), ListFilter{ProjectIDs: []int{}})
+
+	require.NoError(t, err)
+	assert.Empty(t, list)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -1,6 +1,7 @@
 package tickets
 
 import (
+	"innotech/internal/access"
 	"innotech/internal/storage/transport"
 	"innotech/pkg/middleware"
 
@@ -8,14 +9,17 @@ import (
 )
 
 // RegisterRoutes registers HTTP routes for ticket operations.
-func RegisterRoutes(app *fiber.App, h *Handler) {
+func RegisterRoutes(app *fiber.App, h *Handler, guard *access.Guard) {
 	api := app.Group("/api/tickets")
 
-	api.Get("/", h.GetAll)
-	api.Get("/:id", h.GetByID)
+	api.Get("/", guard.Scoped(access.TicketRead), middleware.ValidateQuery[transport.TicketListQueryDTO](h.List))
+	api.Get("/:id", guard.Require(access.TicketRead, guard.Param("id", access.ResourceTicket)), h.GetByID)
 
-	api.Post("/", middleware.ValidateBody[transport.CreateTicketDTO](h.Create))
-	api.Put("/:id", middleware.ValidateBody[transport.UpdateTicketDTO](h.Update))
+	api.Post("/", guard.Require(access.TicketCreate, guard.Body("project_id", access.ResourceProject)), middleware.ValidateBody[transport.CreateTicketDTO](h.Create))
+	api.Put("/:id", guard.Require(access.TicketUpdate, guard.Param("id", access.ResourceTicket)), middleware.ValidateBody[transport.UpdateTicketDTO](h.Update))
 
-	api.Delete("/:id", h.Delete)
+	api.Get("/:id/transitions", guard.Require(access.TicketRead, guard.Param("id", access.ResourceTicket)), h.GetTransitions)
+	api.Post("/:id/transitions", guard.Require(access.TicketUpdate, guard.Param("id", access.ResourceTicket)), middleware.ValidateBody[transport.TicketTransitionDTO](h.Transition))
+
+	api.Delete("/:id", guard.Require(access.TicketDelete, guard.Param("id", access.ResourceTicket)), h.Delete)
 }
@@ -2,44 +2,317 @@ package tickets
 
 import (
 	"context"
+	"innotech/internal/events"
+	"innotech/internal/outbox"
 	"innotech/internal/storage/postgres"
+	"innotech/pkg/auth"
+	"innotech/pkg/db"
+	"time"
 )
 
+// TopicTicketChanged is the outbox topic of committed ticket changes.
+const TopicTicketChanged = "ticket.changed"
+
+// TicketChange is the outbox payload of TopicTicketChanged. A nil Before
+// marks a newly created ticket; ActorID is empty for changes made by
+// integrations.
+type TicketChange struct {
+	Before  *postgres.Ticket `json:"before"`
+	After   *postgres.Ticket `json:"after"`
+	ActorID string           `json:"actor_id,omitempty"`
+}
+
+// ActorResolver tells whether the caller acts as a client or an admin in a project.
+type ActorResolver interface {
+	SenderRole(ctx context.Context, projectID int) (string, error)
+}
+
+// HistoryRecorder stores field-level changes of a ticket. A nil before marks
+// a newly created ticket.
+type HistoryRecorder interface {
+	RecordChanges(ctx context.Context, before, after *postgres.Ticket) error
+}
+
+// SLATracker keeps the SLA deadlines of tickets. TicketChanged is called on
+// every change and carries the deadlines of before over to after.
+type SLATracker interface {
+	Start(ctx context.Context, t *postgres.Ticket) error
+	TicketChanged(ctx context.Context, before, after *postgres.Ticket) error
+}
+
+// ActivityPublisher appends events to the project activity feed.
+type ActivityPublisher interface {
+	Publish(ctx context.Context, projectID int, eventType string, payload any) error
+}
+
+// Outbox records messages to be delivered once the transaction commits.
+type Outbox interface {
+	Write(ctx context.Context, topic string, payload any) error
+}
+
+// Mirror reflects committed ticket changes in an external system. A nil
+// before marks a newly created ticket. Changes are delivered through the
+// outbox, possibly more than once; an error makes the outbox retry.
+type Mirror interface {
+	TicketChanged(ctx context.Context, before, after *postgres.Ticket) error
+}
+
+// MirrorConsumer adapts m to an outbox consumer of TopicTicketChanged.
+func MirrorConsumer(m Mirror) outbox.Consumer {
+	return outbox.Decode(func(ctx context.Context, c *TicketChange) error {
+		return m.TicketChanged(ctx, c.Before, c.After)
+	})
+}
+
 // Service defines the interface for ticket business logic operations.
 type Service interface {
 	Create(ctx context.Context, t *postgres.Ticket) error
 	GetByID(ctx context.Context, id int) (*postgres.Ticket, error)
-	GetAll(ctx context.Context) ([]postgres.Ticket, error)
+	List(ctx context.Context, f ListFilter) (*Page, error)
 	Update(ctx context.Context, t *postgres.Ticket) error
 	Delete(ctx context.Context, id int) error
+	Transition(ctx context.Context, id int, name, resolution string) (*postgres.Ticket, error)
+	ApplyStatus(ctx context.Context, id int, status, resolution string) (*postgres.Ticket, error)
+	AvailableTransitions(ctx context.Context, id int) ([]Transition, error)
 }
 
 type ticketService struct {
-	repo Repository
+	repo      Repository
+	workflow  *Workflow
+	actors    ActorResolver
+	contracts ContractDirectory
+	sla       SLATracker
+	history   HistoryRecorder
+	activity  ActivityPublisher
+	outbox    Outbox
+	tx        db.Transactor
 }
 
 // NewService creates a new Service instance.
-func NewService(repo Repository) Service {
-	return &ticketService{repo: repo}
+func NewService(repo Repository, workflow *Workflow, actors ActorResolver, contracts ContractDirectory, sla SLATracker, history HistoryRecorder, activity ActivityPublisher, outbox Outbox, tx db.Transactor) Service {
+	return &ticketService{repo: repo, workflow: workflow, actors: actors, contracts: contracts, sla: sla, history: history, activity: activity, outbox: outbox, tx: tx}
 }
 
+// Create opens a ticket. The contract is checked as of today; a violation
+// is returned as a *ContractError, and a module of another project as a
+// *ModuleError.
 func (s *ticketService) Create(ctx context.Context, t *postgres.Ticket) error {
-	t.Status = "open"
-	return s.repo.Create(ctx, t)
+	if err := s.workflow.CheckInitial(t.Status); err != nil {
+		return err
+	}
+	t.Status = s.workflow.Initial
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.checkContract(ctx, t, time.Now()); err != nil {
+			return err
+		}
+		if err := s.checkModule(ctx, t); err != nil {
+			return err
+		}
+		if err := s.triage(ctx, nil, t); err != nil {
+			return err
+		}
+		if err := s.repo.Create(ctx, t); err != nil {
+			return err
+		}
+		if err := s.sla.Start(ctx, t); err != nil {
+			return err
+		}
+		if err := s.history.RecordChanges(ctx, nil, t); err != nil {
+			return err
+		}
+		err := s.activity.Publish(ctx, t.ProjectID, events.TicketCreated, map[string]any{
+			"ticket_id":  t.ID,
+			"title":      t.Title,
+			"status":     t.Status,
+			"created_by": t.CreatedBy,
+		})
+		if err != nil {
+			return err
+		}
+		return s.outbox.Write(ctx, TopicTicketChanged, TicketChange{After: t, ActorID: auth.SubjectFromContext(ctx)})
+	})
 }
 
 func (s *ticketService) GetByID(ctx context.Context, id int) (*postgres.Ticket, error) {
 	return s.repo.GetByID(ctx, id)
 }
 
-func (s *ticketService) GetAll(ctx context.Context) ([]postgres.Ticket, error) {
-	return s.repo.GetAll(ctx)
+// List returns one page of tickets. It fetches one extra row to find out
+// whether a next page exists.
+func (s *ticketService) List(ctx context.Context, f ListFilter) (*Page, error) {
+	if f.Sort.Column == "" {
+		f.Sort = DefaultSort
+	}
+	if f.After != nil && f.After.Sort != f.Sort.String() {
+		return nil, ErrInvalidCursor
+	}
+	limit := f.Limit
+	if limit <= 0 {
+		limit = DefaultPageSize
+	}
+	limit = min(limit, MaxPageSize)
+	f.Limit = limit + 1
+
+	items, err := s.repo.List(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &Page{Items: items}
+	if len(items) > limit {
+		page.Items = items[:limit]
+		last := &page.Items[limit-1]
+		page.NextCursor = Cursor{Sort: f.Sort.String(), Value: f.Sort.value(last), ID: last.ID}.Encode()
+	}
+	return page, nil
 }
 
+// Update changes the editable fields of a ticket. A ticket stays in its
+// project, whose access was checked for the request; a module of another
+// project is returned as a *ModuleError.
 func (s *ticketService) Update(ctx context.Context, t *postgres.Ticket) error {
-	return s.repo.Update(ctx, t)
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		current, err := s.repo.GetByID(ctx, t.ID)
+		if err != nil {
+			return err
+		}
+		t.ProjectID = current.ProjectID
+		if err := s.checkModule(ctx, t); err != nil {
+			return err
+		}
+		if t.Status == "" {
+			t.Status = current.Status
+		}
+		if t.Status != current.Status {
+			actor, err := s.actors.SenderRole(ctx, current.ProjectID)
+			if err != nil {
+				return err
+			}
+			// Transitions that need extra input (a resolution note) have to go
+			// through the transitions endpoint.
+			if _, err := s.workflow.Between(current.Status, t.Status, actor, ""); err != nil {
+				return err
+			}
+		}
+		// Update does not touch the resolution, so carry it over for the diff.
+		t.Resolution = current.Resolution
+		if err := s.triage(ctx, current, t); err != nil {
+			return err
+		}
+
+		if err := s.repo.Update(ctx, t); err != nil {
+			return err
+		}
+		if err := s.sla.TicketChanged(ctx, current, t); err != nil {
+			return err
+		}
+		if err := s.history.RecordChanges(ctx, current, t); err != nil {
+			return err
+		}
+		return s.publishChanges(ctx, current, t, "")
+	})
 }
 
 func (s *ticketService) Delete(ctx context.Context, id int) error {
 	return s.repo.Delete(ctx, id)
 }
+
+func (s *ticketService) Transition(ctx context.Context, id int, name, resolution string) (*postgres.Ticket, error) {
+	return s.transition(ctx, id, resolution, func(current *postgres.Ticket) (*Transition, error) {
+		actor, err := s.actors.SenderRole(ctx, current.ProjectID)
+		if err != nil {
+			return nil, err
+		}
+		return s.workflow.Named(name, current.Status, actor, resolution)
+	})
+}
+
+// ApplyStatus moves a ticket to status on behalf of an integrated system,
+// such as an issue closed in GitLab. The change must still be allowed by the
+// workflow for the support side.
+func (s *ticketService) ApplyStatus(ctx context.Context, id int, status, resolution string) (*postgres.Ticket, error) {
+	return s.transition(ctx, id, resolution, func(current *postgres.Ticket) (*Transition, error) {
+		return s.workflow.Between(current.Status, status, ActorAdmin, resolution)
+	})
+}
+
+// transition applies the transition chosen by pick to the ticket and records it.
+func (s *ticketService) transition(ctx context.Context, id int, resolution string, pick func(current *postgres.Ticket) (*Transition, error)) (*postgres.Ticket, error) {
+	var t *postgres.Ticket
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		current, err := s.repo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		tr, err := pick(current)
+		if err != nil {
+			return err
+		}
+
+		next := *current
+		switch {
+		case tr.To == s.workflow.Initial:
+			next.Resolution = nil
+		case resolution != "":
+			next.Resolution = &resolution
+		}
+		next.Status = tr.To
+
+		if err := s.repo.UpdateStatus(ctx, &next); err != nil {
+			return err
+		}
+		if err := s.sla.TicketChanged(ctx, current, &next); err != nil {
+			return err
+		}
+		t = &next
+		if err := s.history.RecordChanges(ctx, current, t); err != nil {
+			return err
+		}
+		return s.publishChanges(ctx, current, t, tr.Name)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (s *ticketService) AvailableTransitions(ctx context.Context, id int) ([]Transition, error) {
+	t, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	actor, err := s.actors.SenderRole(ctx, t.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	return s.workflow.Available(t.Status, actor), nil
+}
+
+// publishChanges announces status and assignee changes on the project feed
+// and queues the change for mirrors.
+func (s *ticketService) publishChanges(ctx context.Context, before, after *postgres.Ticket, transition string) error {
+	if before.Status != after.Status {
+		payload := map[string]any{"ticket_id": after.ID, "from": before.Status, "to": after.Status}
+		if transition != "" {
+			payload["transition"] = transition
+		}
+		if err := s.activity.Publish(ctx, before.ProjectID, events.TicketStatusChanged, payload); err != nil {
+			return err
+		}
+	}
+	if !sameAssignee(before.AssignedTo, after.AssignedTo) {
+		payload := map[string]any{"ticket_id": after.ID, "assigned_to": after.AssignedTo, "previous": before.AssignedTo}
+		if err := s.activity.Publish(ctx, before.ProjectID, events.TicketAssigned, payload); err != nil {
+			return err
+		}
+	}
+	return s.outbox.Write(ctx, TopicTicketChanged, TicketChange{Before: before, After: after, ActorID: auth.SubjectFromContext(ctx)})
+}
+
+func sameAssignee(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -2,12 +2,18 @@ package tickets
 
 import (
 	"context"
+	"database/sql"
 	"errors"
+	"innotech/internal/events"
 	"innotech/internal/storage/postgres"
+	"innotech/pkg/auth"
+	"innotech/pkg/db"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 type mockRepository struct {
@@ -27,8 +33,8 @@ func (m *mockRepository) GetByID(ctx context.Context, id int) (*postgres.Ticket,
 	return args.Get(0).(*postgres.Ticket), args.Error(1)
 }
 
-func (m *mockRepository) GetAll(ctx context.Context) ([]postgres.Ticket, error) {
-	args := m.Called(ctx)
+func (m *mockRepository) List(ctx context.Context, f ListFilter) ([]postgres.Ticket, error) {
+	args := m.Called(ctx, f)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -40,15 +46,105 @@ func (m *mockRepository) Update(ctx context.Context, t *postgres.Ticket) error {
 	return args.Error(0)
 }
 
+func (m *mockRepository) UpdateStatus(ctx context.Context, t *postgres.Ticket) error {
+	args := m.Called(ctx, t)
+	return args.Error(0)
+}
+
 func (m *mockRepository) Delete(ctx context.Context, id int) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
 }
 
+func (m *mockRepository) GetModule(ctx context.Context, id int) (*postgres.Module, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*postgres.Module), args.Error(1)
+}
+
+type stubActors string
+
+func (s stubActors) SenderRole(_ context.Context, _ int) (string, error) {
+	return string(s), nil
+}
+
+// openContracts serves every contract as an open-ended contract of its
+// project, with everyone attached.
+type openContracts int
+
+func (o openContracts) GetByID(_ context.Context, id int) (*postgres.Contract, error) {
+	return &postgres.Contract{ID: id, ProjectID: int(o)}, nil
+}
+
+func (openContracts) IsAssigned(context.Context, int, string) (bool, error) {
+	return true, nil
+}
+
+type nopSLA struct{}
+
+func (nopSLA) Start(context.Context, *postgres.Ticket) error { return nil }
+
+func (nopSLA) TicketChanged(_ context.Context, before, after *postgres.Ticket) error {
+	after.TicketSLA = before.TicketSLA
+	return nil
+}
+
+type mockHistory struct {
+	mock.Mock
+}
+
+func (m *mockHistory) RecordChanges(ctx context.Context, before, after *postgres.Ticket) error {
+	args := m.Called(ctx, before, after)
+	return args.Error(0)
+}
+
+type mockActivity struct {
+	mock.Mock
+}
+
+func (m *mockActivity) Publish(ctx context.Context, projectID int, eventType string, payload any) error {
+	args := m.Called(ctx, projectID, eventType, payload)
+	return args.Error(0)
+}
+
+func nopActivity() *mockActivity {
+	a := new(mockActivity)
+	a.On("Publish", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	return a
+}
+
+type recordingOutbox struct {
+	changes []TicketChange
+}
+
+func (o *recordingOutbox) Write(_ context.Context, topic string, payload any) error {
+	if topic == TopicTicketChanged {
+		o.changes = append(o.changes, payload.(TicketChange))
+	}
+	return nil
+}
+
+type recordingMirror struct {
+	before, after *postgres.Ticket
+}
+
+func (m *recordingMirror) TicketChanged(_ context.Context, before, after *postgres.Ticket) error {
+	m.before, m.after = before, after
+	return nil
+}
+
+func newTestService(repo Repository, actor string) Service {
+	history := new(mockHistory)
+	history.On("RecordChanges", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	return NewService(repo, DefaultWorkflow(), stubActors(actor), openContracts(0), nopSLA{}, history, nopActivity(), &recordingOutbox{}, db.NopTransactor{})
+}
+
 func TestService_Create_SetsStatusAndCallsRepo(t *testing.T) {
 	ctx := context.Background()
 	repo := new(mockRepository)
-	svc := NewService(repo)
+	svc := newTestService(repo, ActorAdmin)
 
 	tIn := &postgres.Ticket{Title: "t1", Message: "m1"}
 
@@ -64,7 +160,7 @@ func TestService_Create_SetsStatusAndCallsRepo(t *testing.T) {
 func TestService_Create_RepoError_ReturnsError(t *testing.T) {
 	ctx := context.Background()
 	repo := new(mockRepository)
-	svc := NewService(repo)
+	svc := newTestService(repo, ActorAdmin)
 
 	tIn := &postgres.Ticket{Title: "t2"}
 	repo.On("Create", mock.Anything, tIn).Return(errors.New("db error")).Once()
@@ -78,7 +174,7 @@ func TestService_Create_RepoError_ReturnsError(t *testing.T) {
 func TestService_GetByID_ReturnsTicket(t *testing.T) {
 	ctx := context.Background()
 	repo := new(mockRepository)
-	svc := NewService(repo)
+	svc := newTestService(repo, ActorAdmin)
 
 	exp := &postgres.Ticket{ID: 1, Title: "t"}
 	repo.On("GetByID", mock.Anything, 1).Return(exp, nil).Once()
@@ -89,26 +185,59 @@ func TestService_GetByID_ReturnsTicket(t *testing.T) {
 	repo.AssertExpectations(t)
 }
 
-func TestService_GetAll_ReturnsList(t *testing.T) {
+func TestService_List_ReturnsPageWithNextCursor(t *testing.T) {
 	ctx := context.Background()
 	repo := new(mockRepository)
-	svc := NewService(repo)
+	svc := newTestService(repo, ActorAdmin)
 
-	list := []postgres.Ticket{{ID: 1}, {ID: 2}}
-	repo.On("GetAll", mock.Anything).Return(list, nil).Once()
+	list := []postgres.Ticket{{ID: 3, Priority: PriorityP1}, {ID: 2, Priority: PriorityP2}, {ID: 1, Priority: PriorityP2}}
+	repo.On("List", mock.Anything, mock.MatchedBy(func(f ListFilter) bool {
+		return f.Limit == 3 && f.Sort == DefaultSort
+	})).Return(list, nil).Once()
 
-	got, err := svc.GetAll(ctx)
-	assert.NoError(t, err)
-	assert.Equal(t, list, got)
+	page, err := svc.List(ctx, ListFilter{Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, list[:2], page.Items)
+
+	next, err := DecodeCursor(page.NextCursor)
+	require.NoError(t, err)
+	assert.Equal(t, Cursor{Sort: "priority", Value: PriorityP2, ID: 2}, *next)
 	repo.AssertExpectations(t)
 }
 
+func TestService_List_LastPageHasNoCursor(t *testing.T) {
+	repo := new(mockRepository)
+	svc := newTestService(repo, ActorAdmin)
+
+	repo.On("List", mock.Anything, mock.MatchedBy(func(f ListFilter) bool {
+		return f.Limit == DefaultPageSize+1
+	})).Return([]postgres.Ticket{{ID: 1}}, nil).Once()
+
+	page, err := svc.List(context.Background(), ListFilter{})
+	require.NoError(t, err)
+	assert.Len(t, page.Items, 1)
+	assert.Empty(t, page.NextCursor)
+}
+
+func TestService_List_RejectsCursorFromOtherSort(t *testing.T) {
+	repo := new(mockRepository)
+	svc := newTestService(repo, ActorAdmin)
+
+	_, err := svc.List(context.Background(), ListFilter{
+		Sort:  Sort{Column: "title"},
+		After: &Cursor{Sort: "-date_created", Value: "x", ID: 1},
+	})
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+	repo.AssertNotCalled(t, "List", mock.Anything, mock.Anything)
+}
+
 func TestService_Update_PassesThrough(t *testing.T) {
 	ctx := context.Background()
 	repo := new(mockRepository)
-	svc := NewService(repo)
+	svc := newTestService(repo, ActorAdmin)
 
-	tIn := &postgres.Ticket{ID: 5, Title: "t"}
+	tIn := &postgres.Ticket{ID: 5, Title: "t", Status: StatusOpen}
+	repo.On("GetByID", mock.Anything, 5).Return(&postgres.Ticket{ID: 5, Status: StatusOpen}, nil).Once()
 	repo.On("Update", mock.Anything, tIn).Return(nil).Once()
 
 	err := svc.Update(ctx, tIn)
@@ -119,7 +248,7 @@ func TestService_Update_PassesThrough(t *testing.T) {
 func TestService_Delete_PassesThrough(t *testing.T) {
 	ctx := context.Background()
 	repo := new(mockRepository)
-	svc := NewService(repo)
+	svc := newTestService(repo, ActorAdmin)
 
 	repo.On("Delete", mock.Anything, 7).Return(nil).Once()
 
@@ -127,3 +256,455 @@ func TestService_Delete_PassesThrough(t *testing.T) {
 	assert.NoError(t, err)
 	repo.AssertExpectations(t)
 }
+
+func TestService_Create_RejectsNonInitialStatus(t *testing.T) {
+	repo := new(mockRepository)
+	svc := newTestService(repo, ActorClient)
+
+	err := svc.Create(context.Background(), &postgres.Ticket{Title: "t", Status: StatusResolved})
+
+	var te *TransitionError
+	assert.ErrorAs(t, err, &te)
+	assert.Equal(t, CodeInvalidInitialStatus, te.Code)
+	repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestService_Update_RejectsInvalidStatusJump(t *testing.T) {
+	repo := new(mockRepository)
+	svc := newTestService(repo, ActorAdmin)
+
+	repo.On("GetByID", mock.Anything, 5).Return(&postgres.Ticket{ID: 5, Status: StatusClosed}, nil).Once()
+
+	err := svc.Update(context.Background(), &postgres.Ticket{ID: 5, Status: StatusInProgress})
+
+	var te *TransitionError
+	assert.ErrorAs(t, err, &te)
+	assert.Equal(t, CodeInvalidTransition, te.Code)
+	assert.Equal(t, []string{"reopen"}, te.Allowed)
+	repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestService_Transition_ResolveStoresResolution(t *testing.T) {
+	repo := new(mockRepository)
+	svc := newTestService(repo, ActorAdmin)
+
+	current := &postgres.Ticket{ID: 3, ProjectID: 1, Status: StatusInProgress}
+	repo.On("GetByID", mock.Anything, 3).Return(current, nil).Once()
+	repo.On("UpdateStatus", mock.Anything, mock.Anything).Return(nil).Once()
+
+	got, err := svc.Transition(context.Background(), 3, "resolve", "restarted the worker")
+
+	assert.NoError(t, err)
+	assert.Equal(t, StatusResolved, got.Status)
+	assert.Equal(t, "restarted the worker", *got.Resolution)
+	repo.AssertExpectations(t)
+}
+
+func TestService_Transition_ClientCannotResolve(t *testing.T) {
+	repo := new(mockRepository)
+	svc := newTestService(repo, ActorClient)
+
+	repo.On("GetByID", mock.Anything, 3).Return(&postgres.Ticket{ID: 3, Status: StatusOpen}, nil).Once()
+
+	_, err := svc.Transition(context.Background(), 3, "resolve", "done")
+
+	var te *TransitionError
+	assert.ErrorAs(t, err, &te)
+	assert.Equal(t, CodeTransitionForbidden, te.Code)
+	repo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything)
+}
+
+func TestService_Transition_ReopenClearsResolution(t *testing.T) {
+	repo := new(mockRepository)
+	svc := newTestService(repo, ActorClient)
+
+	note := "fixed"
+	current := &postgres.Ticket{ID: 4, Status: StatusResolved, Resolution: &note}
+	repo.On("GetByID", mock.Anything, 4).Return(current, nil).Once()
+	repo.On("UpdateStatus", mock.Anything, mock.Anything).Return(nil).Once()
+
+	got, err := svc.Transition(context.Background(), 4, "reopen", "")
+
+	assert.NoError(t, err)
+	assert.Equal(t, StatusOpen, got.Status)
+	assert.Nil(t, got.Resolution)
+}
+
+func TestService_Update_RecordsChangesAgainstCurrent(t *testing.T) {
+	repo := new(mockRepository)
+	history := new(mockHistory)
+	svc := NewService(repo, DefaultWorkflow(), stubActors(ActorAdmin), openContracts(0), nopSLA{}, history, nopActivity(), &recordingOutbox{}, db.NopTransactor{})
+
+	note := "fixed"
+	current := &postgres.Ticket{ID: 5, ProjectID: 1, Title: "old", Status: StatusOpen, Resolution: &note}
+	tIn := &postgres.Ticket{ID: 5, Title: "new", Status: StatusInProgress}
+
+	repo.On("GetByID", mock.Anything, 5).Return(current, nil).Once()
+	repo.On("Update", mock.Anything, tIn).Return(nil).Once()
+	history.On("RecordChanges", mock.Anything, current, tIn).Return(nil).Once()
+
+	err := svc.Update(context.Background(), tIn)
+
+	assert.NoError(t, err)
+	assert.Equal(t, &note, tIn.Resolution)
+	repo.AssertExpectations(t)
+	history.AssertExpectations(t)
+}
+
+func TestService_Transition_HistoryErrorFailsTransition(t *testing.T) {
+	repo := new(mockRepository)
+	history := new(mockHistory)
+	svc := NewService(repo, DefaultWorkflow(), stubActors(ActorAdmin), openContracts(0), nopSLA{}, history, nopActivity(), &recordingOutbox{}, db.NopTransactor{})
+
+	current := &postgres.Ticket{ID: 3, ProjectID: 1, Status: StatusOpen}
+	repo.On("GetByID", mock.Anything, 3).Return(current, nil).Once()
+	repo.On("UpdateStatus", mock.Anything, mock.Anything).Return(nil).Once()
+	history.On("RecordChanges", mock.Anything, current, mock.Anything).Return(errors.New("db error")).Once()
+
+	got, err := svc.Transition(context.Background(), 3, "start", "")
+
+	assert.Error(t, err)
+	assert.Nil(t, got)
+	assert.Equal(t, StatusOpen, current.Status)
+}
+
+func TestService_Transition_PublishesStatusChange(t *testing.T) {
+	repo := new(mockRepository)
+	history := new(mockHistory)
+	activity := new(mockActivity)
+	svc := NewService(repo, DefaultWorkflow(), stubActors(ActorAdmin), openContracts(0), nopSLA{}, history, activity, &recordingOutbox{}, db.NopTransactor{})
+
+	current := &postgres.Ticket{ID: 3, ProjectID: 8, Status: StatusOpen}
+	repo.On("GetByID", mock.Anything, 3).Return(current, nil).Once()
+	repo.On("UpdateStatus", mock.Anything, mock.Anything).Return(nil).Once()
+	history.On("RecordChanges", mock.Anything, current, mock.Anything).Return(nil).Once()
+	activity.On("Publish", mock.Anything, 8, events.TicketStatusChanged, map[string]any{
+		"ticket_id": 3, "from": StatusOpen, "to": StatusInProgress, "transition": "start",
+	}).Return(nil).Once()
+
+	_, err := svc.Transition(context.Background(), 3, "start", "")
+
+	assert.NoError(t, err)
+	activity.AssertExpectations(t)
+}
+
+// recordingSLA pauses the clock of tickets it sees leaving for waiting.
+type recordingSLA struct {
+	started []int
+	changes [][2]string
+}
+
+func (r *recordingSLA) Start(_ context.Context, t *postgres.Ticket) error {
+	r.started = append(r.started, t.ID)
+	return nil
+}
+
+func (r *recordingSLA) TicketChanged(_ context.Context, before, after *postgres.Ticket) error {
+	after.TicketSLA = before.TicketSLA
+	r.changes = append(r.changes, [2]string{before.Status, after.Status})
+	if after.Status == StatusWaiting {
+		now := time.Now()
+		after.SLAPausedAt = &now
+	}
+	return nil
+}
+
+func TestService_Transition_AwaitClientPausesSLA(t *testing.T) {
+	repo := new(mockRepository)
+	history := new(mockHistory)
+	tracker := &recordingSLA{}
+	ob := &recordingOutbox{}
+	svc := NewService(repo, DefaultWorkflow(), stubActors(ActorAdmin), openContracts(0), tracker, history, nopActivity(), ob, db.NopTransactor{})
+
+	current := &postgres.Ticket{ID: 3, ProjectID: 1, Status: StatusInProgress}
+	repo.On("GetByID", mock.Anything, 3).Return(current, nil).Once()
+	repo.On("UpdateStatus", mock.Anything, mock.Anything).Return(nil).Once()
+	history.On("RecordChanges", mock.Anything, current, mock.Anything).Return(nil).Once()
+
+	got, err := svc.Transition(context.Background(), 3, "await_client", "")
+
+	require.NoError(t, err)
+	assert.Equal(t, StatusWaiting, got.Status)
+	assert.Equal(t, [][2]string{{StatusInProgress, StatusWaiting}}, tracker.changes)
+	assert.NotNil(t, got.SLAPausedAt)
+	require.Len(t, ob.changes, 1)
+	assert.NotNil(t, ob.changes[0].After.SLAPausedAt, "mirrors see the paused clock")
+}
+
+func TestService_ApplyStatus_ResolvesAsSupportAndMirrors(t *testing.T) {
+	repo := new(mockRepository)
+	history := new(mockHistory)
+	ob := &recordingOutbox{}
+	// The caller is a client, but integrations act for the support side.
+	svc := NewService(repo, DefaultWorkflow(), stubActors(ActorClient), openContracts(0), nopSLA{}, history, nopActivity(), ob, db.NopTransactor{})
+
+	current := &postgres.Ticket{ID: 3, ProjectID: 8, Status: StatusInProgress}
+	repo.On("GetByID", mock.Anything, 3).Return(current, nil).Once()
+	repo.On("UpdateStatus", mock.Anything, mock.Anything).Return(nil).Once()
+	history.On("RecordChanges", mock.Anything, current, mock.Anything).Return(nil).Once()
+
+	got, err := svc.ApplyStatus(context.Background(), 3, StatusResolved, "closed in GitLab")
+
+	require.NoError(t, err)
+	assert.Equal(t, StatusResolved, got.Status)
+	assert.Equal(t, "closed in GitLab", *got.Resolution)
+	require.Len(t, ob.changes, 1)
+	assert.Same(t, current, ob.changes[0].Before)
+	assert.Same(t, got, ob.changes[0].After)
+}
+
+func TestService_ApplyStatus_RejectsMoveOutsideWorkflow(t *testing.T) {
+	repo := new(mockRepository)
+	ob := &recordingOutbox{}
+	svc := NewService(repo, DefaultWorkflow(), stubActors(ActorAdmin), openContracts(0), nopSLA{}, new(mockHistory), nopActivity(), ob, db.NopTransactor{})
+
+	repo.On("GetByID", mock.Anything, 3).Return(&postgres.Ticket{ID: 3, Status: StatusClosed}, nil).Once()
+
+	_, err := svc.ApplyStatus(context.Background(), 3, StatusResolved, "note")
+
+	var te *TransitionError
+	require.ErrorAs(t, err, &te)
+	assert.Equal(t, CodeInvalidTransition, te.Code)
+	assert.Empty(t, ob.changes)
+}
+
+func TestService_Create_QueuesNewTicket(t *testing.T) {
+	repo := new(mockRepository)
+	history := new(mockHistory)
+	ob := &recordingOutbox{}
+	svc := NewService(repo, DefaultWorkflow(), stubActors(ActorClient), openContracts(1), nopSLA{}, history, nopActivity(), ob, db.NopTransactor{})
+
+	tIn := &postgres.Ticket{ProjectID: 1, Title: "t"}
+	repo.On("Create", mock.Anything, tIn).Return(nil).Once()
+	history.On("RecordChanges", mock.Anything, (*postgres.Ticket)(nil), tIn).Return(nil).Once()
+
+	require.NoError(t, svc.Create(context.Background(), tIn))
+	require.Len(t, ob.changes, 1)
+	assert.Nil(t, ob.changes[0].Before)
+	assert.Same(t, tIn, ob.changes[0].After)
+}
+
+func TestMirrorConsumer_DecodesChange(t *testing.T) {
+	mirror := &recordingMirror{}
+	consume := MirrorConsumer(mirror)
+
+	err := consume(context.Background(), []byte(`{"before":null,"after":{"id":4,"project_id":2,"status":"open"}}`))
+
+	require.NoError(t, err)
+	assert.Nil(t, mirror.before)
+	require.NotNil(t, mirror.after)
+	assert.Equal(t, 4, mirror.after.ID)
+	assert.Equal(t, 2, mirror.after.ProjectID)
+}
+
+type stubContracts struct {
+	byID     map[int]*postgres.Contract
+	assigned map[int]string
+}
+
+func (s stubContracts) GetByID(_ context.Context, id int) (*postgres.Contract, error) {
+	c, ok := s.byID[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return c, nil
+}
+
+func (s stubContracts) IsAssigned(_ context.Context, contractID int, userID string) (bool, error) {
+	return s.assigned[contractID] == userID, nil
+}
+
+func TestService_Create_ChecksContract(t *testing.T) {
+	day := func(offset int) *time.Time {
+		y, m, d := time.Now().Date()
+		date := time.Date(y, m, d+offset, 0, 0, 0, 0, time.UTC)
+		return &date
+	}
+	contracts := stubContracts{
+		byID: map[int]*postgres.Contract{
+			1: {ID: 1, ProjectID: 7, ValidFrom: day(-30), ValidTo: day(30)},
+			2: {ID: 2, ProjectID: 8},
+			3: {ID: 3, ProjectID: 7, ValidFrom: day(1)},
+			4: {ID: 4, ProjectID: 7, ValidTo: day(-1)},
+			5: {ID: 5, ProjectID: 7, ValidTo: day(-3), GraceDays: 3},
+			6: {ID: 6, ProjectID: 7, ValidTo: day(0)},
+		},
+		assigned: map[int]string{1: "client-1", 5: "client-1", 6: "client-1"},
+	}
+	client := auth.WithIdentity(context.Background(), &auth.Identity{Subject: "client-1"})
+	stranger := auth.WithIdentity(context.Background(), &auth.Identity{Subject: "client-2"})
+
+	tests := []struct {
+		name       string
+		ctx        context.Context
+		actor      string
+		contractID int
+		code       string
+	}{
+		{"active contract", client, ActorClient, 1, ""},
+		{"missing contract", client, ActorClient, 99, CodeContractNotInProject},
+		{"contract of another project", client, ActorClient, 2, CodeContractNotInProject},
+		{"contract not started", client, ActorClient, 3, CodeContractNotStarted},
+		{"expired contract", client, ActorClient, 4, CodeContractExpired},
+		{"within grace period", client, ActorClient, 5, ""},
+		{"last day of contract", client, ActorClient, 6, ""},
+		{"client not attached", stranger, ActorClient, 1, CodeContractNotAssigned},
+		{"support files for anyone", stranger, ActorAdmin, 1, ""},
+		{"integration without identity", context.Background(), ActorClient, 1, ""},
+		{"expired even for support", stranger, ActorAdmin, 4, CodeContractExpired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mockRepository)
+			history := new(mockHistory)
+			history.On("RecordChanges", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+			svc := NewService(repo, DefaultWorkflow(), stubActors(tt.actor), contracts, nopSLA{}, history, nopActivity(), &recordingOutbox{}, db.NopTransactor{})
+
+			tIn := &postgres.Ticket{ProjectID: 7, ContractID: tt.contractID, CreatedBy: auth.SubjectFromContext(tt.ctx), Title: "t"}
+			if tt.code == "" {
+				repo.On("Create", mock.Anything, tIn).Return(nil).Once()
+			}
+
+			err := svc.Create(tt.ctx, tIn)
+			if tt.code == "" {
+				require.NoError(t, err)
+			} else {
+				var ce *ContractError
+				require.ErrorAs(t, err, &ce)
+				assert.Equal(t, tt.code, ce.Code)
+				assert.Equal(t, tt.contractID, ce.ContractID)
+			}
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestService_Update_TriagesPriority(t *testing.T) {
+	proposed, urgent := PriorityP2, PriorityP1
+	client := auth.WithIdentity(context.Background(), &auth.Identity{Subject: "client-1"})
+	support := auth.WithIdentity(context.Background(), &auth.Identity{Subject: "admin-1"})
+
+	tests := []struct {
+		name         string
+		ctx          context.Context
+		actor        string
+		proposal     *string
+		requested    string
+		wantPriority string
+		wantProposal *string
+	}{
+		{"client proposes", client, ActorClient, nil, PriorityP1, PriorityP3, &urgent},
+		{"client keeps open proposal", client, ActorClient, &proposed, "", PriorityP3, &proposed},
+		{"client withdraws proposal", client, ActorClient, &proposed, PriorityP3, PriorityP3, nil},
+		{"support sets priority", support, ActorAdmin, &proposed, PriorityP1, PriorityP1, nil},
+		{"support settles proposal as is", support, ActorAdmin, &proposed, PriorityP3, PriorityP3, nil},
+		{"integration sets priority", context.Background(), ActorClient, nil, PriorityP4, PriorityP4, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mockRepository)
+			svc := newTestService(repo, tt.actor)
+
+			current := &postgres.Ticket{ID: 5, ProjectID: 1, Status: StatusOpen, Priority: PriorityP3, ProposedPriority: tt.proposal, Severity: SeverityMajor}
+			tIn := &postgres.Ticket{ID: 5, Title: "t", Status: StatusOpen, Priority: tt.requested}
+			repo.On("GetByID", mock.Anything, 5).Return(current, nil).Once()
+			repo.On("Update", mock.Anything, tIn).Return(nil).Once()
+
+			require.NoError(t, svc.Update(tt.ctx, tIn))
+			assert.Equal(t, tt.wantPriority, tIn.Priority)
+			assert.Equal(t, tt.wantProposal, tIn.ProposedPriority)
+			assert.Equal(t, SeverityMajor, tIn.Severity)
+		})
+	}
+}
+
+func TestService_Create_ClientOnlyProposesPriority(t *testing.T) {
+	repo := new(mockRepository)
+	svc := newTestService(repo, ActorClient)
+	ctx := auth.WithIdentity(context.Background(), &auth.Identity{Subject: "client-1"})
+
+	tIn := &postgres.Ticket{Title: "t", Priority: PriorityP1}
+	repo.On("Create", mock.Anything, tIn).Return(nil).Once()
+
+	require.NoError(t, svc.Create(ctx, tIn))
+	assert.Equal(t, DefaultPriority, tIn.Priority)
+	require.NotNil(t, tIn.ProposedPriority)
+	assert.Equal(t, PriorityP1, *tIn.ProposedPriority)
+	assert.Equal(t, DefaultSeverity, tIn.Severity)
+}
+
+func TestService_Create_RejectsModuleOfAnotherProject(t *testing.T) {
+	tests := []struct {
+		name     string
+		moduleID int
+		ok       bool
+	}{
+		{"module of the project", 1, true},
+		{"module of another project", 2, false},
+		{"missing module", 99, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mockRepository)
+			repo.On("GetModule", mock.Anything, 1).Return(&postgres.Module{ID: 1, ProjectID: 7}, nil).Maybe()
+			repo.On("GetModule", mock.Anything, 2).Return(&postgres.Module{ID: 2, ProjectID: 8}, nil).Maybe()
+			repo.On("GetModule", mock.Anything, 99).Return(nil, sql.ErrNoRows).Maybe()
+			history := new(mockHistory)
+			history.On("RecordChanges", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+			svc := NewService(repo, DefaultWorkflow(), stubActors(ActorAdmin), openContracts(7), nopSLA{}, history, nopActivity(), &recordingOutbox{}, db.NopTransactor{})
+
+			moduleID := tt.moduleID
+			tIn := &postgres.Ticket{ProjectID: 7, ContractID: 1, ModuleID: &moduleID, Title: "t"}
+			if tt.ok {
+				repo.On("Create", mock.Anything, tIn).Return(nil).Once()
+			}
+
+			err := svc.Create(context.Background(), tIn)
+			if tt.ok {
+				require.NoError(t, err)
+			} else {
+				var me *ModuleError
+				require.ErrorAs(t, err, &me)
+				assert.Equal(t, CodeModuleNotInProject, me.Code)
+				assert.Equal(t, tt.moduleID, me.ModuleID)
+				repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+			}
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestService_Update_ChecksModuleAgainstTicketProject(t *testing.T) {
+	repo := new(mockRepository)
+	svc := newTestService(repo, ActorAdmin)
+
+	moduleID := 2
+	repo.On("GetByID", mock.Anything, 5).Return(&postgres.Ticket{ID: 5, ProjectID: 7, Status: StatusOpen}, nil).Once()
+	repo.On("GetModule", mock.Anything, 2).Return(&postgres.Module{ID: 2, ProjectID: 8}, nil).Once()
+
+	// A ticket cannot be moved along with its module either.
+	err := svc.Update(context.Background(), &postgres.Ticket{ID: 5, ProjectID: 8, ModuleID: &moduleID, Title: "t", Status: StatusOpen})
+
+	var me *ModuleError
+	require.ErrorAs(t, err, &me)
+	assert.Equal(t, 2, me.ModuleID)
+	repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	repo.AssertExpectations(t)
+}
+
+func TestService_Update_KeepsTicketInItsProject(t *testing.T) {
+	repo := new(mockRepository)
+	svc := newTestService(repo, ActorAdmin)
+
+	moduleID := 1
+	repo.On("GetByID", mock.Anything, 5).Return(&postgres.Ticket{ID: 5, ProjectID: 7, Status: StatusOpen}, nil).Once()
+	repo.On("GetModule", mock.Anything, 1).Return(&postgres.Module{ID: 1, ProjectID: 7}, nil).Once()
+	repo.On("Update", mock.Anything, mock.MatchedBy(func(t *postgres.Ticket) bool {
+		return t.ProjectID == 7
+	})).Return(nil).Once()
+
+	err := svc.Update(context.Background(), &postgres.Ticket{ID: 5, ProjectID: 8, ModuleID: &moduleID, Title: "t", Status: StatusOpen})
+
+	require.NoError(t, err)
+	repo.AssertExpectations(t)
+}
@@ -0,0 +1,193 @@
+package tickets
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// Ticket statuses from ticket_status_enum.
+const (
+	StatusOpen       = "open"
+	StatusInProgress = "in_progress"
+	StatusWaiting    = "waiting"
+	StatusResolved   = "resolved"
+	StatusClosed     = "closed"
+)
+
+// Actor roles matching sender_role_enum.
+const (
+	ActorClient = "client"
+	ActorAdmin  = "admin"
+)
+
+// Error codes returned in structured workflow errors.
+const (
+	CodeInvalidTransition    = "invalid_transition"
+	CodeTransitionForbidden  = "transition_forbidden"
+	CodeResolutionRequired   = "resolution_required"
+	CodeInvalidInitialStatus = "invalid_initial_status"
+)
+
+// Transition is a named, allowed move between ticket statuses.
+type Transition struct {
+	Name              string   `json:"name"`
+	From              []string `json:"from"`
+	To                string   `json:"to"`
+	Actors            []string `json:"actors"`
+	RequireResolution bool     `json:"require_resolution,omitempty"`
+}
+
+// Workflow is the ticket state machine.
+type Workflow struct {
+	Initial     string       `json:"initial"`
+	Transitions []Transition `json:"transitions"`
+}
+
+// TransitionError describes a rejected status change.
+type TransitionError struct {
+	Code       string   `json:"code"`
+	From       string   `json:"from,omitempty"`
+	To         string   `json:"to,omitempty"`
+	Transition string   `json:"transition,omitempty"`
+	Allowed    []string `json:"allowed,omitempty"`
+}
+
+func (e *TransitionError) Error() string {
+	switch e.Code {
+	case CodeTransitionForbidden:
+		return fmt.Sprintf("transition %q is not permitted for this user", e.Transition)
+	case CodeResolutionRequired:
+		return fmt.Sprintf("transition %q requires a resolution note", e.Transition)
+	case CodeInvalidInitialStatus:
+		return fmt.Sprintf("tickets must be created with status %q", strings.Join(e.Allowed, ", "))
+	default:
+		return fmt.Sprintf("cannot move ticket from %q to %q", e.From, e.To)
+	}
+}
+
+// DefaultWorkflow returns the built-in support workflow.
+func DefaultWorkflow() *Workflow {
+	return &Workflow{
+		Initial: StatusOpen,
+		Transitions: []Transition{
+			{Name: "start", From: []string{StatusOpen}, To: StatusInProgress, Actors: []string{ActorAdmin}},
+			{Name: "await_client", From: []string{StatusOpen, StatusInProgress}, To: StatusWaiting, Actors: []string{ActorAdmin}},
+			{Name: "resume", From: []string{StatusWaiting}, To: StatusInProgress, Actors: []string{ActorClient, ActorAdmin}},
+			{Name: "resolve", From: []string{StatusOpen, StatusInProgress, StatusWaiting}, To: StatusResolved, Actors: []string{ActorAdmin}, RequireResolution: true},
+			{Name: "close", From: []string{StatusResolved}, To: StatusClosed, Actors: []string{ActorClient, ActorAdmin}},
+			{Name: "withdraw", From: []string{StatusOpen, StatusInProgress, StatusWaiting}, To: StatusClosed, Actors: []string{ActorClient, ActorAdmin}},
+			{Name: "reopen", From: []string{StatusResolved, StatusClosed}, To: StatusOpen, Actors: []string{ActorClient, ActorAdmin}},
+		},
+	}
+}
+
+// LoadWorkflow reads a workflow definition from a JSON file.
+func LoadWorkflow(path string) (*Workflow, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read workflow: %w", err)
+	}
+	var w Workflow
+	if err := json.Unmarshal(data, &w); err != nil {
+		return nil, fmt.Errorf("parse workflow: %w", err)
+	}
+	if err := w.validate(); err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+func (w *Workflow) validate() error {
+	if w.Initial == "" {
+		return fmt.Errorf("workflow: initial status is required")
+	}
+	seen := map[string]bool{}
+	for _, t := range w.Transitions {
+		if t.Name == "" || t.To == "" || len(t.From) == 0 {
+			return fmt.Errorf("workflow: transition %q must have name, from and to", t.Name)
+		}
+		if seen[t.Name] {
+			return fmt.Errorf("workflow: duplicate transition %q", t.Name)
+		}
+		seen[t.Name] = true
+	}
+	return nil
+}
+
+// Available returns the transitions the actor may apply from status.
+func (w *Workflow) Available(status, actor string) []Transition {
+	var out []Transition
+	for _, t := range w.Transitions {
+		if slices.Contains(t.From, status) && slices.Contains(t.Actors, actor) {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// Named validates applying the transition called name from status.
+func (w *Workflow) Named(name, status, actor, resolution string) (*Transition, error) {
+	idx := slices.IndexFunc(w.Transitions, func(t Transition) bool { return t.Name == name })
+	if idx < 0 || !slices.Contains(w.Transitions[idx].From, status) {
+		return nil, &TransitionError{
+			Code:       CodeInvalidTransition,
+			From:       status,
+			Transition: name,
+			Allowed:    w.names(status, actor),
+		}
+	}
+	return w.check(&w.Transitions[idx], status, actor, resolution)
+}
+
+// Between validates a direct status change, as done through a ticket update.
+func (w *Workflow) Between(from, to, actor, resolution string) (*Transition, error) {
+	var candidate *Transition
+	for i := range w.Transitions {
+		t := &w.Transitions[i]
+		if t.To != to || !slices.Contains(t.From, from) {
+			continue
+		}
+		if slices.Contains(t.Actors, actor) {
+			return w.check(t, from, actor, resolution)
+		}
+		candidate = t
+	}
+	if candidate != nil {
+		return w.check(candidate, from, actor, resolution)
+	}
+	return nil, &TransitionError{
+		Code:    CodeInvalidTransition,
+		From:    from,
+		To:      to,
+		Allowed: w.names(from, actor),
+	}
+}
+
+// CheckInitial validates the status a ticket is created with.
+func (w *Workflow) CheckInitial(status string) error {
+	if status != "" && status != w.Initial {
+		return &TransitionError{Code: CodeInvalidInitialStatus, To: status, Allowed: []string{w.Initial}}
+	}
+	return nil
+}
+
+func (w *Workflow) check(t *Transition, from, actor, resolution string) (*Transition, error) {
+	if !slices.Contains(t.Actors, actor) {
+		return nil, &TransitionError{Code: CodeTransitionForbidden, From: from, To: t.To, Transition: t.Name}
+	}
+	if t.RequireResolution && strings.TrimSpace(resolution) == "" {
+		return nil, &TransitionError{Code: CodeResolutionRequired, From: from, To: t.To, Transition: t.Name}
+	}
+	return t, nil
+}
+
+func (w *Workflow) names(status, actor string) []string {
+	var names []string
+	for _, t := range w.Available(status, actor) {
+		names = append(names, t.Name)
+	}
+	return names
+}
@@ -0,0 +1,86 @@
+package tickets
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkflow_Between_RequiresResolutionForResolve(t *testing.T) {
+	w := DefaultWorkflow()
+
+	_, err := w.Between(StatusInProgress, StatusResolved, ActorAdmin, "")
+
+	var te *TransitionError
+	require.ErrorAs(t, err, &te)
+	assert.Equal(t, CodeResolutionRequired, te.Code)
+	assert.Equal(t, "resolve", te.Transition)
+}
+
+func TestWorkflow_Between_PicksTransitionAllowedForActor(t *testing.T) {
+	w := DefaultWorkflow()
+
+	tr, err := w.Between(StatusOpen, StatusClosed, ActorClient, "")
+
+	require.NoError(t, err)
+	assert.Equal(t, "withdraw", tr.Name)
+}
+
+func TestWorkflow_Named_UnknownTransitionListsAllowed(t *testing.T) {
+	w := DefaultWorkflow()
+
+	_, err := w.Named("start", StatusResolved, ActorAdmin, "")
+
+	var te *TransitionError
+	require.ErrorAs(t, err, &te)
+	assert.Equal(t, CodeInvalidTransition, te.Code)
+	assert.ElementsMatch(t, []string{"close", "reopen"}, te.Allowed)
+}
+
+func TestWorkflow_OnlySupportWaitsOnClient(t *testing.T) {
+	w := DefaultWorkflow()
+
+	_, err := w.Named("await_client", StatusInProgress, ActorClient, "")
+	var te *TransitionError
+	require.ErrorAs(t, err, &te)
+	assert.Equal(t, CodeTransitionForbidden, te.Code)
+
+	tr, err := w.Between(StatusWaiting, StatusInProgress, ActorClient, "")
+	require.NoError(t, err)
+	assert.Equal(t, "resume", tr.Name)
+}
+
+func TestLoadWorkflow_ReadsCustomDefinition(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "workflow.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"initial": "open",
+		"transitions": [
+			{"name": "finish", "from": ["open"], "to": "closed", "actors": ["admin"]}
+		]
+	}`), 0o600))
+
+	w, err := LoadWorkflow(path)
+
+	require.NoError(t, err)
+	_, err = w.Named("finish", StatusOpen, ActorAdmin, "")
+	assert.NoError(t, err)
+	_, err = w.Named("finish", StatusOpen, ActorClient, "")
+	assert.Error(t, err)
+}
+
+func TestLoadWorkflow_RejectsDuplicateNames(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "workflow.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"initial": "open",
+		"transitions": [
+			{"name": "a", "from": ["open"], "to": "closed", "actors": ["admin"]},
+			{"name": "a", "from": ["closed"], "to": "open", "actors": ["admin"]}
+		]
+	}`), 0o600))
+
+	_, err := LoadWorkflow(path)
+	assert.Error(t, err)
+}
@@ -2,8 +2,10 @@
 package userprojects
 
 import (
+	"innotech/internal/access"
 	"innotech/internal/storage/postgres"
 	"innotech/internal/storage/transport"
+	"innotech/pkg/middleware"
 	"strconv"
 
 	"github.com/gofiber/fiber/v2"
@@ -32,13 +34,16 @@ func NewHandler(service Service) *Handler {
 // Create handles the creation of a new user-project relationship.
 func (h *Handler) Create(c *fiber.Ctx) error {
 	dto := c.Locals("body").(*transport.CreateUserProjectDTO)
+	assignedBy := middleware.UserID(c)
 	up := postgres.UserProject{
 		UserID:      dto.UserID,
 		ProjectID:   dto.ProjectID,
+		ContractID:  dto.ContractID,
 		Role:        dto.Role,
 		Permissions: dto.Permissions,
+		AssignedBy:  &assignedBy,
 	}
-	if err := h.service.Create(c.Context(), &up); err != nil {
+	if err := h.service.Create(c.UserContext(), &up); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 	return c.Status(fiber.StatusCreated).JSON(up)
@@ -61,7 +66,7 @@ func (h *Handler) Get(c *fiber.Ctx) error {
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid project id"})
 	}
-	up, err := h.service.Get(c.Context(), userID, projectID)
+	up, err := h.service.Get(c.UserContext(), userID, projectID)
 	if err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
 	}
@@ -77,10 +82,11 @@ func (h *Handler) Get(c *fiber.Ctx) error {
 // @Router /api/user-projects [get]
 // GetAll retrieves all user-project relationships.
 func (h *Handler) GetAll(c *fiber.Ctx) error {
-	list, err := h.service.GetAll(c.Context())
+	list, err := h.service.GetAll(c.UserContext())
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
+	list = access.Filter(access.ScopeFrom(c), list, func(up postgres.UserProject) int { return up.ProjectID })
 	return c.JSON(list)
 }
 
@@ -107,10 +113,11 @@ func (h *Handler) Update(c *fiber.Ctx) error {
 	up := postgres.UserProject{
 		UserID:      userID,
 		ProjectID:   projectID,
+		ContractID:  dto.ContractID,
 		Role:        dto.Role,
 		Permissions: dto.Permissions,
 	}
-	if err := h.service.Update(c.Context(), &up); err != nil {
+	if err := h.service.Update(c.UserContext(), &up); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 	return c.JSON(up)
@@ -132,7 +139,7 @@ func (h *Handler) Delete(c *fiber.Ctx) error {
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid project id"})
 	}
-	if err := h.service.Delete(c.Context(), userID, projectID); err != nil {
+	if err := h.service.Delete(c.UserContext(), userID, projectID); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 	return c.SendStatus(fiber.StatusNoContent)
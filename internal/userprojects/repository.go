@@ -27,14 +27,18 @@ func NewRepository(db *sqlx.DB) Repository {
 
 func (r *userProjectRepository) Create(ctx context.Context, up *postgres.UserProject) error {
 	query := `
-		INSERT INTO user_projects (user_id, project_id, permissions)
-		VALUES ($1, $2, $3)
+		INSERT INTO user_projects (user_id, project_id, contract_id, role, permissions, assigned_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING date_created, date_updated
 	`
 
 	return r.db.QueryRowxContext(ctx, query,
 		up.UserID,
 		up.ProjectID,
+		up.ContractID,
+		up.Role,
 		up.Permissions,
+		up.AssignedBy,
 	).Scan(&up.DateCreated, &up.DateUpdated)
 }
 
@@ -58,15 +62,18 @@ func (r *userProjectRepository) GetAll(ctx context.Context) ([]postgres.UserProj
 func (r *userProjectRepository) Update(ctx context.Context, up *postgres.UserProject) error {
 	query := `
 		UPDATE user_projects
-		SET permissions = $1
-		WHERE user_id = $2 AND project_id = $3
+		SET contract_id = $1, role = $2, permissions = $3
+		WHERE user_id = $4 AND project_id = $5
+		RETURNING date_created, date_updated
 	`
 
 	return r.db.QueryRowxContext(ctx, query,
+		up.ContractID,
+		up.Role,
 		up.Permissions,
 		up.UserID,
 		up.ProjectID,
-	).Scan(&up.DateUpdated)
+	).Scan(&up.DateCreated, &up.DateUpdated)
 }
 
 func (r *userProjectRepository) Delete(ctx context.Context, userID string, projectID int) error {
@@ -1,6 +1,7 @@
 package userprojects
 
 import (
+	"innotech/internal/access"
 	"innotech/internal/storage/transport"
 	"innotech/pkg/middleware"
 
@@ -8,12 +9,12 @@ import (
 )
 
 // RegisterRoutes registers HTTP routes for user-project operations.
-func RegisterRoutes(app *fiber.App, h *Handler) {
+func RegisterRoutes(app *fiber.App, h *Handler, guard *access.Guard) {
 	api := app.Group("/api/user-projects")
 
-	api.Get("/", h.GetAll)
-	api.Get("/:user_id/:project_id", h.Get)
-	api.Post("/", middleware.ValidateBody[transport.CreateUserProjectDTO](h.Create))
-	api.Put("/:user_id/:project_id", middleware.ValidateBody[transport.UpdateUserProjectDTO](h.Update))
-	api.Delete("/:user_id/:project_id", h.Delete)
+	api.Get("/", guard.Scoped(access.ProjectRead), h.GetAll)
+	api.Get("/:user_id/:project_id", guard.Require(access.ProjectRead, guard.Param("project_id", access.ResourceProject)), h.Get)
+	api.Post("/", guard.Require(access.MembersManage, guard.Body("project_id", access.ResourceProject)), middleware.ValidateBody[transport.CreateUserProjectDTO](h.Create))
+	api.Put("/:user_id/:project_id", guard.Require(access.MembersManage, guard.Param("project_id", access.ResourceProject)), middleware.ValidateBody[transport.UpdateUserProjectDTO](h.Update))
+	api.Delete("/:user_id/:project_id", guard.Require(access.MembersManage, guard.Param("project_id", access.ResourceProject)), h.Delete)
 }
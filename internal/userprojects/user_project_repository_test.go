@@ -20,38 +20,36 @@ func TestUserProjectRepository_CRUD(t *testing.T) {
 	ctx := context.Background()
 	now := time.Now()
 
-	rows := sqlmock.NewRows([]string{"user_id", "project_id", "role", "permissions", "date_created", "date_updated"}).
-		AddRow("uuid-1", 2, "developer", []string{"read", "write"}, now, now)
+	rows := sqlmock.NewRows([]string{"user_id", "project_id", "contract_id", "role", "permissions", "assigned_by", "date_created", "date_updated"}).
+		AddRow("uuid-1", 2, nil, "editor", "{ticket.read,ticket.create}", nil, now, now)
 	mock.ExpectQuery(`SELECT \* FROM user_projects`).WillReturnRows(rows)
 	all, err := repo.GetAll(ctx)
 	assert.NoError(t, err)
 	assert.Len(t, all, 1)
 
-	row := sqlmock.NewRows([]string{"user_id", "project_id", "role", "permissions", "date_created", "date_updated"}).
-		AddRow("uuid-1", 2, "developer", []string{"read", "write"}, now, now)
+	row := sqlmock.NewRows([]string{"user_id", "project_id", "contract_id", "role", "permissions", "assigned_by", "date_created", "date_updated"}).
+		AddRow("uuid-1", 2, nil, "editor", "{ticket.read,ticket.create}", nil, now, now)
 	mock.ExpectQuery(`SELECT \* FROM user_projects WHERE user_id = \$1 AND project_id = \$2`).
 		WithArgs("uuid-1", 2).WillReturnRows(row)
 	got, err := repo.Get(ctx, "uuid-1", 2)
 	assert.NoError(t, err)
 	assert.Equal(t, "uuid-1", got.UserID)
+	assert.Equal(t, postgres.StringArray{"ticket.read", "ticket.create"}, got.Permissions)
 
-	up := &postgres.UserProject{UserID: "uuid-1", ProjectID: 2, Permissions: []string{"read"}}
-	mock.ExpectQuery(`INSERT INTO user_projects`).
-		WithArgs("uuid-1", 2, []string{"read"}).
+	up := &postgres.UserProject{UserID: "uuid-1", ProjectID: 2, Role: "viewer", Permissions: []string{"chat.write"}}
+	mock.ExpectQuery(`INSERT INTO user_projects .*RETURNING date_created, date_updated`).
+		WithArgs("uuid-1", 2, nil, "viewer", "{chat.write}", nil).
 		WillReturnRows(sqlmock.NewRows([]string{"date_created", "date_updated"}).AddRow(now, now))
 	err = repo.Create(ctx, up)
-	if err != nil {
-		t.Logf("Expected error with sqlmock limitations: %v", err)
-	}
+	assert.NoError(t, err)
+	assert.Equal(t, now, up.DateCreated)
 
 	mock.ExpectQuery(`UPDATE user_projects`).
-		WithArgs([]string{"read", "write"}, "uuid-1", 2).
-		WillReturnRows(sqlmock.NewRows([]string{"date_updated"}).AddRow(now))
-	up.Permissions = []string{"read", "write"}
+		WithArgs(nil, "editor", "{chat.write}", "uuid-1", 2).
+		WillReturnRows(sqlmock.NewRows([]string{"date_created", "date_updated"}).AddRow(now, now))
+	up.Role = "editor"
 	err = repo.Update(ctx, up)
-	if err != nil {
-		t.Logf("Expected error with sqlmock limitations: %v", err)
-	}
+	assert.NoError(t, err)
 
 	mock.ExpectExec(`DELETE FROM user_projects WHERE user_id = \$1 AND project_id = \$2`).
 		WithArgs("uuid-1", 2).WillReturnResult(sqlmock.NewResult(1, 1))
@@ -0,0 +1,42 @@
+// Package webhooks delivers project events to subscriber URLs with signed,
+// retried HTTP requests.
+package webhooks
+
+import (
+	"innotech/internal/events"
+	"slices"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// EventInfo describes a subscribable event for the catalogue endpoint.
+type EventInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// Catalogue lists the events a webhook can subscribe to. They are the event
+// types of the project activity log.
+var Catalogue = []EventInfo{
+	{events.TicketCreated, "A ticket was opened"},
+	{events.TicketStatusChanged, "A ticket moved to another status"},
+	{events.TicketAssigned, "A ticket was assigned or unassigned"},
+	{events.TicketSLAAtRisk, "A ticket is close to missing its response or resolution deadline"},
+	{events.TicketSLABreached, "A ticket missed its response or resolution deadline"},
+	{events.ChatMessageCreated, "A message was posted to a ticket chat"},
+	{events.AttachmentAdded, "A file was attached to a ticket"},
+	{events.FileInfected, "An uploaded file was found infected and quarantined"},
+	{events.DocumentationPublished, "A documentation page was published"},
+	{events.DocumentationUpdated, "A new version of a documentation page was published"},
+	{events.ContractExpiring, "A contract is about to expire"},
+}
+
+// IsKnownEvent reports whether name is part of the catalogue.
+func IsKnownEvent(name string) bool {
+	return slices.ContainsFunc(Catalogue, func(info EventInfo) bool { return info.Name == name })
+}
+
+// ValidateEvent is a validator.Func for the "webhook_event" tag.
+func ValidateEvent(fl validator.FieldLevel) bool {
+	return IsKnownEvent(fl.Field().String())
+}
@@ -0,0 +1,163 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"innotech/pkg/logger"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Delivery tuning.
+const (
+	MaxAttempts    = 8
+	BatchSize      = 20
+	requestTimeout = 10 * time.Second
+	dialTimeout    = 5 * time.Second
+	pollInterval   = 2 * time.Second
+	claimLease     = time.Minute
+	baseBackoff    = 30 * time.Second
+	maxBackoff     = 6 * time.Hour
+)
+
+// Backoff returns the wait before the attempt that follows attempt number
+// attempt (1-based): 30s, 1m, 2m, ... capped at 6h.
+func Backoff(attempt int) time.Duration {
+	d := baseBackoff
+	for i := 1; i < attempt && d < maxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, maxBackoff)
+}
+
+// Dispatcher sends queued deliveries in the background.
+type Dispatcher struct {
+	repo   Repository
+	client *http.Client
+	now    func() time.Time
+}
+
+// NewDispatcher creates a Dispatcher. It only connects to public addresses,
+// never through a proxy, and does not follow redirects: a 3xx answer counts
+// as a failure.
+func NewDispatcher(repo Repository) *Dispatcher {
+	dialer := &net.Dialer{Timeout: dialTimeout, Control: dialControl}
+	return &Dispatcher{
+		repo: repo,
+		client: &http.Client{
+			Timeout: requestTimeout,
+			Transport: &http.Transport{
+				DialContext:         dialer.DialContext,
+				ForceAttemptHTTP2:   true,
+				MaxIdleConns:        100,
+				IdleConnTimeout:     90 * time.Second,
+				TLSHandshakeTimeout: dialTimeout,
+			},
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		now: time.Now,
+	}
+}
+
+// Run dispatches due deliveries until ctx is cancelled. Several replicas may
+// run it at once; each delivery is claimed by one of them.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		for {
+			n, err := d.DispatchDue(ctx)
+			if err != nil {
+				logger.Error("webhook dispatch failed", "error", err)
+			}
+			if err != nil || n < BatchSize {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DispatchDue sends one batch of due deliveries and returns its size.
+func (d *Dispatcher) DispatchDue(ctx context.Context) (int, error) {
+	jobs, err := d.repo.ClaimDue(ctx, BatchSize, claimLease)
+	if err != nil {
+		return 0, err
+	}
+
+	var wg sync.WaitGroup
+	for i := range jobs {
+		wg.Add(1)
+		go func(job *Job) {
+			defer wg.Done()
+			d.deliver(ctx, job)
+		}(&jobs[i])
+	}
+	wg.Wait()
+	return len(jobs), nil
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, job *Job) {
+	code, err := d.send(ctx, job)
+	if err == nil {
+		if err := d.repo.RecordAttempt(ctx, job.ID, StatusSucceeded, &code, nil, d.now()); err != nil {
+			logger.Error("failed to record webhook delivery", "delivery_id", job.ID, "error", err)
+		}
+		return
+	}
+
+	var codePtr *int
+	if code != 0 {
+		codePtr = &code
+	}
+	msg := err.Error()
+	attempt := job.Attempts + 1
+	status, next := StatusPending, d.now().Add(Backoff(attempt))
+	if attempt >= MaxAttempts {
+		status = StatusFailed
+	}
+	logger.Warn("webhook delivery failed", "delivery_id", job.ID, "attempt", attempt, "status", status, "error", msg)
+	if err := d.repo.RecordAttempt(ctx, job.ID, status, codePtr, &msg, next); err != nil {
+		logger.Error("failed to record webhook delivery", "delivery_id", job.ID, "error", err)
+	}
+}
+
+// send posts the payload and returns the response status code. Any non-2xx
+// answer counts as a failure; the response body is never read, so nothing
+// the subscriber returns ends up in the delivery log.
+func (d *Dispatcher) send(ctx context.Context, job *Job) (int, error) {
+	body := []byte(job.Payload)
+	ts := d.now().Unix()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, job.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "FeedbackLab-Webhooks/1.0")
+	req.Header.Set(HeaderEvent, job.EventType)
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(job.ID, 10))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(ts, 10))
+	req.Header.Set(HeaderSignature, Sign(job.Secret, ts, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,199 @@
+package webhooks
+
+import (
+	"database/sql"
+	"errors"
+	"innotech/internal/storage/postgres"
+	"innotech/internal/storage/transport"
+	"innotech/pkg/middleware"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Handler handles HTTP requests for webhook operations.
+type Handler struct {
+	service Service
+}
+
+// NewHandler creates a new Handler instance.
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+// CreatedWebhook is returned once on creation; the secret is not shown again.
+type CreatedWebhook struct {
+	postgres.WebhookSubscription
+	Secret string `json:"secret"`
+}
+
+// Events godoc
+// @Summary каталог событий для вебхуков
+// @Tags Webhooks
+// @Produce json
+// @Success 200 {array} webhooks.EventInfo
+// @Router /webhooks/events [get]
+func (h *Handler) Events(c *fiber.Ctx) error {
+	return c.JSON(Catalogue)
+}
+
+// Create godoc
+// @Summary создать вебхук проекта
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Param id path int true "Project ID"
+// @Param webhook body transport.CreateWebhookDTO true "Webhook"
+// @Success 201 {object} webhooks.CreatedWebhook
+// @Failure 400 {object} map[string]string
+// @Router /projects/{id}/webhooks [post]
+func (h *Handler) Create(c *fiber.Ctx) error {
+	dto := c.Locals("body").(*transport.CreateWebhookDTO)
+	projectID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+
+	sub := postgres.WebhookSubscription{
+		ProjectID: projectID,
+		URL:       dto.URL,
+		Secret:    dto.Secret,
+		Events:    dto.Events,
+		CreatedBy: middleware.UserID(c),
+	}
+	if err := h.service.Create(c.UserContext(), &sub); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(fiber.StatusCreated).JSON(CreatedWebhook{WebhookSubscription: sub, Secret: sub.Secret})
+}
+
+// ListByProject godoc
+// @Summary получить вебхуки проекта
+// @Tags Webhooks
+// @Produce json
+// @Param id path int true "Project ID"
+// @Success 200 {array} postgres.WebhookSubscription
+// @Router /projects/{id}/webhooks [get]
+func (h *Handler) ListByProject(c *fiber.Ctx) error {
+	projectID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+	list, err := h.service.ListByProject(c.UserContext(), projectID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(list)
+}
+
+// GetByID godoc
+// @Summary получить вебхук по ID
+// @Tags Webhooks
+// @Produce json
+// @Param id path int true "ID"
+// @Success 200 {object} postgres.WebhookSubscription
+// @Failure 404 {object} map[string]string
+// @Router /webhooks/{id} [get]
+func (h *Handler) GetByID(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+	sub, err := h.service.GetByID(c.UserContext(), id)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "not found"})
+	}
+	return c.JSON(sub)
+}
+
+// Update godoc
+// @Summary обновить вебхук
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Param id path int true "ID"
+// @Param webhook body transport.UpdateWebhookDTO true "Webhook"
+// @Success 200 {object} postgres.WebhookSubscription
+// @Failure 404 {object} map[string]string
+// @Router /webhooks/{id} [put]
+func (h *Handler) Update(c *fiber.Ctx) error {
+	dto := c.Locals("body").(*transport.UpdateWebhookDTO)
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+
+	sub, err := h.service.GetByID(c.UserContext(), id)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "not found"})
+	}
+	sub.URL = dto.URL
+	sub.Events = dto.Events
+	if dto.Active != nil {
+		sub.Active = *dto.Active
+	}
+	if err := h.service.Update(c.UserContext(), sub); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(sub)
+}
+
+// Delete godoc
+// @Summary удалить вебхук
+// @Tags Webhooks
+// @Param id path int true "ID"
+// @Success 204
+// @Router /webhooks/{id} [delete]
+func (h *Handler) Delete(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+	if err := h.service.Delete(c.UserContext(), id); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// Deliveries godoc
+// @Summary журнал доставок вебхука
+// @Tags Webhooks
+// @Produce json
+// @Param id path int true "ID"
+// @Param limit query int false "Limit (default 50, max 200)"
+// @Success 200 {array} postgres.WebhookDelivery
+// @Router /webhooks/{id}/deliveries [get]
+func (h *Handler) Deliveries(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+	list, err := h.service.ListDeliveries(c.UserContext(), id, c.QueryInt("limit"))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(list)
+}
+
+// Redeliver godoc
+// @Summary повторно отправить доставку вебхука
+// @Tags Webhooks
+// @Produce json
+// @Param id path int true "Delivery ID"
+// @Success 202 {object} postgres.WebhookDelivery
+// @Failure 404 {object} map[string]string
+// @Router /webhooks/deliveries/{id}/redeliver [post]
+func (h *Handler) Redeliver(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+	d, err := h.service.Redeliver(c.UserContext(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "not found"})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(fiber.StatusAccepted).JSON(d)
+}
@@ -0,0 +1,183 @@
+package webhooks
+
+import (
+	"context"
+	"innotech/internal/storage/postgres"
+	"innotech/pkg/db"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Delivery statuses from webhook_delivery_status_enum.
+const (
+	StatusPending   = "pending"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+// Job is a claimed delivery together with where and how to send it.
+type Job struct {
+	postgres.WebhookDelivery
+	URL    string `db:"url"`
+	Secret string `db:"secret"`
+}
+
+// Repository defines the interface for webhook data access operations.
+type Repository interface {
+	Create(ctx context.Context, s *postgres.WebhookSubscription) error
+	GetByID(ctx context.Context, id int) (*postgres.WebhookSubscription, error)
+	ListByProject(ctx context.Context, projectID int) ([]postgres.WebhookSubscription, error)
+	Update(ctx context.Context, s *postgres.WebhookSubscription) error
+	Delete(ctx context.Context, id int) error
+
+	Enqueue(ctx context.Context, e *postgres.ProjectEvent, payload []byte) error
+	CreateDelivery(ctx context.Context, d *postgres.WebhookDelivery) error
+	GetDelivery(ctx context.Context, id int64) (*postgres.WebhookDelivery, error)
+	ListDeliveries(ctx context.Context, subscriptionID, limit int) ([]postgres.WebhookDelivery, error)
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]Job, error)
+	RecordAttempt(ctx context.Context, id int64, status string, code *int, errMsg *string, next time.Time) error
+}
+
+type repository struct {
+	db *sqlx.DB
+}
+
+// NewRepository creates a new Repository instance.
+func NewRepository(db *sqlx.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) Create(ctx context.Context, s *postgres.WebhookSubscription) error {
+	query := `
+		INSERT INTO webhook_subscriptions (project_id, url, secret, events, active, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, date_created, date_updated
+	`
+	return db.Conn(ctx, r.db).QueryRowxContext(ctx, query, s.ProjectID, s.URL, s.Secret, s.Events, s.Active, s.CreatedBy).
+		Scan(&s.ID, &s.DateCreated, &s.DateUpdated)
+}
+
+func (r *repository) GetByID(ctx context.Context, id int) (*postgres.WebhookSubscription, error) {
+	var s postgres.WebhookSubscription
+	err := db.Conn(ctx, r.db).GetContext(ctx, &s, `SELECT * FROM webhook_subscriptions WHERE id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *repository) ListByProject(ctx context.Context, projectID int) ([]postgres.WebhookSubscription, error) {
+	list := []postgres.WebhookSubscription{}
+	err := db.Conn(ctx, r.db).SelectContext(ctx, &list,
+		`SELECT * FROM webhook_subscriptions WHERE project_id = $1 ORDER BY id`,
+		projectID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *repository) Update(ctx context.Context, s *postgres.WebhookSubscription) error {
+	query := `
+		UPDATE webhook_subscriptions
+		SET url = $2, events = $3, active = $4
+		WHERE id = $1
+		RETURNING date_updated
+	`
+	return db.Conn(ctx, r.db).QueryRowxContext(ctx, query, s.ID, s.URL, s.Events, s.Active).Scan(&s.DateUpdated)
+}
+
+func (r *repository) Delete(ctx context.Context, id int) error {
+	_, err := db.Conn(ctx, r.db).ExecContext(ctx, `DELETE FROM webhook_subscriptions WHERE id = $1`, id)
+	return err
+}
+
+// Enqueue queues a delivery of the event for every active subscription of its
+// project that listens to the event type. Subscriptions that already have a
+// delivery of the event are skipped, so enqueueing again is harmless.
+func (r *repository) Enqueue(ctx context.Context, e *postgres.ProjectEvent, payload []byte) error {
+	query := `
+		INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, payload)
+		SELECT id, $2, $3, $4 FROM webhook_subscriptions
+		WHERE project_id = $1 AND active AND $3 = ANY(events)
+		ON CONFLICT (subscription_id, event_id) WHERE redelivery_of IS NULL DO NOTHING
+	`
+	_, err := db.Conn(ctx, r.db).ExecContext(ctx, query, e.ProjectID, e.ID, e.Type, string(payload))
+	return err
+}
+
+func (r *repository) CreateDelivery(ctx context.Context, d *postgres.WebhookDelivery) error {
+	query := `
+		INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, payload, redelivery_of)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, status, attempts, next_attempt_at, date_created
+	`
+	return db.Conn(ctx, r.db).QueryRowxContext(ctx, query, d.SubscriptionID, d.EventID, d.EventType, d.Payload, d.RedeliveryOf).
+		Scan(&d.ID, &d.Status, &d.Attempts, &d.NextAttemptAt, &d.DateCreated)
+}
+
+func (r *repository) GetDelivery(ctx context.Context, id int64) (*postgres.WebhookDelivery, error) {
+	var d postgres.WebhookDelivery
+	err := db.Conn(ctx, r.db).GetContext(ctx, &d, `SELECT * FROM webhook_deliveries WHERE id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (r *repository) ListDeliveries(ctx context.Context, subscriptionID, limit int) ([]postgres.WebhookDelivery, error) {
+	list := []postgres.WebhookDelivery{}
+	err := db.Conn(ctx, r.db).SelectContext(ctx, &list,
+		`SELECT * FROM webhook_deliveries WHERE subscription_id = $1 ORDER BY id DESC LIMIT $2`,
+		subscriptionID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// ClaimDue leases up to limit due deliveries of active subscriptions by
+// pushing their next attempt past the lease. Concurrent dispatchers skip rows
+// another one has locked, and a crashed dispatcher's jobs become due again
+// once the lease runs out.
+func (r *repository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]Job, error) {
+	query := `
+		UPDATE webhook_deliveries d
+		SET next_attempt_at = NOW() + make_interval(secs => $2)
+		FROM webhook_subscriptions s
+		WHERE s.id = d.subscription_id AND d.id IN (
+			SELECT dd.id FROM webhook_deliveries dd
+			JOIN webhook_subscriptions ss ON ss.id = dd.subscription_id
+			WHERE dd.status = 'pending' AND dd.next_attempt_at <= NOW() AND ss.active
+			ORDER BY dd.next_attempt_at
+			LIMIT $1
+			FOR UPDATE OF dd SKIP LOCKED
+		)
+		RETURNING d.*, s.url, s.secret
+	`
+	jobs := []Job{}
+	if err := db.Conn(ctx, r.db).SelectContext(ctx, &jobs, query, limit, lease.Seconds()); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// RecordAttempt stores the outcome of an attempt. next is only used while the
+// delivery stays pending.
+func (r *repository) RecordAttempt(ctx context.Context, id int64, status string, code *int, errMsg *string, next time.Time) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = $2::webhook_delivery_status_enum,
+		    attempts = attempts + 1,
+		    last_status_code = $3,
+		    last_error = $4,
+		    next_attempt_at = $5,
+		    date_delivered = CASE WHEN $2 = 'succeeded' THEN NOW() END
+		WHERE id = $1
+	`
+	_, err := db.Conn(ctx, r.db).ExecContext(ctx, query, id, status, code, errMsg, next)
+	return err
+}
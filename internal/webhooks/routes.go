@@ -0,0 +1,25 @@
+package webhooks
+
+import (
+	"innotech/internal/access"
+	"innotech/internal/storage/transport"
+	"innotech/pkg/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RegisterRoutes registers HTTP routes for webhook operations.
+func RegisterRoutes(app *fiber.App, h *Handler, guard *access.Guard) {
+	projects := app.Group("/api/projects")
+	projects.Get("/:id/webhooks", guard.Require(access.WebhookManage, guard.Param("id", access.ResourceProject)), h.ListByProject)
+	projects.Post("/:id/webhooks", guard.Require(access.WebhookManage, guard.Param("id", access.ResourceProject)), middleware.ValidateBody[transport.CreateWebhookDTO](h.Create))
+
+	api := app.Group("/api/webhooks")
+	api.Get("/events", h.Events)
+	api.Post("/deliveries/:id/redeliver", guard.Require(access.WebhookManage, guard.Param("id", access.ResourceWebhookDelivery)), h.Redeliver)
+
+	api.Get("/:id", guard.Require(access.WebhookManage, guard.Param("id", access.ResourceWebhook)), h.GetByID)
+	api.Put("/:id", guard.Require(access.WebhookManage, guard.Param("id", access.ResourceWebhook)), middleware.ValidateBody[transport.UpdateWebhookDTO](h.Update))
+	api.Delete("/:id", guard.Require(access.WebhookManage, guard.Param("id", access.ResourceWebhook)), h.Delete)
+	api.Get("/:id/deliveries", guard.Require(access.WebhookManage, guard.Param("id", access.ResourceWebhook)), h.Deliveries)
+}
@@ -0,0 +1,123 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"innotech/internal/storage/postgres"
+	"time"
+)
+
+// Delivery log page sizes.
+const (
+	DefaultDeliveryLimit = 50
+	MaxDeliveryLimit     = 200
+)
+
+// Envelope is the JSON body of every delivery. ID is the event id, which
+// stays the same across retries and redeliveries so receivers can dedupe.
+type Envelope struct {
+	ID         int64           `json:"id"`
+	Type       string          `json:"type"`
+	ProjectID  int             `json:"project_id"`
+	ActorID    *string         `json:"actor_id,omitempty"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// Service defines the interface for webhook business logic operations. It
+// also implements events.Listener to queue deliveries of committed events.
+type Service interface {
+	Create(ctx context.Context, s *postgres.WebhookSubscription) error
+	GetByID(ctx context.Context, id int) (*postgres.WebhookSubscription, error)
+	ListByProject(ctx context.Context, projectID int) ([]postgres.WebhookSubscription, error)
+	Update(ctx context.Context, s *postgres.WebhookSubscription) error
+	Delete(ctx context.Context, id int) error
+	ListDeliveries(ctx context.Context, subscriptionID, limit int) ([]postgres.WebhookDelivery, error)
+	Redeliver(ctx context.Context, deliveryID int64) (*postgres.WebhookDelivery, error)
+	EventAppended(ctx context.Context, e *postgres.ProjectEvent) error
+}
+
+type service struct {
+	repo Repository
+}
+
+// NewService creates a new Service instance.
+func NewService(repo Repository) Service {
+	return &service{repo: repo}
+}
+
+// Create stores the subscription, generating a signing secret when none is given.
+func (s *service) Create(ctx context.Context, sub *postgres.WebhookSubscription) error {
+	if sub.Secret == "" {
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			return err
+		}
+		sub.Secret = hex.EncodeToString(b)
+	}
+	sub.Active = true
+	return s.repo.Create(ctx, sub)
+}
+
+func (s *service) GetByID(ctx context.Context, id int) (*postgres.WebhookSubscription, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *service) ListByProject(ctx context.Context, projectID int) ([]postgres.WebhookSubscription, error) {
+	return s.repo.ListByProject(ctx, projectID)
+}
+
+func (s *service) Update(ctx context.Context, sub *postgres.WebhookSubscription) error {
+	return s.repo.Update(ctx, sub)
+}
+
+func (s *service) Delete(ctx context.Context, id int) error {
+	return s.repo.Delete(ctx, id)
+}
+
+func (s *service) ListDeliveries(ctx context.Context, subscriptionID, limit int) ([]postgres.WebhookDelivery, error) {
+	if limit <= 0 {
+		limit = DefaultDeliveryLimit
+	}
+	return s.repo.ListDeliveries(ctx, subscriptionID, min(limit, MaxDeliveryLimit))
+}
+
+// Redeliver queues a fresh delivery of the same payload. The original entry
+// is kept in the log untouched.
+func (s *service) Redeliver(ctx context.Context, deliveryID int64) (*postgres.WebhookDelivery, error) {
+	orig, err := s.repo.GetDelivery(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	d := &postgres.WebhookDelivery{
+		SubscriptionID: orig.SubscriptionID,
+		EventID:        orig.EventID,
+		EventType:      orig.EventType,
+		Payload:        orig.Payload,
+		RedeliveryOf:   &orig.ID,
+	}
+	if err := s.repo.CreateDelivery(ctx, d); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// EventAppended queues deliveries of e. The outbox calls it once the event is
+// committed, so nothing is sent for changes that roll back and the request
+// path never waits on subscribers.
+func (s *service) EventAppended(ctx context.Context, e *postgres.ProjectEvent) error {
+	payload, err := json.Marshal(Envelope{
+		ID:         e.ID,
+		Type:       e.Type,
+		ProjectID:  e.ProjectID,
+		ActorID:    e.ActorID,
+		OccurredAt: e.DateCreated,
+		Data:       json.RawMessage(e.Payload),
+	})
+	if err != nil {
+		return err
+	}
+	return s.repo.Enqueue(ctx, e, payload)
+}
@@ -0,0 +1,33 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+)
+
+// Headers sent with every delivery.
+const (
+	HeaderEvent     = "X-FeedbackLab-Event"
+	HeaderDelivery  = "X-FeedbackLab-Delivery"
+	HeaderTimestamp = "X-FeedbackLab-Timestamp"
+	HeaderSignature = "X-FeedbackLab-Signature"
+)
+
+// Sign returns the signature header value of a delivery: the hex HMAC-SHA256
+// of "<timestamp>.<body>" keyed with the subscription secret. Including the
+// timestamp lets receivers reject replayed requests.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature matches the delivery. Receivers written in
+// Go can use it as a reference implementation.
+func Verify(secret string, timestamp int64, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
@@ -0,0 +1,326 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"innotech/internal/storage/postgres"
+	"innotech/pkg/logger"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	logger.Init()
+	os.Exit(m.Run())
+}
+
+type mockRepo struct {
+	mock.Mock
+	mu sync.Mutex
+}
+
+func (m *mockRepo) Create(ctx context.Context, s *postgres.WebhookSubscription) error {
+	return m.Called(ctx, s).Error(0)
+}
+
+func (m *mockRepo) GetByID(ctx context.Context, id int) (*postgres.WebhookSubscription, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*postgres.WebhookSubscription), args.Error(1)
+}
+
+func (m *mockRepo) ListByProject(ctx context.Context, projectID int) ([]postgres.WebhookSubscription, error) {
+	args := m.Called(ctx, projectID)
+	return args.Get(0).([]postgres.WebhookSubscription), args.Error(1)
+}
+
+func (m *mockRepo) Update(ctx context.Context, s *postgres.WebhookSubscription) error {
+	return m.Called(ctx, s).Error(0)
+}
+
+func (m *mockRepo) Delete(ctx context.Context, id int) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *mockRepo) Enqueue(ctx context.Context, e *postgres.ProjectEvent, payload []byte) error {
+	return m.Called(ctx, e, payload).Error(0)
+}
+
+func (m *mockRepo) CreateDelivery(ctx context.Context, d *postgres.WebhookDelivery) error {
+	return m.Called(ctx, d).Error(0)
+}
+
+func (m *mockRepo) GetDelivery(ctx context.Context, id int64) (*postgres.WebhookDelivery, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*postgres.WebhookDelivery), args.Error(1)
+}
+
+func (m *mockRepo) ListDeliveries(ctx context.Context, subscriptionID, limit int) ([]postgres.WebhookDelivery, error) {
+	args := m.Called(ctx, subscriptionID, limit)
+	return args.Get(0).([]postgres.WebhookDelivery), args.Error(1)
+}
+
+func (m *mockRepo) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]Job, error) {
+	args := m.Called(ctx, limit, lease)
+	return args.Get(0).([]Job), args.Error(1)
+}
+
+func (m *mockRepo) RecordAttempt(ctx context.Context, id int64, status string, code *int, errMsg *string, next time.Time) error {
+	// Deliveries are recorded from concurrent goroutines.
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.Called(ctx, id, status, code, errMsg, next).Error(0)
+}
+
+func TestSign_VerifiesOnlyMatchingPayload(t *testing.T) {
+	body := []byte(`{"id":1}`)
+	sig := Sign("s3cret", 1700000000, body)
+
+	assert.Regexp(t, `^sha256=[0-9a-f]{64}$`, sig)
+	assert.True(t, Verify("s3cret", 1700000000, body, sig))
+	assert.False(t, Verify("s3cret", 1700000001, body, sig))
+	assert.False(t, Verify("other", 1700000000, body, sig))
+	assert.False(t, Verify("s3cret", 1700000000, []byte(`{"id":2}`), sig))
+}
+
+func TestBackoff_DoublesUpToCap(t *testing.T) {
+	assert.Equal(t, 30*time.Second, Backoff(1))
+	assert.Equal(t, time.Minute, Backoff(2))
+	assert.Equal(t, 4*time.Minute, Backoff(4))
+	assert.Equal(t, 6*time.Hour, Backoff(20))
+}
+
+func TestService_EventAppended_EnqueuesEnvelope(t *testing.T) {
+	repo := new(mockRepo)
+	svc := NewService(repo)
+	actor := "u1"
+	at := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	e := &postgres.ProjectEvent{ID: 9, ProjectID: 2, Type: "ticket.created", ActorID: &actor,
+		Payload: postgres.JSON(`{"ticket_id":5}`), DateCreated: at}
+
+	repo.On("Enqueue", mock.Anything, e, mock.MatchedBy(func(p []byte) bool {
+		var env Envelope
+		return json.Unmarshal(p, &env) == nil && env.ID == 9 && env.ProjectID == 2 &&
+			env.Type == "ticket.created" && *env.ActorID == "u1" && env.OccurredAt.Equal(at) &&
+			string(env.Data) == `{"ticket_id":5}`
+	})).Return(nil).Once()
+
+	require.NoError(t, svc.EventAppended(context.Background(), e))
+	repo.AssertExpectations(t)
+}
+
+func TestService_Create_GeneratesSecret(t *testing.T) {
+	repo := new(mockRepo)
+	svc := NewService(repo)
+	repo.On("Create", mock.Anything, mock.Anything).Return(nil).Twice()
+
+	generated := &postgres.WebhookSubscription{ProjectID: 1, URL: "https://crm.example/hook"}
+	require.NoError(t, svc.Create(context.Background(), generated))
+	assert.Len(t, generated.Secret, 64)
+	assert.True(t, generated.Active)
+
+	given := &postgres.WebhookSubscription{ProjectID: 1, Secret: "my-own-secret-value"}
+	require.NoError(t, svc.Create(context.Background(), given))
+	assert.Equal(t, "my-own-secret-value", given.Secret)
+}
+
+func TestService_Redeliver_QueuesCopy(t *testing.T) {
+	repo := new(mockRepo)
+	svc := NewService(repo)
+	eventID := int64(9)
+	orig := &postgres.WebhookDelivery{ID: 4, SubscriptionID: 3, EventID: &eventID, EventType: "ticket.created",
+		Payload: postgres.JSON(`{"id":9}`), Status: StatusFailed, Attempts: MaxAttempts}
+
+	repo.On("GetDelivery", mock.Anything, int64(4)).Return(orig, nil).Once()
+	repo.On("CreateDelivery", mock.Anything, mock.MatchedBy(func(d *postgres.WebhookDelivery) bool {
+		return d.SubscriptionID == 3 && *d.EventID == 9 && *d.RedeliveryOf == 4 &&
+			string(d.Payload) == `{"id":9}` && d.Attempts == 0
+	})).Return(nil).Once()
+
+	_, err := svc.Redeliver(context.Background(), 4)
+	require.NoError(t, err)
+	repo.AssertExpectations(t)
+}
+
+// allowLoopback lets d reach test servers, which listen on loopback.
+func allowLoopback(d *Dispatcher) *Dispatcher {
+	d.client.Transport.(*http.Transport).DialContext = (&net.Dialer{}).DialContext
+	return d
+}
+
+func TestDispatcher_DispatchDue_SignsAndRecordsOutcome(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		received = map[string]*http.Request{}
+		bodies   = map[string][]byte{}
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		received[r.URL.Path], bodies[r.URL.Path] = r, body
+		mu.Unlock()
+		if r.URL.Path == "/broken" {
+			http.Error(w, "boom", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	now := time.Unix(1700000000, 0)
+	repo := new(mockRepo)
+	d := allowLoopback(NewDispatcher(repo))
+	d.now = func() time.Time { return now }
+
+	job := func(id int64, path string, attempts int) Job {
+		return Job{
+			WebhookDelivery: postgres.WebhookDelivery{ID: id, EventType: "ticket.created", Payload: postgres.JSON(`{"id":9}`), Attempts: attempts},
+			URL:             srv.URL + path,
+			Secret:          "s3cret",
+		}
+	}
+	repo.On("ClaimDue", mock.Anything, BatchSize, claimLease).
+		Return([]Job{job(1, "/ok", 0), job(2, "/broken", 2), job(3, "/broken", MaxAttempts-1)}, nil).Once()
+
+	ok, failed := http.StatusNoContent, http.StatusInternalServerError
+	repo.On("RecordAttempt", mock.Anything, int64(1), StatusSucceeded, &ok, (*string)(nil), now).Return(nil).Once()
+	statusOnly := "unexpected status 500"
+	repo.On("RecordAttempt", mock.Anything, int64(2), StatusPending, &failed, &statusOnly, now.Add(Backoff(3))).Return(nil).Once()
+	repo.On("RecordAttempt", mock.Anything, int64(3), StatusFailed, &failed, mock.Anything, mock.Anything).Return(nil).Once()
+
+	n, err := d.DispatchDue(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+	repo.AssertExpectations(t)
+
+	req := received["/ok"]
+	require.NotNil(t, req)
+	assert.Equal(t, "ticket.created", req.Header.Get(HeaderEvent))
+	assert.Equal(t, "1", req.Header.Get(HeaderDelivery))
+	ts, _ := strconv.ParseInt(req.Header.Get(HeaderTimestamp), 10, 64)
+	assert.True(t, Verify("s3cret", ts, bodies["/ok"], req.Header.Get(HeaderSignature)))
+}
+
+func TestDispatcher_DispatchDue_RefusesInternalAddresses(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	repo := new(mockRepo)
+	d := NewDispatcher(repo)
+
+	repo.On("ClaimDue", mock.Anything, BatchSize, claimLease).Return([]Job{{
+		WebhookDelivery: postgres.WebhookDelivery{ID: 1, EventType: "ticket.created", Payload: postgres.JSON(`{}`)},
+		URL:             srv.URL,
+	}}, nil).Once()
+	repo.On("RecordAttempt", mock.Anything, int64(1), StatusPending, (*int)(nil), mock.MatchedBy(func(msg *string) bool {
+		return strings.Contains(*msg, ErrForbiddenAddress.Error())
+	}), mock.Anything).Return(nil).Once()
+
+	_, err := d.DispatchDue(context.Background())
+
+	require.NoError(t, err)
+	assert.Zero(t, hits.Load())
+	repo.AssertExpectations(t)
+}
+
+func TestDispatcher_DispatchDue_DoesNotFollowRedirects(t *testing.T) {
+	var followed atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/next" {
+			followed.Store(true)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		http.Redirect(w, r, "/next", http.StatusTemporaryRedirect)
+	}))
+	defer srv.Close()
+
+	repo := new(mockRepo)
+	d := allowLoopback(NewDispatcher(repo))
+
+	repo.On("ClaimDue", mock.Anything, BatchSize, claimLease).Return([]Job{{
+		WebhookDelivery: postgres.WebhookDelivery{ID: 1, EventType: "ticket.created", Payload: postgres.JSON(`{}`)},
+		URL:             srv.URL + "/hook",
+	}}, nil).Once()
+	redirect := http.StatusTemporaryRedirect
+	repo.On("RecordAttempt", mock.Anything, int64(1), StatusPending, &redirect, mock.Anything, mock.Anything).Return(nil).Once()
+
+	_, err := d.DispatchDue(context.Background())
+
+	require.NoError(t, err)
+	assert.False(t, followed.Load())
+	repo.AssertExpectations(t)
+}
+
+func TestDialControl_RefusesNonPublicAddresses(t *testing.T) {
+	for address, public := range map[string]bool{
+		"93.184.215.14:443":           true,
+		"[2606:4700::1111]:443":       true,
+		"127.0.0.1:80":                false,
+		"10.1.2.3:80":                 false,
+		"172.16.0.1:80":               false,
+		"192.168.1.1:80":              false,
+		"169.254.169.254:80":          false,
+		"100.64.0.1:80":               false,
+		"0.0.0.0:80":                  false,
+		"255.255.255.255:80":          false,
+		"224.0.0.1:80":                false,
+		"[::1]:80":                    false,
+		"[fe80::1]:80":                false,
+		"[fd00:ec2::254]:80":          false,
+		"[::ffff:127.0.0.1]:80":       false,
+		"[::ffff:169.254.169.254]:80": false,
+	} {
+		err := dialControl("tcp", address, nil)
+		if public {
+			assert.NoError(t, err, address)
+		} else {
+			assert.True(t, errors.Is(err, ErrForbiddenAddress), address)
+		}
+	}
+	assert.True(t, PublicAddr(netip.MustParseAddr("8.8.8.8")))
+}
+
+func TestValidateURL_RejectsLocalHosts(t *testing.T) {
+	v := validator.New()
+	require.NoError(t, v.RegisterValidation("webhook_url", ValidateURL))
+
+	for url, ok := range map[string]bool{
+		"https://hooks.example.com/feedback": true,
+		"https://93.184.215.14/hook":         true,
+		"http://localhost:8080/hook":         false,
+		"http://api.localhost/hook":          false,
+		"http://127.0.0.1/hook":              false,
+		"http://169.254.169.254/latest":      false,
+		"http://[::1]/hook":                  false,
+		"http://10.0.0.5/hook":               false,
+	} {
+		assert.Equal(t, ok, v.Var(url, "webhook_url") == nil, url)
+	}
+}
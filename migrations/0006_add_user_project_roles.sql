@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE user_projects
+    ADD COLUMN IF NOT EXISTS role TEXT NOT NULL DEFAULT 'viewer'
+        CHECK (role IN ('viewer', 'editor', 'admin', 'owner'));
+
+ALTER TABLE user_projects
+    ALTER COLUMN permissions TYPE TEXT[]
+        USING COALESCE(string_to_array(permissions, ','), '{}'),
+    ALTER COLUMN permissions SET DEFAULT '{}',
+    ALTER COLUMN permissions SET NOT NULL;
+
+ALTER TABLE user_projects RENAME COLUMN date_assigned TO date_created;
+ALTER TABLE user_projects ADD COLUMN IF NOT EXISTS date_updated TIMESTAMP DEFAULT NOW();
+
+CREATE TRIGGER trg_user_projects_set_updated
+    BEFORE UPDATE ON user_projects
+    FOR EACH ROW EXECUTE FUNCTION set_updated_timestamp();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS trg_user_projects_set_updated ON user_projects;
+ALTER TABLE user_projects DROP COLUMN IF EXISTS date_updated;
+ALTER TABLE user_projects RENAME COLUMN date_created TO date_assigned;
+ALTER TABLE user_projects
+    ALTER COLUMN permissions DROP NOT NULL,
+    ALTER COLUMN permissions DROP DEFAULT,
+    ALTER COLUMN permissions TYPE TEXT USING array_to_string(permissions, ',');
+ALTER TABLE user_projects DROP COLUMN IF EXISTS role;
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS ticket_events (
+    id BIGSERIAL PRIMARY KEY,
+    ticket_id INT NOT NULL REFERENCES tickets(id) ON DELETE CASCADE,
+    actor_id UUID,
+    field TEXT NOT NULL,
+    old_value TEXT,
+    new_value TEXT,
+    date_created TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_ticket_events_ticket_id ON ticket_events(ticket_id, date_created);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS ticket_events CASCADE;
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_tickets_module_id ON tickets(module_id);
+CREATE INDEX IF NOT EXISTS idx_tickets_status ON tickets(status);
+CREATE INDEX IF NOT EXISTS idx_tickets_date_created_id ON tickets(date_created, id);
+CREATE INDEX IF NOT EXISTS idx_tickets_date_updated_id ON tickets(date_updated, id);
+CREATE INDEX IF NOT EXISTS idx_tickets_project_date_created_id ON tickets(project_id, date_created, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_tickets_project_date_created_id;
+DROP INDEX IF EXISTS idx_tickets_date_updated_id;
+DROP INDEX IF EXISTS idx_tickets_date_created_id;
+DROP INDEX IF EXISTS idx_tickets_status;
+DROP INDEX IF EXISTS idx_tickets_module_id;
+-- +goose StatementEnd
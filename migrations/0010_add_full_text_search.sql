@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE tickets
+    ADD COLUMN search_ru tsvector GENERATED ALWAYS AS (
+        setweight(to_tsvector('russian', coalesce(title, '')), 'A') ||
+        setweight(to_tsvector('russian', coalesce(message, '')), 'B')
+    ) STORED,
+    ADD COLUMN search_en tsvector GENERATED ALWAYS AS (
+        setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+        setweight(to_tsvector('english', coalesce(message, '')), 'B')
+    ) STORED;
+
+ALTER TABLE ticket_chats
+    ADD COLUMN search_ru tsvector GENERATED ALWAYS AS (to_tsvector('russian', coalesce(message, ''))) STORED,
+    ADD COLUMN search_en tsvector GENERATED ALWAYS AS (to_tsvector('english', coalesce(message, ''))) STORED;
+
+CREATE INDEX idx_tickets_search_ru ON tickets USING GIN (search_ru);
+CREATE INDEX idx_tickets_search_en ON tickets USING GIN (search_en);
+CREATE INDEX idx_ticket_chats_search_ru ON ticket_chats USING GIN (search_ru);
+CREATE INDEX idx_ticket_chats_search_en ON ticket_chats USING GIN (search_en);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_ticket_chats_search_en;
+DROP INDEX IF EXISTS idx_ticket_chats_search_ru;
+DROP INDEX IF EXISTS idx_tickets_search_en;
+DROP INDEX IF EXISTS idx_tickets_search_ru;
+
+ALTER TABLE ticket_chats DROP COLUMN IF EXISTS search_en, DROP COLUMN IF EXISTS search_ru;
+ALTER TABLE tickets DROP COLUMN IF EXISTS search_en, DROP COLUMN IF EXISTS search_ru;
+-- +goose StatementEnd
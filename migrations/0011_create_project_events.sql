@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS project_events (
+    id BIGSERIAL PRIMARY KEY,
+    project_id INT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    type TEXT NOT NULL,
+    actor_id UUID,
+    payload JSONB NOT NULL DEFAULT '{}'::jsonb,
+    date_created TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_project_events_project_id ON project_events(project_id, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS project_events CASCADE;
+-- +goose StatementEnd
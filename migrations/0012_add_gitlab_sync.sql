@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE tickets ADD COLUMN IF NOT EXISTS gitlab_issue_iid INT;
+ALTER TABLE ticket_chats ADD COLUMN IF NOT EXISTS gitlab_note_id BIGINT;
+
+CREATE INDEX IF NOT EXISTS idx_tickets_gitlab_issue_iid ON tickets(gitlab_issue_iid) WHERE gitlab_issue_iid IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ticket_chats_gitlab_note_id ON ticket_chats(gitlab_note_id) WHERE gitlab_note_id IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_ticket_chats_gitlab_note_id;
+DROP INDEX IF EXISTS idx_tickets_gitlab_issue_iid;
+ALTER TABLE ticket_chats DROP COLUMN IF EXISTS gitlab_note_id;
+ALTER TABLE tickets DROP COLUMN IF EXISTS gitlab_issue_iid;
+-- +goose StatementEnd
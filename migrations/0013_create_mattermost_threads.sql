@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS mattermost_threads (
+    ticket_id INT PRIMARY KEY REFERENCES tickets(id) ON DELETE CASCADE,
+    channel_id VARCHAR(100) NOT NULL,
+    root_id VARCHAR(100) NOT NULL UNIQUE,
+    date_created TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_ticket_chats_mattermost_message_id ON ticket_chats(mattermost_message_id) WHERE mattermost_message_id IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_ticket_chats_mattermost_message_id;
+DROP TABLE IF EXISTS mattermost_threads CASCADE;
+-- +goose StatementEnd
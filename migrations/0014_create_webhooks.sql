@@ -0,0 +1,54 @@
+-- +goose Up
+-- +goose StatementBegin
+DO $do$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'webhook_delivery_status_enum') THEN
+CREATE TYPE webhook_delivery_status_enum AS ENUM ('pending', 'succeeded', 'failed');
+END IF;
+END
+$do$;
+
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    id SERIAL PRIMARY KEY,
+    project_id INT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    events TEXT[] NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by UUID NOT NULL,
+    date_created TIMESTAMP DEFAULT NOW(),
+    date_updated TIMESTAMP DEFAULT NOW()
+);
+
+CREATE TRIGGER trg_webhook_subscriptions_set_updated
+    BEFORE UPDATE ON webhook_subscriptions
+    FOR EACH ROW EXECUTE FUNCTION set_updated_timestamp();
+
+CREATE INDEX idx_webhook_subscriptions_project_id ON webhook_subscriptions(project_id);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    subscription_id INT NOT NULL REFERENCES webhook_subscriptions(id) ON DELETE CASCADE,
+    event_id BIGINT REFERENCES project_events(id) ON DELETE SET NULL,
+    event_type TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    status webhook_delivery_status_enum NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    last_status_code INT,
+    last_error TEXT,
+    redelivery_of BIGINT REFERENCES webhook_deliveries(id) ON DELETE SET NULL,
+    date_created TIMESTAMP NOT NULL DEFAULT NOW(),
+    date_delivered TIMESTAMP
+);
+
+CREATE INDEX idx_webhook_deliveries_subscription_id ON webhook_deliveries(subscription_id, id DESC);
+CREATE INDEX idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at) WHERE status = 'pending';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS webhook_deliveries CASCADE;
+DROP TABLE IF EXISTS webhook_subscriptions CASCADE;
+DROP TYPE IF EXISTS webhook_delivery_status_enum;
+-- +goose StatementEnd
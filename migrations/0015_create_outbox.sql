@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+DO $do$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'outbox_status_enum') THEN
+CREATE TYPE outbox_status_enum AS ENUM ('pending', 'done', 'dead');
+END IF;
+END
+$do$;
+
+CREATE TABLE IF NOT EXISTS outbox (
+    id BIGSERIAL PRIMARY KEY,
+    topic TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    status outbox_status_enum NOT NULL DEFAULT 'pending',
+    completed TEXT[] NOT NULL DEFAULT '{}',
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    last_error TEXT,
+    date_created TIMESTAMP NOT NULL DEFAULT NOW(),
+    date_processed TIMESTAMP
+);
+
+CREATE INDEX idx_outbox_due ON outbox(next_attempt_at, id) WHERE status = 'pending';
+CREATE INDEX idx_outbox_processed ON outbox(date_processed) WHERE status = 'done';
+
+-- Project events reach webhooks through the outbox, which may replay them.
+CREATE UNIQUE INDEX uq_webhook_deliveries_event ON webhook_deliveries(subscription_id, event_id) WHERE redelivery_of IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS uq_webhook_deliveries_event;
+DROP TABLE IF EXISTS outbox CASCADE;
+DROP TYPE IF EXISTS outbox_status_enum;
+-- +goose StatementEnd
@@ -0,0 +1,62 @@
+-- +goose Up
+-- +goose StatementBegin
+DO $do$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'notification_digest_enum') THEN
+CREATE TYPE notification_digest_enum AS ENUM ('instant', 'hourly', 'daily');
+END IF;
+    IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'notification_status_enum') THEN
+CREATE TYPE notification_status_enum AS ENUM ('pending', 'sent', 'skipped', 'failed');
+END IF;
+END
+$do$;
+
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    user_id UUID PRIMARY KEY,
+    email TEXT NOT NULL,
+    language VARCHAR(8) NOT NULL DEFAULT 'ru',
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    kinds TEXT[] NOT NULL DEFAULT '{chat_message,status_changed,assigned}',
+    digest notification_digest_enum NOT NULL DEFAULT 'instant',
+    date_created TIMESTAMP DEFAULT NOW(),
+    date_updated TIMESTAMP DEFAULT NOW()
+);
+
+CREATE TRIGGER trg_notification_preferences_set_updated
+    BEFORE UPDATE ON notification_preferences
+    FOR EACH ROW EXECUTE FUNCTION set_updated_timestamp();
+
+CREATE TABLE IF NOT EXISTS ticket_watchers (
+    ticket_id INT NOT NULL REFERENCES tickets(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL,
+    date_created TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (ticket_id, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS notifications (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL,
+    event_key TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    ticket_id INT REFERENCES tickets(id) ON DELETE CASCADE,
+    data JSONB NOT NULL,
+    status notification_status_enum NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    send_after TIMESTAMP NOT NULL DEFAULT NOW(),
+    last_error TEXT,
+    date_created TIMESTAMP NOT NULL DEFAULT NOW(),
+    date_sent TIMESTAMP,
+    UNIQUE (user_id, event_key)
+);
+
+CREATE INDEX idx_notifications_due ON notifications(send_after) WHERE status = 'pending';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS notifications CASCADE;
+DROP TABLE IF EXISTS ticket_watchers CASCADE;
+DROP TABLE IF EXISTS notification_preferences CASCADE;
+DROP TYPE IF EXISTS notification_status_enum;
+DROP TYPE IF EXISTS notification_digest_enum;
+-- +goose StatementEnd
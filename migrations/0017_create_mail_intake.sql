@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS mailboxes (
+    id SERIAL PRIMARY KEY,
+    address TEXT NOT NULL UNIQUE,
+    project_id INT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    contract_id INT NOT NULL REFERENCES contracts(id) ON DELETE CASCADE,
+    date_created TIMESTAMP DEFAULT NOW(),
+    date_updated TIMESTAMP DEFAULT NOW()
+);
+
+CREATE TRIGGER trg_mailboxes_set_updated
+    BEFORE UPDATE ON mailboxes
+    FOR EACH ROW EXECUTE FUNCTION set_updated_timestamp();
+
+CREATE TABLE IF NOT EXISTS inbound_emails (
+    message_id TEXT PRIMARY KEY,
+    sender TEXT NOT NULL,
+    mailbox_id INT REFERENCES mailboxes(id) ON DELETE SET NULL,
+    ticket_id INT REFERENCES tickets(id) ON DELETE SET NULL,
+    chat_id INT REFERENCES ticket_chats(id) ON DELETE SET NULL,
+    date_received TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_notification_preferences_email ON notification_preferences(lower(email));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_notification_preferences_email;
+DROP TABLE IF EXISTS inbound_emails CASCADE;
+DROP TABLE IF EXISTS mailboxes CASCADE;
+-- +goose StatementEnd
@@ -0,0 +1,52 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE ticket_attachments
+    ADD COLUMN IF NOT EXISTS size BIGINT,
+    ADD COLUMN IF NOT EXISTS checksum TEXT;
+
+ALTER TABLE message_attachments
+    ADD COLUMN IF NOT EXISTS size BIGINT,
+    ADD COLUMN IF NOT EXISTS checksum TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_ticket_attachments_file_path ON ticket_attachments(file_path);
+CREATE INDEX IF NOT EXISTS idx_message_attachments_file_path ON message_attachments(file_path);
+
+-- Objects whose attachment records are gone, waiting to be removed from
+-- storage. Filled by triggers so that cascading deletes are covered too.
+CREATE TABLE IF NOT EXISTS file_deletions (
+    key TEXT PRIMARY KEY,
+    date_created TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Only keys issued by the application are queued; records created before
+-- keys were verified may point anywhere.
+CREATE OR REPLACE FUNCTION enqueue_file_deletion()
+    RETURNS TRIGGER AS $$
+BEGIN
+    IF OLD.file_path LIKE 'projects/%' AND (TG_OP = 'DELETE' OR NEW.file_path IS DISTINCT FROM OLD.file_path) THEN
+        INSERT INTO file_deletions (key) VALUES (OLD.file_path) ON CONFLICT DO NOTHING;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_ticket_attachments_delete_file
+    AFTER DELETE OR UPDATE OF file_path ON ticket_attachments
+    FOR EACH ROW EXECUTE FUNCTION enqueue_file_deletion();
+
+CREATE TRIGGER trg_message_attachments_delete_file
+    AFTER DELETE OR UPDATE OF file_path ON message_attachments
+    FOR EACH ROW EXECUTE FUNCTION enqueue_file_deletion();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS trg_message_attachments_delete_file ON message_attachments;
+DROP TRIGGER IF EXISTS trg_ticket_attachments_delete_file ON ticket_attachments;
+DROP FUNCTION IF EXISTS enqueue_file_deletion();
+DROP TABLE IF EXISTS file_deletions;
+DROP INDEX IF EXISTS idx_message_attachments_file_path;
+DROP INDEX IF EXISTS idx_ticket_attachments_file_path;
+ALTER TABLE message_attachments DROP COLUMN IF EXISTS checksum, DROP COLUMN IF EXISTS size;
+ALTER TABLE ticket_attachments DROP COLUMN IF EXISTS checksum, DROP COLUMN IF EXISTS size;
+-- +goose StatementEnd
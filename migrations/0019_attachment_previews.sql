@@ -0,0 +1,93 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE ticket_attachments
+    ADD COLUMN IF NOT EXISTS thumbnail_path TEXT,
+    ADD COLUMN IF NOT EXISTS preview_path TEXT;
+
+ALTER TABLE message_attachments
+    ADD COLUMN IF NOT EXISTS thumbnail_path TEXT,
+    ADD COLUMN IF NOT EXISTS preview_path TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_ticket_attachments_thumbnail_path ON ticket_attachments(thumbnail_path);
+CREATE INDEX IF NOT EXISTS idx_ticket_attachments_preview_path ON ticket_attachments(preview_path);
+CREATE INDEX IF NOT EXISTS idx_message_attachments_thumbnail_path ON message_attachments(thumbnail_path);
+CREATE INDEX IF NOT EXISTS idx_message_attachments_preview_path ON message_attachments(preview_path);
+
+-- Stored files waiting for thumbnails and previews.
+CREATE TABLE IF NOT EXISTS file_previews (
+    key TEXT PRIMARY KEY,
+    date_created TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- A new file gets new previews; the old ones are queued for deletion by
+-- enqueue_file_deletion.
+CREATE OR REPLACE FUNCTION enqueue_file_preview()
+    RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'UPDATE' AND NEW.file_path IS NOT DISTINCT FROM OLD.file_path THEN
+        RETURN NEW;
+    END IF;
+    IF TG_OP = 'UPDATE' THEN
+        NEW.thumbnail_path := NULL;
+        NEW.preview_path := NULL;
+    END IF;
+    IF NEW.file_path LIKE 'projects/%' THEN
+        INSERT INTO file_previews (key) VALUES (NEW.file_path) ON CONFLICT DO NOTHING;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION enqueue_file_deletion()
+    RETURNS TRIGGER AS $$
+BEGIN
+    IF OLD.file_path LIKE 'projects/%' AND (TG_OP = 'DELETE' OR NEW.file_path IS DISTINCT FROM OLD.file_path) THEN
+        INSERT INTO file_deletions (key)
+        SELECT key FROM unnest(ARRAY[OLD.file_path, OLD.thumbnail_path, OLD.preview_path]) AS key
+        WHERE key IS NOT NULL
+        ON CONFLICT DO NOTHING;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_ticket_attachments_preview_file
+    BEFORE INSERT OR UPDATE OF file_path ON ticket_attachments
+    FOR EACH ROW EXECUTE FUNCTION enqueue_file_preview();
+
+CREATE TRIGGER trg_message_attachments_preview_file
+    BEFORE INSERT OR UPDATE OF file_path ON message_attachments
+    FOR EACH ROW EXECUTE FUNCTION enqueue_file_preview();
+
+-- Attachments created before previews existed are queued once.
+INSERT INTO file_previews (key)
+SELECT file_path FROM ticket_attachments WHERE file_path LIKE 'projects/%'
+UNION
+SELECT file_path FROM message_attachments WHERE file_path LIKE 'projects/%'
+ON CONFLICT DO NOTHING;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS trg_message_attachments_preview_file ON message_attachments;
+DROP TRIGGER IF EXISTS trg_ticket_attachments_preview_file ON ticket_attachments;
+DROP FUNCTION IF EXISTS enqueue_file_preview();
+
+CREATE OR REPLACE FUNCTION enqueue_file_deletion()
+    RETURNS TRIGGER AS $$
+BEGIN
+    IF OLD.file_path LIKE 'projects/%' AND (TG_OP = 'DELETE' OR NEW.file_path IS DISTINCT FROM OLD.file_path) THEN
+        INSERT INTO file_deletions (key) VALUES (OLD.file_path) ON CONFLICT DO NOTHING;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TABLE IF EXISTS file_previews;
+DROP INDEX IF EXISTS idx_message_attachments_preview_path;
+DROP INDEX IF EXISTS idx_message_attachments_thumbnail_path;
+DROP INDEX IF EXISTS idx_ticket_attachments_preview_path;
+DROP INDEX IF EXISTS idx_ticket_attachments_thumbnail_path;
+ALTER TABLE message_attachments DROP COLUMN IF EXISTS preview_path, DROP COLUMN IF EXISTS thumbnail_path;
+ALTER TABLE ticket_attachments DROP COLUMN IF EXISTS preview_path, DROP COLUMN IF EXISTS thumbnail_path;
+-- +goose StatementEnd
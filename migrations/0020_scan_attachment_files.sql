@@ -0,0 +1,115 @@
+-- +goose Up
+-- +goose StatementBegin
+DO $do$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'scan_status_enum') THEN
+CREATE TYPE scan_status_enum AS ENUM ('pending', 'clean', 'infected');
+END IF;
+END
+$do$;
+
+-- Malware scans of stored files. New files stay under the quarantine/
+-- prefix until they are found clean.
+CREATE TABLE IF NOT EXISTS file_scans (
+    key TEXT PRIMARY KEY,
+    status scan_status_enum NOT NULL DEFAULT 'pending',
+    signature TEXT,
+    date_created TIMESTAMP NOT NULL DEFAULT NOW(),
+    date_scanned TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_file_scans_pending ON file_scans(date_created) WHERE status = 'pending';
+
+ALTER TABLE ticket_attachments
+    ADD COLUMN IF NOT EXISTS scan_status scan_status_enum NOT NULL DEFAULT 'pending';
+
+ALTER TABLE message_attachments
+    ADD COLUMN IF NOT EXISTS scan_status scan_status_enum NOT NULL DEFAULT 'pending';
+
+-- A new file is queued for scanning and takes over the status of an earlier
+-- scan of the same key. Previews are only made of clean files; the scanner
+-- queues them for files found clean later.
+CREATE OR REPLACE FUNCTION track_attachment_file()
+    RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'UPDATE' AND NEW.file_path IS NOT DISTINCT FROM OLD.file_path THEN
+        RETURN NEW;
+    END IF;
+    IF TG_OP = 'UPDATE' THEN
+        NEW.thumbnail_path := NULL;
+        NEW.preview_path := NULL;
+    END IF;
+    NEW.scan_status := 'pending';
+    IF NEW.file_path LIKE 'projects/%' THEN
+        INSERT INTO file_scans (key) VALUES (NEW.file_path) ON CONFLICT DO NOTHING;
+        SELECT status INTO NEW.scan_status FROM file_scans WHERE key = NEW.file_path;
+        IF NEW.scan_status = 'clean' THEN
+            INSERT INTO file_previews (key) VALUES (NEW.file_path) ON CONFLICT DO NOTHING;
+        END IF;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_ticket_attachments_preview_file ON ticket_attachments;
+DROP TRIGGER IF EXISTS trg_message_attachments_preview_file ON message_attachments;
+DROP FUNCTION IF EXISTS enqueue_file_preview();
+
+DROP TRIGGER IF EXISTS trg_ticket_attachments_track_file ON ticket_attachments;
+DROP TRIGGER IF EXISTS trg_message_attachments_track_file ON message_attachments;
+
+CREATE TRIGGER trg_ticket_attachments_track_file
+    BEFORE INSERT OR UPDATE OF file_path ON ticket_attachments
+    FOR EACH ROW EXECUTE FUNCTION track_attachment_file();
+
+CREATE TRIGGER trg_message_attachments_track_file
+    BEFORE INSERT OR UPDATE OF file_path ON message_attachments
+    FOR EACH ROW EXECUTE FUNCTION track_attachment_file();
+
+-- Files stored before scanning existed are scanned where they are; their
+-- previews wait for the result.
+INSERT INTO file_scans (key)
+SELECT file_path FROM ticket_attachments WHERE file_path LIKE 'projects/%'
+UNION
+SELECT file_path FROM message_attachments WHERE file_path LIKE 'projects/%'
+ON CONFLICT DO NOTHING;
+
+DELETE FROM file_previews;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS trg_message_attachments_track_file ON message_attachments;
+DROP TRIGGER IF EXISTS trg_ticket_attachments_track_file ON ticket_attachments;
+DROP FUNCTION IF EXISTS track_attachment_file();
+
+CREATE OR REPLACE FUNCTION enqueue_file_preview()
+    RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'UPDATE' AND NEW.file_path IS NOT DISTINCT FROM OLD.file_path THEN
+        RETURN NEW;
+    END IF;
+    IF TG_OP = 'UPDATE' THEN
+        NEW.thumbnail_path := NULL;
+        NEW.preview_path := NULL;
+    END IF;
+    IF NEW.file_path LIKE 'projects/%' THEN
+        INSERT INTO file_previews (key) VALUES (NEW.file_path) ON CONFLICT DO NOTHING;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_ticket_attachments_preview_file
+    BEFORE INSERT OR UPDATE OF file_path ON ticket_attachments
+    FOR EACH ROW EXECUTE FUNCTION enqueue_file_preview();
+
+CREATE TRIGGER trg_message_attachments_preview_file
+    BEFORE INSERT OR UPDATE OF file_path ON message_attachments
+    FOR EACH ROW EXECUTE FUNCTION enqueue_file_preview();
+
+ALTER TABLE message_attachments DROP COLUMN IF EXISTS scan_status;
+ALTER TABLE ticket_attachments DROP COLUMN IF EXISTS scan_status;
+DROP TABLE IF EXISTS file_scans;
+DROP TYPE IF EXISTS scan_status_enum;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Days after valid_to during which tickets may still be filed under a contract.
+ALTER TABLE contracts
+    ADD COLUMN IF NOT EXISTS grace_period_days INTEGER NOT NULL DEFAULT 0
+        CHECK (grace_period_days >= 0);
+
+CREATE INDEX IF NOT EXISTS idx_user_projects_contract_id ON user_projects(contract_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_user_projects_contract_id;
+ALTER TABLE contracts DROP COLUMN IF EXISTS grace_period_days;
+-- +goose StatementEnd
@@ -0,0 +1,128 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Tickets wait on the client with the SLA clock paused.
+ALTER TYPE ticket_status_enum ADD VALUE IF NOT EXISTS 'waiting' AFTER 'in_progress';
+
+DO $do$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'sla_state_enum') THEN
+CREATE TYPE sla_state_enum AS ENUM ('on_track', 'at_risk', 'breached', 'met');
+END IF;
+END
+$do$;
+
+-- Support tiers sold with contracts. Deadlines only run during business
+-- hours, a JSON list of {"day": 0-6 (Sunday is 0), "from": "09:00",
+-- "to": "18:00"} in the policy's time zone; an empty list means round the
+-- clock. Holidays stop the clock for the whole day.
+CREATE TABLE IF NOT EXISTS sla_policies (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    timezone TEXT NOT NULL DEFAULT 'UTC',
+    business_hours JSONB NOT NULL DEFAULT '[]',
+    warning_percent INT NOT NULL DEFAULT 80 CHECK (warning_percent BETWEEN 1 AND 99),
+    date_created TIMESTAMP DEFAULT NOW(),
+    date_updated TIMESTAMP DEFAULT NOW()
+);
+
+DROP TRIGGER IF EXISTS trg_sla_policies_set_updated ON sla_policies;
+CREATE TRIGGER trg_sla_policies_set_updated
+    BEFORE UPDATE ON sla_policies
+    FOR EACH ROW EXECUTE FUNCTION set_updated_timestamp();
+
+-- Business minutes until the first response and the resolution of a ticket.
+CREATE TABLE IF NOT EXISTS sla_targets (
+    policy_id INT NOT NULL REFERENCES sla_policies(id) ON DELETE CASCADE,
+    priority VARCHAR(2) NOT NULL CHECK (priority IN ('P1', 'P2', 'P3', 'P4')),
+    first_response_minutes INT NOT NULL CHECK (first_response_minutes > 0),
+    resolution_minutes INT NOT NULL CHECK (resolution_minutes > 0),
+    PRIMARY KEY (policy_id, priority)
+);
+
+CREATE TABLE IF NOT EXISTS sla_holidays (
+    policy_id INT NOT NULL REFERENCES sla_policies(id) ON DELETE CASCADE,
+    day DATE NOT NULL,
+    name VARCHAR(255),
+    PRIMARY KEY (policy_id, day)
+);
+
+ALTER TABLE contracts
+    ADD COLUMN IF NOT EXISTS sla_policy_id INT REFERENCES sla_policies(id) ON DELETE SET NULL;
+
+ALTER TABLE tickets
+    ADD COLUMN IF NOT EXISTS sla_policy_id INT REFERENCES sla_policies(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS first_response_due TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS first_response_warn_at TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS first_responded_at TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS first_response_sla sla_state_enum,
+    ADD COLUMN IF NOT EXISTS resolve_due TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS resolve_warn_at TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS resolve_sla sla_state_enum,
+    ADD COLUMN IF NOT EXISTS sla_paused_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_tickets_first_response_warn_at ON tickets(first_response_warn_at) WHERE first_response_sla = 'on_track';
+CREATE INDEX IF NOT EXISTS idx_tickets_first_response_due ON tickets(first_response_due) WHERE first_response_sla IN ('on_track', 'at_risk');
+CREATE INDEX IF NOT EXISTS idx_tickets_resolve_warn_at ON tickets(resolve_warn_at) WHERE resolve_sla = 'on_track';
+CREATE INDEX IF NOT EXISTS idx_tickets_resolve_due ON tickets(resolve_due) WHERE resolve_sla IN ('on_track', 'at_risk');
+
+-- The first message from support answers the ticket, in time unless the
+-- deadline was already flagged as breached.
+CREATE OR REPLACE FUNCTION record_first_response()
+    RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.sender_role = 'admin' THEN
+        UPDATE tickets
+        SET first_responded_at = COALESCE(NEW.date_created, NOW()),
+            first_response_sla = CASE
+                WHEN first_response_sla IN ('on_track', 'at_risk') THEN 'met'
+                ELSE first_response_sla
+            END
+        WHERE id = NEW.ticket_id AND first_responded_at IS NULL;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_ticket_chats_first_response ON ticket_chats;
+CREATE TRIGGER trg_ticket_chats_first_response
+    AFTER INSERT ON ticket_chats
+    FOR EACH ROW EXECUTE FUNCTION record_first_response();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS trg_ticket_chats_first_response ON ticket_chats;
+DROP FUNCTION IF EXISTS record_first_response();
+
+DROP INDEX IF EXISTS idx_tickets_resolve_due;
+DROP INDEX IF EXISTS idx_tickets_resolve_warn_at;
+DROP INDEX IF EXISTS idx_tickets_first_response_due;
+DROP INDEX IF EXISTS idx_tickets_first_response_warn_at;
+
+ALTER TABLE tickets
+    DROP COLUMN IF EXISTS sla_paused_at,
+    DROP COLUMN IF EXISTS resolve_sla,
+    DROP COLUMN IF EXISTS resolve_warn_at,
+    DROP COLUMN IF EXISTS resolve_due,
+    DROP COLUMN IF EXISTS first_response_sla,
+    DROP COLUMN IF EXISTS first_responded_at,
+    DROP COLUMN IF EXISTS first_response_warn_at,
+    DROP COLUMN IF EXISTS first_response_due,
+    DROP COLUMN IF EXISTS sla_policy_id;
+
+ALTER TABLE contracts DROP COLUMN IF EXISTS sla_policy_id;
+
+DROP TABLE IF EXISTS sla_holidays;
+DROP TABLE IF EXISTS sla_targets;
+DROP TABLE IF EXISTS sla_policies;
+DROP TYPE IF EXISTS sla_state_enum;
+
+-- Enum values cannot be dropped, so the type is rebuilt without 'waiting'.
+UPDATE tickets SET status = 'in_progress' WHERE status = 'waiting';
+ALTER TABLE tickets ALTER COLUMN status DROP DEFAULT;
+ALTER TYPE ticket_status_enum RENAME TO ticket_status_enum_old;
+CREATE TYPE ticket_status_enum AS ENUM ('open', 'in_progress', 'resolved', 'closed');
+ALTER TABLE tickets ALTER COLUMN status TYPE ticket_status_enum USING status::text::ticket_status_enum;
+ALTER TABLE tickets ALTER COLUMN status SET DEFAULT 'open';
+DROP TYPE ticket_status_enum_old;
+-- +goose StatementEnd
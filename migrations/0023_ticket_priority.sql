@@ -0,0 +1,40 @@
+-- +goose Up
+-- +goose StatementBegin
+-- P1 is the most urgent priority and critical the worst severity, so both
+-- sort most pressing first.
+DO $do$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'ticket_priority_enum') THEN
+CREATE TYPE ticket_priority_enum AS ENUM ('P1', 'P2', 'P3', 'P4');
+END IF;
+    IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'ticket_severity_enum') THEN
+CREATE TYPE ticket_severity_enum AS ENUM ('critical', 'major', 'minor', 'trivial');
+END IF;
+END
+$do$;
+
+-- Clients may only propose a priority; support sets the one that counts.
+ALTER TABLE tickets
+    ADD COLUMN IF NOT EXISTS priority ticket_priority_enum NOT NULL DEFAULT 'P3',
+    ADD COLUMN IF NOT EXISTS proposed_priority ticket_priority_enum,
+    ADD COLUMN IF NOT EXISTS severity ticket_severity_enum NOT NULL DEFAULT 'minor';
+
+CREATE INDEX IF NOT EXISTS idx_tickets_priority_id ON tickets(priority, id);
+CREATE INDEX IF NOT EXISTS idx_tickets_project_priority_id ON tickets(project_id, priority, id);
+CREATE INDEX IF NOT EXISTS idx_tickets_severity_id ON tickets(severity, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_tickets_severity_id;
+DROP INDEX IF EXISTS idx_tickets_project_priority_id;
+DROP INDEX IF EXISTS idx_tickets_priority_id;
+
+ALTER TABLE tickets
+    DROP COLUMN IF EXISTS severity,
+    DROP COLUMN IF EXISTS proposed_priority,
+    DROP COLUMN IF EXISTS priority;
+
+DROP TYPE IF EXISTS ticket_severity_enum;
+DROP TYPE IF EXISTS ticket_priority_enum;
+-- +goose StatementEnd
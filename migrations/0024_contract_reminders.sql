@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_contracts_valid_to ON contracts(valid_to) WHERE valid_to IS NOT NULL;
+
+-- Expiry reminders already sent, per end date so that a renewed contract is
+-- reminded about again. A reminder for a window also covers the wider ones.
+CREATE TABLE IF NOT EXISTS contract_reminders (
+    contract_id INT NOT NULL REFERENCES contracts(id) ON DELETE CASCADE,
+    valid_to DATE NOT NULL,
+    window_days INT NOT NULL CHECK (window_days >= 0),
+    date_created TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (contract_id, valid_to, window_days)
+);
+
+-- Project owners get expiry reminders unless they opt out.
+ALTER TABLE notification_preferences
+    ALTER COLUMN kinds SET DEFAULT '{chat_message,status_changed,assigned,contract_expiring}';
+UPDATE notification_preferences
+SET kinds = array_append(kinds, 'contract_expiring')
+WHERE NOT 'contract_expiring' = ANY(kinds);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+UPDATE notification_preferences SET kinds = array_remove(kinds, 'contract_expiring');
+ALTER TABLE notification_preferences
+    ALTER COLUMN kinds SET DEFAULT '{chat_message,status_changed,assigned}';
+
+DROP TABLE IF EXISTS contract_reminders;
+DROP INDEX IF EXISTS idx_contracts_valid_to;
+-- +goose StatementEnd
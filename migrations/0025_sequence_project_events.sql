@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Event ids are taken at insert time and commit out of order, so streams
+-- resume by a sequence number assigned after commit, one project at a time.
+CREATE SEQUENCE IF NOT EXISTS project_events_seq;
+
+ALTER TABLE project_events ADD COLUMN IF NOT EXISTS seq BIGINT;
+
+UPDATE project_events SET seq = id WHERE seq IS NULL;
+SELECT setval('project_events_seq', GREATEST((SELECT MAX(seq) FROM project_events), 1));
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_project_events_project_seq ON project_events(project_id, seq) WHERE seq IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS uq_project_events_project_seq;
+ALTER TABLE project_events DROP COLUMN IF EXISTS seq;
+DROP SEQUENCE IF EXISTS project_events_seq;
+-- +goose StatementEnd
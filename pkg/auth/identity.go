@@ -0,0 +1,41 @@
+// Package auth provides Keycloak token verification and the authenticated identity model.
+package auth
+
+import (
+	"context"
+	"slices"
+)
+
+// Identity describes the authenticated caller extracted from a verified access token.
+type Identity struct {
+	Subject  string   `json:"sub"`
+	Username string   `json:"preferred_username,omitempty"`
+	Email    string   `json:"email,omitempty"`
+	Roles    []string `json:"roles"`
+}
+
+// HasRole reports whether the identity carries the given realm role.
+func (i *Identity) HasRole(role string) bool {
+	return i != nil && slices.Contains(i.Roles, role)
+}
+
+type identityKey struct{}
+
+// WithIdentity returns a copy of ctx carrying the identity.
+func WithIdentity(ctx context.Context, id *Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, id)
+}
+
+// FromContext extracts the identity stored by WithIdentity.
+func FromContext(ctx context.Context) (*Identity, bool) {
+	id, ok := ctx.Value(identityKey{}).(*Identity)
+	return id, ok && id != nil
+}
+
+// SubjectFromContext returns the subject UUID of the caller or an empty string.
+func SubjectFromContext(ctx context.Context) string {
+	if id, ok := FromContext(ctx); ok {
+		return id.Subject
+	}
+	return ""
+}
@@ -0,0 +1,199 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrKeyNotFound is returned when the JWKS does not contain the requested key id.
+var ErrKeyNotFound = errors.New("signing key not found")
+
+const (
+	defaultJWKSTTL        = 15 * time.Minute
+	defaultMinRefreshWait = 30 * time.Second
+	jwksRequestTimeout    = 10 * time.Second
+)
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// KeySet caches the public signing keys published by a JWKS endpoint.
+//
+// Keys are refreshed after the TTL expires or when a token references an
+// unknown key id (key rotation); forced refreshes are rate limited so that
+// tokens with bogus key ids cannot hammer the identity provider.
+type KeySet struct {
+	url            string
+	client         *http.Client
+	ttl            time.Duration
+	minRefreshWait time.Duration
+
+	mu          sync.RWMutex
+	keys        map[string]any
+	fetchedAt   time.Time
+	lastAttempt time.Time
+}
+
+// NewKeySet creates a KeySet for the given JWKS URL. A zero ttl uses the default.
+func NewKeySet(url string, ttl time.Duration) *KeySet {
+	if ttl <= 0 {
+		ttl = defaultJWKSTTL
+	}
+	return &KeySet{
+		url:            url,
+		client:         &http.Client{Timeout: jwksRequestTimeout},
+		ttl:            ttl,
+		minRefreshWait: defaultMinRefreshWait,
+		keys:           map[string]any{},
+	}
+}
+
+// Key returns the public key identified by kid, refreshing the cache when needed.
+func (k *KeySet) Key(ctx context.Context, kid string) (any, error) {
+	k.mu.RLock()
+	key, ok := k.keys[kid]
+	fresh := time.Since(k.fetchedAt) < k.ttl
+	k.mu.RUnlock()
+
+	if ok && fresh {
+		return key, nil
+	}
+
+	if err := k.refresh(ctx, !ok); err != nil && !ok {
+		return nil, err
+	}
+
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	if key, ok := k.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("%w: kid %q", ErrKeyNotFound, kid)
+}
+
+func (k *KeySet) refresh(ctx context.Context, unknownKid bool) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	// Another goroutine may have refreshed while we waited for the lock.
+	if time.Since(k.fetchedAt) < k.ttl && !unknownKid {
+		return nil
+	}
+	if time.Since(k.lastAttempt) < k.minRefreshWait && len(k.keys) > 0 {
+		return nil
+	}
+	k.lastAttempt = time.Now()
+
+	keys, err := k.fetch(ctx)
+	if err != nil {
+		return err
+	}
+	k.keys = keys
+	k.fetchedAt = time.Now()
+	return nil
+}
+
+func (k *KeySet) fetch(ctx context.Context) (map[string]any, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, k.url, http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create jwks request: %w", err)
+	}
+
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("jwks request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("jwks request failed: unexpected status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("cannot decode jwks: %w", err)
+	}
+
+	keys := make(map[string]any, len(body.Keys))
+	for _, j := range body.Keys {
+		if j.Use != "" && j.Use != "sig" {
+			continue
+		}
+		pub, err := j.publicKey()
+		if err != nil {
+			continue
+		}
+		keys[j.Kid] = pub
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("jwks contains no usable signing keys")
+	}
+	return keys, nil
+}
+
+func (j *jwk) publicKey() (any, error) {
+	switch j.Kty {
+	case "RSA":
+		n, err := decodeBigInt(j.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(j.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch j.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", j.Crv)
+		}
+		x, err := decodeBigInt(j.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(j.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", j.Kty)
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ErrInvalidToken is returned for tokens that fail signature, expiry, issuer or scope checks.
+var ErrInvalidToken = errors.New("invalid token")
+
+// KeyProvider resolves a signing key by its key id.
+type KeyProvider interface {
+	Key(ctx context.Context, kid string) (any, error)
+}
+
+// Config describes the realm and client tokens must be issued for.
+type Config struct {
+	Issuer   string
+	ClientID string
+	Leeway   time.Duration
+}
+
+type claims struct {
+	jwt.RegisteredClaims
+	AuthorizedParty   string `json:"azp"`
+	PreferredUsername string `json:"preferred_username"`
+	Email             string `json:"email"`
+	RealmAccess       struct {
+		Roles []string `json:"roles"`
+	} `json:"realm_access"`
+}
+
+// Verifier validates Keycloak access tokens.
+type Verifier struct {
+	keys   KeyProvider
+	cfg    Config
+	parser *jwt.Parser
+}
+
+// NewVerifier creates a Verifier that resolves signing keys through keys.
+func NewVerifier(keys KeyProvider, cfg Config) *Verifier {
+	return &Verifier{
+		keys: keys,
+		cfg:  cfg,
+		parser: jwt.NewParser(
+			jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}),
+			jwt.WithIssuer(cfg.Issuer),
+			jwt.WithExpirationRequired(),
+			jwt.WithLeeway(cfg.Leeway),
+		),
+	}
+}
+
+// Verify checks the raw bearer token and returns the identity it carries.
+func (v *Verifier) Verify(ctx context.Context, raw string) (*Identity, error) {
+	var c claims
+	_, err := v.parser.ParseWithClaims(raw, &c, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		return v.keys.Key(ctx, kid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	if !v.scoped(&c) {
+		return nil, fmt.Errorf("%w: token was not issued for client %q", ErrInvalidToken, v.cfg.ClientID)
+	}
+	if c.Subject == "" {
+		return nil, fmt.Errorf("%w: missing subject", ErrInvalidToken)
+	}
+
+	return &Identity{
+		Subject:  c.Subject,
+		Username: c.PreferredUsername,
+		Email:    c.Email,
+		Roles:    c.RealmAccess.Roles,
+	}, nil
+}
+
+// scoped accepts tokens whose audience or authorized party is the API client.
+func (v *Verifier) scoped(c *claims) bool {
+	if v.cfg.ClientID == "" {
+		return true
+	}
+	return c.AuthorizedParty == v.cfg.ClientID || slices.Contains(c.Audience, v.cfg.ClientID)
+}
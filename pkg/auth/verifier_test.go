@@ -0,0 +1,175 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testIssuer   = "http://keycloak.test/realms/feedbacklab"
+	testClientID = "feedbacklab-api"
+)
+
+type stubJWKS struct {
+	mu       sync.Mutex
+	keys     map[string]*rsa.PrivateKey
+	requests int
+}
+
+func newStubJWKS(t *testing.T) (*stubJWKS, *httptest.Server) {
+	t.Helper()
+	stub := &stubJWKS{keys: map[string]*rsa.PrivateKey{}}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		stub.mu.Lock()
+		defer stub.mu.Unlock()
+		stub.requests++
+
+		var set struct {
+			Keys []map[string]string `json:"keys"`
+		}
+		for kid, key := range stub.keys {
+			set.Keys = append(set.Keys, map[string]string{
+				"kty": "RSA",
+				"kid": kid,
+				"use": "sig",
+				"alg": "RS256",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			})
+		}
+		_ = json.NewEncoder(w).Encode(set)
+	}))
+	t.Cleanup(srv.Close)
+	return stub, srv
+}
+
+func (s *stubJWKS) addKey(t *testing.T, kid string) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	s.mu.Lock()
+	s.keys[kid] = key
+	s.mu.Unlock()
+	return key
+}
+
+func signToken(t *testing.T, key *rsa.PrivateKey, kid string, mutate func(jwt.MapClaims)) string {
+	t.Helper()
+	c := jwt.MapClaims{
+		"iss":          testIssuer,
+		"sub":          "4a7c1f0e-7a61-4d3a-9b52-3c2f4f0d9a11",
+		"azp":          testClientID,
+		"aud":          []string{"account"},
+		"exp":          time.Now().Add(time.Hour).Unix(),
+		"iat":          time.Now().Unix(),
+		"realm_access": map[string]any{"roles": []string{"admin", "offline_access"}},
+	}
+	if mutate != nil {
+		mutate(c)
+	}
+	tok := jwt.NewWithClaims(jwt.SigningMethodRS256, c)
+	tok.Header["kid"] = kid
+	raw, err := tok.SignedString(key)
+	require.NoError(t, err)
+	return raw
+}
+
+func newTestVerifier(url string) *Verifier {
+	ks := NewKeySet(url, time.Hour)
+	ks.minRefreshWait = 0
+	return NewVerifier(ks, Config{Issuer: testIssuer, ClientID: testClientID})
+}
+
+func TestVerifier_ValidToken_ReturnsIdentity(t *testing.T) {
+	stub, srv := newStubJWKS(t)
+	key := stub.addKey(t, "k1")
+	v := newTestVerifier(srv.URL)
+
+	id, err := v.Verify(context.Background(), signToken(t, key, "k1", nil))
+
+	require.NoError(t, err)
+	assert.Equal(t, "4a7c1f0e-7a61-4d3a-9b52-3c2f4f0d9a11", id.Subject)
+	assert.True(t, id.HasRole("admin"))
+}
+
+func TestVerifier_RejectsExpiredToken(t *testing.T) {
+	stub, srv := newStubJWKS(t)
+	key := stub.addKey(t, "k1")
+	v := newTestVerifier(srv.URL)
+
+	raw := signToken(t, key, "k1", func(c jwt.MapClaims) {
+		c["exp"] = time.Now().Add(-time.Hour).Unix()
+	})
+
+	_, err := v.Verify(context.Background(), raw)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestVerifier_RejectsTokenForOtherClient(t *testing.T) {
+	stub, srv := newStubJWKS(t)
+	key := stub.addKey(t, "k1")
+	v := newTestVerifier(srv.URL)
+
+	raw := signToken(t, key, "k1", func(c jwt.MapClaims) {
+		c["azp"] = "some-other-client"
+	})
+
+	_, err := v.Verify(context.Background(), raw)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestVerifier_RejectsWrongIssuer(t *testing.T) {
+	stub, srv := newStubJWKS(t)
+	key := stub.addKey(t, "k1")
+	v := newTestVerifier(srv.URL)
+
+	raw := signToken(t, key, "k1", func(c jwt.MapClaims) {
+		c["iss"] = "http://evil.test/realms/feedbacklab"
+	})
+
+	_, err := v.Verify(context.Background(), raw)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestVerifier_RejectsUnknownSigningKey(t *testing.T) {
+	stub, srv := newStubJWKS(t)
+	stub.addKey(t, "k1")
+	v := newTestVerifier(srv.URL)
+
+	forged, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	_, err = v.Verify(context.Background(), signToken(t, forged, "k1", nil))
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestKeySet_CachesKeysAndRefreshesOnRotation(t *testing.T) {
+	stub, srv := newStubJWKS(t)
+	k1 := stub.addKey(t, "k1")
+	v := newTestVerifier(srv.URL)
+	ctx := context.Background()
+
+	_, err := v.Verify(ctx, signToken(t, k1, "k1", nil))
+	require.NoError(t, err)
+	_, err = v.Verify(ctx, signToken(t, k1, "k1", nil))
+	require.NoError(t, err)
+	assert.Equal(t, 1, stub.requests, "second verification should hit the cache")
+
+	k2 := stub.addKey(t, "k2")
+	_, err = v.Verify(ctx, signToken(t, k2, "k2", nil))
+	require.NoError(t, err)
+	assert.Equal(t, 2, stub.requests, "unknown kid should trigger a refresh")
+}
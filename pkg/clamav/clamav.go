@@ -0,0 +1,137 @@
+// Package clamav scans data for malware with a ClamAV daemon, speaking the
+// clamd socket protocol.
+package clamav
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// chunkSize is the size of the chunks data is streamed to clamd in.
+const chunkSize = 64 << 10
+
+// Client talks to clamd. Every call opens its own connection.
+type Client struct {
+	network string
+	address string
+	timeout time.Duration
+}
+
+// New creates a Client for the clamd listening at address: host:port for
+// TCP, or the path of a unix socket. Calls give up after timeout.
+func New(address string, timeout time.Duration) *Client {
+	network := "tcp"
+	if strings.HasPrefix(address, "/") {
+		network = "unix"
+	}
+	return &Client{network: network, address: address, timeout: timeout}
+}
+
+// Ping checks that clamd is reachable.
+func (c *Client) Ping(ctx context.Context) error {
+	reply, err := c.call(ctx, "zPING\x00", nil)
+	if err != nil {
+		return err
+	}
+	if reply != "PONG" {
+		return fmt.Errorf("clamd: unexpected reply %q", reply)
+	}
+	return nil
+}
+
+// Scan streams r to clamd and returns the name of the malware found in it,
+// or an empty string when it is clean.
+func (c *Client) Scan(ctx context.Context, r io.Reader) (string, error) {
+	reply, err := c.call(ctx, "zINSTREAM\x00", r)
+	if err != nil {
+		return "", err
+	}
+	result, ok := strings.CutPrefix(reply, "stream: ")
+	switch {
+	case !ok:
+		return "", fmt.Errorf("clamd: unexpected reply %q", reply)
+	case result == "OK":
+		return "", nil
+	case strings.HasSuffix(result, " FOUND"):
+		return strings.TrimSuffix(result, " FOUND"), nil
+	}
+	return "", fmt.Errorf("clamd: %s", result)
+}
+
+// call sends command, followed by the contents of body in chunks when it is
+// not nil, and reads the reply.
+func (c *Client) call(ctx context.Context, command string, body io.Reader) (string, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, c.network, c.address)
+	if err != nil {
+		return "", fmt.Errorf("clamd: %w", err)
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+	if c.timeout > 0 {
+		if err := conn.SetDeadline(time.Now().Add(c.timeout)); err != nil {
+			return "", err
+		}
+	}
+
+	if _, err := io.WriteString(conn, command); err != nil {
+		return "", fmt.Errorf("clamd: %w", err)
+	}
+	if body != nil {
+		if err := sendChunks(conn, body); err != nil {
+			// clamd hangs up on streams over its size limit, but replies first.
+			if reply, readErr := readReply(conn); readErr == nil && reply != "" {
+				return "", fmt.Errorf("clamd: %s", reply)
+			}
+			return "", fmt.Errorf("clamd: %w", err)
+		}
+	}
+	reply, err := readReply(conn)
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		return "", fmt.Errorf("clamd: %w", err)
+	}
+	return reply, nil
+}
+
+func sendChunks(w io.Writer, r io.Reader) error {
+	buf := make([]byte, 4+chunkSize)
+	for {
+		n, err := io.ReadFull(r, buf[4:])
+		if n > 0 {
+			binary.BigEndian.PutUint32(buf, uint32(n))
+			if _, werr := w.Write(buf[:4+n]); werr != nil {
+				return werr
+			}
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	_, err := w.Write([]byte{0, 0, 0, 0})
+	return err
+}
+
+// readReply reads a reply terminated by a NUL byte, as requested by the z
+// prefix of commands.
+func readReply(r io.Reader) (string, error) {
+	reply, err := bufio.NewReader(r).ReadBytes(0)
+	if err != nil && !(errors.Is(err, io.EOF) && len(reply) > 0) {
+		return "", err
+	}
+	return string(bytes.TrimSpace(bytes.TrimRight(reply, "\x00"))), nil
+}
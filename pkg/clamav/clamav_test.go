@@ -0,0 +1,116 @@
+package clamav
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClamd answers INSTREAM commands, reporting streams that contain
+// "EICAR" as infected and streams over limit bytes as too large.
+func fakeClamd(t *testing.T, limit int) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveClamd(conn, limit)
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func serveClamd(conn net.Conn, limit int) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	command, err := r.ReadString(0)
+	if err != nil {
+		return
+	}
+	switch command {
+	case "zPING\x00":
+		io.WriteString(conn, "PONG\x00")
+		return
+	case "zINSTREAM\x00":
+	default:
+		io.WriteString(conn, "UNKNOWN COMMAND\x00")
+		return
+	}
+
+	var data []byte
+	for {
+		var size uint32
+		if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+			return
+		}
+		if size == 0 {
+			break
+		}
+		chunk := make([]byte, size)
+		if _, err := io.ReadFull(r, chunk); err != nil {
+			return
+		}
+		data = append(data, chunk...)
+		if len(data) > limit {
+			io.WriteString(conn, "INSTREAM size limit exceeded. ERROR\x00")
+			return
+		}
+	}
+	if bytes.Contains(data, []byte("EICAR")) {
+		io.WriteString(conn, "stream: Eicar-Test-Signature FOUND\x00")
+		return
+	}
+	io.WriteString(conn, "stream: OK\x00")
+}
+
+func TestClient_Scan(t *testing.T) {
+	c := New(fakeClamd(t, 1<<20), 5*time.Second)
+	ctx := context.Background()
+
+	signature, err := c.Scan(ctx, strings.NewReader("hello"))
+	require.NoError(t, err)
+	assert.Empty(t, signature)
+
+	signature, err = c.Scan(ctx, strings.NewReader(strings.Repeat("x", 3*chunkSize)+"EICAR"))
+	require.NoError(t, err)
+	assert.Equal(t, "Eicar-Test-Signature", signature)
+
+	signature, err = c.Scan(ctx, strings.NewReader(""))
+	require.NoError(t, err)
+	assert.Empty(t, signature)
+}
+
+func TestClient_Scan_SizeLimit(t *testing.T) {
+	c := New(fakeClamd(t, 10), 5*time.Second)
+
+	_, err := c.Scan(context.Background(), strings.NewReader(strings.Repeat("x", 100)))
+	assert.ErrorContains(t, err, "size limit exceeded")
+}
+
+func TestClient_Ping(t *testing.T) {
+	assert.NoError(t, New(fakeClamd(t, 10), time.Second).Ping(context.Background()))
+}
+
+func TestClient_Unreachable(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	ln.Close()
+
+	_, err = New(addr, time.Second).Scan(context.Background(), strings.NewReader("x"))
+	assert.ErrorContains(t, err, "clamd:")
+}
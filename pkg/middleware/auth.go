@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"context"
+	"innotech/pkg/auth"
+	"innotech/pkg/logger"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// TokenVerifier validates a raw bearer token and returns the caller identity.
+type TokenVerifier interface {
+	Verify(ctx context.Context, raw string) (*auth.Identity, error)
+}
+
+// Auth creates a middleware that requires a valid Keycloak bearer token.
+//
+// On success the subject UUID and realm roles are stored in the "user_id" and
+// "roles" locals, and the identity is attached to the user context so services
+// can read it through auth.FromContext.
+func Auth(verifier TokenVerifier) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		token := bearerToken(c)
+		if token == "" {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": Localize(c, "common.error.unauthorized"),
+			})
+		}
+
+		identity, err := verifier.Verify(c.UserContext(), token)
+		if err != nil {
+			logger.Warn("request authentication failed",
+				"method", c.Method(),
+				"path", c.Path(),
+				"ip", c.IP(),
+				"error", err.Error(),
+			)
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": Localize(c, "common.error.unauthorized"),
+			})
+		}
+
+		c.Locals("user_id", identity.Subject)
+		c.Locals("roles", identity.Roles)
+		c.Locals("identity", identity)
+		c.SetUserContext(auth.WithIdentity(c.UserContext(), identity))
+
+		return c.Next()
+	}
+}
+
+// bearerToken extracts the access token from the Authorization header.
+// Browsers cannot set headers on WebSocket handshakes or EventSource
+// requests, so those may pass the token in the access_token query parameter.
+func bearerToken(c *fiber.Ctx) string {
+	scheme, token, found := strings.Cut(c.Get(fiber.HeaderAuthorization), " ")
+	if found && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(token)
+	}
+	if strings.EqualFold(c.Get(fiber.HeaderUpgrade), "websocket") ||
+		strings.Contains(c.Get(fiber.HeaderAccept), "text/event-stream") {
+		return c.Query("access_token")
+	}
+	return ""
+}
+
+// UserID returns the authenticated subject UUID stored by Auth.
+func UserID(c *fiber.Ctx) string {
+	id, _ := c.Locals("user_id").(string)
+	return id
+}
+
+// Roles returns the realm roles stored by Auth.
+func Roles(c *fiber.Ctx) []string {
+	roles, _ := c.Locals("roles").([]string)
+	return roles
+}
@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"innotech/pkg/auth"
+	"innotech/pkg/logger"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	logger.Init()
+	m.Run()
+}
+
+type stubVerifier struct{}
+
+func (stubVerifier) Verify(_ context.Context, raw string) (*auth.Identity, error) {
+	if raw != "good" {
+		return nil, errors.New("bad token")
+	}
+	return &auth.Identity{Subject: "user-1", Roles: []string{"admin"}}, nil
+}
+
+func newAuthApp() *fiber.App {
+	app := fiber.New()
+	app.Use(Auth(stubVerifier{}))
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString(UserID(c) + "|" + auth.SubjectFromContext(c.UserContext()))
+	})
+	return app
+}
+
+func TestAuth_RejectsMissingToken(t *testing.T) {
+	resp, err := newAuthApp().Test(httptest.NewRequest("GET", "/", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestAuth_RejectsInvalidToken(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Authorization", "Bearer bad")
+
+	resp, err := newAuthApp().Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestAuth_StoresIdentity(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Authorization", "Bearer good")
+
+	resp, err := newAuthApp().Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, "user-1|user-1", string(body))
+}
+
+func TestAuth_QueryTokenOnlyForWebSocketUpgrade(t *testing.T) {
+	req := httptest.NewRequest("GET", "/?access_token=good", nil)
+	resp, err := newAuthApp().Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
+
+	req = httptest.NewRequest("GET", "/?access_token=good", nil)
+	req.Header.Set("Upgrade", "websocket")
+	resp, err = newAuthApp().Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+}
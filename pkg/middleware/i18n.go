@@ -28,3 +28,22 @@ func I18nMiddleware(bundle *goi18n.Bundle) fiber.Handler {
 		return c.Next()
 	}
 }
+
+// Localize translates messageID with the request localizer, falling back to the ID itself.
+func Localize(c *fiber.Ctx, messageID string, data ...map[string]any) string {
+	localizer, ok := c.Locals("localizer").(*goi18n.Localizer)
+	if !ok {
+		return messageID
+	}
+
+	cfg := &goi18n.LocalizeConfig{MessageID: messageID}
+	if len(data) > 0 {
+		cfg.TemplateData = data[0]
+	}
+
+	msg, err := localizer.Localize(cfg)
+	if err != nil {
+		return messageID
+	}
+	return msg
+}
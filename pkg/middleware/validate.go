@@ -60,3 +60,52 @@ func ValidateBody[T any](next fiber.Handler) fiber.Handler {
 		return next(c)
 	}
 }
+
+// RegisterValidation adds a custom validation tag to the shared body validator.
+func RegisterValidation(tag string, fn validator.Func) error {
+	return validate.RegisterValidation(tag, fn)
+}
+
+// RegisterStructValidation adds a validation of whole structs of the given
+// types to the shared body validator.
+func RegisterStructValidation(fn validator.StructLevelFunc, types ...any) {
+	validate.RegisterStructValidation(fn, types...)
+}
+
+// ValidateQuery creates a middleware that parses and validates query parameters
+// against the provided type and stores the result in the "query" local.
+func ValidateQuery[T any](next fiber.Handler) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var query T
+
+		if err := c.QueryParser(&query); err != nil {
+			logger.Warn("query parsing failed",
+				"method", c.Method(),
+				"path", c.Path(),
+				"error", err.Error(),
+			)
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "invalid query: " + err.Error(),
+			})
+		}
+
+		if err := validate.Struct(&query); err != nil {
+			errors := make(map[string]string)
+			for _, e := range err.(validator.ValidationErrors) {
+				errors[e.Field()] = e.Tag()
+			}
+
+			logger.Warn("query validation failed",
+				"method", c.Method(),
+				"path", c.Path(),
+				"validation_errors", errors,
+			)
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"validation_errors": errors,
+			})
+		}
+
+		c.Locals("query", &query)
+		return next(c)
+	}
+}
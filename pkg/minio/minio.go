@@ -3,22 +3,28 @@ package minio
 
 import (
 	"context"
+	"io"
 	"log"
+	"mime"
+	"net/http"
 	"net/url"
 	"time"
 
 	"innotech/pkg/logger"
+	"innotech/pkg/storage"
 
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
 )
 
-// MinioClient provides helper methods for interacting with MinIO storage.
+// MinioClient is the storage.Backend driver for MinIO.
 type MinioClient struct {
 	Client     *minio.Client
 	BucketName string
 }
 
+var _ storage.Backend = (*MinioClient)(nil)
+
 func New(endpoint, accessKey, secretKey, bucket string, useSSL bool) (*MinioClient, error) {
 	logger.Info("initializing MinIO client",
 		"endpoint", endpoint,
@@ -73,47 +79,79 @@ func New(endpoint, accessKey, secretKey, bucket string, useSSL bool) (*MinioClie
 	}, nil
 }
 
-func (m *MinioClient) Upload(ctx context.Context, objectName, filePath string) error {
-	logger.Info("uploading file to MinIO",
-		"bucket", m.BucketName,
-		"object_name", objectName,
-		"file_path", filePath,
-	)
-
-	_, err := m.Client.FPutObject(ctx, m.BucketName, objectName, filePath, minio.PutObjectOptions{})
+// Put uploads the contents of r as objectName.
+func (m *MinioClient) Put(ctx context.Context, objectName string, r io.Reader, size int64, contentType string) error {
+	_, err := m.Client.PutObject(ctx, m.BucketName, objectName, r, size, minio.PutObjectOptions{ContentType: contentType})
 	if err != nil {
-		logger.Error("failed to upload file to MinIO",
+		logger.Error("failed to upload object to MinIO",
 			"bucket", m.BucketName,
 			"object_name", objectName,
-			"file_path", filePath,
 			"error", err,
 		)
 		return err
 	}
-
-	logger.Info("file uploaded successfully",
+	logger.Info("object uploaded successfully",
 		"bucket", m.BucketName,
 		"object_name", objectName,
-		"file_path", filePath,
+		"size", size,
 	)
 	return nil
 }
 
-func (m *MinioClient) GetFileURL(objectName string) (string, error) {
-	logger.Debug("generating presigned URL for object",
-		"bucket", m.BucketName,
-		"object_name", objectName,
-	)
+// Get returns a reader of the contents of objectName.
+func (m *MinioClient) Get(ctx context.Context, objectName string) (io.ReadCloser, error) {
+	obj, err := m.Client.GetObject(ctx, m.BucketName, objectName, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, err
+	}
+	// GetObject is lazy; Stat surfaces a missing object before reading.
+	if _, err := obj.Stat(); err != nil {
+		_ = obj.Close()
+		return nil, notFound(err)
+	}
+	return obj, nil
+}
 
-	reqParams := make(url.Values)
+// Stat describes objectName.
+func (m *MinioClient) Stat(ctx context.Context, objectName string) (*storage.Info, error) {
+	info, err := m.Client.StatObject(ctx, m.BucketName, objectName, minio.StatObjectOptions{})
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &storage.Info{Size: info.Size, ContentType: info.ContentType, Modified: info.LastModified}, nil
+}
 
-	presignedURL, err := m.Client.PresignedGetObject(
-		context.Background(),
-		m.BucketName,
-		objectName,
-		time.Hour*24,
-		reqParams,
-	)
+// Delete removes objectName. Missing objects are not an error.
+func (m *MinioClient) Delete(ctx context.Context, objectName string) error {
+	return m.Client.RemoveObject(ctx, m.BucketName, objectName, minio.RemoveObjectOptions{})
+}
+
+// List calls fn for every object whose name starts with prefix.
+func (m *MinioClient) List(ctx context.Context, prefix string, fn func(objectName string, info storage.Info) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	for obj := range m.Client.ListObjects(ctx, m.BucketName, minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+		if obj.Err != nil {
+			return obj.Err
+		}
+		if err := fn(obj.Key, storage.Info{Size: obj.Size, ContentType: obj.ContentType, Modified: obj.LastModified}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PresignGet issues a URL for downloading objectName that is valid for ttl.
+// The object is always served as a download, under filename when it is not
+// empty, so that browsers never render it.
+func (m *MinioClient) PresignGet(ctx context.Context, objectName, filename string, ttl time.Duration) (string, error) {
+	reqParams := make(url.Values)
+	disposition := "attachment"
+	if filename != "" {
+		disposition = mime.FormatMediaType("attachment", map[string]string{"filename": filename})
+	}
+	reqParams.Set("response-content-disposition", disposition)
+	u, err := m.Client.PresignedGetObject(ctx, m.BucketName, objectName, ttl, reqParams)
 	if err != nil {
 		logger.Error("failed to generate presigned URL",
 			"bucket", m.BucketName,
@@ -122,13 +160,43 @@ func (m *MinioClient) GetFileURL(objectName string) (string, error) {
 		)
 		return "", err
 	}
+	return u.String(), nil
+}
 
-	urlString := presignedURL.String()
-	logger.Debug("presigned URL generated successfully",
-		"bucket", m.BucketName,
-		"object_name", objectName,
-		"url_length", len(urlString),
-	)
+// PresignPut issues a POST policy for uploading objectName directly to the
+// bucket; unlike a presigned PUT, a policy can limit the content length.
+func (m *MinioClient) PresignPut(ctx context.Context, objectName, contentType string, maxSize int64, ttl time.Duration) (*storage.Upload, error) {
+	policy := minio.NewPostPolicy()
+	if err := policy.SetBucket(m.BucketName); err != nil {
+		return nil, err
+	}
+	if err := policy.SetKey(objectName); err != nil {
+		return nil, err
+	}
+	if err := policy.SetExpires(time.Now().UTC().Add(ttl)); err != nil {
+		return nil, err
+	}
+	if err := policy.SetContentType(contentType); err != nil {
+		return nil, err
+	}
+	if err := policy.SetContentLengthRange(1, maxSize); err != nil {
+		return nil, err
+	}
+	u, fields, err := m.Client.PresignedPostPolicy(ctx, policy)
+	if err != nil {
+		logger.Error("failed to generate presigned POST policy",
+			"bucket", m.BucketName,
+			"object_name", objectName,
+			"error", err,
+		)
+		return nil, err
+	}
+	return &storage.Upload{Method: http.MethodPost, URL: u.String(), Fields: fields}, nil
+}
 
-	return urlString, nil
+func notFound(err error) error {
+	if minio.ToErrorResponse(err).StatusCode == http.StatusNotFound {
+		return storage.ErrNotFound
+	}
+	return err
 }
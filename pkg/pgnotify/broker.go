@@ -0,0 +1,81 @@
+// Package pgnotify fans out messages between application replicas over
+// PostgreSQL LISTEN/NOTIFY.
+package pgnotify
+
+import (
+	"encoding/json"
+	"innotech/pkg/logger"
+	"sync"
+)
+
+// Message is a payload published on a topic.
+type Message struct {
+	Topic string          `json:"topic"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// Subscription receives the messages of one topic until it is closed.
+type Subscription struct {
+	C chan Message
+
+	topic  string
+	broker *Broker
+	once   sync.Once
+}
+
+// Close unsubscribes and releases the subscription.
+func (s *Subscription) Close() {
+	s.once.Do(func() { s.broker.remove(s) })
+}
+
+// Broker delivers messages to in-process subscribers.
+type Broker struct {
+	mu   sync.RWMutex
+	subs map[string]map[*Subscription]struct{}
+}
+
+// NewBroker creates an empty Broker.
+func NewBroker() *Broker {
+	return &Broker{subs: map[string]map[*Subscription]struct{}{}}
+}
+
+// SubscriptionBuffer is the number of messages a subscriber may lag behind
+// before new messages for it are dropped.
+const SubscriptionBuffer = 64
+
+// Subscribe starts receiving messages published on topic.
+func (b *Broker) Subscribe(topic string) *Subscription {
+	s := &Subscription{C: make(chan Message, SubscriptionBuffer), topic: topic, broker: b}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.subs[topic] == nil {
+		b.subs[topic] = map[*Subscription]struct{}{}
+	}
+	b.subs[topic][s] = struct{}{}
+	return s
+}
+
+// Dispatch delivers m to the local subscribers of its topic. Slow subscribers
+// miss the message rather than block the others.
+func (b *Broker) Dispatch(m Message) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for s := range b.subs[m.Topic] {
+		select {
+		case s.C <- m:
+		default:
+			logger.Warn("pgnotify subscriber is lagging, message dropped", "topic", m.Topic)
+		}
+	}
+}
+
+func (b *Broker) remove(s *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.subs[s.topic], s)
+	if len(b.subs[s.topic]) == 0 {
+		delete(b.subs, s.topic)
+	}
+	close(s.C)
+}
@@ -0,0 +1,33 @@
+package pgnotify
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBroker_DispatchReachesTopicSubscribersOnly(t *testing.T) {
+	b := NewBroker()
+	a1, a2, other := b.Subscribe("a"), b.Subscribe("a"), b.Subscribe("b")
+	defer a1.Close()
+	defer a2.Close()
+	defer other.Close()
+
+	b.Dispatch(Message{Topic: "a", Data: []byte(`1`)})
+
+	assert.Equal(t, `1`, string((<-a1.C).Data))
+	assert.Equal(t, `1`, string((<-a2.C).Data))
+	assert.Empty(t, other.C)
+}
+
+func TestBroker_CloseStopsDelivery(t *testing.T) {
+	b := NewBroker()
+	s := b.Subscribe("a")
+	s.Close()
+	s.Close()
+
+	b.Dispatch(Message{Topic: "a", Data: []byte(`1`)})
+
+	_, open := <-s.C
+	assert.False(t, open)
+}
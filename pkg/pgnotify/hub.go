@@ -0,0 +1,107 @@
+package pgnotify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"innotech/pkg/db"
+	"innotech/pkg/logger"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jmoiron/sqlx"
+)
+
+// MaxPayload is the largest NOTIFY payload PostgreSQL accepts, in bytes.
+const MaxPayload = 7999
+
+// ErrPayloadTooLarge is returned when an encoded message exceeds MaxPayload.
+var ErrPayloadTooLarge = errors.New("pgnotify: payload too large")
+
+// Hub publishes messages with NOTIFY and dispatches everything received on
+// its channel to local subscribers, so every replica sees every message.
+type Hub struct {
+	*Broker
+
+	db      *sqlx.DB
+	dsn     string
+	channel string
+}
+
+// NewHub creates a Hub that notifies through database and listens on channel
+// using a dedicated connection opened from dsn. Run must be started for
+// subscribers to receive anything.
+func NewHub(database *sqlx.DB, dsn, channel string) *Hub {
+	return &Hub{Broker: NewBroker(), db: database, dsn: dsn, channel: channel}
+}
+
+// Publish encodes data and notifies all replicas. Inside a transaction bound
+// with db.Transactor, delivery happens only once the transaction commits.
+func (h *Hub) Publish(ctx context.Context, topic string, data any) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(Message{Topic: topic, Data: raw})
+	if err != nil {
+		return err
+	}
+	if len(payload) > MaxPayload {
+		return ErrPayloadTooLarge
+	}
+
+	_, err = db.Conn(ctx, h.db).ExecContext(ctx, `SELECT pg_notify($1, $2)`, h.channel, string(payload))
+	return err
+}
+
+// Run listens for notifications until ctx is cancelled, reconnecting with
+// backoff when the connection drops.
+func (h *Hub) Run(ctx context.Context) {
+	backoff := time.Second
+	for ctx.Err() == nil {
+		started := time.Now()
+		err := h.listen(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if time.Since(started) > time.Minute {
+			backoff = time.Second
+		}
+		logger.Error("pgnotify listener stopped, reconnecting", "channel", h.channel, "error", err, "retry_in", backoff.String())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, 30*time.Second)
+	}
+}
+
+func (h *Hub) listen(ctx context.Context) error {
+	conn, err := pgx.Connect(ctx, h.dsn)
+	if err != nil {
+		return fmt.Errorf("connect: %w", err)
+	}
+	defer func() { _ = conn.Close(context.Background()) }()
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{h.channel}.Sanitize()); err != nil {
+		return fmt.Errorf("listen: %w", err)
+	}
+	logger.Info("pgnotify listener started", "channel", h.channel)
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		var m Message
+		if err := json.Unmarshal([]byte(n.Payload), &m); err != nil {
+			logger.Warn("pgnotify received malformed payload", "channel", h.channel, "error", err)
+			continue
+		}
+		h.Dispatch(m)
+	}
+}
@@ -0,0 +1,345 @@
+// Package fs is a storage.Backend that keeps objects on the local
+// filesystem, for development without MinIO. Presigned URLs point back to
+// the application, which serves them through Driver.Serve after checking
+// their HMAC signature.
+package fs
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"innotech/pkg/storage"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RoutePrefix is the path under which the application serves signed URLs.
+const RoutePrefix = "/storage/"
+
+const defaultContentType = "application/octet-stream"
+
+// ErrInvalidKey is returned for keys that would escape the storage root.
+var ErrInvalidKey = errors.New("invalid object key")
+
+// Driver stores objects as files below a root directory. Contents live in
+// objects/ and their content types in meta/.
+type Driver struct {
+	root    string
+	baseURL string
+	secret  []byte
+}
+
+var _ storage.Backend = (*Driver)(nil)
+
+type meta struct {
+	ContentType string `json:"content_type"`
+}
+
+// New creates a Driver storing objects under root. Presigned URLs start with
+// baseURL, the public address of the application, and are signed with secret.
+func New(root, baseURL, secret string) (*Driver, error) {
+	if secret == "" {
+		return nil, errors.New("filesystem storage requires a URL signing secret")
+	}
+	for _, dir := range []string{"objects", "meta"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0o750); err != nil {
+			return nil, err
+		}
+	}
+	return &Driver{root: root, baseURL: strings.TrimRight(baseURL, "/"), secret: []byte(secret)}, nil
+}
+
+// Put stores the contents of r as key. The file is written aside and
+// renamed into place so that readers never see a partial object.
+func (d *Driver) Put(_ context.Context, key string, r io.Reader, size int64, contentType string) error {
+	name, err := d.objectPath(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0o750); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	written, err := io.Copy(tmp, r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if size >= 0 && written != size {
+		return io.ErrUnexpectedEOF
+	}
+	if err := d.writeMeta(key, meta{ContentType: contentType}); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
+
+// Get returns a reader of the contents of key.
+func (d *Driver) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	name, err := d.objectPath(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, storage.ErrNotFound
+	}
+	return f, err
+}
+
+// Stat describes key.
+func (d *Driver) Stat(_ context.Context, key string) (*storage.Info, error) {
+	name, err := d.objectPath(key)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := os.Stat(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, storage.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &storage.Info{Size: fi.Size(), ContentType: d.contentType(key), Modified: fi.ModTime()}, nil
+}
+
+// Delete removes key. Missing objects are not an error.
+func (d *Driver) Delete(_ context.Context, key string) error {
+	name, err := d.objectPath(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if err := os.Remove(d.metaPath(name)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// List calls fn for every object whose key starts with prefix, in key order.
+func (d *Driver) List(ctx context.Context, prefix string, fn func(key string, info storage.Info) error) error {
+	objects := filepath.Join(d.root, "objects")
+	// Start at the deepest directory the prefix names in full.
+	start := objects
+	if dir := path.Dir(prefix + "x"); dir != "." {
+		if _, err := d.objectPath(dir); err != nil {
+			return err
+		}
+		start = filepath.Join(objects, filepath.FromSlash(dir))
+	}
+	err := filepath.WalkDir(start, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".upload-") {
+			return nil
+		}
+		rel, err := filepath.Rel(objects, name)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		fi, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		return fn(key, storage.Info{Size: fi.Size(), ContentType: d.contentType(key), Modified: fi.ModTime()})
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// PresignGet issues a signed application URL for downloading key.
+func (d *Driver) PresignGet(_ context.Context, key, filename string, ttl time.Duration) (string, error) {
+	if _, err := d.objectPath(key); err != nil {
+		return "", err
+	}
+	q := url.Values{"expires": {strconv.FormatInt(time.Now().Add(ttl).Unix(), 10)}}
+	if filename != "" {
+		q.Set("filename", filename)
+	}
+	return d.signedURL(http.MethodGet, key, q), nil
+}
+
+// PresignPut issues a signed application URL for uploading key with PUT.
+func (d *Driver) PresignPut(_ context.Context, key, contentType string, maxSize int64, ttl time.Duration) (*storage.Upload, error) {
+	if _, err := d.objectPath(key); err != nil {
+		return nil, err
+	}
+	q := url.Values{
+		"expires":      {strconv.FormatInt(time.Now().Add(ttl).Unix(), 10)},
+		"content_type": {contentType},
+		"max_size":     {strconv.FormatInt(maxSize, 10)},
+	}
+	return &storage.Upload{
+		Method:  http.MethodPut,
+		URL:     d.signedURL(http.MethodPut, key, q),
+		Headers: map[string]string{"Content-Type": contentType},
+	}, nil
+}
+
+// Serve handles GET and PUT requests to URLs issued by PresignGet and
+// PresignPut. It is mounted at RoutePrefix.
+func (d *Driver) Serve(c *fiber.Ctx) error {
+	key, err := url.PathUnescape(c.Params("*"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid key"})
+	}
+	q, err := url.ParseQuery(string(c.Request().URI().QueryString()))
+	if err != nil || !d.validSignature(c.Method(), key, q) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "invalid signature"})
+	}
+	expires, err := strconv.ParseInt(q.Get("expires"), 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "url expired"})
+	}
+
+	ctx := c.UserContext()
+	if c.Method() == fiber.MethodPut {
+		maxSize, _ := strconv.ParseInt(q.Get("max_size"), 10, 64)
+		body := c.Body()
+		if int64(len(body)) > maxSize {
+			return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{"error": "file is too large"})
+		}
+		if c.Get(fiber.HeaderContentType) != q.Get("content_type") {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "content type does not match"})
+		}
+		if err := d.Put(ctx, key, bytes.NewReader(body), int64(len(body)), q.Get("content_type")); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.SendStatus(fiber.StatusOK)
+	}
+
+	info, err := d.Stat(ctx, key)
+	if errors.Is(err, storage.ErrNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "not found"})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	r, err := d.Get(ctx, key)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	// Stored files are never rendered by the browser, whatever their type.
+	c.Set(fiber.HeaderContentType, info.ContentType)
+	c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+	disposition := "attachment"
+	if filename := q.Get("filename"); filename != "" {
+		disposition = mime.FormatMediaType("attachment", map[string]string{"filename": filename})
+	}
+	c.Set(fiber.HeaderContentDisposition, disposition)
+	return c.SendStream(r, int(info.Size))
+}
+
+func (d *Driver) signedURL(method, key string, q url.Values) string {
+	q.Set("signature", d.sign(method, key, q))
+	return d.baseURL + RoutePrefix + escapeKey(key) + "?" + q.Encode()
+}
+
+func (d *Driver) validSignature(method, key string, q url.Values) bool {
+	got := q.Get("signature")
+	rest := url.Values{}
+	for k, v := range q {
+		if k != "signature" {
+			rest[k] = v
+		}
+	}
+	return hmac.Equal([]byte(got), []byte(d.sign(method, key, rest)))
+}
+
+func (d *Driver) sign(method, key string, q url.Values) string {
+	mac := hmac.New(sha256.New, d.secret)
+	mac.Write([]byte(method + "\n" + key + "\n" + q.Encode()))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (d *Driver) objectPath(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") || strings.ContainsRune(key, 0) || strings.Contains(key, "\\") {
+		return "", ErrInvalidKey
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return "", ErrInvalidKey
+		}
+	}
+	return filepath.Join(d.root, "objects", filepath.FromSlash(key)), nil
+}
+
+func (d *Driver) metaPath(objectPath string) string {
+	rel, _ := filepath.Rel(filepath.Join(d.root, "objects"), objectPath)
+	return filepath.Join(d.root, "meta", rel+".json")
+}
+
+func (d *Driver) writeMeta(key string, m meta) error {
+	name, err := d.objectPath(key)
+	if err != nil {
+		return err
+	}
+	name = d.metaPath(name)
+	if err := os.MkdirAll(filepath.Dir(name), 0o750); err != nil {
+		return err
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(name, data, 0o640)
+}
+
+func (d *Driver) contentType(key string) string {
+	name, err := d.objectPath(key)
+	if err != nil {
+		return defaultContentType
+	}
+	data, err := os.ReadFile(d.metaPath(name))
+	if err != nil {
+		return defaultContentType
+	}
+	var m meta
+	if json.Unmarshal(data, &m) != nil || m.ContentType == "" {
+		return defaultContentType
+	}
+	return m.ContentType
+}
+
+func escapeKey(key string) string {
+	parts := strings.Split(key, "/")
+	for i, p := range parts {
+		parts[i] = url.PathEscape(p)
+	}
+	return strings.Join(parts, "/")
+}
@@ -0,0 +1,192 @@
+package fs
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"innotech/pkg/storage"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newDriver(t *testing.T) *Driver {
+	d, err := New(t.TempDir(), "http://app.local/", "secret")
+	require.NoError(t, err)
+	return d
+}
+
+func newApp(d *Driver) *fiber.App {
+	app := fiber.New()
+	app.Get(RoutePrefix+"*", d.Serve)
+	app.Put(RoutePrefix+"*", d.Serve)
+	return app
+}
+
+// requestURI returns the request URI of a URL issued by the driver.
+func requestURI(t *testing.T, raw string) string {
+	u, err := url.Parse(raw)
+	require.NoError(t, err)
+	return u.RequestURI()
+}
+
+func TestDriver_PutGetStatDelete(t *testing.T) {
+	ctx := context.Background()
+	d := newDriver(t)
+
+	require.NoError(t, d.Put(ctx, "projects/1/tickets/2/a", strings.NewReader("hello"), 5, "text/plain"))
+
+	info, err := d.Stat(ctx, "projects/1/tickets/2/a")
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), info.Size)
+	assert.Equal(t, "text/plain", info.ContentType)
+
+	r, err := d.Get(ctx, "projects/1/tickets/2/a")
+	require.NoError(t, err)
+	data, _ := io.ReadAll(r)
+	r.Close()
+	assert.Equal(t, "hello", string(data))
+
+	require.NoError(t, d.Delete(ctx, "projects/1/tickets/2/a"))
+	require.NoError(t, d.Delete(ctx, "projects/1/tickets/2/a"), "deleting twice is fine")
+	_, err = d.Stat(ctx, "projects/1/tickets/2/a")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+	_, err = d.Get(ctx, "projects/1/tickets/2/a")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+}
+
+func TestDriver_Put_SizeMismatch(t *testing.T) {
+	d := newDriver(t)
+
+	err := d.Put(context.Background(), "a", strings.NewReader("abc"), 5, "text/plain")
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	_, err = d.Stat(context.Background(), "a")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+}
+
+func TestDriver_RejectsEscapingKeys(t *testing.T) {
+	d := newDriver(t)
+
+	for _, key := range []string{"", "/etc/passwd", "../x", "a/../../x", "a//b", "a\\b", "a\x00b"} {
+		err := d.Put(context.Background(), key, strings.NewReader("x"), 1, "text/plain")
+		assert.ErrorIs(t, err, ErrInvalidKey, key)
+	}
+}
+
+func TestDriver_List(t *testing.T) {
+	ctx := context.Background()
+	d := newDriver(t)
+	for _, key := range []string{"projects/1/tickets/2/a", "projects/1/files/b", "projects/10/files/c", "other/d"} {
+		require.NoError(t, d.Put(ctx, key, strings.NewReader("x"), 1, "text/plain"))
+	}
+
+	var keys []string
+	err := d.List(ctx, "projects/1/", func(key string, info storage.Info) error {
+		keys = append(keys, key)
+		assert.Equal(t, int64(1), info.Size)
+		assert.WithinDuration(t, time.Now(), info.Modified, time.Minute)
+		return nil
+	})
+	require.NoError(t, err)
+	sort.Strings(keys)
+	assert.Equal(t, []string{"projects/1/files/b", "projects/1/tickets/2/a"}, keys)
+
+	keys = nil
+	require.NoError(t, d.List(ctx, "projects/1", func(key string, _ storage.Info) error {
+		keys = append(keys, key)
+		return nil
+	}))
+	assert.Len(t, keys, 3)
+
+	require.NoError(t, d.List(ctx, "missing/", func(string, storage.Info) error {
+		t.Fatal("nothing to list")
+		return nil
+	}))
+}
+
+func TestDriver_SignedDownload(t *testing.T) {
+	ctx := context.Background()
+	d := newDriver(t)
+	require.NoError(t, d.Put(ctx, "projects/1/files/a b", strings.NewReader("hello"), 5, "text/plain"))
+
+	raw, err := d.PresignGet(ctx, "projects/1/files/a b", "report.txt", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(raw, "http://app.local/storage/projects/1/files/a%20b?"), raw)
+
+	resp, err := newApp(d).Test(httptest.NewRequest("GET", requestURI(t, raw), nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, "hello", string(body))
+	assert.Equal(t, "text/plain", resp.Header.Get(fiber.HeaderContentType))
+	assert.Equal(t, `attachment; filename=report.txt`, resp.Header.Get(fiber.HeaderContentDisposition))
+	assert.Equal(t, "nosniff", resp.Header.Get(fiber.HeaderXContentTypeOptions))
+
+	unnamed, err := d.PresignGet(ctx, "projects/1/files/a b", "", time.Minute)
+	require.NoError(t, err)
+	resp, err = newApp(d).Test(httptest.NewRequest("GET", requestURI(t, unnamed), nil))
+	require.NoError(t, err)
+	assert.Equal(t, "attachment", resp.Header.Get(fiber.HeaderContentDisposition), "never rendered inline")
+
+	tampered := strings.Replace(requestURI(t, raw), "report.txt", "other.txt", 1)
+	resp, err = newApp(d).Test(httptest.NewRequest("GET", tampered, nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+
+	resp, err = newApp(d).Test(httptest.NewRequest("PUT", requestURI(t, raw), strings.NewReader("x")))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusForbidden, resp.StatusCode, "a download URL does not allow uploads")
+}
+
+func TestDriver_SignedDownload_Expired(t *testing.T) {
+	ctx := context.Background()
+	d := newDriver(t)
+	require.NoError(t, d.Put(ctx, "a", strings.NewReader("x"), 1, "text/plain"))
+
+	raw, err := d.PresignGet(ctx, "a", "", -time.Minute)
+	require.NoError(t, err)
+
+	resp, err := newApp(d).Test(httptest.NewRequest("GET", requestURI(t, raw), nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+}
+
+func TestDriver_SignedUpload(t *testing.T) {
+	ctx := context.Background()
+	d := newDriver(t)
+
+	up, err := d.PresignPut(ctx, "projects/1/tickets/2/a", "image/png", 8, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, "PUT", up.Method)
+	assert.Equal(t, "image/png", up.Headers["Content-Type"])
+
+	put := func(body, contentType string) int {
+		req := httptest.NewRequest(up.Method, requestURI(t, up.URL), bytes.NewReader([]byte(body)))
+		req.Header.Set(fiber.HeaderContentType, contentType)
+		resp, err := newApp(d).Test(req)
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, fiber.StatusRequestEntityTooLarge, put("123456789", "image/png"))
+	assert.Equal(t, fiber.StatusBadRequest, put("1234", "text/plain"))
+	assert.Equal(t, fiber.StatusOK, put("1234", "image/png"))
+
+	info, err := d.Stat(ctx, "projects/1/tickets/2/a")
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), info.Size)
+	assert.Equal(t, "image/png", info.ContentType)
+}
+
+func TestNew_RequiresSecret(t *testing.T) {
+	_, err := New(t.TempDir(), "http://app.local", "")
+	assert.Error(t, err)
+}
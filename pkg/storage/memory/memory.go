@@ -0,0 +1,126 @@
+// Package memory is a storage.Backend that keeps objects in memory, for
+// tests and throwaway local setups. Presigned URLs it issues are not served.
+package memory
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"innotech/pkg/storage"
+)
+
+type object struct {
+	data        []byte
+	contentType string
+	modified    time.Time
+}
+
+// Driver stores objects in a map.
+type Driver struct {
+	mu      sync.RWMutex
+	objects map[string]object
+}
+
+var _ storage.Backend = (*Driver)(nil)
+
+// New creates an empty Driver.
+func New() *Driver {
+	return &Driver{objects: map[string]object{}}
+}
+
+// Put stores the contents of r as key.
+func (d *Driver) Put(_ context.Context, key string, r io.Reader, size int64, contentType string) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if size >= 0 && int64(len(data)) != size {
+		return io.ErrUnexpectedEOF
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.objects[key] = object{data: data, contentType: contentType, modified: time.Now()}
+	return nil
+}
+
+// Get returns a reader of the contents of key.
+func (d *Driver) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	obj, ok := d.objects[key]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	return io.NopCloser(bytes.NewReader(obj.data)), nil
+}
+
+// Stat describes key.
+func (d *Driver) Stat(_ context.Context, key string) (*storage.Info, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	obj, ok := d.objects[key]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	return obj.info(), nil
+}
+
+// Delete removes key. Missing objects are not an error.
+func (d *Driver) Delete(_ context.Context, key string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.objects, key)
+	return nil
+}
+
+// List calls fn for every object whose key starts with prefix, in key order.
+func (d *Driver) List(_ context.Context, prefix string, fn func(key string, info storage.Info) error) error {
+	d.mu.RLock()
+	keys := make([]string, 0, len(d.objects))
+	infos := make(map[string]storage.Info, len(d.objects))
+	for key, obj := range d.objects {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+			infos[key] = *obj.info()
+		}
+	}
+	d.mu.RUnlock()
+
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err := fn(key, infos[key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PresignGet returns a memory:// URL naming key.
+func (d *Driver) PresignGet(_ context.Context, key, filename string, ttl time.Duration) (string, error) {
+	q := url.Values{"expires": {time.Now().Add(ttl).UTC().Format(time.RFC3339)}}
+	if filename != "" {
+		q.Set("filename", filename)
+	}
+	return "memory:///" + key + "?" + q.Encode(), nil
+}
+
+// PresignPut returns a PUT request to a memory:// URL naming key.
+func (d *Driver) PresignPut(_ context.Context, key, contentType string, _ int64, ttl time.Duration) (*storage.Upload, error) {
+	q := url.Values{"expires": {time.Now().Add(ttl).UTC().Format(time.RFC3339)}}
+	return &storage.Upload{
+		Method:  http.MethodPut,
+		URL:     "memory:///" + key + "?" + q.Encode(),
+		Headers: map[string]string{"Content-Type": contentType},
+	}, nil
+}
+
+func (o object) info() *storage.Info {
+	return &storage.Info{Size: int64(len(o.data)), ContentType: o.contentType, Modified: o.modified}
+}
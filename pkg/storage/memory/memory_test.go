@@ -0,0 +1,72 @@
+package memory
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"innotech/pkg/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDriver_PutGetStatDelete(t *testing.T) {
+	ctx := context.Background()
+	d := New()
+
+	require.NoError(t, d.Put(ctx, "projects/1/files/a", strings.NewReader("hello"), 5, "text/plain"))
+
+	info, err := d.Stat(ctx, "projects/1/files/a")
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), info.Size)
+	assert.Equal(t, "text/plain", info.ContentType)
+
+	r, err := d.Get(ctx, "projects/1/files/a")
+	require.NoError(t, err)
+	data, _ := io.ReadAll(r)
+	assert.Equal(t, "hello", string(data))
+
+	require.NoError(t, d.Delete(ctx, "projects/1/files/a"))
+	_, err = d.Stat(ctx, "projects/1/files/a")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+	_, err = d.Get(ctx, "projects/1/files/a")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+}
+
+func TestDriver_Put_SizeMismatch(t *testing.T) {
+	err := New().Put(context.Background(), "a", strings.NewReader("abc"), 5, "text/plain")
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+}
+
+func TestDriver_List(t *testing.T) {
+	ctx := context.Background()
+	d := New()
+	for _, key := range []string{"projects/1/tickets/2/a", "projects/1/files/b", "other/c"} {
+		require.NoError(t, d.Put(ctx, key, strings.NewReader("x"), 1, "text/plain"))
+	}
+
+	var keys []string
+	err := d.List(ctx, "projects/", func(key string, info storage.Info) error {
+		keys = append(keys, key)
+		assert.WithinDuration(t, time.Now(), info.Modified, time.Minute)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"projects/1/files/b", "projects/1/tickets/2/a"}, keys)
+}
+
+func TestDriver_Presign(t *testing.T) {
+	d := New()
+
+	raw, err := d.PresignGet(context.Background(), "projects/1/files/a", "a.txt", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(raw, "memory:///projects/1/files/a?"), raw)
+
+	up, err := d.PresignPut(context.Background(), "projects/1/files/a", "image/png", 10, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, "PUT", up.Method)
+	assert.Equal(t, "image/png", up.Headers["Content-Type"])
+}
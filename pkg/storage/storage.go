@@ -0,0 +1,48 @@
+// Package storage defines the object storage used for uploaded files. The
+// MinIO client in pkg/minio is the production driver; pkg/storage/fs keeps
+// objects on the local filesystem and pkg/storage/memory in memory.
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+// ErrNotFound is returned for objects that do not exist.
+var ErrNotFound = errors.New("object not found")
+
+// Info describes a stored object.
+type Info struct {
+	Size        int64
+	ContentType string
+	Modified    time.Time
+}
+
+// Upload is a presigned request for uploading an object directly. With
+// Fields set it is a multipart form POST that must carry the fields before
+// the file; otherwise the body is sent as is, with Headers.
+type Upload struct {
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Fields  map[string]string `json:"fields,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// Backend stores objects by key.
+type Backend interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Stat(ctx context.Context, key string) (*Info, error)
+	Delete(ctx context.Context, key string) error
+	// List calls fn for every object whose key starts with prefix.
+	List(ctx context.Context, prefix string, fn func(key string, info Info) error) error
+	// PresignGet issues a URL for downloading key that is valid for ttl. The
+	// file is always served as a download, under filename when it is not
+	// empty.
+	PresignGet(ctx context.Context, key, filename string, ttl time.Duration) (string, error)
+	// PresignPut issues a request for uploading key directly. The upload
+	// must be of contentType and at most maxSize bytes.
+	PresignPut(ctx context.Context, key, contentType string, maxSize int64, ttl time.Duration) (*Upload, error)
+}